@@ -3,8 +3,14 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"beebrain/internal/config"
+	"beebrain/internal/health"
 	"beebrain/internal/llm"
 	slackhandler "beebrain/internal/slack"
 	"beebrain/internal/vectordb"
@@ -40,45 +46,81 @@ func main() {
 	}
 	logger.SetLevel(level)
 
-	// Get Slack tokens
-	botToken := os.Getenv("SLACK_BOT_TOKEN")
-	if botToken == "" {
-		logger.Fatal("SLACK_BOT_TOKEN environment variable is not set")
-	}
+	// Every startup problem is collected and reported at once, so that they
+	// can all be fixed before the next attempt
+	var startup config.StartupErrors
 
-	verificationToken := os.Getenv("SLACK_VERIFICATION_TOKEN")
-	if verificationToken == "" {
-		logger.Fatal("SLACK_VERIFICATION_TOKEN environment variable is not set")
+	// Get Slack tokens
+	botToken := startup.Require("SLACK_BOT_TOKEN")
+	verificationToken := startup.Require("SLACK_VERIFICATION_TOKEN")
+	for _, key := range []string{"SLACK_API_URL", "OLLAMA_API_URL", "EMBEDDING_API_URL"} {
+		startup.CheckURL(key)
 	}
 
 	// Initialize Slack client
-	slackClient := slackapi.New(botToken)
+	slackClient := slackapi.New(botToken, slackhandler.ClientOptions(logger)...)
 
 	// Verify Slack authentication
-	if _, err := slackClient.AuthTest(); err != nil {
-		logger.Fatalf("Failed to verify Slack authentication: %v", err)
+	if botToken != "" {
+		if _, err := slackClient.AuthTest(); err != nil {
+			startup.Addf("failed to verify Slack authentication: %w", err)
+		} else {
+			logger.Info("Successfully authenticated with Slack")
+		}
 	}
-	logger.Info("Successfully authenticated with Slack")
 
 	// Initialize LLM client with bot name
 	llmClient := llm.NewClient(logger, "BeeBrain")
 
-	// Initialize VectorDB client
-	vectorDB, err := vectordb.NewClient(logger)
+	// Initialize the embedder used for indexing, which may differ from the chat backend
+	embedder, err := llm.NewEmbedder(logger, llmClient)
 	if err != nil {
-		logger.Fatalf("Failed to create VectorDB client: %v", err)
+		startup.Addf("failed to create embedder: %w", err)
+	}
+
+	// Initialize VectorDB client, unless running as a plain chat bot
+	var vectorDB vectordb.VectorDBClient
+	// Embeddings have to match the collection, the memory store takes any size
+	embeddingDimension := 0
+	if !config.Bool(logger, "VECTORDB_ENABLED", true) {
+		logger.Info("VectorDB disabled, running without indexing or retrieval")
+	} else {
+		switch backend := os.Getenv("VECTORDB_BACKEND"); backend {
+		case "memory":
+			vectorDB = vectordb.NewMemoryClient(logger)
+			logger.Info("Using in-memory VectorDB")
+		case "", "qdrant":
+			qdrantClient, err := vectordb.NewClient(logger)
+			if err != nil {
+				startup.Addf("failed to create VectorDB client: %w", err)
+				break
+			}
+
+			// Initialize VectorDB collection
+			if err := qdrantClient.InitializeCollection(context.Background()); err != nil {
+				startup.Addf("failed to initialize VectorDB collection: %w", err)
+				break
+			}
+			vectorDB = qdrantClient
+			embeddingDimension = int(qdrantClient.VectorSize())
+			logger.Info("Successfully initialized VectorDB")
+		default:
+			startup.Addf("invalid VECTORDB_BACKEND '%s', expected 'qdrant' or 'memory'", backend)
+		}
 	}
 
-	// Initialize VectorDB collection
-	if err := vectorDB.InitializeCollection(context.Background()); err != nil {
-		logger.Fatalf("Failed to initialize VectorDB collection: %v", err)
+	// A persona is sent with every answer, so an oversized one is costly
+	startup.Add(slackhandler.CheckPersonaSizes(logger))
+
+	if err := startup.Err(); err != nil {
+		logger.Fatal(err)
 	}
-	logger.Info("Successfully initialized VectorDB")
 
 	// Create Slack event handler
 	slackHandler := slackhandler.NewBeeBrainSlackHandler(
 		slackClient,
 		llmClient,
+		embedder,
 		vectorDB,
 		logger,
 		os.Getenv("SLACK_SIGNING_SECRET"),
@@ -86,6 +128,37 @@ func main() {
 		os.Getenv("LLM_MODE"),
 	)
 
+	// Load the model before accepting traffic when WARMUP is set, the bot
+	// still works without it, only its first answer is slower
+	if err := llm.Warmup(llmClient, logger); err != nil {
+		logger.Warn(err)
+	}
+
+	// Stop on SIGINT/SIGTERM so connections can be closed cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Run button actions in the background until shutdown
+	slackHandler.SetContext(ctx)
+
+	// Post periodic channel digests in the background
+	go slackHandler.StartDigests(ctx)
+
+	// Post the daily standup and summarize the replies when STANDUP_CHANNEL is set
+	go slackHandler.StartStandups(ctx)
+
+	// Retry messages that failed to index when STORE_FAILURE_STRATEGY=queue
+	go slackHandler.StartStoreQueue(ctx)
+
+	// Retry responses that failed to post when POST_RETRY_QUEUE_SIZE is set
+	go slackHandler.StartPostQueue(ctx)
+
+	// Index messages in the background when INDEX_QUEUE_SIZE is set
+	go slackHandler.StartIndexQueue(ctx)
+
+	// Handle events on a bounded pool of workers when EVENT_WORKERS is set
+	go slackHandler.StartEventWorkers(ctx)
+
 	// Create Echo instance
 	e := echo.New()
 	// Customize logging middleware to avoid log spamming
@@ -101,6 +174,16 @@ func main() {
 	// Add routes
 	e.POST("/", slackHandler.HandleSlackEvents)       // Handle Slack events at root
 	e.POST("/events", slackHandler.HandleSlackEvents) // Also handle events at /events
+	e.POST("/interactions", slackHandler.HandleInteractions)
+
+	// Ready once Ollama and the embedding model both answer
+	checker := health.NewChecker(logger,
+		health.Check{Name: "ollama", Run: llmClient.Ping},
+		health.Check{Name: "embedding", Run: func(ctx context.Context) error {
+			return llm.PingEmbedding(ctx, embedder, embeddingDimension)
+		}},
+	)
+	e.GET("/readyz", checker.HandleReady)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -108,5 +191,23 @@ func main() {
 		port = "8080"
 	}
 	logger.Infof("Starting server on port %s", port)
-	e.Logger.Fatal(e.Start(":" + port))
+	go func() {
+		if err := e.Start(":" + port); err != nil && err != http.ErrServerClosed {
+			logger.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		logger.Errorf("Failed to shut down server: %v", err)
+	}
+	if vectorDB != nil {
+		if err := vectorDB.Close(); err != nil {
+			logger.Errorf("Failed to close VectorDB client: %v", err)
+		}
+	}
 }
@@ -0,0 +1,51 @@
+// Command dedup collapses near-identical stored messages, such as reposts
+// and thank-yous, keeping the earliest message of each group.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"beebrain/internal/vectordb"
+
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	threshold := flag.Float64("threshold", 0.98, "similarity at which messages count as duplicates")
+	dryRun := flag.Bool("dry-run", false, "only report how many messages would be removed")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	logger := logrus.New()
+
+	if *threshold <= 0 || *threshold > 1 {
+		logger.Fatal("-threshold must be between 0 and 1")
+	}
+
+	client, err := vectordb.NewClient(logger)
+	if err != nil {
+		logger.Fatalf("Failed to create VectorDB client: %v", err)
+	}
+	defer client.Close()
+
+	removed, err := client.Dedup(context.Background(), float32(*threshold), *dryRun)
+	if err != nil {
+		// Running again picks up the duplicates that are left
+		logger.Errorf("Dedup stopped after %d messages: %v", removed, err)
+		os.Exit(1)
+	}
+
+	if *dryRun {
+		logger.Infof("Would remove %d duplicate messages", removed)
+		return
+	}
+	logger.Infof("Removed %d duplicate messages", removed)
+}
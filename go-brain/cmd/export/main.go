@@ -0,0 +1,82 @@
+// Command export writes the indexed messages of a channel to a JSON file, or
+// with -import stores the messages of such a file, e.g. to move a channel to
+// another Qdrant instance or collection.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	channel := flag.String("channel", "", "channel to export")
+	file := flag.String("file", "", "file to write the export to or read it from")
+	importFile := flag.Bool("import", false, "import -file instead of exporting")
+	withVectors := flag.Bool("vectors", true, "include embeddings in the export, otherwise they are recomputed on import")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	logger := logrus.New()
+
+	if *file == "" {
+		logger.Fatal("-file is required")
+	}
+	if !*importFile && *channel == "" {
+		logger.Fatal("-channel is required to export")
+	}
+
+	client, err := vectordb.NewClient(logger)
+	if err != nil {
+		logger.Fatalf("Failed to create VectorDB client: %v", err)
+	}
+	defer client.Close()
+
+	if *importFile {
+		in, err := os.Open(*file)
+		if err != nil {
+			logger.Fatalf("Failed to open %s: %v", *file, err)
+		}
+		defer in.Close()
+
+		// Only needed for exports taken without embeddings
+		llmClient := llm.NewClient(logger, "BeeBrain")
+		embedder, err := llm.NewEmbedder(logger, llmClient)
+		if err != nil {
+			logger.Fatalf("Failed to create embedder: %v", err)
+		}
+
+		imported, err := client.ImportChannel(context.Background(), in, embedder)
+		if err != nil {
+			// Messages keep their IDs, so importing again is safe
+			logger.Errorf("Import stopped after %d messages: %v", imported, err)
+			os.Exit(1)
+		}
+		logger.Infof("Imported %d messages from %s", imported, *file)
+		return
+	}
+
+	out, err := os.Create(*file)
+	if err != nil {
+		logger.Fatalf("Failed to create %s: %v", *file, err)
+	}
+	defer out.Close()
+
+	exported, err := client.ExportChannel(context.Background(), *channel, out, *withVectors)
+	if err != nil {
+		logger.Errorf("Failed to export channel %s: %v", *channel, err)
+		os.Exit(1)
+	}
+	logger.Infof("Exported %d messages of channel %s to %s", exported, *channel, *file)
+}
@@ -0,0 +1,42 @@
+// Command recreate deletes the collection with every message in it and
+// creates it again empty, for a clean slate in development. It refuses to run
+// without -confirm.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"beebrain/internal/vectordb"
+
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	confirm := flag.Bool("confirm", false, "confirm that every stored message is to be deleted")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	logger := logrus.New()
+
+	client, err := vectordb.NewClient(logger)
+	if err != nil {
+		logger.Fatalf("Failed to create VectorDB client: %v", err)
+	}
+	defer client.Close()
+
+	if !*confirm {
+		logger.Fatalf("This deletes every message in collection %s, pass -confirm to go ahead", client.Collection())
+	}
+
+	if err := client.RecreateCollection(context.Background()); err != nil {
+		logger.Fatalf("Failed to recreate collection %s: %v", client.Collection(), err)
+	}
+	logger.Infof("Recreated collection %s empty", client.Collection())
+}
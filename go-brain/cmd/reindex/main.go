@@ -0,0 +1,58 @@
+// Command reindex re-embeds every stored message with the currently
+// configured embedding model and copies it into a new Qdrant collection,
+// including the messages the running bot stores meanwhile. Set
+// QDRANT_VECTOR_SIZE to the new model's dimension before running it, and
+// QDRANT_COLLECTION to the target collection once it completes.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	target := flag.String("target", "", "collection to write the re-embedded messages to")
+	batchSize := flag.Uint("batch-size", 100, "number of messages re-embedded per batch")
+	flag.Parse()
+
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	logger := logrus.New()
+
+	if *target == "" {
+		logger.Fatal("-target is required")
+	}
+
+	llmClient := llm.NewClient(logger, "BeeBrain")
+	embedder, err := llm.NewEmbedder(logger, llmClient)
+	if err != nil {
+		logger.Fatalf("Failed to create embedder: %v", err)
+	}
+
+	client, err := vectordb.NewClient(logger)
+	if err != nil {
+		logger.Fatalf("Failed to create VectorDB client: %v", err)
+	}
+	defer client.Close()
+
+	migrated, err := client.Reindex(context.Background(), embedder, *target, uint32(*batchSize))
+	if err != nil {
+		// Running again with the same target resumes where this run stopped
+		logger.Errorf("Reindex stopped after %d messages: %v", migrated, err)
+		os.Exit(1)
+	}
+
+	// Messages stored from now on only reach the target if this is run again
+	logger.Infof("Reindexed %d messages, set QDRANT_COLLECTION=%s and restart BeeBrain to use them", migrated, *target)
+}
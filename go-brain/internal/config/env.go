@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// String returns the value of the environment variable or def when unset
+func String(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// Int parses the environment variable as an integer, warning and falling
+// back to def when it is unset or invalid
+func Int(logger *logrus.Logger, key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		logger.Warnf("Invalid %s '%s', defaulting to %d", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// Float parses the environment variable as a float, warning and falling
+// back to def when it is unset or invalid
+func Float(logger *logrus.Logger, key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		logger.Warnf("Invalid %s '%s', defaulting to %v", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// Bool parses the environment variable as a boolean, warning and falling
+// back to def when it is unset or invalid
+func Bool(logger *logrus.Logger, key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.Warnf("Invalid %s '%s', defaulting to %t", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// Duration parses the environment variable as a time.Duration (e.g. "10m"),
+// warning and falling back to def when it is unset or invalid
+func Duration(logger *logrus.Logger, key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		logger.Warnf("Invalid %s '%s', defaulting to %s", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// TimeOfDay parses an "HH:MM" environment variable into an offset from
+// midnight. The second return value is false when the variable is unset or
+// invalid.
+func TimeOfDay(logger *logrus.Logger, key string) (time.Duration, bool) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, false
+	}
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		logger.Warnf("Invalid %s '%s', expected HH:MM", key, value)
+		return 0, false
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, true
+}
+
+// List splits a comma-separated environment variable into trimmed,
+// non-empty values
+func List(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Map parses a comma-separated list of key=value pairs, skipping and
+// warning about malformed entries
+func Map(logger *logrus.Logger, key string) map[string]string {
+	values := make(map[string]string)
+	for _, entry := range List(key) {
+		k, v, ok := strings.Cut(entry, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" {
+			logger.Warnf("Ignoring malformed %s entry '%s'", key, entry)
+			continue
+		}
+		values[k] = v
+	}
+	return values
+}
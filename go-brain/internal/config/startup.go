@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// StartupErrors collects the problems found while starting up, so that they
+// are all reported together instead of one per attempt
+type StartupErrors struct {
+	errs []error
+}
+
+// Add records err, nil is ignored
+func (e *StartupErrors) Add(err error) {
+	if err != nil {
+		e.errs = append(e.errs, err)
+	}
+}
+
+// Addf records an error formatted like fmt.Errorf
+func (e *StartupErrors) Addf(format string, args ...interface{}) {
+	e.Add(fmt.Errorf(format, args...))
+}
+
+// Require returns the environment variable, recording an error when it is
+// unset
+func (e *StartupErrors) Require(key string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		e.Addf("%s environment variable is not set", key)
+	}
+	return value
+}
+
+// CheckURL records an error when the environment variable is set to
+// something other than an http or https URL
+func (e *StartupErrors) CheckURL(key string) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		e.Addf("%s '%s' is not a valid http or https URL", key, value)
+	}
+}
+
+// Err returns the recorded errors as one error, or nil when there are none
+func (e *StartupErrors) Err() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *StartupErrors) Error() string {
+	lines := make([]string, 0, len(e.errs)+1)
+	lines = append(lines, fmt.Sprintf("%d startup problem(s):", len(e.errs)))
+	for _, err := range e.errs {
+		lines = append(lines, "  - "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns the recorded errors, for errors.Is and errors.As
+func (e *StartupErrors) Unwrap() []error {
+	return e.errs
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"beebrain/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errUnreachable = errors.New("connection refused")
+
+func TestStartupErrorsReportsEveryProblem(t *testing.T) {
+	t.Setenv("SLACK_BOT_TOKEN", "")
+	t.Setenv("SLACK_VERIFICATION_TOKEN", "")
+	t.Setenv("OLLAMA_API_URL", "localhost:11434")
+
+	var startup config.StartupErrors
+	startup.Require("SLACK_BOT_TOKEN")
+	startup.Require("SLACK_VERIFICATION_TOKEN")
+	startup.CheckURL("OLLAMA_API_URL")
+	startup.Addf("failed to create VectorDB client: %w", errUnreachable)
+
+	err := startup.Err()
+	assert.Error(t, err)
+	message := err.Error()
+	assert.Contains(t, message, "4 startup problem(s)")
+	assert.Contains(t, message, "SLACK_BOT_TOKEN environment variable is not set")
+	assert.Contains(t, message, "SLACK_VERIFICATION_TOKEN environment variable is not set")
+	assert.Contains(t, message, "OLLAMA_API_URL 'localhost:11434' is not a valid http or https URL")
+	assert.Contains(t, message, "connection refused")
+
+	// The underlying errors stay reachable
+	assert.ErrorIs(t, err, errUnreachable)
+}
+
+func TestStartupErrorsNilWhenValid(t *testing.T) {
+	t.Setenv("SLACK_BOT_TOKEN", "xoxb-token")
+	t.Setenv("OLLAMA_API_URL", "http://ollama:11434")
+
+	var startup config.StartupErrors
+	assert.Equal(t, "xoxb-token", startup.Require("SLACK_BOT_TOKEN"))
+	startup.CheckURL("OLLAMA_API_URL")
+	// Unset URLs fall back to their defaults
+	t.Setenv("EMBEDDING_API_URL", "")
+	startup.CheckURL("EMBEDDING_API_URL")
+	startup.Add(nil)
+
+	assert.NoError(t, startup.Err())
+}
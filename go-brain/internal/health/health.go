@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultCheckTimeout = 5 * time.Second
+
+// Check is a dependency that has to work for the bot to be ready
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Checker runs the readiness checks
+type Checker struct {
+	logger  *logrus.Logger
+	checks  []Check
+	timeout time.Duration
+}
+
+func NewChecker(logger *logrus.Logger, checks ...Check) *Checker {
+	return &Checker{
+		logger:  logger,
+		checks:  checks,
+		timeout: defaultCheckTimeout,
+	}
+}
+
+// HandleReady reports 200 when every check passes and 503 with the failures
+// otherwise
+func (c *Checker) HandleReady(ctx echo.Context) error {
+	checkCtx, cancel := context.WithTimeout(ctx.Request().Context(), c.timeout)
+	defer cancel()
+
+	failures := make(map[string]string)
+	for _, check := range c.checks {
+		if err := check.Run(checkCtx); err != nil {
+			c.logger.Warnf("Readiness check %s failed: %v", check.Name, err)
+			failures[check.Name] = err.Error()
+		}
+	}
+
+	if len(failures) > 0 {
+		return ctx.JSON(http.StatusServiceUnavailable, map[string]interface{}{"status": "unavailable", "failures": failures})
+	}
+	return ctx.JSON(http.StatusOK, map[string]string{"status": "ready"})
+}
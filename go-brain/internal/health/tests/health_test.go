@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"beebrain/internal/health"
+	"beebrain/internal/llm"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// newOllamaServer starts a fake Ollama whose model list always answers and
+// whose embeddings endpoint is handled by embeddings
+func newOllamaServer(t *testing.T, embeddings http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"models": []interface{}{}})
+		case "/api/embeddings":
+			embeddings(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func readiness(t *testing.T, embeddings http.HandlerFunc, dimension int) *httptest.ResponseRecorder {
+	server := newOllamaServer(t, embeddings)
+	t.Setenv("OLLAMA_API_URL", server.URL)
+
+	logger := logrus.New()
+	client := llm.NewClient(logger, "BeeBrain")
+	checker := health.NewChecker(logger,
+		health.Check{Name: "ollama", Run: client.Ping},
+		health.Check{Name: "embedding", Run: func(ctx context.Context) error {
+			return llm.PingEmbedding(ctx, client, dimension)
+		}},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, checker.HandleReady(echo.New().NewContext(req, rec)))
+	return rec
+}
+
+func embeddingOf(size int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"embedding": make([]float32, size)})
+	}
+}
+
+func TestReadyWhenEmbeddingModelAnswers(t *testing.T) {
+	rec := readiness(t, embeddingOf(4), 4)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestNotReadyWhenEmbeddingModelFails(t *testing.T) {
+	tests := []struct {
+		name       string
+		embeddings http.HandlerFunc
+	}{
+		{
+			name: "server error",
+			embeddings: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		},
+		{
+			name: "model not loaded",
+			embeddings: func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "model not found"})
+			},
+		},
+		{
+			name:       "wrong dimension",
+			embeddings: embeddingOf(3),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := readiness(t, tt.embeddings, 4)
+			assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+			var body struct {
+				Failures map[string]string `json:"failures"`
+			}
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Contains(t, body.Failures, "embedding")
+			// Chat still works, only the embedding check fails
+			assert.NotContains(t, body.Failures, "ollama")
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BatchEmbedder is an Embedder that can embed several documents with one
+// request
+type BatchEmbedder interface {
+	Embedder
+	GetEmbeddings(texts []string) ([][]float32, error)
+}
+
+// wrappingEmbedder is implemented by embedders that wrap another one, and
+// take batches only when it does
+type wrappingEmbedder interface {
+	batches() bool
+}
+
+// CanBatch reports whether embedder embeds several documents with one request
+func CanBatch(embedder Embedder) bool {
+	if e, ok := embedder.(wrappingEmbedder); ok {
+		return e.batches()
+	}
+	_, ok := embedder.(BatchEmbedder)
+	return ok
+}
+
+// EmbedDocuments embeds texts that are stored to be searched, sending at most
+// batchSize of them per request to embedders that take batches, or all of
+// them when batchSize is 0. The embeddings are returned in the order of
+// texts. A batch that fails is embedded one text at a time so that one bad
+// input doesn't lose the rest; texts that still fail get a nil embedding and
+// are reported in the returned error.
+func EmbedDocuments(embedder Embedder, texts []string, batchSize int) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	batcher, ok := embedder.(BatchEmbedder)
+	if !ok || !CanBatch(embedder) {
+		return embeddings, embedEach(embedder, texts, embeddings, 0)
+	}
+	if batchSize <= 0 {
+		batchSize = len(texts)
+	}
+
+	var errs []error
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+		batch, err := batcher.GetEmbeddings(texts[start:end])
+		if err == nil && len(batch) != end-start {
+			err = fmt.Errorf("got %d embeddings for %d texts", len(batch), end-start)
+		}
+		if err != nil {
+			errs = append(errs, embedEach(embedder, texts[start:end], embeddings[start:end], start))
+			continue
+		}
+		copy(embeddings[start:end], batch)
+	}
+	return embeddings, errors.Join(errs...)
+}
+
+// embedEach embeds texts one at a time into embeddings, leaving the
+// embedding of a text that fails nil. Errors name the text by its index plus
+// offset.
+func embedEach(embedder Embedder, texts []string, embeddings [][]float32, offset int) error {
+	var errs []error
+	for i, text := range texts {
+		embedding, err := EmbedDocument(embedder, text)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to embed text %d: %w", offset+i, err))
+			continue
+		}
+		embeddings[i] = embedding
+	}
+	return errors.Join(errs...)
+}
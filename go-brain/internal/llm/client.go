@@ -6,23 +6,35 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 
+	"beebrain/internal/config"
+
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	ollamaEndpoint          = "http://ollama:11434/api/chat"
-	ollamaGenerateEndpoint  = "http://ollama:11434/api/generate"
-	ollamaEmbeddingEndpoint = "http://ollama:11434/api/embeddings"
+	defaultOllamaURL        = "http://ollama:11434"
+	ollamaEndpoint          = "/api/chat"
+	ollamaGenerateEndpoint  = "/api/generate"
+	ollamaEmbeddingEndpoint = "/api/embeddings"
 	defaultModel            = "llama3"
+
+	defaultEmbeddingMaxChars = 8000
+)
+
+// Strategies for handling embedding inputs longer than the configured limit
+const (
+	EmbeddingStrategyTruncate = "truncate"
+	EmbeddingStrategyChunk    = "chunk"
 )
 
 // LLMClient interface defines the methods for LLM operations
 type LLMClient interface {
-	Chat(messages []Message) (string, error)
-	Generate(prompt string) (string, error)
-	GetEmbedding(text string) ([]float32, error)
+	Chat(messages []Message, opts ...Option) (string, error)
+	Generate(prompt string, opts ...Option) (string, error)
+	Summarize(messages []Message, opts ...Option) (string, error)
 }
 
 type User struct {
@@ -34,32 +46,100 @@ type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
 	User    *User  `json:"user,omitempty"`
+	// Label names the source of a message no one wrote, such as retrieved
+	// context or a summary, where prompts are rendered as text
+	Label string `json:"-"`
 }
 
 type Client struct {
-	logger *logrus.Logger
-	Name   string
+	logger            *logrus.Logger
+	Name              string
+	baseURL           string
+	model             string
+	embeddingModel    string
+	logPrompts        bool
+	redactPrompts     bool
+	embeddingMaxChars int
+	embeddingStrategy string
+	embeddingSample   int
+	maxResponseTokens int
+	contextSize       int
+	limiter           *callLimiter
+	embeddingLimiter  *callLimiter
+	summaryLength     summaryLength
 }
 
 func NewClient(logger *logrus.Logger, name string) *Client {
+	baseURL := config.String("OLLAMA_API_URL", defaultOllamaURL)
+
+	// Inputs longer than this are truncated or chunked before embedding
+	embeddingMaxChars := config.Int(logger, "EMBEDDING_MAX_CHARS", defaultEmbeddingMaxChars)
+	if embeddingMaxChars <= 0 {
+		logger.Warnf("Invalid EMBEDDING_MAX_CHARS '%d', defaulting to %d", embeddingMaxChars, defaultEmbeddingMaxChars)
+		embeddingMaxChars = defaultEmbeddingMaxChars
+	}
+
+	embeddingStrategy := os.Getenv("EMBEDDING_OVERFLOW_STRATEGY")
+	switch embeddingStrategy {
+	case EmbeddingStrategyTruncate, EmbeddingStrategyChunk:
+	case "":
+		embeddingStrategy = EmbeddingStrategyTruncate
+	default:
+		logger.Warnf("Invalid EMBEDDING_OVERFLOW_STRATEGY '%s', defaulting to '%s'", embeddingStrategy, EmbeddingStrategyTruncate)
+		embeddingStrategy = EmbeddingStrategyTruncate
+	}
+
 	return &Client{
-		logger: logger,
-		Name:   name,
+		logger:            logger,
+		Name:              name,
+		baseURL:           strings.TrimSuffix(baseURL, "/"),
+		model:             config.String("LLM_MODEL", defaultModel),
+		embeddingModel:    config.String("OLLAMA_EMBEDDING_MODEL", defaultModel),
+		embeddingMaxChars: embeddingMaxChars,
+		embeddingStrategy: embeddingStrategy,
+		// 0 logs only the size of embeddings, not a sample of them
+		embeddingSample: config.Int(logger, "EMBEDDING_LOG_SAMPLE", 0),
+		// 0 leaves the response length up to the model
+		maxResponseTokens: config.Int(logger, "MAX_RESPONSE_TOKENS", 0),
+		// 0 disables context overflow detection
+		contextSize: config.Int(logger, "LLM_CONTEXT_SIZE", 0),
+		// Full prompts are only logged on request since they may contain PII
+		logPrompts:    config.Bool(logger, "LOG_PROMPTS", false),
+		redactPrompts: config.Bool(logger, "LOG_PROMPTS_REDACT", true),
+		// 0 lets every call run at once, otherwise calls wait for a slot up
+		// to LLM_QUEUE_WAIT
+		limiter: newCallLimiter(config.Int(logger, "LLM_MAX_CONCURRENT", 0), config.Duration(logger, "LLM_QUEUE_WAIT", 0)),
+		// Embeddings are limited on their own so indexing can't starve
+		// answers of slots, and wait for a slot however long it takes
+		embeddingLimiter: newCallLimiter(config.Int(logger, "MAX_CONCURRENT_EMBEDDINGS", 0), 0),
+		// Unset leaves the length of summaries up to the model
+		summaryLength: loadSummaryLength(logger),
 	}
 }
 
-func (c *Client) Chat(messages []Message) (string, error) {
-	// Add system message for context
-	messages = append(messages, Message{
-		Role:    "system",
-		Content: "Respond in a conversational, human voice, with a neutral tone. Use short sentences and simple words. Avoid academic language, transition phrases, and corporate jargon. Make it sound like someone talking to a friend in simple terms. Keep the key points but strip away any unnecessary words. Use Slack formatting: *bold* for emphasis, _italic_ for subtle emphasis, `code` for code, ```code block``` for multiple lines of code, and • for bullet points. Do not use markdown formatting.",
-	})
+func (c *Client) Chat(messages []Message, opts ...Option) (string, error) {
+	model := c.modelFor(opts)
+
+	// Add system message for context, except in JSON mode where a persona's
+	// formatting instructions would fight the format
+	if !ApplyOptions(opts...).JSON {
+		messages = append(messages, Message{
+			Role:    "system",
+			Content: c.personaFor(opts),
+		})
+	}
 
 	reqBody := map[string]interface{}{
-		"model":    defaultModel,
+		"model":    model,
 		"messages": messages,
 		"stream":   false, // Disable streaming for now
 	}
+	if options := c.requestOptions(opts); options != nil {
+		reqBody["options"] = options
+	}
+	if ApplyOptions(opts...).JSON {
+		reqBody["format"] = "json"
+	}
 
 	// Marshal the request
 	jsonBody, err := json.Marshal(reqBody)
@@ -67,10 +147,16 @@ func (c *Client) Chat(messages []Message) (string, error) {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	c.logger.Infof("Sending request to LLM (model: %s, messages: %d)", defaultModel, len(messages))
+	c.logger.Infof("Sending request to LLM (model: %s, messages: %d)", model, len(messages))
+	c.logPrompt(formatMessages(messages))
 
-	// Make the request
-	resp, err := http.Post(ollamaEndpoint, "application/json", bytes.NewBuffer(jsonBody))
+	// Wait for a free slot, then make the request
+	release, err := c.limiter.acquire()
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	resp, err := http.Post(c.baseURL+ollamaEndpoint, "application/json", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return "", fmt.Errorf("failed to make request: %w", err)
 	}
@@ -91,6 +177,7 @@ func (c *Client) Chat(messages []Message) (string, error) {
 			Content string `json:"content"`
 		} `json:"message"`
 		Done bool `json:"done"`
+		Timings
 	}
 	if err := json.Unmarshal(body, &response); err != nil {
 		c.logger.Errorf("Failed to decode LLM response: %v", err)
@@ -102,20 +189,32 @@ func (c *Client) Chat(messages []Message) (string, error) {
 	}
 
 	c.logger.Infof("Received response from LLM (model: %s, length: %d)", response.Model, len(response.Message.Content))
+	c.logTimings(model, response.Timings)
+	if err := c.checkOverflow(model, response.PromptEvalCount, response.Message.Content); err != nil {
+		return "", err
+	}
 	return response.Message.Content, nil
 }
 
-func (c *Client) Generate(prompt string) (string, error) {
-	// Append instructions to the prompt
-	prompt = fmt.Sprintf("%s\nRespond in a conversational, human voice, with a neutral tone. Use short sentences and simple words. Avoid academic language, transition phrases, and corporate jargon. Make it sound like someone talking to a friend in simple terms. Keep the key points but strip away any unnecessary words. Use Slack formatting: *bold* for emphasis, _italic_ for subtle emphasis, `code` for code, ```code block``` for multiple lines of code, and • for bullet points. Do not use markdown formatting.", prompt)
+func (c *Client) Generate(prompt string, opts ...Option) (string, error) {
+	model := c.modelFor(opts)
 
-	c.logger.Debugf("Generating response for prompt: %s", prompt)
+	// Append instructions to the prompt, except in JSON mode
+	if !ApplyOptions(opts...).JSON {
+		prompt = fmt.Sprintf("%s\n%s", prompt, c.personaFor(opts))
+	}
 
 	reqBody := map[string]interface{}{
-		"model":  defaultModel,
+		"model":  model,
 		"prompt": prompt,
 		"stream": false,
 	}
+	if options := c.requestOptions(opts); options != nil {
+		reqBody["options"] = options
+	}
+	if ApplyOptions(opts...).JSON {
+		reqBody["format"] = "json"
+	}
 
 	// Marshal the request
 	jsonBody, err := json.Marshal(reqBody)
@@ -123,10 +222,16 @@ func (c *Client) Generate(prompt string) (string, error) {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	c.logger.Infof("Sending generation request to LLM (model: %s)", defaultModel)
+	c.logger.Infof("Sending generation request to LLM (model: %s)", model)
+	c.logPrompt(prompt)
 
-	// Make the request
-	resp, err := http.Post(ollamaGenerateEndpoint, "application/json", bytes.NewBuffer(jsonBody))
+	// Wait for a free slot, then make the request
+	release, err := c.limiter.acquire()
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	resp, err := http.Post(c.baseURL+ollamaGenerateEndpoint, "application/json", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return "", fmt.Errorf("failed to make request: %w", err)
 	}
@@ -144,6 +249,7 @@ func (c *Client) Generate(prompt string) (string, error) {
 		CreatedAt string `json:"created_at"`
 		Response  string `json:"response"`
 		Done      bool   `json:"done"`
+		Timings
 	}
 	if err := json.Unmarshal(body, &response); err != nil {
 		c.logger.Errorf("Failed to decode LLM generation response: %v", err)
@@ -155,14 +261,22 @@ func (c *Client) Generate(prompt string) (string, error) {
 	}
 
 	c.logger.Infof("Received generation response from LLM (model: %s, length: %d)", response.Model, len(response.Response))
+	c.logTimings(model, response.Timings)
+	if err := c.checkOverflow(model, response.PromptEvalCount, response.Response); err != nil {
+		return "", err
+	}
 	return response.Response, nil
 }
 
-// Summarize takes a list of messages and generates a summary
-func (c *Client) Summarize(messages []Message) (string, error) {
+// Summarize takes a list of messages and generates a summary, of
+// SUMMARY_LENGTH when it is set. The response is then capped at the tokens
+// that length takes, unless the call sets its own cap, and cut to length.
+func (c *Client) Summarize(messages []Message, opts ...Option) (string, error) {
 	// Create a prompt for summarization
 	var prompt strings.Builder
-	prompt.WriteString("Please provide a concise summary of the following conversation thread. Focus on the key points and main ideas. Keep it brief but informative. Use bullet points for clarity:\n\n")
+	prompt.WriteString("Please provide a concise summary of the following conversation thread. Focus on the key points and main ideas. Keep it brief but informative. ")
+	prompt.WriteString(c.summaryLength.instruction())
+	prompt.WriteString("\n\n")
 
 	// Add all messages to the prompt
 	for _, msg := range messages {
@@ -172,13 +286,109 @@ func (c *Client) Summarize(messages []Message) (string, error) {
 	// Add final instruction
 	prompt.WriteString("\nSummary:")
 
+	if c.summaryLength.count > 0 && ApplyOptions(opts...).MaxTokens <= 0 {
+		opts = append(opts, WithMaxTokens(c.summaryLength.maxTokens()))
+	}
+
 	// Use the Generate function with the summarization prompt
-	return c.Generate(prompt.String())
+	summary, err := c.Generate(prompt.String(), opts...)
+	if err != nil {
+		return "", err
+	}
+	return c.summaryLength.truncate(summary), nil
+}
+
+// modelFor returns the model a call should use, honoring any override
+func (c *Client) modelFor(opts []Option) string {
+	if model := ApplyOptions(opts...).Model; model != "" {
+		return model
+	}
+	return c.model
 }
 
+// DefaultPersona tells the model how to sound and format its answers, unless
+// a call brings its own persona
+const DefaultPersona = "Respond in a conversational, human voice, with a neutral tone. Use short sentences and simple words. Avoid academic language, transition phrases, and corporate jargon. Make it sound like someone talking to a friend in simple terms. Keep the key points but strip away any unnecessary words. Use Slack formatting: *bold* for emphasis, _italic_ for subtle emphasis, `code` for code, ```code block``` for multiple lines of code, and • for bullet points. Do not use markdown formatting."
+
+// personaFor returns the persona of a call, the default one unless opts
+// override it
+func (c *Client) personaFor(opts []Option) string {
+	if persona := ApplyOptions(opts...).Persona; persona != "" {
+		return persona
+	}
+	return DefaultPersona
+}
+
+// requestOptions returns the Ollama model options for a call, or nil when
+// there are none to send
+func (c *Client) requestOptions(opts []Option) map[string]interface{} {
+	maxTokens := c.maxResponseTokens
+	if override := ApplyOptions(opts...).MaxTokens; override > 0 {
+		maxTokens = override
+	}
+	if maxTokens <= 0 {
+		return nil
+	}
+	return map[string]interface{}{"num_predict": maxTokens}
+}
+
+// GetEmbedding returns the embedding for text. Inputs longer than the
+// configured limit are either truncated or split into chunks whose
+// embeddings are averaged, depending on the configured strategy.
 func (c *Client) GetEmbedding(text string) ([]float32, error) {
+	runes := []rune(text)
+	if len(runes) <= c.embeddingMaxChars {
+		return c.embed(text)
+	}
+
+	if c.embeddingStrategy == EmbeddingStrategyChunk {
+		c.logger.Infof("Embedding input of %d chars exceeds limit of %d, chunking", len(runes), c.embeddingMaxChars)
+		return c.embedChunks(runes)
+	}
+
+	c.logger.Warnf("Embedding input of %d chars exceeds limit of %d, truncating", len(runes), c.embeddingMaxChars)
+	return c.embed(string(runes[:c.embeddingMaxChars]))
+}
+
+// embedChunks embeds each chunk of the input separately and averages the
+// resulting vectors
+func (c *Client) embedChunks(runes []rune) ([]float32, error) {
+	var sum []float32
+	chunks := 0
+	for start := 0; start < len(runes); start += c.embeddingMaxChars {
+		end := start + c.embeddingMaxChars
+		if end > len(runes) {
+			end = len(runes)
+		}
+
+		embedding, err := c.embed(string(runes[start:end]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed chunk %d: %w", chunks, err)
+		}
+
+		if sum == nil {
+			sum = make([]float32, len(embedding))
+		}
+		if len(embedding) != len(sum) {
+			return nil, fmt.Errorf("chunk %d embedding size %d does not match %d", chunks, len(embedding), len(sum))
+		}
+		for i, v := range embedding {
+			sum[i] += v
+		}
+		chunks++
+	}
+
+	for i := range sum {
+		sum[i] /= float32(chunks)
+	}
+
+	c.logger.Debugf("Averaged %d chunk embeddings of size: %d", chunks, len(sum))
+	return sum, nil
+}
+
+func (c *Client) embed(text string) ([]float32, error) {
 	reqBody := map[string]interface{}{
-		"model":  defaultModel,
+		"model":  c.embeddingModel,
 		"prompt": text,
 	}
 
@@ -190,8 +400,13 @@ func (c *Client) GetEmbedding(text string) ([]float32, error) {
 
 	c.logger.Debugf("Getting embedding for text: %s", text)
 
-	// Make the request
-	resp, err := http.Post(ollamaEmbeddingEndpoint, "application/json", bytes.NewBuffer(jsonBody))
+	// Wait for a free slot, then make the request
+	release, err := c.embeddingLimiter.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp, err := http.Post(c.baseURL+ollamaEmbeddingEndpoint, "application/json", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -212,6 +427,6 @@ func (c *Client) GetEmbedding(text string) ([]float32, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	c.logger.Debugf("Received embedding of size: %d", len(response.Embedding))
+	logEmbedding(c.logger, response.Embedding, c.embeddingSample)
 	return response.Embedding, nil
 }
@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"beebrain/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultOpenAIURL            = "https://api.openai.com/v1"
+	defaultOpenAIEmbeddingModel = "text-embedding-3-small"
+	openAIEmbeddingEndpoint     = "/embeddings"
+)
+
+// Embedding providers selectable with EMBEDDING_PROVIDER
+const (
+	EmbeddingProviderOllama = "ollama"
+	EmbeddingProviderOpenAI = "openai"
+)
+
+// Embedder interface defines the methods for turning text into vectors. It is
+// configured independently of the chat backend.
+type Embedder interface {
+	GetEmbedding(text string) ([]float32, error)
+}
+
+// NewEmbedder returns the embedder selected by EMBEDDING_PROVIDER. The Ollama
+// provider reuses the chat client's connection settings. Documents and
+// queries are prefixed with EMBEDDING_DOCUMENT_PREFIX and
+// EMBEDDING_QUERY_PREFIX, and with EMBEDDING_NORMALIZE set, embeddings are
+// scaled to unit length.
+func NewEmbedder(logger *logrus.Logger, ollamaClient *Client) (Embedder, error) {
+	embedder, err := newProviderEmbedder(logger, ollamaClient)
+	if err != nil {
+		return nil, err
+	}
+
+	documentPrefix, queryPrefix := os.Getenv("EMBEDDING_DOCUMENT_PREFIX"), os.Getenv("EMBEDDING_QUERY_PREFIX")
+	if documentPrefix != "" || queryPrefix != "" {
+		logger.Infof("Prefixing documents with '%s' and queries with '%s' before embedding", documentPrefix, queryPrefix)
+		embedder = &prefixingEmbedder{embedder: embedder, documentPrefix: documentPrefix, queryPrefix: queryPrefix}
+	}
+
+	if config.Bool(logger, "EMBEDDING_NORMALIZE", false) {
+		logger.Info("Normalizing embeddings to unit length")
+		return &normalizingEmbedder{embedder: embedder}, nil
+	}
+	return embedder, nil
+}
+
+func newProviderEmbedder(logger *logrus.Logger, ollamaClient *Client) (Embedder, error) {
+	switch provider := config.String("EMBEDDING_PROVIDER", EmbeddingProviderOllama); provider {
+	case EmbeddingProviderOllama:
+		return ollamaClient, nil
+	case EmbeddingProviderOpenAI:
+		return NewOpenAIEmbedder(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown EMBEDDING_PROVIDER '%s', expected '%s' or '%s'", provider, EmbeddingProviderOllama, EmbeddingProviderOpenAI)
+	}
+}
+
+// OpenAIEmbedder gets embeddings from an OpenAI-compatible embeddings API
+type OpenAIEmbedder struct {
+	logger  *logrus.Logger
+	baseURL string
+	apiKey  string
+	model   string
+	// logSample is how many components of an embedding are logged
+	logSample int
+	// limiter bounds how many embedding requests run at once
+	limiter *callLimiter
+}
+
+func NewOpenAIEmbedder(logger *logrus.Logger) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		logger:  logger,
+		baseURL: strings.TrimSuffix(config.String("EMBEDDING_API_URL", defaultOpenAIURL), "/"),
+		apiKey:  os.Getenv("EMBEDDING_API_KEY"),
+		model:   config.String("EMBEDDING_MODEL", defaultOpenAIEmbeddingModel),
+		// 0 logs only the size of embeddings, not a sample of them
+		logSample: config.Int(logger, "EMBEDDING_LOG_SAMPLE", 0),
+		// 0 lets every request run at once
+		limiter: newCallLimiter(config.Int(logger, "MAX_CONCURRENT_EMBEDDINGS", 0), 0),
+	}
+}
+
+func (e *OpenAIEmbedder) GetEmbedding(text string) ([]float32, error) {
+	embeddings, err := e.embed(text)
+	if err != nil {
+		return nil, err
+	}
+
+	logEmbedding(e.logger, embeddings[0], e.logSample)
+	return embeddings[0], nil
+}
+
+// GetEmbeddings embeds several texts with one request, returning their
+// embeddings in the same order
+func (e *OpenAIEmbedder) GetEmbeddings(texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	embeddings, err := e.embed(texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedding API returned %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+
+	e.logger.Debugf("Received %d embeddings in one batch", len(embeddings))
+	return embeddings, nil
+}
+
+// embed requests the embeddings of input, a text or a list of texts, and
+// returns them in the order of the input
+func (e *OpenAIEmbedder) embed(input interface{}) ([][]float32, error) {
+	reqBody := map[string]interface{}{
+		"model": e.model,
+		"input": input,
+	}
+
+	// Marshal the request
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.baseURL+openAIEmbeddingEndpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	e.logger.Debugf("Getting embedding from %s (model: %s)", e.baseURL, e.model)
+
+	// Wait for a free slot, then make the request
+	release, err := e.limiter.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Read the response
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Parse the response
+	var response struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+			Index     int       `json:"index"`
+		} `json:"data"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		e.logger.Errorf("Failed to decode embedding response: %v", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if response.Error != nil {
+		return nil, fmt.Errorf("embedding API error: %s", response.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding API returned status %d", resp.StatusCode)
+	}
+	if len(response.Data) == 0 {
+		return nil, fmt.Errorf("embedding API returned no data")
+	}
+
+	// The API may return the embeddings out of order, their index says which
+	// input each belongs to
+	embeddings := make([][]float32, len(response.Data))
+	for _, data := range response.Data {
+		if data.Index < 0 || data.Index >= len(embeddings) {
+			return nil, fmt.Errorf("embedding API returned index %d for %d inputs", data.Index, len(embeddings))
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EmbeddingSample formats the first n components and the norm of an
+// embedding, so that it can be logged without printing the whole vector
+func EmbeddingSample(embedding []float32, n int) string {
+	var sumSquares float64
+	for _, v := range embedding {
+		sumSquares += float64(v) * float64(v)
+	}
+
+	if n > len(embedding) {
+		n = len(embedding)
+	}
+	components := make([]string, n)
+	for i, v := range embedding[:n] {
+		components[i] = fmt.Sprintf("%.4f", v)
+	}
+	sample := strings.Join(components, ", ")
+	if n < len(embedding) {
+		sample += ", ..."
+	}
+
+	return fmt.Sprintf("[%s] (norm: %.4f)", sample, math.Sqrt(sumSquares))
+}
+
+// logEmbedding logs the size of an embedding, and a sample of its first
+// sample components when sample is positive
+func logEmbedding(logger *logrus.Logger, embedding []float32, sample int) {
+	if sample <= 0 {
+		logger.Debugf("Received embedding of size: %d", len(embedding))
+		return
+	}
+	logger.Debugf("Received embedding of size: %d %s", len(embedding), EmbeddingSample(embedding, sample))
+}
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const ollamaTagsEndpoint = "/api/tags"
+
+// Ping checks that Ollama is up by listing its models
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+ollamaTagsEndpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Ollama returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// PingEmbedding checks that embedder works by embedding a short text, which
+// fails when its model isn't available even though the chat model is. When
+// dimension is positive the embedding must have that many values, so an
+// embedder that no longer matches the vector store is caught too.
+func PingEmbedding(ctx context.Context, embedder Embedder, dimension int) error {
+	type result struct {
+		embedding []float32
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		embedding, err := embedder.GetEmbedding("ping")
+		done <- result{embedding: embedding, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("embedding check timed out: %w", ctx.Err())
+	case r := <-done:
+		if r.err != nil {
+			return fmt.Errorf("failed to get embedding: %w", r.err)
+		}
+		if len(r.embedding) == 0 {
+			return fmt.Errorf("embedding model returned an empty embedding")
+		}
+		if dimension > 0 && len(r.embedding) != dimension {
+			return fmt.Errorf("embedding has %d dimensions, expected %d", len(r.embedding), dimension)
+		}
+		return nil
+	}
+}
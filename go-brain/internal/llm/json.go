@@ -0,0 +1,46 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const jsonRepairPrompt = "Your previous response was supposed to be valid JSON but it failed to parse (%v). Reply with the corrected JSON only, without any explanation.\n\nPrevious response:\n%s"
+
+// GenerateJSON asks client to answer prompt in JSON mode and unmarshals the
+// response into v. When the response isn't valid JSON the model is asked
+// once to repair it before giving up.
+func GenerateJSON(client LLMClient, prompt string, v interface{}, opts ...Option) error {
+	opts = append(opts, WithJSON())
+	response, err := client.Generate(prompt, opts...)
+	if err != nil {
+		return err
+	}
+
+	parseErr := unmarshalResponse(response, v)
+	if parseErr == nil {
+		return nil
+	}
+
+	repaired, err := client.Generate(fmt.Sprintf(jsonRepairPrompt, parseErr, response), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to repair JSON response: %w", err)
+	}
+	if err := unmarshalResponse(repaired, v); err != nil {
+		return fmt.Errorf("failed to parse JSON response after repair: %w", err)
+	}
+	return nil
+}
+
+// unmarshalResponse unmarshals a JSON response into v, ignoring a code fence
+// the model may have wrapped it in
+func unmarshalResponse(response string, v interface{}) error {
+	response = strings.TrimSpace(response)
+	if strings.HasPrefix(response, "```") {
+		response = strings.TrimPrefix(response, "```json")
+		response = strings.TrimPrefix(response, "```")
+		response = strings.TrimSuffix(strings.TrimSpace(response), "```")
+	}
+	return json.Unmarshal([]byte(response), v)
+}
@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBusy is returned when a call couldn't get one of the LLM_MAX_CONCURRENT
+// slots within LLM_QUEUE_WAIT
+var ErrBusy = errors.New("LLM is busy")
+
+// callLimiter bounds how many LLM calls run at once. A call waits up to wait
+// for a slot to free up, or as long as it takes when wait is 0. A nil
+// limiter lets every call through.
+type callLimiter struct {
+	slots chan struct{}
+	wait  time.Duration
+}
+
+func newCallLimiter(max int, wait time.Duration) *callLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &callLimiter{slots: make(chan struct{}, max), wait: wait}
+}
+
+// acquire takes a slot and returns the function releasing it
+func (l *callLimiter) acquire() (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if l.wait <= 0 {
+		l.slots <- struct{}{}
+		return l.release, nil
+	}
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: no free slot after %s", ErrBusy, l.wait)
+	}
+}
+
+func (l *callLimiter) release() {
+	<-l.slots
+}
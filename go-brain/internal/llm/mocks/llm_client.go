@@ -11,17 +11,27 @@ type MockLLMClient struct {
 	mock.Mock
 }
 
-func (m *MockLLMClient) Chat(messages []llm.Message) (string, error) {
-	args := m.Called(messages)
+func (m *MockLLMClient) Chat(messages []llm.Message, opts ...llm.Option) (string, error) {
+	args := m.Called(messages, opts)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockLLMClient) Generate(prompt string) (string, error) {
-	args := m.Called(prompt)
+func (m *MockLLMClient) Generate(prompt string, opts ...llm.Option) (string, error) {
+	args := m.Called(prompt, opts)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockLLMClient) GetEmbedding(text string) ([]float32, error) {
+func (m *MockLLMClient) Summarize(messages []llm.Message, opts ...llm.Option) (string, error) {
+	args := m.Called(messages, opts)
+	return args.String(0), args.Error(1)
+}
+
+// MockEmbedder is a mock implementation of Embedder
+type MockEmbedder struct {
+	mock.Mock
+}
+
+func (m *MockEmbedder) GetEmbedding(text string) ([]float32, error) {
 	args := m.Called(text)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
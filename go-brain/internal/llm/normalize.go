@@ -0,0 +1,60 @@
+package llm
+
+import "math"
+
+// normalizingEmbedder scales every embedding to unit length, so cosine and
+// dot-product scores are comparable across models
+type normalizingEmbedder struct {
+	embedder Embedder
+}
+
+func (e *normalizingEmbedder) GetEmbedding(text string) ([]float32, error) {
+	return e.EmbedDocument(text)
+}
+
+func (e *normalizingEmbedder) EmbedDocument(text string) ([]float32, error) {
+	return normalized(EmbedDocument(e.embedder, text))
+}
+
+func (e *normalizingEmbedder) EmbedQuery(text string) ([]float32, error) {
+	return normalized(EmbedQuery(e.embedder, text))
+}
+
+func (e *normalizingEmbedder) batches() bool {
+	return CanBatch(e.embedder)
+}
+
+func (e *normalizingEmbedder) GetEmbeddings(texts []string) ([][]float32, error) {
+	embeddings, err := EmbedDocuments(e.embedder, texts, 0)
+	for i, embedding := range embeddings {
+		if embedding != nil {
+			embeddings[i] = Normalize(embedding)
+		}
+	}
+	return embeddings, err
+}
+
+func normalized(embedding []float32, err error) ([]float32, error) {
+	if err != nil {
+		return nil, err
+	}
+	return Normalize(embedding), nil
+}
+
+// Normalize returns v scaled to unit L2 norm. A zero vector is returned as is.
+func Normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+
+	norm := math.Sqrt(sum)
+	normalized := make([]float32, len(v))
+	for i, x := range v {
+		normalized[i] = float32(float64(x) / norm)
+	}
+	return normalized
+}
@@ -0,0 +1,56 @@
+package llm
+
+// CallOptions overrides client defaults for a single LLM call
+type CallOptions struct {
+	// Model replaces the client's default model when not empty
+	Model string
+	// MaxTokens replaces the client's response token cap when positive
+	MaxTokens int
+	// Persona replaces the default instructions on tone and formatting when
+	// not empty
+	Persona string
+	// JSON asks the model to answer with valid JSON only
+	JSON bool
+}
+
+// Option sets a field of CallOptions
+type Option func(*CallOptions)
+
+// WithModel runs the call against model instead of the default one
+func WithModel(model string) Option {
+	return func(o *CallOptions) {
+		o.Model = model
+	}
+}
+
+// WithMaxTokens caps the response of the call at n tokens
+func WithMaxTokens(n int) Option {
+	return func(o *CallOptions) {
+		o.MaxTokens = n
+	}
+}
+
+// WithPersona answers with persona instead of the default tone and
+// formatting instructions
+func WithPersona(persona string) Option {
+	return func(o *CallOptions) {
+		o.Persona = persona
+	}
+}
+
+// WithJSON constrains the response of the call to valid JSON, using the
+// JSON format mode of Ollama
+func WithJSON() Option {
+	return func(o *CallOptions) {
+		o.JSON = true
+	}
+}
+
+// ApplyOptions resolves opts into the CallOptions for a call
+func ApplyOptions(opts ...Option) CallOptions {
+	var options CallOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
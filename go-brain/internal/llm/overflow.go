@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrContextOverflow is matched by errors.Is when a prompt didn't fit in the
+// model's context window
+var ErrContextOverflow = errors.New("prompt exceeds the model context window")
+
+// ContextOverflowError reports a prompt that filled the model's context
+// window, so Ollama dropped its start. Response is what the model answered
+// with the truncated prompt, for callers that would rather use it than fail.
+type ContextOverflowError struct {
+	PromptTokens int
+	ContextSize  int
+	Response     string
+}
+
+func (e *ContextOverflowError) Error() string {
+	return fmt.Sprintf("%v: prompt used %d of %d tokens", ErrContextOverflow, e.PromptTokens, e.ContextSize)
+}
+
+func (e *ContextOverflowError) Is(target error) bool {
+	return target == ErrContextOverflow
+}
+
+// checkOverflow returns a ContextOverflowError when a prompt of promptTokens
+// filled the configured context window. Ollama truncates such prompts
+// silently and reports at most the window size as its token count.
+func (c *Client) checkOverflow(model string, promptTokens int, response string) error {
+	if c.contextSize <= 0 || promptTokens < c.contextSize {
+		return nil
+	}
+	c.logger.Warnf("Prompt filled the context window of %s (%d of %d tokens), the start of it was dropped", model, promptTokens, c.contextSize)
+	return &ContextOverflowError{PromptTokens: promptTokens, ContextSize: c.contextSize, Response: response}
+}
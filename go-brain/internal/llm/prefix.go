@@ -0,0 +1,58 @@
+package llm
+
+// QueryEmbedder is an Embedder that embeds search queries differently from
+// the documents they are searched against, as instruction-tuned embedding
+// models expect. GetEmbedding embeds documents.
+type QueryEmbedder interface {
+	Embedder
+	EmbedDocument(text string) ([]float32, error)
+	EmbedQuery(text string) ([]float32, error)
+}
+
+// EmbedDocument embeds text that is stored to be searched
+func EmbedDocument(embedder Embedder, text string) ([]float32, error) {
+	if e, ok := embedder.(QueryEmbedder); ok {
+		return e.EmbedDocument(text)
+	}
+	return embedder.GetEmbedding(text)
+}
+
+// EmbedQuery embeds text that stored documents are searched with
+func EmbedQuery(embedder Embedder, text string) ([]float32, error) {
+	if e, ok := embedder.(QueryEmbedder); ok {
+		return e.EmbedQuery(text)
+	}
+	return embedder.GetEmbedding(text)
+}
+
+// prefixingEmbedder prepends the prefixes instruction-tuned models such as
+// nomic-embed-text expect, e.g. "search_document: " and "search_query: "
+type prefixingEmbedder struct {
+	embedder       Embedder
+	documentPrefix string
+	queryPrefix    string
+}
+
+func (e *prefixingEmbedder) GetEmbedding(text string) ([]float32, error) {
+	return e.EmbedDocument(text)
+}
+
+func (e *prefixingEmbedder) EmbedDocument(text string) ([]float32, error) {
+	return e.embedder.GetEmbedding(e.documentPrefix + text)
+}
+
+func (e *prefixingEmbedder) EmbedQuery(text string) ([]float32, error) {
+	return e.embedder.GetEmbedding(e.queryPrefix + text)
+}
+
+func (e *prefixingEmbedder) batches() bool {
+	return CanBatch(e.embedder)
+}
+
+func (e *prefixingEmbedder) GetEmbeddings(texts []string) ([][]float32, error) {
+	prefixed := make([]string, len(texts))
+	for i, text := range texts {
+		prefixed[i] = e.documentPrefix + text
+	}
+	return EmbedDocuments(e.embedder, prefixed, 0)
+}
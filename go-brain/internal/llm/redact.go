@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// sensitivePatterns match values that must not end up in logs
+var sensitivePatterns = []*regexp.Regexp{
+	// Email addresses
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	// Slack tokens
+	regexp.MustCompile(`xox[abposr]-[A-Za-z0-9-]+`),
+	// OpenAI style API keys
+	regexp.MustCompile(`sk-[A-Za-z0-9_-]{16,}`),
+	// Bearer tokens in pasted headers
+	regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`),
+}
+
+const redacted = "[REDACTED]"
+
+// SensitivePatterns returns the patterns Redact masks, for filters that mask
+// them elsewhere
+func SensitivePatterns() []*regexp.Regexp {
+	return append([]*regexp.Regexp(nil), sensitivePatterns...)
+}
+
+// Redact masks emails, tokens and API keys in text
+func Redact(text string) string {
+	for _, pattern := range sensitivePatterns {
+		text = pattern.ReplaceAllString(text, redacted)
+	}
+	return text
+}
+
+// logPrompt logs the full prompt sent to the LLM at debug level when
+// LOG_PROMPTS is enabled. The prompt is logged as a field so log formatters
+// that shorten messages leave it intact.
+func (c *Client) logPrompt(prompt string) {
+	if !c.logPrompts {
+		return
+	}
+	if c.redactPrompts {
+		prompt = Redact(prompt)
+	}
+	c.logger.WithField("prompt", prompt).Debug("Sending prompt to LLM")
+}
+
+// formatMessages renders chat messages as a prompt transcript for logging
+func formatMessages(messages []Message) string {
+	var transcript strings.Builder
+	for _, msg := range messages {
+		transcript.WriteString(msg.Role)
+		if msg.User != nil {
+			transcript.WriteString(" (" + msg.User.SlackName + ")")
+		}
+		transcript.WriteString(": " + msg.Content + "\n")
+	}
+	return strings.TrimSuffix(transcript.String(), "\n")
+}
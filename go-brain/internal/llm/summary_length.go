@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"beebrain/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Units a summary length is counted in
+const (
+	SummaryBullets   = "bullets"
+	SummarySentences = "sentences"
+)
+
+// summaryTokensPer is roughly how many tokens a bullet or sentence of a
+// summary takes, to cap the response at the configured length
+var summaryTokensPer = map[string]int{
+	SummaryBullets:   60,
+	SummarySentences: 40,
+}
+
+// summaryLength is the target length of summaries, such as 5 bullets. A zero
+// count leaves the length up to the model.
+type summaryLength struct {
+	count int
+	unit  string
+}
+
+// loadSummaryLength reads SUMMARY_LENGTH, a count followed by bullets or
+// sentences, such as "5 bullets"
+func loadSummaryLength(logger *logrus.Logger) summaryLength {
+	value := config.String("SUMMARY_LENGTH", "")
+	if value == "" {
+		return summaryLength{}
+	}
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) == 2 {
+		count, err := strconv.Atoi(fields[0])
+		if _, ok := summaryTokensPer[fields[1]]; ok && err == nil && count > 0 {
+			return summaryLength{count: count, unit: fields[1]}
+		}
+	}
+	logger.Warnf("Invalid SUMMARY_LENGTH '%s', expected a count of %s or %s, leaving the length up to the model", value, SummaryBullets, SummarySentences)
+	return summaryLength{}
+}
+
+// instruction tells the model how long the summary should be
+func (l summaryLength) instruction() string {
+	switch {
+	case l.count == 0:
+		return "Use bullet points for clarity."
+	case l.unit == SummarySentences:
+		return fmt.Sprintf("Write at most %d sentences, without bullet points.", l.count)
+	default:
+		return fmt.Sprintf("Use at most %d bullet points.", l.count)
+	}
+}
+
+// maxTokens is the token cap of a summary of this length
+func (l summaryLength) maxTokens() int {
+	return l.count * summaryTokensPer[l.unit]
+}
+
+// summaryBullet matches the first line of a bullet point
+var summaryBullet = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s`)
+
+// summarySentenceEnd matches the end of a sentence
+var summarySentenceEnd = regexp.MustCompile(`[.!?](?:\s+|$)`)
+
+// truncate cuts a summary that runs past its length, keeping the first count
+// bullets or sentences
+func (l summaryLength) truncate(summary string) string {
+	if l.count == 0 {
+		return summary
+	}
+	if l.unit == SummarySentences {
+		ends := summarySentenceEnd.FindAllStringIndex(summary, l.count+1)
+		if len(ends) <= l.count || strings.TrimSpace(summary[ends[l.count-1][1]:]) == "" {
+			return summary
+		}
+		return strings.TrimSpace(summary[:ends[l.count-1][1]])
+	}
+
+	lines := strings.Split(summary, "\n")
+	bullets := 0
+	for i, line := range lines {
+		if !summaryBullet.MatchString(line) {
+			continue
+		}
+		if bullets++; bullets > l.count {
+			return strings.TrimRight(strings.Join(lines[:i], "\n"), "\n ")
+		}
+	}
+	return summary
+}
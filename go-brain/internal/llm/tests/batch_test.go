@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBatchEmbedder embeds each text as its length and records the size of
+// every batch. Batches containing a text in fail are rejected, and so are
+// those texts on their own.
+type fakeBatchEmbedder struct {
+	batches []int
+	fail    map[string]bool
+}
+
+func (e *fakeBatchEmbedder) GetEmbedding(text string) ([]float32, error) {
+	if e.fail[text] {
+		return nil, fmt.Errorf("cannot embed %q", text)
+	}
+	return []float32{float32(len(text))}, nil
+}
+
+func (e *fakeBatchEmbedder) GetEmbeddings(texts []string) ([][]float32, error) {
+	e.batches = append(e.batches, len(texts))
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		if e.fail[text] {
+			return nil, fmt.Errorf("batch contains %q", text)
+		}
+		embeddings[i] = []float32{float32(len(text))}
+	}
+	return embeddings, nil
+}
+
+func TestEmbedDocumentsSplitsIntoBatches(t *testing.T) {
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff", "ggggggg"}
+
+	tests := []struct {
+		name        string
+		batchSize   int
+		wantBatches []int
+	}{
+		{name: "Batches of the configured size", batchSize: 3, wantBatches: []int{3, 3, 1}},
+		{name: "One batch when it fits", batchSize: 10, wantBatches: []int{7}},
+		{name: "Everything at once without a limit", batchSize: 0, wantBatches: []int{7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder := &fakeBatchEmbedder{}
+
+			embeddings, err := llm.EmbedDocuments(embedder, texts, tt.batchSize)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBatches, embedder.batches)
+
+			// Embeddings come back in the order of the texts
+			assert.Len(t, embeddings, len(texts))
+			for i, embedding := range embeddings {
+				assert.Equal(t, []float32{float32(i + 1)}, embedding)
+			}
+		})
+	}
+}
+
+func TestEmbedDocumentsKeepsTheRestOfAFailedBatch(t *testing.T) {
+	embedder := &fakeBatchEmbedder{fail: map[string]bool{"bad": true}}
+
+	embeddings, err := llm.EmbedDocuments(embedder, []string{"a", "bad", "ccc", "dddd"}, 2)
+	assert.ErrorContains(t, err, "failed to embed text 1")
+	assert.Equal(t, [][]float32{{1}, nil, {3}, {4}}, embeddings)
+}
+
+func TestEmbedDocumentsWithoutBatchSupport(t *testing.T) {
+	embedder := &mocks.MockEmbedder{}
+	embedder.On("GetEmbedding", "one").Return([]float32{1}, nil)
+	embedder.On("GetEmbedding", "two").Return([]float32{2}, nil)
+	assert.False(t, llm.CanBatch(embedder))
+
+	// Each text is embedded on its own
+	embeddings, err := llm.EmbedDocuments(embedder, []string{"one", "two"}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}}, embeddings)
+	embedder.AssertNumberOfCalls(t, "GetEmbedding", 2)
+}
+
+func TestOpenAIEmbedderBatchKeepsInputOrder(t *testing.T) {
+	var inputs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		inputs = req.Input
+
+		// Returned out of order, the index says which input each is for
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"index": 1, "embedding": []float32{2}},
+				{"index": 0, "embedding": []float32{1}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("EMBEDDING_API_URL", server.URL)
+	embedder := llm.NewOpenAIEmbedder(logrus.New())
+	assert.True(t, llm.CanBatch(embedder))
+
+	embeddings, err := embedder.GetEmbeddings([]string{"first", "second"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, inputs)
+	assert.Equal(t, [][]float32{{1}, {2}}, embeddings)
+}
+
+func TestWrappedEmbedderBatchesOnlyWhenTheProviderDoes(t *testing.T) {
+	t.Setenv("EMBEDDING_NORMALIZE", "true")
+	t.Setenv("EMBEDDING_DOCUMENT_PREFIX", "doc: ")
+
+	t.Setenv("EMBEDDING_PROVIDER", "ollama")
+	embedder, err := llm.NewEmbedder(logrus.New(), llm.NewClient(logrus.New(), "BeeBrain"))
+	assert.NoError(t, err)
+	assert.False(t, llm.CanBatch(embedder))
+
+	var inputs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input []string `json:"input"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		inputs = req.Input
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{
+				{"index": 0, "embedding": []float32{3, 4}},
+				{"index": 1, "embedding": []float32{0, 2}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("EMBEDDING_PROVIDER", "openai")
+	t.Setenv("EMBEDDING_API_URL", server.URL)
+	embedder, err = llm.NewEmbedder(logrus.New(), nil)
+	assert.NoError(t, err)
+	assert.True(t, llm.CanBatch(embedder))
+
+	embeddings, err := llm.EmbedDocuments(embedder, []string{"a", "b"}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "doc: a,doc: b", strings.Join(inputs, ","))
+	assert.Equal(t, [][]float32{{0.6, 0.8}, {0, 1}}, embeddings)
+}
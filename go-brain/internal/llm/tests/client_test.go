@@ -0,0 +1,354 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// newEmbeddingServer starts a fake Ollama embeddings endpoint that records
+// the prompts it receives and returns a fixed-size embedding for each
+func newEmbeddingServer(t *testing.T, size int) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	prompts := []string{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/embeddings", r.URL.Path)
+
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		mu.Lock()
+		prompts = append(prompts, req.Prompt)
+		value := float32(len(prompts))
+		mu.Unlock()
+
+		embedding := make([]float32, size)
+		for i := range embedding {
+			embedding[i] = value
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"embedding": embedding})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &prompts
+}
+
+func TestGetEmbeddingChunksOversizedInput(t *testing.T) {
+	server, prompts := newEmbeddingServer(t, 4)
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	t.Setenv("EMBEDDING_MAX_CHARS", "10")
+	t.Setenv("EMBEDDING_OVERFLOW_STRATEGY", llm.EmbeddingStrategyChunk)
+
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+
+	// 25 characters split into chunks of 10, 10 and 5
+	embedding, err := client.GetEmbedding(strings.Repeat("a", 25))
+	assert.NoError(t, err)
+	assert.Len(t, embedding, 4)
+	assert.Len(t, *prompts, 3)
+
+	// The chunk embeddings are 1, 2 and 3 so the average is 2
+	for _, v := range embedding {
+		assert.InDelta(t, 2.0, v, 1e-6)
+	}
+}
+
+func TestGetEmbeddingTruncatesOversizedInput(t *testing.T) {
+	server, prompts := newEmbeddingServer(t, 4)
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	t.Setenv("EMBEDDING_MAX_CHARS", "10")
+	t.Setenv("EMBEDDING_OVERFLOW_STRATEGY", llm.EmbeddingStrategyTruncate)
+
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+
+	embedding, err := client.GetEmbedding(strings.Repeat("a", 25))
+	assert.NoError(t, err)
+	assert.Len(t, embedding, 4)
+	assert.Equal(t, []string{strings.Repeat("a", 10)}, *prompts)
+}
+
+func TestGetEmbeddingShortInputIsSentAsIs(t *testing.T) {
+	server, prompts := newEmbeddingServer(t, 4)
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	t.Setenv("EMBEDDING_MAX_CHARS", "10")
+	t.Setenv("EMBEDDING_OVERFLOW_STRATEGY", llm.EmbeddingStrategyChunk)
+
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+
+	embedding, err := client.GetEmbedding("short")
+	assert.NoError(t, err)
+	assert.Len(t, embedding, 4)
+	assert.Equal(t, []string{"short"}, *prompts)
+}
+
+func TestChatModelOverride(t *testing.T) {
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		models = append(models, req.Model)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"model":   req.Model,
+			"message": map[string]string{"role": "assistant", "content": "Hi!"},
+			"done":    true,
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	t.Setenv("LLM_MODEL", "llama3:8b")
+
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+
+	_, err := client.Chat([]llm.Message{{Role: "user", Content: "Hello"}})
+	assert.NoError(t, err)
+	_, err = client.Chat([]llm.Message{{Role: "user", Content: "Hello"}}, llm.WithModel("codellama"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"llama3:8b", "codellama"}, models)
+}
+
+func TestResponseTokenCap(t *testing.T) {
+	var caps []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model   string `json:"model"`
+			Options struct {
+				NumPredict int `json:"num_predict"`
+			} `json:"options"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		caps = append(caps, req.Options.NumPredict)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"model":    req.Model,
+			"message":  map[string]string{"role": "assistant", "content": "Hi!"},
+			"response": "Hi!",
+			"done":     true,
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	t.Setenv("MAX_RESPONSE_TOKENS", "256")
+
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+
+	_, err := client.Chat([]llm.Message{{Role: "user", Content: "Hello"}})
+	assert.NoError(t, err)
+	_, err = client.Generate("Hello", llm.WithMaxTokens(64))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []int{256, 64}, caps)
+}
+
+func TestResponseTokenCapOmittedByDefault(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": "Hi!"},
+			"done":    true,
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_API_URL", server.URL)
+
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+	_, err := client.Chat([]llm.Message{{Role: "user", Content: "Hello"}})
+	assert.NoError(t, err)
+	assert.NotContains(t, body, "options")
+}
+
+func TestContextOverflowDetection(t *testing.T) {
+	tests := []struct {
+		name         string
+		promptTokens int
+		overflow     bool
+	}{
+		{name: "Prompt fits in the window", promptTokens: 1500, overflow: false},
+		{name: "Prompt fills the window", promptTokens: 2048, overflow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"message":           map[string]string{"role": "assistant", "content": "Hi!"},
+					"done":              true,
+					"prompt_eval_count": tt.promptTokens,
+				})
+			}))
+			defer server.Close()
+
+			t.Setenv("OLLAMA_API_URL", server.URL)
+			t.Setenv("LLM_CONTEXT_SIZE", "2048")
+
+			client := llm.NewClient(logrus.New(), "BeeBrain")
+			response, err := client.Chat([]llm.Message{{Role: "user", Content: "Hello"}})
+			if !tt.overflow {
+				assert.NoError(t, err)
+				assert.Equal(t, "Hi!", response)
+				return
+			}
+
+			assert.ErrorIs(t, err, llm.ErrContextOverflow)
+			var overflow *llm.ContextOverflowError
+			if assert.ErrorAs(t, err, &overflow) {
+				assert.Equal(t, 2048, overflow.PromptTokens)
+				assert.Equal(t, "Hi!", overflow.Response)
+			}
+		})
+	}
+}
+
+func TestEmbeddingSampleIsBounded(t *testing.T) {
+	embedding := make([]float32, 4096)
+	embedding[0], embedding[1] = 3, 4
+
+	sample := llm.EmbeddingSample(embedding, 3)
+	assert.Equal(t, "[3.0000, 4.0000, 0.0000, ...] (norm: 5.0000)", sample)
+
+	// Shorter embeddings are printed whole
+	assert.Equal(t, "[3.0000, 4.0000] (norm: 5.0000)", llm.EmbeddingSample(embedding[:2], 3))
+}
+
+func TestEmbeddingLogSampleIsGated(t *testing.T) {
+	tests := []struct {
+		name       string
+		sample     string
+		wantSample bool
+	}{
+		{name: "disabled by default", sample: "", wantSample: false},
+		{name: "enabled", sample: "2", wantSample: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newEmbeddingServer(t, 64)
+			t.Setenv("OLLAMA_API_URL", server.URL)
+			t.Setenv("EMBEDDING_LOG_SAMPLE", tt.sample)
+			logger, hook := test.NewNullLogger()
+			logger.SetLevel(logrus.DebugLevel)
+
+			client := llm.NewClient(logger, "BeeBrain")
+			_, err := client.GetEmbedding("hello")
+			assert.NoError(t, err)
+
+			var logged string
+			for _, entry := range hook.AllEntries() {
+				if strings.HasPrefix(entry.Message, "Received embedding") {
+					logged = entry.Message
+				}
+			}
+			assert.Contains(t, logged, "size: 64")
+			assert.Equal(t, tt.wantSample, strings.Contains(logged, "norm"))
+			if tt.wantSample {
+				// Only the first two of the 64 components are logged
+				assert.Equal(t, 2, strings.Count(logged, "1.0000"))
+			}
+		})
+	}
+}
+
+func TestPersonaOverride(t *testing.T) {
+	var systems []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []llm.Message `json:"messages"`
+			Prompt   string        `json:"prompt"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if len(req.Messages) > 0 {
+			systems = append(systems, req.Messages[len(req.Messages)-1].Content)
+		} else {
+			systems = append(systems, req.Prompt)
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  map[string]string{"role": "assistant", "content": "Hi!"},
+			"response": "Hi!",
+			"done":     true,
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+
+	_, err := client.Chat([]llm.Message{{Role: "user", Content: "Hello"}})
+	assert.NoError(t, err)
+	_, err = client.Chat([]llm.Message{{Role: "user", Content: "Hello"}}, llm.WithPersona("Be formal."))
+	assert.NoError(t, err)
+	_, err = client.Generate("Hello", llm.WithPersona("Be casual."))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{llm.DefaultPersona, "Be formal.", "Hello\nBe casual."}, systems)
+}
+
+func TestCallTimingsAreLogged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":              map[string]string{"role": "assistant", "content": "Hi!"},
+			"response":             "Hi!",
+			"done":                 true,
+			"total_duration":       2_500_000_000,
+			"load_duration":        100_000_000,
+			"prompt_eval_count":    26,
+			"prompt_eval_duration": 400_000_000,
+			"eval_count":           40,
+			"eval_duration":        2_000_000_000,
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	logger, hook := test.NewNullLogger()
+	client := llm.NewClient(logger, "BeeBrain")
+
+	_, err := client.Chat([]llm.Message{{Role: "user", Content: "Hello"}})
+	assert.NoError(t, err)
+	_, err = client.Generate("Hello")
+	assert.NoError(t, err)
+
+	var logged []string
+	for _, entry := range hook.AllEntries() {
+		if strings.HasPrefix(entry.Message, "LLM call timings") {
+			logged = append(logged, entry.Message)
+		}
+	}
+	assert.Len(t, logged, 2)
+	for _, message := range logged {
+		assert.Contains(t, message, "latency: 2.5s")
+		assert.Contains(t, message, "load: 100ms")
+		assert.Contains(t, message, "prompt tokens: 26")
+		assert.Contains(t, message, "response tokens: 40")
+		assert.Contains(t, message, "tokens/s: 20.0")
+	}
+}
+
+func TestTimingsTokensPerSecond(t *testing.T) {
+	timings := llm.Timings{TotalDuration: 3e9, EvalCount: 30, EvalDuration: 1.5e9}
+	assert.Equal(t, 3*time.Second, timings.Latency())
+	assert.InDelta(t, 20.0, timings.TokensPerSecond(), 0.001)
+
+	// Responses without timings don't divide by zero
+	assert.Zero(t, llm.Timings{EvalCount: 10}.TokensPerSecond())
+}
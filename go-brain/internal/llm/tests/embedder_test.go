@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"beebrain/internal/llm"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAIEmbedderRequestAndResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var req struct {
+			Model string `json:"model"`
+			Input string `json:"input"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "text-embedding-3-small", req.Model)
+		assert.Equal(t, "hello world", req.Input)
+
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.25,-0.5,1]}],"model":"text-embedding-3-small"}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("EMBEDDING_API_URL", server.URL+"/v1")
+	t.Setenv("EMBEDDING_API_KEY", "test-key")
+
+	embedder := llm.NewOpenAIEmbedder(logrus.New())
+	embedding, err := embedder.GetEmbedding("hello world")
+	assert.NoError(t, err)
+	assert.Equal(t, []float32{0.25, -0.5, 1}, embedding)
+}
+
+func TestOpenAIEmbedderAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"Incorrect API key provided"}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("EMBEDDING_API_URL", server.URL)
+
+	embedder := llm.NewOpenAIEmbedder(logrus.New())
+	_, err := embedder.GetEmbedding("hello world")
+	assert.ErrorContains(t, err, "Incorrect API key provided")
+}
+
+func TestNewEmbedderSelectsProvider(t *testing.T) {
+	logger := logrus.New()
+	ollamaClient := llm.NewClient(logger, "BeeBrain")
+
+	t.Setenv("EMBEDDING_PROVIDER", "")
+	embedder, err := llm.NewEmbedder(logger, ollamaClient)
+	assert.NoError(t, err)
+	assert.Same(t, ollamaClient, embedder)
+
+	t.Setenv("EMBEDDING_PROVIDER", llm.EmbeddingProviderOpenAI)
+	embedder, err = llm.NewEmbedder(logger, ollamaClient)
+	assert.NoError(t, err)
+	assert.IsType(t, &llm.OpenAIEmbedder{}, embedder)
+
+	t.Setenv("EMBEDDING_PROVIDER", "unknown")
+	_, err = llm.NewEmbedder(logger, ollamaClient)
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJSONModeSetsFormat(t *testing.T) {
+	var formats []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		formats = append(formats, body["format"])
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  map[string]string{"role": "assistant", "content": `{"ok":true}`},
+			"response": `{"ok":true}`,
+			"done":     true,
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+
+	_, err := client.Chat([]llm.Message{{Role: "user", Content: "Hello"}}, llm.WithJSON())
+	assert.NoError(t, err)
+	_, err = client.Generate("Hello", llm.WithJSON())
+	assert.NoError(t, err)
+	_, err = client.Generate("Hello")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []interface{}{"json", "json", nil}, formats)
+}
+
+func TestJSONModeLeavesOutPersona(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		bodies = append(bodies, string(body))
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":  map[string]string{"role": "assistant", "content": `{"ok":true}`},
+			"response": `{"ok":true}`,
+			"done":     true,
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+
+	_, err := client.Chat([]llm.Message{{Role: "user", Content: "Hello"}}, llm.WithJSON())
+	assert.NoError(t, err)
+	_, err = client.Generate("Hello", llm.WithJSON())
+	assert.NoError(t, err)
+	_, err = client.Generate("Hello")
+	assert.NoError(t, err)
+
+	persona := "Use Slack formatting"
+	assert.NotContains(t, bodies[0], persona)
+	assert.NotContains(t, bodies[1], persona)
+	assert.Contains(t, bodies[2], persona)
+}
+
+type jsonAnswer struct {
+	Answer     string  `json:"answer"`
+	Confidence float64 `json:"confidence"`
+}
+
+func jsonMode(opts []llm.Option) bool {
+	return llm.ApplyOptions(opts...).JSON
+}
+
+func TestGenerateJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []string
+		want      jsonAnswer
+		wantErr   bool
+	}{
+		{
+			name:      "Valid JSON",
+			responses: []string{`{"answer":"Fridays","confidence":0.9}`},
+			want:      jsonAnswer{Answer: "Fridays", Confidence: 0.9},
+		},
+		{
+			name:      "Fenced JSON",
+			responses: []string{"```json\n{\"answer\":\"Fridays\",\"confidence\":0.9}\n```"},
+			want:      jsonAnswer{Answer: "Fridays", Confidence: 0.9},
+		},
+		{
+			name:      "Repaired JSON",
+			responses: []string{`{"answer":"Fridays",`, `{"answer":"Fridays","confidence":0.5}`},
+			want:      jsonAnswer{Answer: "Fridays", Confidence: 0.5},
+		},
+		{
+			name:      "Still invalid after repair",
+			responses: []string{`{"answer":`, `not JSON either`},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockLLMClient.On("Generate", "When do we deploy?", mock.MatchedBy(jsonMode)).Return(tt.responses[0], nil).Once()
+			if len(tt.responses) > 1 {
+				mockLLMClient.On("Generate", mock.MatchedBy(func(prompt string) bool {
+					return strings.Contains(prompt, tt.responses[0])
+				}), mock.MatchedBy(jsonMode)).Return(tt.responses[1], nil).Once()
+			}
+
+			var got jsonAnswer
+			err := llm.GenerateJSON(mockLLMClient, "When do we deploy?", &got)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+			mockLLMClient.AssertNumberOfCalls(t, "Generate", len(tt.responses))
+		})
+	}
+}
+
+func TestGenerateJSONReturnsLLMErrors(t *testing.T) {
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockLLMClient.On("Generate", mock.Anything, mock.Anything).Return("", errors.New("model unavailable"))
+
+	var got jsonAnswer
+	assert.Error(t, llm.GenerateJSON(mockLLMClient, "When do we deploy?", &got))
+	mockLLMClient.AssertNumberOfCalls(t, "Generate", 1)
+}
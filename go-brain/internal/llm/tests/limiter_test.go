@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingServer answers chat requests once release is closed, announcing
+// each request it got on started
+func blockingServer(t *testing.T, started chan<- struct{}, release <-chan struct{}) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"model":   "llama3",
+			"message": map[string]string{"role": "assistant", "content": "Hi!"},
+			"done":    true,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestChatIsBusyWhenNoSlotFreesUp(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	server := blockingServer(t, started, release)
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	t.Setenv("LLM_MAX_CONCURRENT", "1")
+	t.Setenv("LLM_QUEUE_WAIT", "20ms")
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.Chat([]llm.Message{{Role: "user", Content: "first"}})
+		first <- err
+	}()
+	<-started
+
+	// The only slot is taken by the first call
+	_, err := client.Chat([]llm.Message{{Role: "user", Content: "second"}})
+	assert.ErrorIs(t, err, llm.ErrBusy)
+
+	close(release)
+	assert.NoError(t, <-first)
+
+	// The slot is free again
+	_, err = client.Chat([]llm.Message{{Role: "user", Content: "third"}})
+	assert.NoError(t, err)
+	// Of the calls after the first, only the third reached the server
+	assert.Len(t, started, 1)
+}
+
+func TestChatWaitsForSlotWithinQueueWait(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	server := blockingServer(t, started, release)
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	t.Setenv("LLM_MAX_CONCURRENT", "1")
+	t.Setenv("LLM_QUEUE_WAIT", "5s")
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.Chat([]llm.Message{{Role: "user", Content: "first"}})
+		first <- err
+	}()
+	<-started
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := client.Generate("second")
+		second <- err
+	}()
+
+	close(release)
+	assert.NoError(t, <-first)
+	assert.NoError(t, <-second)
+}
+
+func TestEmbeddingConcurrencyIsLimitedApartFromChat(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	embedding := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/embeddings" {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				if old := peak.Load(); n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			embedding <- struct{}{}
+			<-release
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"embedding": []float32{0.1, 0.2}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"model":   "llama3",
+			"message": map[string]string{"role": "assistant", "content": "Hi!"},
+			"done":    true,
+		})
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("OLLAMA_API_URL", server.URL)
+	t.Setenv("MAX_CONCURRENT_EMBEDDINGS", "2")
+	t.Setenv("LLM_MAX_CONCURRENT", "1")
+	t.Setenv("LLM_QUEUE_WAIT", "20ms")
+	client := llm.NewClient(logrus.New(), "BeeBrain")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetEmbedding("hello")
+			assert.NoError(t, err)
+		}()
+	}
+
+	// Both embedding slots are taken, the other requests wait
+	<-embedding
+	<-embedding
+	assert.Never(t, func() bool { return inFlight.Load() > 2 }, 50*time.Millisecond, 5*time.Millisecond)
+
+	// Chat has slots of its own, so it isn't busy while embeddings are
+	_, err := client.Chat([]llm.Message{{Role: "user", Content: "Hello"}})
+	assert.NoError(t, err)
+
+	go func() {
+		for range embedding {
+		}
+	}()
+	close(release)
+	wg.Wait()
+	close(embedding)
+	assert.Equal(t, int32(2), peak.Load())
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"beebrain/internal/llm"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func norm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+func TestNormalizeUnitLength(t *testing.T) {
+	normalized := llm.Normalize([]float32{3, 4, 12})
+	assert.InDelta(t, 1.0, norm(normalized), 1e-6)
+	assert.InDeltaSlice(t, []float32{3.0 / 13, 4.0 / 13, 12.0 / 13}, normalized, 1e-6)
+
+	// A zero vector has no direction and is left alone
+	assert.Equal(t, []float32{0, 0}, llm.Normalize([]float32{0, 0}))
+}
+
+func TestNewEmbedderNormalizes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"embedding":[3,4]}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("EMBEDDING_PROVIDER", llm.EmbeddingProviderOpenAI)
+	t.Setenv("EMBEDDING_API_URL", server.URL)
+
+	logger := logrus.New()
+	ollamaClient := llm.NewClient(logger, "BeeBrain")
+
+	tests := []struct {
+		name      string
+		normalize string
+		want      []float32
+	}{
+		{name: "Normalization enabled", normalize: "true", want: []float32{0.6, 0.8}},
+		{name: "Normalization disabled", normalize: "false", want: []float32{3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EMBEDDING_NORMALIZE", tt.normalize)
+
+			embedder, err := llm.NewEmbedder(logger, ollamaClient)
+			assert.NoError(t, err)
+
+			embedding, err := embedder.GetEmbedding("hello")
+			assert.NoError(t, err)
+			assert.InDeltaSlice(t, tt.want, embedding, 1e-6)
+		})
+	}
+}
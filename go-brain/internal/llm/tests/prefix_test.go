@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddingPrefixes(t *testing.T) {
+	tests := []struct {
+		name           string
+		documentPrefix string
+		queryPrefix    string
+		normalize      string
+		wantPrompts    []string
+	}{
+		{
+			name:        "No prefixes by default",
+			wantPrompts: []string{"deploys", "deploys", "when do we deploy?"},
+		},
+		{
+			name:           "Documents and queries are prefixed",
+			documentPrefix: "search_document: ",
+			queryPrefix:    "search_query: ",
+			wantPrompts:    []string{"search_document: deploys", "search_document: deploys", "search_query: when do we deploy?"},
+		},
+		{
+			name:           "Prefixes survive normalization",
+			documentPrefix: "search_document: ",
+			queryPrefix:    "search_query: ",
+			normalize:      "true",
+			wantPrompts:    []string{"search_document: deploys", "search_document: deploys", "search_query: when do we deploy?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, prompts := newEmbeddingServer(t, 2)
+			t.Setenv("OLLAMA_API_URL", server.URL)
+			t.Setenv("EMBEDDING_PROVIDER", "")
+			t.Setenv("EMBEDDING_NORMALIZE", tt.normalize)
+			t.Setenv("EMBEDDING_DOCUMENT_PREFIX", tt.documentPrefix)
+			t.Setenv("EMBEDDING_QUERY_PREFIX", tt.queryPrefix)
+
+			logger := logrus.New()
+			embedder, err := llm.NewEmbedder(logger, llm.NewClient(logger, "BeeBrain"))
+			assert.NoError(t, err)
+
+			// GetEmbedding embeds documents
+			_, err = embedder.GetEmbedding("deploys")
+			assert.NoError(t, err)
+			_, err = llm.EmbedDocument(embedder, "deploys")
+			assert.NoError(t, err)
+			_, err = llm.EmbedQuery(embedder, "when do we deploy?")
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.wantPrompts, *prompts)
+		})
+	}
+}
+
+func TestEmbedQueryFallsBackToGetEmbedding(t *testing.T) {
+	embedder := &mocks.MockEmbedder{}
+	embedder.On("GetEmbedding", "when do we deploy?").Return([]float32{1, 0}, nil)
+
+	embedding, err := llm.EmbedQuery(embedder, "when do we deploy?")
+	assert.NoError(t, err)
+	assert.Equal(t, []float32{1, 0}, embedding)
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"beebrain/internal/llm"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// summaryServer answers generate requests with response, recording the
+// prompt and token cap of the last one
+func summaryServer(t *testing.T, response string, prompt *string, numPredict *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Prompt  string `json:"prompt"`
+			Options struct {
+				NumPredict int `json:"num_predict"`
+			} `json:"options"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		*prompt, *numPredict = req.Prompt, req.Options.NumPredict
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"response": response, "done": true})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSummarizeHonorsSummaryLength(t *testing.T) {
+	tests := []struct {
+		name            string
+		length          string
+		opts            []llm.Option
+		response        string
+		wantInstruction string
+		wantNumPredict  int
+		wantSummary     string
+	}{
+		{
+			name:            "Length unset",
+			response:        "• One\n• Two\n• Three",
+			wantInstruction: "Use bullet points for clarity.",
+			wantSummary:     "• One\n• Two\n• Three",
+		},
+		{
+			name:            "Bullets",
+			length:          "2 bullets",
+			response:        "• One\n• Two\n  continued\n• Three",
+			wantInstruction: "Use at most 2 bullet points.",
+			wantNumPredict:  120,
+			wantSummary:     "• One\n• Two\n  continued",
+		},
+		{
+			name:            "Sentences",
+			length:          "2 sentences",
+			response:        "We shipped. It went well! Then we slept.",
+			wantInstruction: "Write at most 2 sentences, without bullet points.",
+			wantNumPredict:  80,
+			wantSummary:     "We shipped. It went well!",
+		},
+		{
+			name:            "Within length",
+			length:          "3 sentences",
+			response:        "We shipped. It went well!",
+			wantInstruction: "Write at most 3 sentences, without bullet points.",
+			wantNumPredict:  120,
+			wantSummary:     "We shipped. It went well!",
+		},
+		{
+			name:            "Cap set by the call",
+			length:          "2 bullets",
+			opts:            []llm.Option{llm.WithMaxTokens(500)},
+			response:        "• One",
+			wantInstruction: "Use at most 2 bullet points.",
+			wantNumPredict:  500,
+			wantSummary:     "• One",
+		},
+		{
+			name:            "Invalid length",
+			length:          "short",
+			response:        "• One",
+			wantInstruction: "Use bullet points for clarity.",
+			wantSummary:     "• One",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt string
+			var numPredict int
+			server := summaryServer(t, tt.response, &prompt, &numPredict)
+			t.Setenv("OLLAMA_API_URL", server.URL)
+			t.Setenv("SUMMARY_LENGTH", tt.length)
+			client := llm.NewClient(logrus.New(), "BeeBrain")
+
+			summary, err := client.Summarize([]llm.Message{
+				{Role: "user", Content: "We shipped the release", User: &llm.User{SlackName: "alice"}},
+			}, tt.opts...)
+			assert.NoError(t, err)
+			assert.Contains(t, prompt, tt.wantInstruction)
+			assert.Equal(t, tt.wantNumPredict, numPredict)
+			assert.Equal(t, tt.wantSummary, summary)
+		})
+	}
+}
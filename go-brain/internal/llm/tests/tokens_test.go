@@ -0,0 +1,18 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/llm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, llm.EstimateTokens(""))
+	assert.Equal(t, 1, llm.EstimateTokens("hi"))
+	assert.Equal(t, 2, llm.EstimateTokens("hello!!!"))
+	assert.Equal(t, 3, llm.EstimateTokens("hello world"))
+	// Characters are counted, not bytes
+	assert.Equal(t, 1, llm.EstimateTokens("日本語"))
+}
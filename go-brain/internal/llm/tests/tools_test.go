@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// hasMessage reports whether any message contains text
+func hasMessage(messages []llm.Message, text string) bool {
+	for _, msg := range messages {
+		if strings.Contains(msg.Content, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func openPRsTool(calls *[]string) llm.Tool {
+	return llm.Tool{
+		Name:        "count_open_prs",
+		Description: "Counts the open pull requests of a repository.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"repo":{"type":"string"}}}`),
+		Handler: func(args json.RawMessage) (string, error) {
+			var params struct {
+				Repo string `json:"repo"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return "", err
+			}
+			*calls = append(*calls, params.Repo)
+			return "7", nil
+		},
+	}
+}
+
+func TestToolRunnerExecutesToolAndFeedsResultBack(t *testing.T) {
+	mockLLMClient := &mocks.MockLLMClient{}
+	runner := llm.NewToolRunner(mockLLMClient, logrus.New(), 3)
+
+	var calls []string
+	assert.NoError(t, runner.Register(openPRsTool(&calls)))
+
+	// The tools are described to the model, which calls one first
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return hasMessage(messages, "count_open_prs: Counts the open pull requests") && !hasMessage(messages, "Result of")
+	}), mock.Anything).Return("```json\n{\"tool\": \"count_open_prs\", \"arguments\": {\"repo\": \"bee-brain\"}}\n```", nil).Once()
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return hasMessage(messages, "Result of count_open_prs: 7")
+	}), mock.Anything).Return("There are 7 open PRs.", nil).Once()
+
+	response, err := runner.Chat([]llm.Message{{Role: "user", Content: "How many open PRs?"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "There are 7 open PRs.", response)
+	assert.Equal(t, []string{"bee-brain"}, calls)
+	mockLLMClient.AssertExpectations(t)
+}
+
+func TestToolRunnerReportsToolErrorsToModel(t *testing.T) {
+	mockLLMClient := &mocks.MockLLMClient{}
+	runner := llm.NewToolRunner(mockLLMClient, logrus.New(), 3)
+	assert.NoError(t, runner.Register(llm.Tool{
+		Name:    "flaky",
+		Handler: func(json.RawMessage) (string, error) { return "", errors.New("backend down") },
+	}))
+
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return !hasMessage(messages, "Result of")
+	}), mock.Anything).Return(`{"tool": "flaky", "arguments": {}}`, nil).Once()
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return hasMessage(messages, "Result of flaky: error: backend down")
+	}), mock.Anything).Return("I couldn't check right now.", nil).Once()
+
+	response, err := runner.Chat([]llm.Message{{Role: "user", Content: "Check it"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "I couldn't check right now.", response)
+}
+
+func TestToolRunnerStopsAfterMaxSteps(t *testing.T) {
+	mockLLMClient := &mocks.MockLLMClient{}
+	runner := llm.NewToolRunner(mockLLMClient, logrus.New(), 2)
+	var calls []string
+	assert.NoError(t, runner.Register(openPRsTool(&calls)))
+
+	mockLLMClient.On("Chat", mock.Anything, mock.Anything).Return(`{"tool": "count_open_prs", "arguments": {"repo": "x"}}`, nil)
+
+	_, err := runner.Chat([]llm.Message{{Role: "user", Content: "Loop forever"}})
+	assert.ErrorIs(t, err, llm.ErrTooManyToolCalls)
+	assert.Len(t, calls, 2)
+}
+
+func TestToolRunnerRejectsDuplicateTools(t *testing.T) {
+	runner := llm.NewToolRunner(&mocks.MockLLMClient{}, logrus.New(), 3)
+	var calls []string
+	assert.NoError(t, runner.Register(openPRsTool(&calls)))
+	assert.Error(t, runner.Register(openPRsTool(&calls)))
+	assert.Error(t, runner.Register(llm.Tool{Name: "no_handler"}))
+}
+
+func TestParseToolCall(t *testing.T) {
+	call, ok := llm.ParseToolCall(`{"tool": "count_open_prs", "arguments": {"repo": "bee-brain"}}`)
+	assert.True(t, ok)
+	assert.Equal(t, "count_open_prs", call.Tool)
+	assert.JSONEq(t, `{"repo": "bee-brain"}`, string(call.Arguments))
+
+	// Answers, including ones that merely contain JSON, are not tool calls
+	for _, response := range []string{
+		"There are 7 open PRs.",
+		`The config is {"tool": "x"}`,
+		`{"answer": 7}`,
+		`{"tool": `,
+	} {
+		_, ok := llm.ParseToolCall(response)
+		assert.False(t, ok, response)
+	}
+}
@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWarmupIssuesOneCallWhenEnabled(t *testing.T) {
+	t.Setenv("WARMUP", "true")
+	logger, hook := test.NewNullLogger()
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockLLMClient.On("Generate", mock.Anything, mock.MatchedBy(func(opts []llm.Option) bool {
+		return llm.ApplyOptions(opts...).MaxTokens == 1
+	})).Return("Hello", nil)
+
+	assert.NoError(t, llm.Warmup(mockLLMClient, logger))
+
+	mockLLMClient.AssertNumberOfCalls(t, "Generate", 1)
+	assert.Contains(t, hook.LastEntry().Message, "Warmed up the model in")
+}
+
+func TestWarmupDisabledByDefault(t *testing.T) {
+	mockLLMClient := &mocks.MockLLMClient{}
+
+	assert.NoError(t, llm.Warmup(mockLLMClient, logrus.New()))
+
+	mockLLMClient.AssertNotCalled(t, "Generate", mock.Anything, mock.Anything)
+}
+
+func TestWarmupFailure(t *testing.T) {
+	t.Setenv("WARMUP", "true")
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockLLMClient.On("Generate", mock.Anything, mock.Anything).Return("", assert.AnError)
+
+	err := llm.Warmup(mockLLMClient, logrus.New())
+	assert.ErrorIs(t, err, assert.AnError)
+}
@@ -0,0 +1,43 @@
+package llm
+
+import "time"
+
+// Timings are the token counts and durations Ollama reports with every
+// response, durations in nanoseconds
+type Timings struct {
+	TotalDuration      int64 `json:"total_duration"`
+	LoadDuration       int64 `json:"load_duration"`
+	PromptEvalCount    int   `json:"prompt_eval_count"`
+	PromptEvalDuration int64 `json:"prompt_eval_duration"`
+	EvalCount          int   `json:"eval_count"`
+	EvalDuration       int64 `json:"eval_duration"`
+}
+
+// Latency is how long Ollama took to answer, model loading included
+func (t Timings) Latency() time.Duration {
+	return time.Duration(t.TotalDuration)
+}
+
+// TokensPerSecond is how fast the response was generated, or 0 when Ollama
+// didn't report it
+func (t Timings) TokensPerSecond() float64 {
+	if t.EvalDuration <= 0 {
+		return 0
+	}
+	return float64(t.EvalCount) / time.Duration(t.EvalDuration).Seconds()
+}
+
+// logTimings logs the performance of a call, when Ollama reported it
+func (c *Client) logTimings(model string, timings Timings) {
+	if timings.TotalDuration <= 0 {
+		return
+	}
+	c.logger.Infof("LLM call timings (model: %s, latency: %s, load: %s, prompt tokens: %d, response tokens: %d, tokens/s: %.1f)",
+		model,
+		timings.Latency().Round(time.Millisecond),
+		time.Duration(timings.LoadDuration).Round(time.Millisecond),
+		timings.PromptEvalCount,
+		timings.EvalCount,
+		timings.TokensPerSecond(),
+	)
+}
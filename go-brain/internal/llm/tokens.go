@@ -0,0 +1,12 @@
+package llm
+
+import "unicode/utf8"
+
+// charsPerToken is a rough average for English text across common tokenizers
+const charsPerToken = 4
+
+// EstimateTokens approximates how many tokens text takes up in a prompt
+// without running a tokenizer
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrTooManyToolCalls is returned when the model keeps calling tools without
+// coming to an answer
+var ErrTooManyToolCalls = errors.New("too many tool calls")
+
+// Tool is a function the model can call while answering
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON schema of the arguments the tool takes
+	Parameters json.RawMessage
+	// Handler runs the tool with the arguments the model passed and returns
+	// the result handed back to the model
+	Handler func(args json.RawMessage) (string, error)
+}
+
+// ToolCall is the JSON the model responds with to call a tool
+type ToolCall struct {
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+const toolsPrompt = `You can call the following tools to look up information you don't have:
+%s
+To call a tool, respond with ONLY a JSON object like {"tool": "name", "arguments": {...}} and nothing else. You will get the result back and can call more tools or answer. When you have what you need, answer normally without JSON.`
+
+// ToolRunner answers chats with the help of tools. The tools are described in
+// the prompt and called through JSON responses, so it works with any chat
+// model rather than only those with native function calling.
+type ToolRunner struct {
+	client   LLMClient
+	logger   *logrus.Logger
+	tools    []Tool
+	maxSteps int
+}
+
+func NewToolRunner(client LLMClient, logger *logrus.Logger, maxSteps int) *ToolRunner {
+	if maxSteps < 1 {
+		maxSteps = 1
+	}
+	return &ToolRunner{
+		client:   client,
+		logger:   logger,
+		maxSteps: maxSteps,
+	}
+}
+
+// Register makes tool available to the model
+func (r *ToolRunner) Register(tool Tool) error {
+	if tool.Name == "" || tool.Handler == nil {
+		return fmt.Errorf("tool needs a name and a handler")
+	}
+	for _, existing := range r.tools {
+		if existing.Name == tool.Name {
+			return fmt.Errorf("tool %s is already registered", tool.Name)
+		}
+	}
+	r.tools = append(r.tools, tool)
+	return nil
+}
+
+// HasTools reports whether any tool is registered
+func (r *ToolRunner) HasTools() bool {
+	return r != nil && len(r.tools) > 0
+}
+
+// Chat runs the conversation, executing the tools the model calls and feeding
+// their results back, until the model answers or maxSteps tool calls were made
+func (r *ToolRunner) Chat(messages []Message, opts ...Option) (string, error) {
+	conversation := make([]Message, 0, len(messages)+1+2*r.maxSteps)
+	conversation = append(conversation, Message{Role: "system", Content: r.prompt()})
+	conversation = append(conversation, messages...)
+
+	for step := 0; step < r.maxSteps; step++ {
+		response, err := r.client.Chat(conversation, opts...)
+		if err != nil {
+			return "", err
+		}
+
+		call, ok := ParseToolCall(response)
+		if !ok {
+			return response, nil
+		}
+
+		r.logger.Infof("Model called tool %s (step %d/%d)", call.Tool, step+1, r.maxSteps)
+		conversation = append(conversation,
+			Message{Role: "assistant", Content: response},
+			Message{Role: "system", Content: fmt.Sprintf("Result of %s: %s", call.Tool, r.run(call))},
+		)
+	}
+	return "", fmt.Errorf("%w: no answer after %d calls", ErrTooManyToolCalls, r.maxSteps)
+}
+
+// run executes a tool call. Failures are reported to the model as the result
+// so it can recover, e.g. by fixing its arguments.
+func (r *ToolRunner) run(call ToolCall) string {
+	for _, tool := range r.tools {
+		if tool.Name != call.Tool {
+			continue
+		}
+		result, err := tool.Handler(call.Arguments)
+		if err != nil {
+			r.logger.Warnf("Tool %s failed: %v", call.Tool, err)
+			return fmt.Sprintf("error: %v", err)
+		}
+		return result
+	}
+
+	r.logger.Warnf("Model called unknown tool %s", call.Tool)
+	return fmt.Sprintf("error: there is no tool called %s", call.Tool)
+}
+
+func (r *ToolRunner) prompt() string {
+	var tools strings.Builder
+	for _, tool := range r.tools {
+		parameters := string(tool.Parameters)
+		if parameters == "" {
+			parameters = "{}"
+		}
+		tools.WriteString(fmt.Sprintf("- %s: %s Arguments schema: %s\n", tool.Name, tool.Description, parameters))
+	}
+	return fmt.Sprintf(toolsPrompt, strings.TrimSuffix(tools.String(), "\n"))
+}
+
+// ParseToolCall reads a tool call from a model response, which may be wrapped
+// in a code block. It returns false for anything that isn't a tool call.
+func ParseToolCall(response string) (ToolCall, bool) {
+	response = strings.TrimSpace(response)
+	response = strings.TrimPrefix(response, "```json")
+	response = strings.TrimPrefix(response, "```")
+	response = strings.TrimSuffix(response, "```")
+	response = strings.TrimSpace(response)
+	if !strings.HasPrefix(response, "{") {
+		return ToolCall{}, false
+	}
+
+	var call ToolCall
+	if err := json.Unmarshal([]byte(response), &call); err != nil || call.Tool == "" {
+		return ToolCall{}, false
+	}
+	return call, true
+}
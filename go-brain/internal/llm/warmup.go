@@ -0,0 +1,29 @@
+package llm
+
+import (
+	"fmt"
+	"time"
+
+	"beebrain/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+const warmupPrompt = "Hi"
+
+// Warmup sends a tiny throwaway request when WARMUP is set, so the model is
+// loaded into memory before the first question rather than while answering
+// it. It logs how long loading took.
+func Warmup(client LLMClient, logger *logrus.Logger) error {
+	if !config.Bool(logger, "WARMUP", false) {
+		return nil
+	}
+
+	logger.Info("Warming up the model")
+	started := time.Now()
+	if _, err := client.Generate(warmupPrompt, WithMaxTokens(1)); err != nil {
+		return fmt.Errorf("failed to warm up the model: %w", err)
+	}
+	logger.Infof("Warmed up the model in %s", time.Since(started).Round(time.Millisecond))
+	return nil
+}
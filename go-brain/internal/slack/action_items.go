@@ -0,0 +1,90 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"beebrain/internal/llm"
+)
+
+// ActionItem is a task extracted from a conversation
+type ActionItem struct {
+	Owner string `json:"owner"`
+	Task  string `json:"task"`
+	Due   string `json:"due"`
+}
+
+const actionItemsPrompt = `Extract the action items from the following conversation thread.
+Respond with ONLY a JSON object of the form {"action_items": [...]} and no other text. Each action item must be an object with the keys "owner" (who is responsible, or "" if unclear), "task" (what needs to be done) and "due" (the deadline, or "" if none was given).
+If there are no action items, respond with {"action_items": []}.
+
+`
+
+// isActionItemsRequest reports whether a mention asks for the action items of
+// the conversation
+func isActionItemsRequest(text string) bool {
+	return strings.Contains(strings.ToLower(text), "action items")
+}
+
+// ExtractActionItems asks the LLM for the action items in messages in JSON
+// mode and parses them, llm.GenerateJSON asking once for a repair if the
+// output is malformed
+func (m *ConversationManager) ExtractActionItems(messages []llm.Message) ([]ActionItem, error) {
+	var prompt strings.Builder
+	prompt.WriteString(actionItemsPrompt)
+	for _, msg := range messages {
+		name := ""
+		if msg.User != nil {
+			name = msg.User.SlackName
+		}
+		prompt.WriteString(fmt.Sprintf("%s: %s\n", name, msg.Content))
+	}
+
+	var response struct {
+		ActionItems []ActionItem `json:"action_items"`
+	}
+	if err := llm.GenerateJSON(m.llmClient, prompt.String(), &response); err != nil {
+		return nil, fmt.Errorf("failed to extract action items: %w", err)
+	}
+
+	// Drop entries without a task, they aren't actionable
+	items := make([]ActionItem, 0, len(response.ActionItems))
+	for _, item := range response.ActionItems {
+		if strings.TrimSpace(item.Task) != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// FormatActionItems renders action items as a Slack checklist
+func FormatActionItems(items []ActionItem) string {
+	if len(items) == 0 {
+		return "I couldn't find any action items in this conversation."
+	}
+
+	var checklist strings.Builder
+	checklist.WriteString("*Action items*\n")
+	for _, item := range items {
+		checklist.WriteString("☐ ")
+		if item.Owner != "" {
+			checklist.WriteString(fmt.Sprintf("*%s*: ", item.Owner))
+		}
+		checklist.WriteString(item.Task)
+		if item.Due != "" {
+			checklist.WriteString(fmt.Sprintf(" _(due %s)_", item.Due))
+		}
+		checklist.WriteString("\n")
+	}
+	return strings.TrimSuffix(checklist.String(), "\n")
+}
+
+// ProcessActionItems extracts the action items from a conversation and
+// returns them formatted as a checklist
+func (m *ConversationManager) ProcessActionItems(messages []llm.Message) (string, error) {
+	items, err := m.ExtractActionItems(messages)
+	if err != nil {
+		return "", err
+	}
+	return FormatActionItems(items), nil
+}
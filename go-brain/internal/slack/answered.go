@@ -0,0 +1,79 @@
+package slack
+
+import (
+	"strings"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+)
+
+// answeredQuoteLimit caps how much of an earlier answer is quoted back
+const answeredQuoteLimit = 1000
+
+// earlierAnswer looks for a question in the thread that closely matches text
+// and was already answered by the bot, and returns a response pointing back to
+// that answer. Only the most similar question at or above
+// ANSWERED_MIN_SIMILARITY counts.
+func (m *ConversationManager) earlierAnswer(threadMessages []llm.Message, text string) (string, bool) {
+	if !m.config.answeredCheck || len(threadMessages) == 0 {
+		return "", false
+	}
+
+	var query []float32
+	bestScore := m.config.answeredMinScore
+	bestAnswer := ""
+	for i, msg := range threadMessages {
+		if msg.Role != "user" || strings.TrimSpace(msg.Content) == "" {
+			continue
+		}
+		answer, ok := nextAnswer(threadMessages[i+1:])
+		if !ok {
+			continue
+		}
+
+		// The question is only embedded once there is something to compare
+		if query == nil {
+			embedding, err := llm.EmbedQuery(m.embedder, text)
+			if err != nil {
+				m.logger.Warnf("Failed to embed question to look for an earlier answer: %v", err)
+				return "", false
+			}
+			query = embedding
+		}
+		embedding, err := llm.EmbedQuery(m.embedder, msg.Content)
+		if err != nil {
+			m.logger.Warnf("Failed to embed earlier question: %v", err)
+			continue
+		}
+		if score := vectordb.CosineSimilarity(query, embedding); score >= bestScore {
+			bestScore = score
+			bestAnswer = answer
+		}
+	}
+	if bestAnswer == "" {
+		return "", false
+	}
+
+	m.logger.Infof("Question was answered earlier in the thread (similarity %.2f)", bestScore)
+	return "I answered this earlier in the thread:\n" + quote(bestAnswer, answeredQuoteLimit), true
+}
+
+// nextAnswer returns the first bot message in messages, taken as the answer
+// to the question right before them
+func nextAnswer(messages []llm.Message) (string, bool) {
+	for _, msg := range messages {
+		if msg.Role == "assistant" {
+			return msg.Content, strings.TrimSpace(msg.Content) != ""
+		}
+	}
+	return "", false
+}
+
+// quote formats text as a Slack block quote, cut off after limit runes
+func quote(text string, limit int) string {
+	text = strings.TrimSpace(text)
+	if runes := []rune(text); len(runes) > limit {
+		text = strings.TrimSpace(string(runes[:limit])) + "…"
+	}
+	return "> " + strings.ReplaceAll(text, "\n", "\n> ")
+}
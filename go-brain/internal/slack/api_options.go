@@ -0,0 +1,25 @@
+package slack
+
+import (
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+// ClientOptions returns the options for the Slack API client. SLACK_API_URL
+// points it at another API than the public one, such as Slack GovCloud or a
+// local emulator.
+func ClientOptions(logger *logrus.Logger) []slack.Option {
+	var options []slack.Option
+	if apiURL := os.Getenv("SLACK_API_URL"); apiURL != "" {
+		// The client appends method names directly to the URL
+		if !strings.HasSuffix(apiURL, "/") {
+			apiURL += "/"
+		}
+		logger.Infof("Using Slack API at %s", apiURL)
+		options = append(options, slack.OptionAPIURL(apiURL))
+	}
+	return options
+}
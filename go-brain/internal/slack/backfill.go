@@ -0,0 +1,142 @@
+package slack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+
+	"github.com/google/uuid"
+	"github.com/slack-go/slack"
+)
+
+// BackfillChannel indexes the recent history of a channel. Messages are
+// embedded concurrently on BACKFILL_WORKERS workers, in batches of
+// EMBEDDING_BATCH_SIZE when the embedder takes batches, and stored in one
+// batch; a message that fails to embed is skipped rather than aborting the
+// backfill. It returns the number of messages indexed.
+func (m *ConversationManager) BackfillChannel(channelID string) (int, error) {
+	if m.vectorDB == nil {
+		return 0, fmt.Errorf("vectorDB client is not initialized")
+	}
+
+	history, err := m.conversationHistory(channelID, time.Time{}, time.Time{}, m.config.backfillLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	jobs := make(chan []slack.Message)
+	indexed := make(chan vectordb.Message, len(history))
+
+	workers := m.config.backfillWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range jobs {
+				m.backfillChunk(channelID, chunk, indexed)
+			}
+		}()
+	}
+
+	// Embedders that don't take batches get one message per request, spread
+	// over the workers
+	batchSize := m.config.embeddingBatch
+	if batchSize < 1 || !llm.CanBatch(m.embedder) {
+		batchSize = 1
+	}
+	chunk := make([]slack.Message, 0, batchSize)
+	for _, msg := range history {
+		if !m.backfillable(msg) {
+			continue
+		}
+		chunk = append(chunk, msg)
+		if len(chunk) == batchSize {
+			jobs <- chunk
+			chunk = make([]slack.Message, 0, batchSize)
+		}
+	}
+	if len(chunk) > 0 {
+		jobs <- chunk
+	}
+	close(jobs)
+	wg.Wait()
+	close(indexed)
+
+	batch := make([]vectordb.Message, 0, len(indexed))
+	for msg := range indexed {
+		batch = append(batch, msg)
+	}
+	if err := m.vectorDB.StoreMessages(batch); err != nil {
+		return 0, fmt.Errorf("failed to store backfilled messages: %w", err)
+	}
+
+	m.logger.Infof("Backfilled %d messages from channel %s", len(batch), channelID)
+	return len(batch), nil
+}
+
+// backfillable reports whether a message of a channel's history is indexed
+// by a backfill. Only what people wrote is, not joins, bot posts and other
+// events, /me messages included when ME_MESSAGES is set.
+func (m *ConversationManager) backfillable(msg slack.Message) bool {
+	if msg.SubType != "" && !(msg.SubType == "me_message" && m.config.meMessages) {
+		return false
+	}
+	return msg.BotID == "" && strings.TrimSpace(msg.Text) != ""
+}
+
+// backfillChunk embeds a chunk of a channel's history in one batch and sends
+// the messages that embedded to indexed
+func (m *ConversationManager) backfillChunk(channelID string, chunk []slack.Message, indexed chan<- vectordb.Message) {
+	texts := make([]string, len(chunk))
+	rawTexts := make([]string, len(chunk))
+	for i, msg := range chunk {
+		texts[i], rawTexts[i] = m.indexedText(msg.Text)
+	}
+
+	embeddings, err := llm.EmbedDocuments(m.embedder, texts, len(texts))
+	if err != nil {
+		m.logger.Warnf("Skipping messages that failed to embed in backfill of %s: %v", channelID, err)
+	}
+	for i, msg := range chunk {
+		if embeddings[i] == nil {
+			continue
+		}
+		indexed <- vectordb.Message{
+			ID:        messageID(channelID, msg.Timestamp),
+			Text:      texts[i],
+			RawText:   rawTexts[i],
+			UserID:    msg.User,
+			ChannelID: channelID,
+			Timestamp: slackTime(msg.Timestamp).Format(time.RFC3339),
+			ThreadID:  msg.ThreadTimestamp,
+			MessageTS: msg.Timestamp,
+			Embedding: embeddings[i],
+		}
+	}
+}
+
+// messageID derives a stable point ID from a message's channel and timestamp,
+// so indexing the same message twice overwrites it instead of duplicating it
+func messageID(channelID, timestamp string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(channelID+"/"+timestamp)).String()
+}
+
+// slackTime converts a Slack message timestamp like "1700000000.000100" to a
+// time
+func slackTime(timestamp string) time.Time {
+	seconds, err := strconv.ParseFloat(timestamp, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+}
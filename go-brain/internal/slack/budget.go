@@ -0,0 +1,53 @@
+package slack
+
+import (
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+)
+
+// trimToBudget fits the thread history and the retrieved sources of a prompt
+// into PROMPT_TOKEN_BUDGET. History gets PROMPT_HISTORY_SHARE of the budget and
+// sources the rest, unless one of them is empty and the other can have it
+// all. The oldest history messages and the lowest ranked sources go first.
+func (m *ConversationManager) trimToBudget(history []llm.Message, sources []vectordb.Message) ([]llm.Message, []vectordb.Message) {
+	budget := m.config.promptTokenBudget
+	if budget <= 0 {
+		return history, sources
+	}
+
+	historyBudget := int(float64(budget) * m.config.promptHistoryShare)
+	switch {
+	case len(sources) == 0:
+		historyBudget = budget
+	case len(history) == 0:
+		historyBudget = 0
+	}
+	sourcesBudget := budget - historyBudget
+
+	// Keep the most recent history messages that fit
+	used, start := 0, len(history)
+	for start > 0 {
+		tokens := llm.EstimateTokens(history[start-1].Content)
+		if used+tokens > historyBudget {
+			break
+		}
+		used += tokens
+		start--
+	}
+
+	// Keep the best ranked sources that fit
+	used, end := 0, 0
+	for end < len(sources) {
+		tokens := llm.EstimateTokens(sources[end].Text)
+		if used+tokens > sourcesBudget {
+			break
+		}
+		used += tokens
+		end++
+	}
+
+	if start > 0 || end < len(sources) {
+		m.logger.Debugf("Trimmed %d history messages and %d sources to fit a budget of %d tokens", start, len(sources)-end, budget)
+	}
+	return history[start:], sources[:end]
+}
@@ -0,0 +1,104 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"beebrain/internal/llm"
+
+	"github.com/slack-go/slack"
+)
+
+type channelCacheEntry struct {
+	channel   *slack.Channel
+	expiresAt time.Time
+}
+
+// channelCache is a TTL cache of Slack channel lookups keyed by channel ID
+type channelCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]channelCacheEntry
+}
+
+func newChannelCache(ttl time.Duration) *channelCache {
+	return &channelCache{
+		ttl:     ttl,
+		entries: make(map[string]channelCacheEntry),
+	}
+}
+
+func (c *channelCache) get(channelID string) (*slack.Channel, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[channelID]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, channelID)
+		return nil, false
+	}
+	return entry.channel, true
+}
+
+func (c *channelCache) set(channelID string, channel *slack.Channel) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[channelID] = channelCacheEntry{channel: channel, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// getChannelInfo returns the Slack channel with the given ID, serving
+// repeated lookups from a cache until they expire
+func (m *ConversationManager) getChannelInfo(channelID string) (*slack.Channel, error) {
+	if channel, ok := m.channels.get(channelID); ok {
+		return channel, nil
+	}
+
+	channel, err := m.client.GetConversationInfo(&slack.GetConversationInfoInput{ChannelID: channelID})
+	if err != nil {
+		return nil, err
+	}
+
+	m.channels.set(channelID, channel)
+	return channel, nil
+}
+
+// channelContextMessage describes the topic and purpose of channel for the
+// prompt. It returns false when the channel has neither or they can't be
+// looked up.
+func (m *ConversationManager) channelContextMessage(channelID string) (llm.Message, bool) {
+	channel, err := m.getChannelInfo(channelID)
+	if err != nil {
+		m.logger.Warnf("Failed to look up the topic of channel %s: %v", channelID, err)
+		return llm.Message{}, false
+	}
+
+	var details []string
+	if topic := strings.TrimSpace(channel.Topic.Value); topic != "" {
+		details = append(details, fmt.Sprintf("Its topic is: %s", topic))
+	}
+	if purpose := strings.TrimSpace(channel.Purpose.Value); purpose != "" {
+		details = append(details, fmt.Sprintf("Its purpose is: %s", purpose))
+	}
+	if len(details) == 0 {
+		return llm.Message{}, false
+	}
+
+	about := "About this channel"
+	if channel.Name != "" {
+		about = fmt.Sprintf("About the #%s channel", channel.Name)
+	}
+	return llm.Message{
+		Role:    "system",
+		Content: fmt.Sprintf("%s: %s", about, strings.Join(details, "\n")),
+		Label:   m.config.contextLabel,
+	}, true
+}
@@ -0,0 +1,229 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+
+	"github.com/slack-go/slack"
+)
+
+// citationMarker matches an inline citation such as [2]
+var citationMarker = regexp.MustCompile(`\[(\d+)\]`)
+
+// SetWorkspaceURL sets the workspace URL (e.g. https://acme.slack.com/) that
+// message permalinks are built from
+func (m *ConversationManager) SetWorkspaceURL(url string) {
+	m.workspaceURL = url
+}
+
+// NoGroundingResponse is the answer given instead of an LLM response when
+// nothing in the index is similar enough to the question
+const NoGroundingResponse = "I don't have any information about that in this workspace."
+
+// retrieveSources returns the indexed messages most similar to text, or nil
+// when retrieval is disabled or fails. grounded is false when retrieval worked
+// but nothing scored above the grounding threshold
+func (m *ConversationManager) retrieveSources(text string) (sources []vectordb.Message, grounded bool) {
+	if m.config.ragResults <= 0 || m.vectorDB == nil {
+		return nil, true
+	}
+
+	sources, grounded, err := m.searchSources(text, m.config.ragResults)
+	if err != nil {
+		m.logger.Warnf("Failed to retrieve related messages: %v", err)
+		return nil, true
+	}
+	if !grounded {
+		m.logger.Infof("No related message scored above %v, not answering", m.config.groundingMinScore)
+		return nil, false
+	}
+
+	m.logger.Debugf("Retrieved %d related messages", len(sources))
+	return sources, true
+}
+
+// searchSources returns the results indexed messages most similar to text,
+// without the bot's own answers and reranked when a reranker is set. grounded
+// is false, with no sources, when nothing scored above the grounding threshold
+func (m *ConversationManager) searchSources(text string, results int) (sources []vectordb.Message, grounded bool, err error) {
+	embedding, err := llm.EmbedQuery(m.embedder, text)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	// Fetch extra candidates for the reranker or the recency boost to pick
+	// the best from
+	limit := results
+	if (m.reranker != nil || m.config.recencyHalfLife > 0) && m.config.rerankCandidates > limit {
+		limit = m.config.rerankCandidates
+	}
+
+	sources, err = m.vectorDB.SearchSimilar(context.Background(), embedding, uint64(limit))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	sources = m.withoutResponses(sources)
+
+	// Check the similarity scores before the reranker reorders them
+	if !m.grounded(sources) {
+		return nil, false, nil
+	}
+
+	if m.config.recencyHalfLife > 0 {
+		sources = vectordb.RankByRecency(sources, m.config.recencyHalfLife, m.config.recencyWeight, time.Now())
+	}
+	if m.reranker != nil {
+		reranked, err := m.reranker.Rerank(text, sources)
+		if err != nil {
+			// Similarity order is still a reasonable ranking
+			m.logger.Warnf("Failed to rerank related messages: %v", err)
+		} else {
+			sources = reranked
+		}
+	}
+	if len(sources) > results {
+		sources = sources[:results]
+	}
+	return sources, true, nil
+}
+
+// grounded reports whether the best of the search results meets the
+// GROUNDING_MIN_SCORE threshold, always true when no threshold is set
+func (m *ConversationManager) grounded(results []vectordb.Message) bool {
+	if m.config.groundingMinScore <= 0 {
+		return true
+	}
+	for _, result := range results {
+		if float64(result.Score) >= m.config.groundingMinScore {
+			return true
+		}
+	}
+	return false
+}
+
+// sourcesMessage numbers the retrieved messages so the LLM can cite them
+func (m *ConversationManager) sourcesMessage(sources []vectordb.Message) llm.Message {
+	var prompt strings.Builder
+	prompt.WriteString("Relevant messages from this workspace:\n")
+	for i, source := range sources {
+		prompt.WriteString(fmt.Sprintf("[%d] <@%s>: %s\n", i+1, source.UserID, source.Text))
+	}
+	if m.config.ragCitations {
+		prompt.WriteString("When your answer uses one of these messages, cite it inline by its number, like [1]. Do not cite anything else.")
+	}
+
+	return llm.Message{Role: "system", Content: strings.TrimSuffix(prompt.String(), "\n"), Label: m.config.contextLabel}
+}
+
+// citationLinks returns the permalinks of sources, in citation order
+func (m *ConversationManager) citationLinks(sources []vectordb.Message) []string {
+	links := make([]string, len(sources))
+	for i, source := range sources {
+		links[i] = Permalink(m.workspaceURL, source)
+	}
+	return links
+}
+
+// indexPermalink fetches the permalink of a message being indexed when
+// INDEX_PERMALINKS is set. Short messages aren't worth the API call and are
+// linked to by building their permalink if they are ever cited.
+func (m *ConversationManager) indexPermalink(channelID, timestamp, text string) string {
+	if !m.config.indexPermalinks || timestamp == "" || len([]rune(strings.TrimSpace(text))) < m.config.permalinkMinChars {
+		return ""
+	}
+
+	permalink, err := m.client.GetPermalink(&slack.PermalinkParameters{Channel: channelID, Ts: timestamp})
+	if err != nil {
+		m.logger.Warnf("Failed to get permalink of message %s in channel %s: %v", timestamp, channelID, err)
+		return ""
+	}
+	return permalink
+}
+
+// PostSources sends the sources of an answer to the user who asked, visible
+// only to them, when RAG_SOURCES_EPHEMERAL is set
+func (m *ConversationManager) PostSources(channel, userID, threadTimestamp string, sources []vectordb.Message) error {
+	if !m.config.ephemeralSources || len(sources) == 0 {
+		return nil
+	}
+
+	// Sources quote indexed messages, which the output filter masks like
+	// anything else the bot posts
+	text := m.outputFilter.Apply(FormatSources(sources, m.citationLinks(sources)))
+	options := []slack.MsgOption{slack.MsgOptionText(text, false)}
+	if threadTimestamp != "" {
+		options = append(options, slack.MsgOptionTS(threadTimestamp))
+	}
+	if _, err := m.client.PostEphemeral(channel, userID, options...); err != nil {
+		return fmt.Errorf("failed to post sources: %w", err)
+	}
+	return nil
+}
+
+// Permalink returns the Slack link to an indexed message, the one stored with
+// it or else one built from the workspace URL, or "" when the message can't be
+// linked to
+func Permalink(workspaceURL string, msg vectordb.Message) string {
+	if msg.Permalink != "" {
+		return msg.Permalink
+	}
+	if workspaceURL == "" || msg.ChannelID == "" || msg.MessageTS == "" {
+		return ""
+	}
+
+	link := fmt.Sprintf("%s/archives/%s/p%s", strings.TrimSuffix(workspaceURL, "/"), msg.ChannelID, strings.Replace(msg.MessageTS, ".", "", 1))
+	if msg.ThreadID != "" && msg.ThreadID != msg.MessageTS {
+		link += fmt.Sprintf("?thread_ts=%s&cid=%s", msg.ThreadID, msg.ChannelID)
+	}
+	return link
+}
+
+// RenderCitations turns [n] markers in response into Slack links to links[n-1].
+// Markers without a link or outside the range of links are left as plain
+// text. Code and existing markup are never touched, and a marker right after
+// an identifier or an index, like items[0] or grid[1][2], is indexing rather
+// than a citation.
+func RenderCitations(response string, links []string) string {
+	var rendered strings.Builder
+	copied, start := 0, 0
+	// cited is where the last citation ended, so adjacent citations like
+	// [1][3] are told apart from chained indexes
+	cited := -1
+	protected := append(protectedMarkup.FindAllStringIndex(response, -1), []int{len(response), len(response)})
+	for _, span := range protected {
+		for _, loc := range citationMarker.FindAllStringSubmatchIndex(response[start:span[0]], -1) {
+			markerStart, markerEnd := start+loc[0], start+loc[1]
+			if markerStart > 0 && markerStart != cited && indexable(response[markerStart-1]) {
+				continue
+			}
+			n, err := strconv.Atoi(response[start+loc[2] : start+loc[3]])
+			if err != nil || n < 1 || n > len(links) {
+				continue
+			}
+			cited = markerEnd
+			if links[n-1] == "" {
+				continue
+			}
+			rendered.WriteString(response[copied:markerStart])
+			rendered.WriteString(fmt.Sprintf("<%s|[%d]>", links[n-1], n))
+			copied = markerEnd
+		}
+		start = span[1]
+	}
+	rendered.WriteString(response[copied:])
+	return rendered.String()
+}
+
+// indexable reports whether a [n] right after c indexes something, c being
+// part of an identifier or the end of another index
+func indexable(c byte) bool {
+	return c == '_' || c == ']' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,275 @@
+package slack
+
+import (
+	"time"
+
+	"beebrain/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// managerConfig holds the environment-driven settings of the conversation
+// manager
+type managerConfig struct {
+	// stopIndexingOnLeave stops indexing messages from a channel once the bot
+	// has been removed from it
+	stopIndexingOnLeave bool
+	// channelModels maps channel IDs to the LLM model used to answer in them,
+	// channels without an entry use the client's default model
+	channelModels map[string]string
+	// channelPersonas maps channel IDs to files holding the persona used in
+	// them, channels without an entry use the default persona
+	channelPersonas map[string]string
+	// ragResults is how many related messages are retrieved from the index to
+	// ground an answer, 0 disables retrieval
+	ragResults int
+	// ragCitations asks the LLM to cite retrieved messages as [n] and links
+	// those markers to the messages
+	ragCitations bool
+	// ephemeralSources sends the sources of an answer only to the asker,
+	// instead of linking them from the answer
+	ephemeralSources bool
+	// rerank has the LLM reorder the top rerankCandidates search results
+	// before the best ragResults of them are used
+	rerank           bool
+	rerankCandidates int
+	// groundingMinScore is the similarity the best retrieved message needs
+	// for the bot to answer at all, below it the bot says it doesn't know,
+	// 0 disables the check
+	groundingMinScore float64
+	// recencyHalfLife boosts recent search results, weighing recencyWeight of
+	// their score by an age decay that halves every recencyHalfLife, 0
+	// ranks by similarity alone
+	recencyHalfLife time.Duration
+	recencyWeight   float64
+	// snippetMinLines is how many lines the code of a mostly-code answer needs
+	// for it to be uploaded as a snippet, 0 disables snippets. Snippets have
+	// no footer or buttons and aren't indexed.
+	snippetMinLines int
+	// backfillOnJoin indexes the recent history of a channel when the bot is
+	// added to it, embedding up to backfillLimit messages on backfillWorkers
+	// workers
+	backfillOnJoin  bool
+	backfillLimit   int
+	backfillWorkers int
+	// embeddingBatch is how many messages are embedded with one request by
+	// embedders that take batches
+	embeddingBatch int
+	// sentimentTagging asks the LLM for the sentiment of every indexed message
+	// and stores it in the message metadata
+	sentimentTagging bool
+	// linkDomains are the domains whose shared links are fetched and indexed,
+	// subdomains included, empty disables link indexing. Pages are fetched
+	// within linkTimeout and cut off after linkMaxBytes.
+	linkDomains  []string
+	linkTimeout  time.Duration
+	linkMaxBytes int
+	// storeFailure is what happens to a message that fails to store: drop it,
+	// retry in place or queue it for a background retry. Retries happen
+	// storeRetries times with a backoff doubling from storeRetryBackoff, and
+	// at most storeQueueSize messages wait in the queue.
+	storeFailure      string
+	storeRetries      int
+	storeRetryBackoff time.Duration
+	storeQueueSize    int
+	// responseButtons attaches Summarize thread and Show sources buttons to
+	// responses posted in threads
+	responseButtons bool
+	// responseFooter is shown in small print under every response, empty
+	// leaves it out
+	responseFooter string
+	// promptTokenBudget caps the estimated tokens of thread history and
+	// retrieved sources in a prompt, 0 disables the cap. History gets
+	// promptHistoryShare of it and sources the rest.
+	promptTokenBudget  int
+	promptHistoryShare float64
+	// toolsEnabled lets the model call registered tools in chat mode, up to
+	// toolMaxSteps times per answer
+	toolsEnabled bool
+	toolMaxSteps int
+	// normalizeMarkup replaces Slack mention and link markup with readable
+	// text before messages are embedded, keeping the raw text alongside
+	normalizeMarkup bool
+	// summaryMaxTokens caps the length of thread summaries and digests,
+	// 0 uses the client's MAX_RESPONSE_TOKENS
+	summaryMaxTokens int
+	// overflowSummarize retries a prompt that overflowed the model's context
+	// with all but the overflowKeep newest thread messages summarized,
+	// otherwise the answer to the truncated prompt is used
+	overflowSummarize bool
+	overflowKeep      int
+	// userProfiles keeps a profile of every user the bot answers and tells
+	// the LLM about it
+	userProfiles bool
+	// scopeGuard declines questions outside the bot's domain, judged by the
+	// LLM against scopeDomain or by scopeKeywords, with scopeDecline. It
+	// applies in scopeChannels, or everywhere when that is empty.
+	scopeGuard    string
+	scopeDomain   string
+	scopeKeywords []string
+	scopeChannels []string
+	scopeDecline  string
+	// indexQueueSize is how many messages and links can wait to be indexed
+	// by indexWorkers in the background, 0 indexes them while handling the
+	// event
+	indexQueueSize int
+	indexWorkers   int
+	// noContext is how questions without any earlier conversation are asked:
+	// proceed with just the question, or note that there is no context
+	noContext string
+	// mentionResolve turns the names of people in the conversation into
+	// mentions in responses, notifying at most mentionMax of them
+	mentionResolve bool
+	mentionMax     int
+	// indexPermalinks fetches and stores the permalink of every indexed
+	// message of at least permalinkMinChars characters
+	indexPermalinks   bool
+	permalinkMinChars int
+	// answeredCheck points back to the bot's earlier answer when a question
+	// is at least answeredMinScore similar to one already answered in the
+	// thread, instead of answering it again
+	answeredCheck    bool
+	answeredMinScore float64
+	// speakerNames prefixes what people said with their names in chat mode,
+	// so the model can tell the people in a thread apart
+	speakerNames bool
+	// contextLabel and systemLabel stand in for the author of retrieved
+	// context, summaries and instructions in generate mode prompts
+	contextLabel string
+	systemLabel  string
+	// meMessages indexes and answers /me messages like normal messages
+	meMessages bool
+	// indexResponses indexes the answers the bot posts, tagged with the
+	// assistant role, and excludeResponses leaves them out of retrieval
+	indexResponses   bool
+	excludeResponses bool
+	// languageDirectives answers in the language requested by a trailing
+	// directive such as "(in Spanish)"
+	languageDirectives bool
+	// postQueueSize bounds the queue of responses that failed to post, 0
+	// disables retrying them. Queued posts are retried postRetries times with
+	// a backoff doubling from postRetryBackoff.
+	postQueueSize    int
+	postRetries      int
+	postRetryBackoff time.Duration
+	// channelTopic adds the topic and purpose of the channel to prompts, with
+	// channel lookups cached for channelInfoTTL
+	channelTopic   bool
+	channelInfoTTL time.Duration
+	// Topics cluster up to topicsMaxMessages recent messages of a channel
+	// at topicsSimilarity, keeping at most topicsMax clusters of at least
+	// topicsMinSize messages
+	topicsSimilarity  float64
+	topicsMinSize     int
+	topicsMax         int
+	topicsMaxMessages int
+	// customEmojiResolve describes custom emoji reactions with their
+	// customEmojiMeanings and skips the decorative ones, with the custom
+	// emoji cached for customEmojiTTL
+	customEmojiResolve  bool
+	customEmojiMeanings map[string]string
+	customEmojiTTL      time.Duration
+	// promptDirectives adds the directives of a leading block such as
+	// "[answer concisely]" to the prompt for that answer only
+	promptDirectives bool
+}
+
+func loadManagerConfig(logger *logrus.Logger) managerConfig {
+	cfg := managerConfig{
+		stopIndexingOnLeave: config.Bool(logger, "STOP_INDEXING_ON_LEAVE", true),
+		channelModels:       config.Map(logger, "CHANNEL_MODELS"),
+		channelPersonas:     config.Map(logger, "CHANNEL_PERSONAS"),
+		ragResults:          config.Int(logger, "RAG_RESULTS", 0),
+		ragCitations:        config.Bool(logger, "RAG_CITATIONS", true),
+		ephemeralSources:    config.Bool(logger, "RAG_SOURCES_EPHEMERAL", false),
+		rerank:              config.Bool(logger, "RERANK_ENABLED", false),
+		rerankCandidates:    config.Int(logger, "RERANK_CANDIDATES", 20),
+		groundingMinScore:   config.Float(logger, "GROUNDING_MIN_SCORE", 0),
+		recencyHalfLife:     config.Duration(logger, "RAG_RECENCY_HALF_LIFE", 0),
+		recencyWeight:       config.Float(logger, "RAG_RECENCY_WEIGHT", 0.3),
+		snippetMinLines:     config.Int(logger, "SNIPPET_MIN_LINES", 0),
+		backfillOnJoin:      config.Bool(logger, "BACKFILL_ON_JOIN", false),
+		backfillLimit:       config.Int(logger, "BACKFILL_LIMIT", 200),
+		backfillWorkers:     config.Int(logger, "BACKFILL_WORKERS", 4),
+		embeddingBatch:      config.Int(logger, "EMBEDDING_BATCH_SIZE", 32),
+		sentimentTagging:    config.Bool(logger, "SENTIMENT_TAGGING", false),
+		linkDomains:         config.List("LINK_DOMAINS"),
+		linkTimeout:         config.Duration(logger, "LINK_FETCH_TIMEOUT", 10*time.Second),
+		linkMaxBytes:        config.Int(logger, "LINK_MAX_BYTES", 1<<20),
+		storeFailure:        config.String("STORE_FAILURE_STRATEGY", storeFailureDrop),
+		storeRetries:        config.Int(logger, "STORE_RETRIES", 3),
+		storeRetryBackoff:   config.Duration(logger, "STORE_RETRY_BACKOFF", 500*time.Millisecond),
+		storeQueueSize:      config.Int(logger, "STORE_QUEUE_SIZE", 1000),
+		responseButtons:     config.Bool(logger, "RESPONSE_BUTTONS", false),
+		responseFooter:      config.String("RESPONSE_FOOTER", ""),
+		promptTokenBudget:   config.Int(logger, "PROMPT_TOKEN_BUDGET", 0),
+		promptHistoryShare:  config.Float(logger, "PROMPT_HISTORY_SHARE", 0.7),
+		toolsEnabled:        config.Bool(logger, "TOOLS_ENABLED", false),
+		toolMaxSteps:        config.Int(logger, "TOOL_MAX_STEPS", 5),
+		normalizeMarkup:     config.Bool(logger, "INDEX_NORMALIZE_MARKUP", true),
+		summaryMaxTokens:    config.Int(logger, "SUMMARY_MAX_TOKENS", 0),
+		overflowSummarize:   config.Bool(logger, "CONTEXT_OVERFLOW_SUMMARIZE", true),
+		overflowKeep:        config.Int(logger, "CONTEXT_OVERFLOW_KEEP_MESSAGES", 4),
+		userProfiles:        config.Bool(logger, "USER_PROFILES", false),
+		scopeGuard:          config.String("SCOPE_GUARD", ""),
+		scopeDomain:         config.String("SCOPE_DOMAIN", ""),
+		scopeKeywords:       config.List("SCOPE_KEYWORDS"),
+		scopeChannels:       config.List("SCOPE_CHANNELS"),
+		scopeDecline:        config.String("SCOPE_DECLINE_MESSAGE", defaultScopeDecline),
+		indexQueueSize:      config.Int(logger, "INDEX_QUEUE_SIZE", 0),
+		indexWorkers:        config.Int(logger, "INDEX_WORKERS", 2),
+		noContext:           config.String("NO_CONTEXT_BEHAVIOR", noContextProceed),
+		mentionResolve:      config.Bool(logger, "MENTION_RESOLVE", false),
+		mentionMax:          config.Int(logger, "MENTION_MAX", 2),
+		indexPermalinks:     config.Bool(logger, "INDEX_PERMALINKS", false),
+		permalinkMinChars:   config.Int(logger, "PERMALINK_MIN_CHARS", 20),
+		answeredCheck:       config.Bool(logger, "ANSWERED_DETECTION", false),
+		answeredMinScore:    config.Float(logger, "ANSWERED_MIN_SIMILARITY", 0.92),
+		speakerNames:        config.Bool(logger, "CHAT_SPEAKER_NAMES", false),
+		contextLabel:        config.String("GENERATE_CONTEXT_LABEL", "context"),
+		systemLabel:         config.String("GENERATE_SYSTEM_LABEL", "system"),
+		meMessages:          config.Bool(logger, "ME_MESSAGES", false),
+		indexResponses:      config.Bool(logger, "INDEX_RESPONSES", false),
+		excludeResponses:    config.Bool(logger, "RAG_EXCLUDE_RESPONSES", false),
+		languageDirectives:  config.Bool(logger, "LANGUAGE_DIRECTIVES", false),
+		postQueueSize:       config.Int(logger, "POST_RETRY_QUEUE_SIZE", 0),
+		postRetries:         config.Int(logger, "POST_RETRIES", 5),
+		postRetryBackoff:    config.Duration(logger, "POST_RETRY_BACKOFF", time.Second),
+		channelTopic:        config.Bool(logger, "CHANNEL_TOPIC_CONTEXT", false),
+		channelInfoTTL:      config.Duration(logger, "CHANNEL_INFO_CACHE_TTL", time.Hour),
+		topicsSimilarity:    config.Float(logger, "TOPICS_SIMILARITY", 0.8),
+		topicsMinSize:       config.Int(logger, "TOPICS_MIN_SIZE", 3),
+		topicsMax:           config.Int(logger, "TOPICS_MAX", 5),
+		topicsMaxMessages:   config.Int(logger, "TOPICS_MAX_MESSAGES", 500),
+		customEmojiResolve:  config.Bool(logger, "CUSTOM_EMOJI_RESOLVE", false),
+		customEmojiMeanings: config.Map(logger, "CUSTOM_EMOJI_MEANINGS"),
+		customEmojiTTL:      config.Duration(logger, "CUSTOM_EMOJI_CACHE_TTL", time.Hour),
+		promptDirectives:    config.Bool(logger, "PROMPT_DIRECTIVES", false),
+	}
+
+	switch cfg.storeFailure {
+	case storeFailureDrop, storeFailureRetry, storeFailureQueue:
+	default:
+		logger.Warnf("Invalid STORE_FAILURE_STRATEGY '%s', defaulting to '%s'", cfg.storeFailure, storeFailureDrop)
+		cfg.storeFailure = storeFailureDrop
+	}
+	switch cfg.noContext {
+	case noContextProceed, noContextNote:
+	default:
+		logger.Warnf("Invalid NO_CONTEXT_BEHAVIOR '%s', defaulting to '%s'", cfg.noContext, noContextProceed)
+		cfg.noContext = noContextProceed
+	}
+	if cfg.recencyWeight < 0 || cfg.recencyWeight > 1 {
+		logger.Warnf("Invalid RAG_RECENCY_WEIGHT '%v', defaulting to 0.3", cfg.recencyWeight)
+		cfg.recencyWeight = 0.3
+	}
+	if cfg.promptHistoryShare < 0 || cfg.promptHistoryShare > 1 {
+		logger.Warnf("Invalid PROMPT_HISTORY_SHARE '%v', defaulting to 0.7", cfg.promptHistoryShare)
+		cfg.promptHistoryShare = 0.7
+	}
+	if cfg.topicsSimilarity <= 0 || cfg.topicsSimilarity > 1 {
+		logger.Warnf("Invalid TOPICS_SIMILARITY '%v', defaulting to 0.8", cfg.topicsSimilarity)
+		cfg.topicsSimilarity = 0.8
+	}
+	return cfg
+}
@@ -0,0 +1,73 @@
+package slack
+
+import (
+	"net/http"
+	"regexp"
+	"unicode"
+
+	"beebrain/internal/config"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// What happens to mentions with nothing to answer, like a bare emoji
+const (
+	contentlessAck    = "ack"
+	contentlessIgnore = "ignore"
+	contentlessAnswer = "answer"
+)
+
+const defaultContentlessReply = "Hi! Ask me a question and I'll do my best to answer it."
+
+var (
+	// mentionMarkup matches user, channel and special mentions such as
+	// <@U123>, <#C123|general> and <!here>
+	mentionMarkup = regexp.MustCompile(`<[@#!][^>]*>`)
+	// emojiShortcode matches emoji as Slack sends them, e.g. :thumbsup: or
+	// :skin-tone-2:
+	emojiShortcode = regexp.MustCompile(`:[a-z0-9_+'-]+:`)
+)
+
+// isContentless reports whether a message has nothing to answer once the
+// mentions are removed: only emoji, punctuation and whitespace
+func isContentless(text string) bool {
+	text = mentionMarkup.ReplaceAllString(text, "")
+	text = emojiShortcode.ReplaceAllString(text, "")
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// loadContentlessMode reads CONTENTLESS_MENTIONS, what is done with mentions
+// with nothing to answer: ack replies with CONTENTLESS_MENTION_REPLY, ignore
+// leaves them be and answer asks the LLM like for any other mention
+func loadContentlessMode(logger *logrus.Logger) string {
+	mode := config.String("CONTENTLESS_MENTIONS", contentlessAck)
+	switch mode {
+	case contentlessAck, contentlessIgnore, contentlessAnswer:
+		return mode
+	default:
+		logger.Warnf("Invalid CONTENTLESS_MENTIONS '%s', defaulting to '%s'", mode, contentlessAck)
+		return contentlessAck
+	}
+}
+
+// handleContentlessMention acknowledges a mention with nothing to answer
+// without asking the LLM
+func (h *BeeBrainSlackHandler) handleContentlessMention(c echo.Context, ev *slackevents.AppMentionEvent) error {
+	h.logger.Infof("Not answering content-less mention from %s in channel %s", ev.User, ev.Channel)
+	if h.contentlessMode == contentlessIgnore {
+		return c.NoContent(http.StatusOK)
+	}
+
+	threadTimestamp := replyTimestamp(ev.ThreadTimeStamp, ev.TimeStamp)
+	if err := h.conversationManager.postResponse(ev.Channel, h.contentlessReply, threadTimestamp, false); err != nil {
+		h.logger.Errorf("Failed to acknowledge mention from %s in channel %s: %v", ev.User, ev.Channel, err)
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -1,11 +1,15 @@
 package slack
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"beebrain/internal/config"
 	"beebrain/internal/llm"
 	"beebrain/internal/vectordb"
 
@@ -15,11 +19,27 @@ import (
 
 // SlackClient interface defines the methods we need from slack.Client
 type SlackClient interface {
+	AuthTest() (*slack.AuthTestResponse, error)
 	GetConversationHistory(params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error)
 	GetConversationReplies(params *slack.GetConversationRepliesParameters) ([]slack.Message, bool, string, error)
 	PostMessage(channelID string, options ...slack.MsgOption) (string, string, error)
+	PostEphemeral(channelID, userID string, options ...slack.MsgOption) (string, error)
+	GetUserInfo(userID string) (*slack.User, error)
+	AddReaction(name string, item slack.ItemRef) error
+	RemoveReaction(name string, item slack.ItemRef) error
+	UploadFile(params slack.FileUploadParameters) (*slack.File, error)
+	GetPermalink(params *slack.PermalinkParameters) (string, error)
+	UpdateMessage(channelID, timestamp string, options ...slack.MsgOption) (string, string, string, error)
+	GetConversationInfo(input *slack.GetConversationInfoInput) (*slack.Channel, error)
+	GetEmoji() (map[string]string, error)
 }
 
+// ErrEmptyResponse is returned when the LLM completes without any content
+var ErrEmptyResponse = errors.New("LLM returned an empty response")
+
+// emptyResponseFallback is posted instead of an empty LLM response
+const emptyResponseFallback = "Sorry, I couldn't come up with an answer to that. Could you try rephrasing your question?"
+
 // TruncatingFormatter is a custom formatter that truncates long messages
 type TruncatingFormatter struct {
 	Formatter logrus.Formatter
@@ -38,16 +58,55 @@ func (f *TruncatingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 type ConversationManager struct {
 	client         SlackClient
 	llmClient      llm.LLMClient
+	embedder       llm.Embedder
 	logger         *logrus.Logger
 	messageHistory *sync.Map
 	llmMode        string
 	vectorDB       vectordb.VectorDBClient
+	digest         DigestConfig
+	digestRunning  atomic.Bool
+	standup        StandupConfig
+	config         managerConfig
+	leftChannels   *sync.Map // key: channel ID, value: time.Time
+	quietHours     *QuietHours
+	users          *userCache
+	workspaceURL   string
+	botUserID      string
+	botID          string
+	reranker       Reranker
+	linkFetcher    LinkFetcher
+	storeQueue     chan failedStore
+	trimmer        *ResponseTrimmer
+	outputFilter   *OutputFilter
+	tools          *llm.ToolRunner
+	profiles       *profileStore
+	scope          ScopeClassifier
+	indexQueue     chan indexTask
+	personas       map[string]string // key: channel ID, value: persona
+	// standupMu guards pendingStandups, the posted standups waiting for the
+	// summary of their replies
+	standupMu       sync.Mutex
+	pendingStandups []pendingStandup
+	// postedResponses remembers responses by request, so retries update
+	// them instead of posting them again
+	postedResponses *responseLog
+	// postQueue holds the responses that failed to post for the retry
+	// worker, nil when POST_RETRY_QUEUE_SIZE is 0
+	postQueue chan failedPost
+	// channels caches channel lookups for the topic and purpose context
+	channels *channelCache
+	// customEmojiCache holds the custom emoji reactions are resolved with
+	customEmojiCache *emojiCache
+	// ctx bounds the work started in the background outside of the Start
+	// loops, such as button actions, see SetContext
+	ctx context.Context
 }
 
-func NewConversationManager(client SlackClient, llmClient llm.LLMClient, logger *logrus.Logger, llmMode string, vectorDB vectordb.VectorDBClient) *ConversationManager {
+// NewConversationManager creates a conversation manager. vectorDB may be nil,
+// which disables indexing and retrieval.
+func NewConversationManager(client SlackClient, llmClient llm.LLMClient, embedder llm.Embedder, logger *logrus.Logger, llmMode string, vectorDB vectordb.VectorDBClient) *ConversationManager {
 	if vectorDB == nil {
-		logger.Error("vectorDB client is not initialized")
-		return nil
+		logger.Info("No vectorDB client, indexing and retrieval are disabled")
 	}
 
 	// Set up custom formatter that truncates long messages
@@ -57,44 +116,87 @@ func NewConversationManager(client SlackClient, llmClient llm.LLMClient, logger
 		},
 	})
 
-	return &ConversationManager{
-		client:         client,
+	m := &ConversationManager{
+		client:         &rateLimitedClient{client: client, retrier: newRateLimitRetrier(logger)},
 		llmClient:      llmClient,
+		embedder:       embedder,
 		logger:         logger,
 		messageHistory: &sync.Map{},
 		llmMode:        llmMode,
 		vectorDB:       vectorDB,
+		digest:         loadDigestConfig(logger),
+		standup:        loadStandupConfig(logger),
+		config:         loadManagerConfig(logger),
+		leftChannels:   &sync.Map{},
+		quietHours:     loadQuietHours(logger),
+		trimmer:        loadResponseTrimmer(logger),
+		outputFilter:   loadOutputFilter(logger),
+		users:          newUserCache(config.Duration(logger, "USER_CACHE_TTL", 10*time.Minute)),
+		ctx:            context.Background(),
+	}
+	m.postedResponses = newResponseLog(postedResponseTTL)
+	m.channels = newChannelCache(m.config.channelInfoTTL)
+	m.customEmojiCache = newEmojiCache(m.config.customEmojiTTL)
+	m.linkFetcher = NewHTTPFetcher(m.config.linkTimeout, int64(m.config.linkMaxBytes))
+	if m.config.storeFailure == storeFailureQueue {
+		m.storeQueue = make(chan failedStore, m.config.storeQueueSize)
+	}
+	if m.config.postQueueSize > 0 {
+		m.postQueue = make(chan failedPost, m.config.postQueueSize)
+	}
+	if m.config.indexQueueSize > 0 {
+		m.indexQueue = make(chan indexTask, m.config.indexQueueSize)
+	}
+	m.scope = loadScopeClassifier(llmClient, logger, m.config)
+	m.personas = loadChannelPersonas(logger, m.config.channelPersonas)
+	if m.config.userProfiles {
+		m.profiles = newProfileStore()
+	}
+	if m.config.rerank {
+		m.reranker = NewLLMReranker(llmClient, logger)
+	}
+	if m.config.toolsEnabled {
+		m.tools = llm.NewToolRunner(llmClient, logger, m.config.toolMaxSteps)
+		if vectorDB != nil {
+			if err := m.tools.Register(m.searchMessagesTool()); err != nil {
+				logger.Warnf("Failed to register search tool: %v", err)
+			}
+		}
 	}
+	return m
 }
 
 func (m *ConversationManager) GetLastHourConversation(channel string) ([]llm.Message, error) {
 	// Get the last hour of conversation
-	oneHourAgo := time.Now().Add(-1 * time.Hour).Unix()
-	history, err := m.client.GetConversationHistory(&slack.GetConversationHistoryParameters{
-		ChannelID: channel,
-		Oldest:    fmt.Sprintf("%d.000000", oneHourAgo),
-		Limit:     100, // Maximum number of messages to fetch
-	})
+	return m.conversationWindow(channel, time.Now().Add(-1*time.Hour), time.Time{}, 100)
+}
+
+// GetConversationSince returns all the top-level channel messages posted
+// between oldest and latest, in chronological order, paging through the
+// history as needed. A zero latest means up to now.
+func (m *ConversationManager) GetConversationSince(channel string, oldest, latest time.Time) ([]llm.Message, error) {
+	return m.conversationWindow(channel, oldest, latest, 0)
+}
+
+// conversationWindow returns up to limit of the most recent top-level channel
+// messages posted between oldest and latest as LLM messages, in
+// chronological order. A limit of 0 returns all of them.
+func (m *ConversationManager) conversationWindow(channel string, oldest, latest time.Time, limit int) ([]llm.Message, error) {
+	history, err := m.conversationHistory(channel, oldest, latest, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get conversation history: %w", err)
+		return nil, err
 	}
 
 	// Convert history messages to LLM messages
-	messages := make([]llm.Message, 0, len(history.Messages))
-	for _, msg := range history.Messages {
+	messages := make([]llm.Message, 0, len(history))
+	for _, msg := range history {
 		// Skip thread replies as they're handled separately
 		if msg.ThreadTimestamp != "" {
 			continue
 		}
 
-		// Determine the role based on whether it's a bot message
-		role := "user"
-		if msg.BotID != "" || msg.SubType == "bot_message" {
-			role = "assistant"
-		}
-
 		messages = append(messages, llm.Message{
-			Role:    role,
+			Role:    m.messageRole(msg),
 			Content: msg.Text,
 			User: &llm.User{
 				SlackName: msg.Username,
@@ -111,6 +213,50 @@ func (m *ConversationManager) GetLastHourConversation(channel string) ([]llm.Mes
 	return messages, nil
 }
 
+// SetContext bounds the work the manager starts in the background, such as
+// running button actions, to ctx. Work isn't started once ctx is cancelled.
+func (m *ConversationManager) SetContext(ctx context.Context) {
+	m.ctx = ctx
+}
+
+// background runs fn in a goroutine with the manager's context, unless that
+// is already cancelled
+func (m *ConversationManager) background(fn func(ctx context.Context)) {
+	if m.ctx.Err() != nil {
+		return
+	}
+	go fn(m.ctx)
+}
+
+// SetBotIdentity sets the user and bot IDs the bot posts as, so that only its
+// own messages are given to the LLM as assistant messages
+func (m *ConversationManager) SetBotIdentity(userID, botID string) {
+	m.botUserID = userID
+	m.botID = botID
+}
+
+// messageRole is "assistant" for the bot's own messages and "user" for
+// everyone else's, other bots included. Without a bot identity every bot
+// message is taken for the bot's own.
+func (m *ConversationManager) messageRole(msg slack.Message) string {
+	if m.botUserID == "" && m.botID == "" {
+		if msg.BotID != "" || msg.SubType == "bot_message" {
+			return "assistant"
+		}
+		return "user"
+	}
+	if m.ownMessage(msg.User, msg.BotID) {
+		return "assistant"
+	}
+	return "user"
+}
+
+// ownMessage reports whether a message posted by userID or botID is one of
+// the bot's own, which takes a bot identity to tell
+func (m *ConversationManager) ownMessage(userID, botID string) bool {
+	return (m.botUserID != "" && userID == m.botUserID) || (m.botID != "" && botID == m.botID)
+}
+
 func (m *ConversationManager) GetThreadContext(channel, threadTimestamp string) ([]llm.Message, error) {
 	if threadTimestamp != "" {
 		// Get thread messages
@@ -125,14 +271,8 @@ func (m *ConversationManager) GetThreadContext(channel, threadTimestamp string)
 		// Convert thread messages to LLM messages
 		messages := make([]llm.Message, 0, len(threadMessages))
 		for _, msg := range threadMessages {
-			// Determine the role based on whether it's a bot message
-			role := "user"
-			if msg.BotID != "" || msg.SubType == "bot_message" {
-				role = "assistant"
-			}
-
 			messages = append(messages, llm.Message{
-				Role:    role,
+				Role:    m.messageRole(msg),
 				Content: msg.Text,
 				User: &llm.User{
 					SlackName: msg.Username,
@@ -146,12 +286,105 @@ func (m *ConversationManager) GetThreadContext(channel, threadTimestamp string)
 	// If no thread timestamp, get the last hour of conversation
 	return m.GetLastHourConversation(channel)
 }
-func (m *ConversationManager) ProcessMessage(threadMessages []llm.Message, text string, userInfo *slack.User) (string, error) {
-	messages := make([]llm.Message, 0, len(threadMessages)+2)
+
+// ProcessMessage answers text in the context of threadMessages using the model
+// configured for channel
+func (m *ConversationManager) ProcessMessage(channel string, threadMessages []llm.Message, text string, userInfo *slack.User) (string, error) {
+	response, _, err := m.ProcessMessageWithSources(channel, threadMessages, text, userInfo)
+	return response, err
+}
+
+// ProcessMessageWithSources answers like ProcessMessage and also returns the
+// indexed messages the answer was grounded in
+func (m *ConversationManager) ProcessMessageWithSources(channel string, threadMessages []llm.Message, text string, userInfo *slack.User) (string, []vectordb.Message, error) {
+	if !m.inScope(channel, text) {
+		return m.config.scopeDecline, nil, nil
+	}
+	if answer, ok := m.earlierAnswer(threadMessages, text); ok {
+		return answer, nil, nil
+	}
+
+	// Ground the answer in related messages from the index
+	question := text
+	if m.config.promptDirectives {
+		question, _ = parsePromptDirectives(question)
+	}
+	if m.config.languageDirectives {
+		question, _ = parseLanguageDirective(question)
+	}
+	sources, grounded := m.retrieveSources(question)
+	if !grounded {
+		return NoGroundingResponse, nil, nil
+	}
+	threadMessages, sources = m.trimToBudget(threadMessages, sources)
+	m.UpdateUserProfile(userInfo, text)
+
+	// Get response from LLM with thread context
+	response, err := m.getLLMResponse(channel, m.buildPrompt(channel, threadMessages, sources, text, userInfo))
+	if errors.Is(err, llm.ErrContextOverflow) {
+		response, err = m.recoverOverflow(channel, err, threadMessages, sources, text, userInfo)
+	}
+	response, err = m.checkResponse(response, err)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(sources) > 0 && m.config.ragCitations {
+		links := m.citationLinks(sources)
+		if m.config.ephemeralSources {
+			// The links go to the asker with the sources, the answer keeps
+			// plain markers matching their numbers
+			links = make([]string, len(sources))
+		}
+		response = RenderCitations(response, links)
+	}
+	return m.resolveMentions(response, threadMessages, userInfo), sources, nil
+}
+
+// Behaviors for questions asked without any earlier conversation
+const (
+	noContextProceed = "proceed"
+	noContextNote    = "note"
+)
+
+const noContextPrompt = "There is no earlier conversation in this channel or thread, only the question below. Don't assume or refer to context you haven't been given."
+
+// buildPrompt lays out the messages sent to the LLM to answer text asked in
+// channel
+func (m *ConversationManager) buildPrompt(channel string, threadMessages []llm.Message, sources []vectordb.Message, text string, userInfo *slack.User) []llm.Message {
+	messages := make([]llm.Message, 0, len(threadMessages)+4)
+	if m.config.channelTopic {
+		if message, ok := m.channelContextMessage(channel); ok {
+			messages = append(messages, message)
+		}
+	}
 	if len(threadMessages) > 0 {
 		messages = append(messages, threadMessages...)
+	} else if m.config.noContext == noContextNote {
+		messages = append(messages, llm.Message{Role: "system", Content: noContextPrompt, Label: m.config.systemLabel})
+	}
+	if len(sources) > 0 {
+		messages = append(messages, m.sourcesMessage(sources))
 	}
-	messages = append(messages, llm.Message{
+	if profile, ok := m.GetUserProfile(userInfo.ID); ok {
+		if message, ok := profileMessage(profile); ok {
+			message.Label = m.config.contextLabel
+			messages = append(messages, message)
+		}
+	}
+	if m.config.promptDirectives {
+		var directives []string
+		if text, directives = parsePromptDirectives(text); len(directives) > 0 {
+			messages = append(messages, llm.Message{Role: "system", Content: promptDirectivesPrompt(directives), Label: m.config.systemLabel})
+		}
+	}
+	if m.config.languageDirectives {
+		var language string
+		if text, language = parseLanguageDirective(text); language != "" {
+			messages = append(messages, llm.Message{Role: "system", Content: languagePrompt(language), Label: m.config.systemLabel})
+		}
+	}
+	return append(messages, llm.Message{
 		Role:    "user",
 		Content: text,
 		User: &llm.User{
@@ -159,28 +392,83 @@ func (m *ConversationManager) ProcessMessage(threadMessages []llm.Message, text
 			SlackID:   userInfo.ID,
 		},
 	})
-
-	// Get response from LLM with thread context
-	return m.getLLMResponse(messages)
 }
 
 func (m *ConversationManager) ProcessReaction(reaction string) (string, error) {
-	return m.llmClient.Generate(fmt.Sprintf("User reacted with :%s: to my message", reaction))
+	return m.respondToReaction(fmt.Sprintf(":%s:", reaction))
 }
 
-func (m *ConversationManager) ProcessIncommingMessage(text string, user *slack.User, channelID string) {
+// respondToReaction answers a reaction to a bot message, described as
+// describeReaction puts it
+func (m *ConversationManager) respondToReaction(description string) (string, error) {
+	return m.checkResponse(m.llmClient.Generate(fmt.Sprintf("User reacted with %s to my message", description)))
+}
+
+// checkResponse trims filler from an LLM response and turns a blank one into
+// ErrEmptyResponse so we never post an empty message
+func (m *ConversationManager) checkResponse(response string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	response = m.trimmer.Trim(response)
+	if strings.TrimSpace(response) == "" {
+		m.logger.Warn("LLM returned an empty response")
+		return "", ErrEmptyResponse
+	}
+	return response, nil
+}
+
+// LeaveChannel evicts all cached state for a channel the bot was removed
+// from and stops posting to (and optionally indexing) it
+func (m *ConversationManager) LeaveChannel(channelID string) {
+	m.messageHistory.Delete(channelID)
+	m.leftChannels.Store(channelID, time.Now())
+	m.logger.Infof("Left channel %s, cleared cached state", channelID)
+}
+
+// JoinChannel resumes normal handling of a channel the bot was added back to,
+// and backfills its history in the background when configured to
+func (m *ConversationManager) JoinChannel(channelID string) {
+	if _, left := m.leftChannels.LoadAndDelete(channelID); left {
+		m.logger.Infof("Rejoined channel %s", channelID)
+	}
+
+	if m.config.backfillOnJoin {
+		go func() {
+			if _, err := m.BackfillChannel(channelID); err != nil {
+				m.logger.Errorf("Failed to backfill channel %s: %v", channelID, err)
+			}
+		}()
+	}
+}
+
+func (m *ConversationManager) hasLeft(channelID string) bool {
+	_, left := m.leftChannels.Load(channelID)
+	return left
+}
+
+// ProcessIncommingMessage indexes a message posted at timestamp, in the thread
+// started at threadTimestamp if any
+func (m *ConversationManager) ProcessIncommingMessage(text string, user *slack.User, channelID, timestamp, threadTimestamp string) {
+	if m.config.stopIndexingOnLeave && m.hasLeft(channelID) {
+		m.logger.Debugf("Not indexing message from left channel %s", channelID)
+		return
+	}
+
 	if _, exists := m.messageHistory.Load(channelID); !exists {
 		m.loadHistory(channelID)
 	}
 
-	// Check if vectorDB is initialized
+	// Indexing is disabled
 	if m.vectorDB == nil {
-		m.logger.Error("vectorDB client is not initialized")
 		return
 	}
 
+	// Mention markup would otherwise end up in the embedding
+	text, rawText := m.indexedText(text)
+
 	// Get embedding for the message
-	embedding, err := m.llmClient.GetEmbedding(text)
+	embedding, err := llm.EmbedDocument(m.embedder, text)
 	if err != nil {
 		m.logger.Errorf("Failed to get embedding for message: %v", err)
 		return
@@ -188,20 +476,28 @@ func (m *ConversationManager) ProcessIncommingMessage(text string, user *slack.U
 
 	// Create message for vectorDB
 	msg := vectordb.Message{
+		// A redelivered message overwrites the one indexed before
+		ID:        messageID(channelID, timestamp),
 		Text:      text,
 		UserID:    user.ID,
 		ChannelID: channelID,
 		Timestamp: time.Now().Format(time.RFC3339),
+		ThreadID:  threadTimestamp,
+		MessageTS: timestamp,
+		RawText:   rawText,
+		Permalink: m.indexPermalink(channelID, timestamp, text),
 		Embedding: embedding,
 	}
 
-	// Store message in vectorDB
-	if err := m.vectorDB.StoreMessage(msg); err != nil {
-		m.logger.Errorf("Failed to store message in vectorDB: %v", err)
-		return
+	// Tag the message for team-health features, at the cost of an LLM call
+	if m.config.sentimentTagging {
+		if sentiment := m.classifySentiment(text); sentiment != "" {
+			msg.Metadata = map[string]string{"sentiment": sentiment}
+		}
 	}
 
-	m.logger.Infof("Successfully stored message in vectorDB for channel %s", channelID)
+	// Store message in vectorDB
+	m.storeMessage(msg)
 }
 
 func (m *ConversationManager) loadHistory(channelID string) {
@@ -229,22 +525,112 @@ func (m *ConversationManager) loadHistory(channelID string) {
 	m.messageHistory.Store(channelID, history.Messages)
 }
 
-func (m *ConversationManager) getLLMResponse(messages []llm.Message) (string, error) {
+// modelOptions returns the LLM options for a call answering in channel
+func (m *ConversationManager) modelOptions(channel string) []llm.Option {
+	var opts []llm.Option
+	if model, ok := m.config.channelModels[channel]; ok && model != "" {
+		m.logger.Debugf("Using model %s for channel %s", model, channel)
+		opts = append(opts, llm.WithModel(model))
+	}
+	if persona, ok := m.personas[channel]; ok {
+		opts = append(opts, llm.WithPersona(persona))
+	}
+	return opts
+}
+
+// summaryOptions returns the LLM options for a summary of channel
+func (m *ConversationManager) summaryOptions(channel string) []llm.Option {
+	opts := m.modelOptions(channel)
+	if m.config.summaryMaxTokens > 0 {
+		opts = append(opts, llm.WithMaxTokens(m.config.summaryMaxTokens))
+	}
+	return opts
+}
+
+func (m *ConversationManager) getLLMResponse(channel string, messages []llm.Message) (string, error) {
+	opts := m.modelOptions(channel)
+
 	// Choose between Chat and Generate based on LLM_MODE
 	if m.llmMode == "chat" {
-		return m.llmClient.Chat(messages)
+		if m.config.speakerNames {
+			messages = m.withSpeakerNames(messages)
+		}
+		if m.tools.HasTools() {
+			return m.tools.Chat(messages, opts...)
+		}
+		return m.llmClient.Chat(messages, opts...)
 	} else {
 		// Default to Generate mode
 		// Concatenate all messages into a single string
 		var fullContext strings.Builder
 		for _, msg := range messages {
-			fullContext.WriteString(fmt.Sprintf("%s|%s: %s\n", msg.User.SlackID, msg.User.SlackName, msg.Content))
+			fullContext.WriteString(generateLine(msg) + "\n")
 		}
-		return m.llmClient.Generate(fullContext.String())
+		return m.llmClient.Generate(fullContext.String(), opts...)
 	}
 }
 
+// generateLine renders a message for a generate mode prompt: labelled when it
+// is synthetic, attributed to its author when it has one and to its role when
+// the author is unknown
+func generateLine(msg llm.Message) string {
+	switch {
+	case msg.Label != "":
+		return fmt.Sprintf("%s: %s", msg.Label, msg.Content)
+	case msg.User == nil:
+		return msg.Content
+	case msg.User.SlackID == "" && msg.User.SlackName == "":
+		return fmt.Sprintf("%s: %s", msg.Role, msg.Content)
+	default:
+		return fmt.Sprintf("%s|%s: %s", msg.User.SlackID, msg.User.SlackName, msg.Content)
+	}
+}
+
+// PostResponse posts response to channel, which does not have to be the
+// channel the triggering message came from. Responses in a thread get action
+// buttons when RESPONSE_BUTTONS is on, and every response gets the
+// RESPONSE_FOOTER when it is set. Responses are masked by the output filter
+// when one is configured.
 func (m *ConversationManager) PostResponse(channel, response, threadTimestamp string) error {
+	return m.postResponse(channel, response, threadTimestamp, true)
+}
+
+// postResponse posts response, with the buttons and footer of an answer when
+// answer is set
+func (m *ConversationManager) postResponse(channel, response, threadTimestamp string, answer bool) error {
+	_, err := m.sendResponse(channel, response, threadTimestamp, "", answer)
+	return err
+}
+
+// sendResponse posts response, or updates the response already posted for
+// requestID when it is set, and returns the timestamp of the message. It is
+// empty when the response was uploaded as a snippet.
+func (m *ConversationManager) sendResponse(channel, response, threadTimestamp, requestID string, answer bool) (string, error) {
+	if m.hasLeft(channel) {
+		return "", fmt.Errorf("bot is no longer a member of channel %s", channel)
+	}
+	if strings.TrimSpace(response) == "" {
+		return "", fmt.Errorf("refusing to post an empty message to channel %s", channel)
+	}
+
+	// Nothing the filter masks leaves the bot, whatever kind of message it is
+	response = m.outputFilter.Apply(response)
+
+	// Answers that are mostly code read better as a highlighted snippet. A
+	// snippet can't be updated, so a retried request uploads it only once.
+	if m.config.snippetMinLines > 0 && !m.postedBefore(channel, threadTimestamp, requestID) {
+		if snippet, ok := detectCodeSnippet(response, m.config.snippetMinLines); ok {
+			err := m.UploadSnippet(channel, threadTimestamp, snippet.code, snippet.filetype, snippet.comment)
+			if err == nil {
+				if requestID != "" {
+					m.postedResponses.record(postKey{channel: channel, thread: threadTimestamp, requestID: requestID}, "")
+				}
+				return "", nil
+			}
+			m.logger.Warnf("Posting code answer as a message instead: %v", err)
+		}
+	}
+
 	// Create message options with formatting enabled
 	opts := []slack.MsgOption{
 		slack.MsgOptionText(response, false), // false means don't escape special characters
@@ -252,17 +638,30 @@ func (m *ConversationManager) PostResponse(channel, response, threadTimestamp st
 		slack.MsgOptionAsUser(true),          // Post as the bot user
 	}
 
-	// Add thread timestamp if available
-	if threadTimestamp != "" {
-		opts = append(opts, slack.MsgOptionTS(threadTimestamp))
+	// The text stays as the notification fallback
+	var blocks []slack.Block
+	if answer && m.config.responseButtons && threadTimestamp != "" {
+		blocks = m.responseBlocks(response, threadTimestamp)
+	}
+	if answer && m.config.responseFooter != "" {
+		if blocks == nil {
+			blocks = textBlocks(response)
+		}
+		blocks = append(blocks, footerBlock(m.config.responseFooter))
+	}
+	if len(blocks) > 0 {
+		opts = append(opts, slack.MsgOptionBlocks(blocks...))
 	}
 
-	// Post the message
-	_, _, err := m.client.PostMessage(channel, opts...)
+	// Post the message, in the thread if there is one
+	timestamp, err := m.deliver(channel, threadTimestamp, requestID, opts)
 	if err != nil {
+		if m.queuePost(failedPost{channel: channel, threadTimestamp: threadTimestamp, requestID: requestID, opts: opts, err: err}) {
+			return "", nil
+		}
 		m.logger.Errorf("Failed to post message: %v", err)
-		return err
+		return "", err
 	}
 
-	return nil
+	return timestamp, nil
 }
@@ -0,0 +1,219 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"beebrain/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DigestConfig controls where and how often channel digests are posted
+type DigestConfig struct {
+	// TargetChannel is the channel digests are posted to, e.g. #digests
+	TargetChannel string
+	// SourceChannels are the channels that get summarized
+	SourceChannels []string
+	// Interval is how often digests are posted and how far back they look
+	Interval time.Duration
+	// At is the offset from local midnight that runs are anchored to, so a
+	// 24h interval with At of 9h posts every day at 09:00
+	At time.Duration
+	// CatchUp posts the most recent missed digest on startup
+	CatchUp bool
+	// CheckInterval is how often the scheduler checks whether a run is due
+	CheckInterval time.Duration
+}
+
+func loadDigestConfig(logger *logrus.Logger) DigestConfig {
+	// Runs default to being anchored at midnight
+	at, _ := config.TimeOfDay(logger, "DIGEST_AT")
+	return DigestConfig{
+		TargetChannel:  os.Getenv("DIGEST_CHANNEL"),
+		SourceChannels: config.List("DIGEST_SOURCE_CHANNELS"),
+		Interval:       config.Duration(logger, "DIGEST_INTERVAL", 24*time.Hour),
+		At:             at,
+		CatchUp:        config.Bool(logger, "DIGEST_CATCH_UP", false),
+		CheckInterval:  config.Duration(logger, "DIGEST_CHECK_INTERVAL", time.Minute),
+	}
+}
+
+func (c DigestConfig) enabled() bool {
+	return c.TargetChannel != "" && len(c.SourceChannels) > 0 && c.Interval > 0
+}
+
+// NextRun returns the first scheduled run strictly after the given time
+func (c DigestConfig) NextRun(after time.Time) time.Time {
+	next := c.PreviousRun(after)
+	for !next.After(after) {
+		next = next.Add(c.Interval)
+	}
+	return next
+}
+
+// PreviousRun returns the latest scheduled run at or before the given time
+func (c DigestConfig) PreviousRun(at time.Time) time.Time {
+	midnight := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	run := midnight.Add(c.At)
+	for run.After(at) {
+		run = run.Add(-c.Interval)
+	}
+	for !run.Add(c.Interval).After(at) {
+		run = run.Add(c.Interval)
+	}
+	return run
+}
+
+// Window returns the period summarized by the run scheduled at runAt
+func (c DigestConfig) Window(runAt time.Time) (time.Time, time.Time) {
+	return runAt.Add(-c.Interval), runAt
+}
+
+// PostChannelDigest summarizes the messages posted in sourceChannel between
+// oldest and latest and posts the summary to the configured digest channel
+func (m *ConversationManager) PostChannelDigest(sourceChannel string, oldest, latest time.Time) error {
+	if m.digest.TargetChannel == "" {
+		return fmt.Errorf("digest channel is not configured")
+	}
+
+	messages, err := m.GetConversationSince(sourceChannel, oldest, latest)
+	if err != nil {
+		return fmt.Errorf("failed to get messages for digest: %w", err)
+	}
+	if len(messages) == 0 {
+		m.logger.Infof("No messages in channel %s since %s, skipping digest", sourceChannel, oldest.Format(time.RFC3339))
+		return nil
+	}
+
+	summary, err := m.llmClient.Summarize(messages, m.summaryOptions(sourceChannel)...)
+	if err != nil {
+		return fmt.Errorf("failed to summarize channel %s: %w", sourceChannel, err)
+	}
+
+	digest := digestHeading(sourceChannel) + "\n" + summary
+	_, err = m.PostProactive(m.digest.TargetChannel, digest)
+	return err
+}
+
+// RunDigests posts the digest of every source channel for the run scheduled
+// at runAt. It returns false without doing anything if a previous run is
+// still in progress.
+func (m *ConversationManager) RunDigests(runAt time.Time) bool {
+	return m.runDigests(runAt, m.digest.SourceChannels)
+}
+
+// runDigests posts the digests of channels for the run scheduled at runAt,
+// like RunDigests
+func (m *ConversationManager) runDigests(runAt time.Time, channels []string) bool {
+	if !m.digestRunning.CompareAndSwap(false, true) {
+		m.logger.Warnf("Previous digest run still in progress, skipping run scheduled at %s", runAt.Format(time.RFC3339))
+		return false
+	}
+	defer m.digestRunning.Store(false)
+
+	oldest, latest := m.digest.Window(runAt)
+	for _, channel := range channels {
+		if err := m.PostChannelDigest(channel, oldest, latest); err != nil {
+			m.logger.Errorf("Failed to post digest for channel %s: %v", channel, err)
+		}
+	}
+	return true
+}
+
+// catchUpDigests posts the digests of the run scheduled at runAt that are
+// missing from the digest channel, so a restart doesn't post them again
+func (m *ConversationManager) catchUpDigests(runAt time.Time) {
+	posted, err := m.postedDigests(runAt)
+	if err != nil {
+		m.logger.Errorf("Not catching up on digests, failed to check which were posted: %v", err)
+		return
+	}
+
+	var missed []string
+	for _, channel := range m.digest.SourceChannels {
+		if !posted[channel] {
+			missed = append(missed, channel)
+		}
+	}
+	if len(missed) == 0 {
+		m.logger.Debugf("Digests of the run at %s were posted already", runAt.Format(time.RFC3339))
+		return
+	}
+	m.logger.Infof("Catching up on %d digests missed at %s", len(missed), runAt.Format(time.RFC3339))
+	m.runDigests(runAt, missed)
+}
+
+// postedDigests returns the source channels the bot posted a digest of to
+// the digest channel since the given time
+func (m *ConversationManager) postedDigests(since time.Time) (map[string]bool, error) {
+	history, err := m.conversationHistory(m.digest.TargetChannel, since, time.Time{}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	posted := make(map[string]bool)
+	for _, msg := range history {
+		if m.messageRole(msg) != "assistant" {
+			continue
+		}
+		for _, channel := range m.digest.SourceChannels {
+			if isDigestOf(msg.Text, channel) {
+				posted[channel] = true
+			}
+		}
+	}
+	return posted, nil
+}
+
+// digestHeading is the first line of the digest of channel
+func digestHeading(channel string) string {
+	return fmt.Sprintf("*Digest for <#%s>*", channel)
+}
+
+// isDigestOf reports whether text is the digest of channel, whose link Slack
+// may have given the channel name since it was posted
+func isDigestOf(text, channel string) bool {
+	rest, ok := strings.CutPrefix(text, "*Digest for <#"+channel)
+	return ok && (strings.HasPrefix(rest, ">") || strings.HasPrefix(rest, "|"))
+}
+
+// StartDigests posts channel digests on the configured schedule until ctx is
+// cancelled
+func (m *ConversationManager) StartDigests(ctx context.Context) {
+	if !m.digest.enabled() {
+		m.logger.Debug("Channel digests are not configured")
+		return
+	}
+
+	now := time.Now()
+	if m.digest.CatchUp {
+		// Post the most recent digest in case we were down when it was due
+		m.catchUpDigests(m.digest.PreviousRun(now))
+	}
+
+	next := m.digest.NextRun(now)
+	m.logger.Infof("Posting digests of %d channels to %s every %s, next run at %s",
+		len(m.digest.SourceChannels), m.digest.TargetChannel, m.digest.Interval, next.Format(time.RFC3339))
+
+	ticker := time.NewTicker(m.digest.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if now.Before(next) {
+				continue
+			}
+			// Run in the background so a slow run doesn't delay the schedule;
+			// overlapping runs are skipped by RunDigests
+			go m.RunDigests(next)
+			next = m.digest.NextRun(now)
+		}
+	}
+}
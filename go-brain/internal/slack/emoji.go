@@ -0,0 +1,108 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// emojiAliasPrefix marks a custom emoji that is another name for an emoji
+const emojiAliasPrefix = "alias:"
+
+// maxEmojiAliases bounds how many aliases are followed, in case they loop
+const maxEmojiAliases = 5
+
+// emojiCache holds the workspace's custom emoji, by name, for ttl or until
+// they change
+type emojiCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	emoji     map[string]string
+	fetchedAt time.Time
+}
+
+func newEmojiCache(ttl time.Duration) *emojiCache {
+	return &emojiCache{ttl: ttl}
+}
+
+// get returns the cached custom emoji, or false when they need fetching
+func (c *emojiCache) get() (map[string]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.emoji == nil || time.Since(c.fetchedAt) > c.ttl {
+		return nil, false
+	}
+	return c.emoji, true
+}
+
+func (c *emojiCache) set(emoji map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.emoji = emoji
+	c.fetchedAt = time.Now()
+}
+
+// clear drops the cached custom emoji, so the next lookup fetches them again
+func (c *emojiCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.emoji = nil
+}
+
+// customEmoji returns the workspace's custom emoji, from the cache when it is
+// still fresh
+func (m *ConversationManager) customEmoji() (map[string]string, error) {
+	if emoji, ok := m.customEmojiCache.get(); ok {
+		return emoji, nil
+	}
+
+	emoji, err := m.client.GetEmoji()
+	if err != nil {
+		return nil, err
+	}
+	m.customEmojiCache.set(emoji)
+	return emoji, nil
+}
+
+// CustomEmojiChanged forgets the cached custom emoji after they were added,
+// renamed or removed
+func (m *ConversationManager) CustomEmojiChanged() {
+	m.customEmojiCache.clear()
+}
+
+// describeReaction returns how a reaction is put to the LLM, or false when it
+// isn't worth answering. With CUSTOM_EMOJI_RESOLVE set, custom emoji aliases
+// are resolved to the emoji they stand for, custom emoji with a meaning in
+// CUSTOM_EMOJI_MEANINGS are described with it and the other custom emoji are
+// taken as decorative and skipped.
+func (m *ConversationManager) describeReaction(reaction string) (string, bool) {
+	if !m.config.customEmojiResolve {
+		return fmt.Sprintf(":%s:", reaction), true
+	}
+
+	name, _, _ := strings.Cut(reaction, "::skin-tone-")
+	emoji, err := m.customEmoji()
+	if err != nil {
+		m.logger.Warnf("Failed to list custom emoji, passing :%s: on as it is: %v", reaction, err)
+		return fmt.Sprintf(":%s:", reaction), true
+	}
+
+	for i := 0; i < maxEmojiAliases; i++ {
+		if meaning, ok := m.config.customEmojiMeanings[name]; ok {
+			return fmt.Sprintf(":%s: (a custom emoji meaning %s)", name, meaning), true
+		}
+		value, custom := emoji[name]
+		if !custom {
+			// A standard emoji the LLM knows
+			return fmt.Sprintf(":%s:", name), true
+		}
+		target, alias := strings.CutPrefix(value, emojiAliasPrefix)
+		if !alias {
+			m.logger.Debugf("Reaction :%s: is a decorative custom emoji, skipping processing", reaction)
+			return "", false
+		}
+		name = target
+	}
+	return "", false
+}
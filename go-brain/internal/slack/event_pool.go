@@ -0,0 +1,84 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// eventJob is a Slack event waiting for an event worker
+type eventJob struct {
+	name   string
+	handle func(c echo.Context) error
+	c      echo.Context
+}
+
+// dispatchEvent handles an event on the event workers when EVENT_WORKERS is
+// set, answering Slack straight away, and in the request otherwise. An event
+// that finds the queue full is dropped, still with a 200 so Slack doesn't
+// retry it into an already overloaded bot.
+func (h *BeeBrainSlackHandler) dispatchEvent(c echo.Context, name string, handle func(c echo.Context) error) error {
+	if h.eventQueue == nil {
+		return handle(c)
+	}
+
+	// The handler runs after the request is done, so it gets a copy of the
+	// request that isn't cancelled with it and a response nobody reads
+	req := c.Request()
+	background := c.Echo().NewContext(req.WithContext(context.WithoutCancel(req.Context())), &discardResponse{header: http.Header{}})
+
+	select {
+	case h.eventQueue <- eventJob{name: name, handle: handle, c: background}:
+		return c.NoContent(http.StatusOK)
+	default:
+		dropped := h.droppedEvents.Add(1)
+		h.logger.Warnf("Event queue is full (%d events), dropping %s event (%d dropped so far)", cap(h.eventQueue), name, dropped)
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+// DroppedEvents returns how many events were dropped because the event queue
+// was full
+func (h *BeeBrainSlackHandler) DroppedEvents() int64 {
+	return h.droppedEvents.Load()
+}
+
+// StartEventWorkers handles queued events until ctx is cancelled. It returns
+// straight away unless EVENT_WORKERS is set.
+func (h *BeeBrainSlackHandler) StartEventWorkers(ctx context.Context) {
+	if h.eventQueue == nil {
+		return
+	}
+
+	h.logger.Infof("Starting %d event workers", h.eventWorkers)
+	var wg sync.WaitGroup
+	for i := 0; i < h.eventWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-h.eventQueue:
+					if err := job.handle(job.c); err != nil {
+						h.logger.Errorf("Failed to handle %s event: %v", job.name, err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// discardResponse is the response of an event handled after Slack has been
+// answered
+type discardResponse struct {
+	header http.Header
+}
+
+func (r *discardResponse) Header() http.Header         { return r.header }
+func (r *discardResponse) Write(b []byte) (int, error) { return len(b), nil }
+func (r *discardResponse) WriteHeader(int)             {}
@@ -1,13 +1,19 @@
 package slack
 
 import (
+	"beebrain/internal/config"
 	"beebrain/internal/llm"
 	"beebrain/internal/vectordb"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -16,43 +22,164 @@ import (
 	"github.com/slack-go/slack/slackevents"
 )
 
+// defaultBusyResponse answers mentions when the LLM is busy and
+// LLM_BUSY_MESSAGE is unset
+const defaultBusyResponse = "I'm answering a lot of questions right now, please try again in a minute."
+
 type BeeBrainSlackHandler struct {
-	client              *slack.Client
+	client              SlackClient
 	logger              *logrus.Logger
 	signingSecret       string
 	verificationToken   string
 	processedEvents     sync.Map // key: string, value: time.Time
 	botUserID           string
 	conversationManager *ConversationManager
+	// triggerWords make plain messages starting with them answered like
+	// mentions
+	triggerWords []string
+	// reactions are the reactions on bot messages that get a response, empty
+	// means all of them
+	reactions []string
+	// followWindow is how long the bot keeps answering follow-ups in a thread
+	// after answering there, 0 requires a mention for every answer
+	followWindow    time.Duration
+	followedThreads sync.Map // key: channel:thread_ts, value: time.Time expiry
+	// maxBodyBytes caps the size of request bodies
+	maxBodyBytes int64
+	// eventQueue holds events for eventWorkers to handle after Slack has been
+	// answered, nil handles them in the request
+	eventQueue    chan eventJob
+	eventWorkers  int
+	droppedEvents atomic.Int64
+	// pipelineRetries is how many times answering a mention is retried after
+	// a transient failure, waiting pipelineBackoff doubled after each attempt
+	pipelineRetries int
+	pipelineBackoff time.Duration
+	// mentionTimeout is how long answering a mention may take before the
+	// bot says it is taking too long, 0 waits for the answer
+	mentionTimeout time.Duration
+	// subtypeHandlers handle the message subtypes that are switched on, such
+	// as welcoming people on channel_join with welcomeMessage
+	subtypeHandlers map[string]subtypeHandler
+	welcomeMessage  string
+	welcomeChannels []string
+	// contentlessMode is what is done with mentions with nothing to answer,
+	// like a bare emoji: ack them with contentlessReply, ignore them or
+	// answer them like any other
+	contentlessMode  string
+	contentlessReply string
+	// busyResponse answers mentions that couldn't get an LLM slot within
+	// LLM_QUEUE_WAIT
+	busyResponse string
+	// joinGreeting is posted when the bot is added to a channel, empty
+	// when GREET_ON_JOIN is off
+	joinGreeting string
+	// inFlight has duplicates of a mention being answered wait for its
+	// answer, nil when DEDUP_IN_FLIGHT is off
+	inFlight *inFlightAnswers
 }
 
-func NewBeeBrainSlackHandler(client *slack.Client, llmClient *llm.Client, vectorDB *vectordb.Client, logger *logrus.Logger, signingSecret, verificationToken, llmMode string) *BeeBrainSlackHandler {
+func NewBeeBrainSlackHandler(client SlackClient, llmClient llm.LLMClient, embedder llm.Embedder, vectorDB vectordb.VectorDBClient, logger *logrus.Logger, signingSecret, verificationToken, llmMode string) *BeeBrainSlackHandler {
 	// Get bot user ID
 	auth, err := client.AuthTest()
 	if err != nil {
 		logger.Fatal("Failed to get bot user ID")
 	}
 
-	return &BeeBrainSlackHandler{
+	conversationManager := NewConversationManager(client, llmClient, embedder, logger, llmMode, vectorDB)
+	conversationManager.SetWorkspaceURL(auth.URL)
+	conversationManager.SetBotIdentity(auth.UserID, auth.BotID)
+
+	h := &BeeBrainSlackHandler{
 		client:              client,
 		logger:              logger,
 		signingSecret:       signingSecret,
 		verificationToken:   verificationToken,
 		botUserID:           auth.UserID,
-		conversationManager: NewConversationManager(client, llmClient, logger, llmMode, vectorDB),
+		conversationManager: conversationManager,
+		triggerWords:        config.List("TRIGGER_WORDS"),
+		reactions:           config.List("REACTION_WHITELIST"),
+		followWindow:        config.Duration(logger, "THREAD_FOLLOW_WINDOW", 0),
+		maxBodyBytes:        int64(config.Int(logger, "MAX_REQUEST_BODY_BYTES", 1<<20)),
+		eventWorkers:        config.Int(logger, "EVENT_WORKERS", 0),
+		pipelineRetries:     config.Int(logger, "PIPELINE_RETRIES", 0),
+		pipelineBackoff:     config.Duration(logger, "PIPELINE_RETRY_BACKOFF", time.Second),
+		mentionTimeout:      config.Duration(logger, "MENTION_TIMEOUT", 0),
+		contentlessMode:     loadContentlessMode(logger),
+		contentlessReply:    config.String("CONTENTLESS_MENTION_REPLY", defaultContentlessReply),
+		busyResponse:        config.String("LLM_BUSY_MESSAGE", defaultBusyResponse),
+	}
+	if h.eventWorkers > 0 {
+		h.eventQueue = make(chan eventJob, config.Int(logger, "EVENT_QUEUE_SIZE", 100))
+	}
+	h.subtypeHandlers = h.loadSubtypeHandlers(logger)
+	if config.Bool(logger, "GREET_ON_JOIN", false) {
+		h.joinGreeting = config.String("JOIN_GREETING", defaultJoinGreeting)
 	}
+	if config.Bool(logger, "DEDUP_IN_FLIGHT", true) {
+		h.inFlight = newInFlightAnswers()
+	}
+	return h
+}
+
+// SetContext bounds the work started in the background, such as running
+// button actions, to ctx
+func (h *BeeBrainSlackHandler) SetContext(ctx context.Context) {
+	h.conversationManager.SetContext(ctx)
+}
+
+// StartDigests runs the periodic channel digests until ctx is cancelled
+func (h *BeeBrainSlackHandler) StartDigests(ctx context.Context) {
+	h.conversationManager.StartDigests(ctx)
+}
+
+// StartStandups posts the scheduled standups and summarizes the replies to
+// them until ctx is cancelled
+func (h *BeeBrainSlackHandler) StartStandups(ctx context.Context) {
+	h.conversationManager.StartStandups(ctx)
+}
+
+// StartStoreQueue retries messages that failed to index until ctx is cancelled
+func (h *BeeBrainSlackHandler) StartStoreQueue(ctx context.Context) {
+	h.conversationManager.StartStoreQueue(ctx)
+}
+
+// StartPostQueue retries responses that failed to post until ctx is cancelled
+func (h *BeeBrainSlackHandler) StartPostQueue(ctx context.Context) {
+	h.conversationManager.StartPostQueue(ctx)
+}
+
+// readBody reads the request body, failing once it grows past maxBodyBytes so
+// a huge request can't exhaust memory
+func (h *BeeBrainSlackHandler) readBody(c echo.Context) ([]byte, error) {
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, h.maxBodyBytes)
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// StartIndexQueue indexes messages in the background until ctx is cancelled
+func (h *BeeBrainSlackHandler) StartIndexQueue(ctx context.Context) {
+	h.conversationManager.StartIndexQueue(ctx)
 }
 
 // HandleSlackEvents handles incoming Slack events
 func (h *BeeBrainSlackHandler) HandleSlackEvents(c echo.Context) error {
 	// Read the request body once
-	body, err := io.ReadAll(c.Request().Body)
+	body, err := h.readBody(c)
+	if isBodyTooLarge(err) {
+		h.logger.Warnf("Rejected request body over %d bytes", h.maxBodyBytes)
+		return c.NoContent(http.StatusRequestEntityTooLarge)
+	}
 	if err != nil {
 		h.logger.Error("Failed to read request body:", err)
 		// Return 200 OK to prevent Slack from retrying
 		return c.String(http.StatusOK, "Invalid request")
 	}
-	defer c.Request().Body.Close()
 
 	// Parse and verify the event using slackevents
 	slackEvent, err := slackevents.ParseEvent(
@@ -78,31 +205,59 @@ func (h *BeeBrainSlackHandler) HandleSlackEvents(c echo.Context) error {
 		innerEvent := slackEvent.InnerEvent
 		h.logger.Debugf("Inner event type: %T", innerEvent.Data)
 
-		switch ev := innerEvent.Data.(type) {
-		case *slackevents.AppMentionEvent:
-			return h.handleAppMention(c, ev)
-		case *slackevents.MessageEvent:
-			// Handle different message subtypes
-			switch ev.SubType {
-			case "": // no subtype, i.e. normal message
-				return h.handleIncommingMessage(c, ev)
-			default:
-				return h.handleUnknownEvent(c, ev)
-			}
-		case *slackevents.ReactionAddedEvent:
-			h.logger.Debugf("Processing reaction event: %+v", ev)
-			return h.handleReactionAdded(c, ev)
+		return h.dispatchEvent(c, innerEvent.Type, func(c echo.Context) error {
+			return h.handleCallbackEvent(c, innerEvent)
+		})
+	}
+
+	// Return 200 OK for unhandled event types
+	return c.NoContent(http.StatusOK)
+}
+
+// handleCallbackEvent routes an event to its handler
+func (h *BeeBrainSlackHandler) handleCallbackEvent(c echo.Context, innerEvent slackevents.EventsAPIInnerEvent) error {
+	switch ev := innerEvent.Data.(type) {
+	case *slackevents.AppMentionEvent:
+		return h.handleAppMention(c, ev)
+	case *slackevents.MessageEvent:
+		// Handle different message subtypes
+		switch ev.SubType {
+		case "": // no subtype, i.e. normal message
+			return h.handleIncommingMessage(c, ev)
+		case "thread_broadcast": // thread reply also sent to the channel
+			return h.handleThreadBroadcast(c, ev)
 		default:
-			h.logger.Debugf("Unhandled event type: %T", ev)
-			if msgEvent, ok := innerEvent.Data.(*slackevents.MessageEvent); ok {
-				return h.handleUnknownEvent(c, msgEvent)
+			if handle, ok := h.subtypeHandlers[ev.SubType]; ok {
+				return handle(c, ev)
 			}
+			return h.handleUnknownEvent(c, ev)
+		}
+	case *slackevents.ReactionAddedEvent:
+		h.logger.Debugf("Processing reaction event: %+v", ev)
+		return h.handleReactionAdded(c, ev)
+	case *slackevents.ChannelLeftEvent:
+		return h.handleChannelLeft(c, ev.Channel)
+	case *slackevents.GroupLeftEvent:
+		return h.handleChannelLeft(c, ev.Channel)
+	case *slackevents.MemberLeftChannelEvent:
+		if ev.User != h.botUserID {
 			return c.NoContent(http.StatusOK)
 		}
+		return h.handleChannelLeft(c, ev.Channel)
+	case *slackevents.MemberJoinedChannelEvent:
+		return h.handleMemberJoinedChannel(c, ev)
+	case *slackevents.LinkSharedEvent:
+		return h.handleLinkShared(c, ev)
+	case *slackevents.EmojiChangedEvent:
+		h.conversationManager.CustomEmojiChanged()
+		return c.NoContent(http.StatusOK)
+	default:
+		h.logger.Debugf("Unhandled event type: %T", ev)
+		if msgEvent, ok := innerEvent.Data.(*slackevents.MessageEvent); ok {
+			return h.handleUnknownEvent(c, msgEvent)
+		}
+		return c.NoContent(http.StatusOK)
 	}
-
-	// Return 200 OK for unhandled event types
-	return c.NoContent(http.StatusOK)
 }
 
 // handleURLVerification handles the Slack URL verification challenge
@@ -133,12 +288,11 @@ func (h *BeeBrainSlackHandler) isDuplicateEvent(eventType, eventTimestamp string
 	// Create a composite key of event type and timestamp
 	eventKey := fmt.Sprintf("%s:%s", eventType, eventTimestamp)
 
-	if _, exists := h.processedEvents.Load(eventKey); exists {
+	if _, exists := h.processedEvents.LoadOrStore(eventKey, time.Now()); exists {
 		h.logger.Debugf("Skipping duplicate event: %s", eventKey)
 		return true
 	}
 
-	h.processedEvents.Store(eventKey, time.Now())
 	// Clean up old events
 	h.cleanupOldEvents()
 	return false
@@ -149,6 +303,10 @@ func (h *BeeBrainSlackHandler) handleAppMention(c echo.Context, ev *slackevents.
 	if h.isDuplicateEvent("app_mention", ev.EventTimeStamp) {
 		return c.NoContent(http.StatusOK)
 	}
+	// A bare emoji or "@beebrain ?" has nothing for the LLM to answer
+	if h.contentlessMode != contentlessAnswer && isContentless(ev.Text) {
+		return h.handleContentlessMention(c, ev)
+	}
 
 	h.logger.Infof("APP MENTION: Processing message from %s on channel %s", ev.User, ev.Channel)
 
@@ -161,7 +319,7 @@ func (h *BeeBrainSlackHandler) handleAppMention(c echo.Context, ev *slackevents.
 	}
 
 	// Get user info for the person mentioning the bot
-	userInfo, err := h.client.GetUserInfo(ev.User)
+	userInfo, err := h.conversationManager.GetUserInfo(ev.User)
 	if err != nil {
 		userInfo = &slack.User{
 			Name: "Unknown UserName",
@@ -170,24 +328,37 @@ func (h *BeeBrainSlackHandler) handleAppMention(c echo.Context, ev *slackevents.
 	}
 	h.logger.Debugf("User info retrieved: %s (%s)", userInfo.Name, userInfo.ID)
 
-	// Get thread context if available
-	threadMessages, err := h.conversationManager.GetThreadContext(ev.Channel, ev.ThreadTimeStamp)
-	if err != nil {
-		h.logger.Error("Failed to get thread context:", err)
+	// Get the thread context and the response, retrying transient failures
+	// until MENTION_TIMEOUT
+	answer, shared := h.answerMentionOnce(ev, userInfo)
+	if shared {
+		h.logger.Debugf("Mention %s in channel %s was answered for a duplicate event", ev.TimeStamp, ev.Channel)
+		return c.NoContent(http.StatusOK)
 	}
-
-	// Process the message and get response
-	response, err := h.conversationManager.ProcessMessage(threadMessages, ev.Text, userInfo)
-	if err != nil {
+	response, sources, err := answer.response, answer.sources, answer.err
+	if errors.Is(err, ErrEmptyResponse) {
+		response = emptyResponseFallback
+	} else if errors.Is(err, llm.ErrBusy) {
+		h.logger.Warnf("Not answering mention from %s in channel %s: %v", ev.User, ev.Channel, err)
+		response = h.busyResponse
+	} else if errors.Is(err, errMentionTimeout) {
+		h.logger.Warnf("Failed to answer mention from %s in channel %s: %v", ev.User, ev.Channel, err)
+		response = mentionTimeoutResponse
+	} else if err != nil {
 		h.logger.Error("Failed to process message:", err)
 		response = "Sorry, I encountered an error processing your request."
 	}
 
-	// Post response to Slack
-	if err := h.conversationManager.PostResponse(ev.Channel, response, ev.ThreadTimeStamp); err != nil {
+	// Post response to Slack, in reply to the mention
+	threadTimestamp := replyTimestamp(ev.ThreadTimeStamp, ev.TimeStamp)
+	if err := h.postAnswer(ev.Channel, response, threadTimestamp, ev.TimeStamp); err != nil {
 		h.logger.Error("Failed to post message:", err)
 		return c.String(http.StatusOK, "Error processing request")
 	}
+	h.followThread(ev.Channel, threadTimestamp)
+	if err := h.conversationManager.PostSources(ev.Channel, ev.User, threadTimestamp, sources); err != nil {
+		h.logger.Error("Failed to post sources:", err)
+	}
 
 	// Remove reaction
 	if err := h.client.RemoveReaction("eyes", slack.ItemRef{
@@ -202,14 +373,84 @@ func (h *BeeBrainSlackHandler) handleAppMention(c echo.Context, ev *slackevents.
 	return c.String(http.StatusOK, "Message processed")
 }
 
+// replyTimestamp is the thread a reply to a message goes in: the message's
+// thread, or a new thread under a top-level message
+func replyTimestamp(threadTimestamp, timestamp string) string {
+	if threadTimestamp != "" {
+		return threadTimestamp
+	}
+	return timestamp
+}
+
 func (h *BeeBrainSlackHandler) handleIncommingMessage(c echo.Context, ev *slackevents.MessageEvent) error {
 	// Skip if this is a duplicate event
 	if h.isDuplicateEvent("message", ev.EventTimeStamp) {
 		return c.NoContent(http.StatusOK)
 	}
 
+	h.indexMessage(ev)
+
+	// Answer messages addressing the bot by name, or following up in a thread
+	// it answered in, as if it was mentioned
+	if ev.BotID == "" && (matchesTrigger(ev.Text, h.triggerWords) || h.followingThread(ev.Channel, ev.ThreadTimeStamp)) {
+		// A "👍" following up in a thread isn't talking to the bot, so only
+		// real mentions get the content-less reply
+		if h.contentlessMode != contentlessAnswer && isContentless(ev.Text) {
+			h.logger.Debugf("Ignoring content-less message from %s in channel %s", ev.User, ev.Channel)
+			return c.NoContent(http.StatusOK)
+		}
+		return h.handleAppMention(c, mentionEvent(ev))
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// handleThreadBroadcast indexes a thread reply that was also sent to the
+// channel, and answers it in the thread when it mentions the bot
+func (h *BeeBrainSlackHandler) handleThreadBroadcast(c echo.Context, ev *slackevents.MessageEvent) error {
+	if h.isDuplicateEvent("message", ev.EventTimeStamp) {
+		return c.NoContent(http.StatusOK)
+	}
+
+	h.indexMessage(ev)
+
+	if !strings.Contains(ev.Text, fmt.Sprintf("<@%s>", h.botUserID)) {
+		return c.NoContent(http.StatusOK)
+	}
+
+	return h.handleAppMention(c, mentionEvent(ev))
+}
+
+// mentionEvent turns a message into the app mention it is answered as. Its
+// event timestamp dedups it against the app_mention event Slack may also send
+// for the message.
+func mentionEvent(ev *slackevents.MessageEvent) *slackevents.AppMentionEvent {
+	return &slackevents.AppMentionEvent{
+		Type:            "app_mention",
+		User:            ev.User,
+		Text:            ev.Text,
+		TimeStamp:       ev.TimeStamp,
+		ThreadTimeStamp: ev.ThreadTimeStamp,
+		Channel:         ev.Channel,
+		EventTimeStamp:  ev.EventTimeStamp,
+	}
+}
+
+// indexMessage stores a message posted to a channel in the vector database,
+// in the background when the index queue is enabled. The bot's own answers
+// are left to indexResponse, which tags them as answers when INDEX_RESPONSES
+// is set.
+func (h *BeeBrainSlackHandler) indexMessage(ev *slackevents.MessageEvent) {
+	if h.conversationManager.ownMessage(ev.User, ev.BotID) {
+		return
+	}
+	if !h.conversationManager.QueueIndexing("message "+ev.TimeStamp, func() { h.storeMessage(ev) }) {
+		h.storeMessage(ev)
+	}
+}
+
+func (h *BeeBrainSlackHandler) storeMessage(ev *slackevents.MessageEvent) {
 	// Get user info from Slack API
-	userInfo, err := h.client.GetUserInfo(ev.User)
+	userInfo, err := h.conversationManager.GetUserInfo(ev.User)
 	if err != nil {
 		h.logger.Warnf("Failed to get user info for %s: %v", ev.User, err)
 		userInfo = &slack.User{
@@ -221,8 +462,7 @@ func (h *BeeBrainSlackHandler) handleIncommingMessage(c echo.Context, ev *slacke
 	h.logger.Infof("IncommingMessage - User: %s (%s), Channel: %s, Thread: %s, Text: %s",
 		userInfo.Name, userInfo.ID, ev.Channel, ev.ThreadTimeStamp, ev.Text)
 
-	h.conversationManager.ProcessIncommingMessage(ev.Text, userInfo, ev.Channel)
-	return c.NoContent(http.StatusOK)
+	h.conversationManager.ProcessIncommingMessage(ev.Text, userInfo, ev.Channel, ev.TimeStamp, ev.ThreadTimeStamp)
 }
 
 func (h *BeeBrainSlackHandler) handleUnknownEvent(c echo.Context, ev *slackevents.MessageEvent) error {
@@ -245,14 +485,24 @@ func (h *BeeBrainSlackHandler) handleReactionAdded(c echo.Context, ev *slackeven
 		return c.NoContent(http.StatusOK)
 	}
 
+	if !actionableReaction(ev.Reaction, h.reactions) {
+		h.logger.Debugf("Reaction :%s: is not whitelisted, skipping processing", ev.Reaction)
+		return c.NoContent(http.StatusOK)
+	}
+
 	// Check if this is a reaction to a bot message
 	if ev.ItemUser != h.botUserID {
 		h.logger.Info("Reaction is not on a bot message, skipping processing")
 		return c.NoContent(http.StatusOK)
 	}
 
+	description, ok := h.conversationManager.describeReaction(ev.Reaction)
+	if !ok {
+		return c.NoContent(http.StatusOK)
+	}
+
 	// Process the reaction
-	response, err := h.conversationManager.ProcessReaction(ev.Reaction)
+	response, err := h.conversationManager.respondToReaction(description)
 	if err != nil {
 		h.logger.Error("Failed to process reaction:", err)
 		return c.String(http.StatusOK, "Error processing reaction")
@@ -267,6 +517,76 @@ func (h *BeeBrainSlackHandler) handleReactionAdded(c echo.Context, ev *slackeven
 	return c.NoContent(http.StatusOK)
 }
 
+// actionableReaction reports whether reaction is in whitelist, ignoring any
+// skin tone. Every reaction is actionable when the whitelist is empty.
+func actionableReaction(reaction string, whitelist []string) bool {
+	if len(whitelist) == 0 {
+		return true
+	}
+	reaction, _, _ = strings.Cut(reaction, "::skin-tone-")
+	for _, allowed := range whitelist {
+		if strings.EqualFold(reaction, strings.Trim(allowed, ":")) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleChannelLeft clears cached state for a channel the bot was removed from
+func (h *BeeBrainSlackHandler) handleChannelLeft(c echo.Context, channelID string) error {
+	h.conversationManager.LeaveChannel(channelID)
+	return c.NoContent(http.StatusOK)
+}
+
+// handleMemberJoinedChannel notes the bot being added to a channel and
+// introduces it there when GREET_ON_JOIN is set. Other members joining are
+// left to the channel_join message.
+func (h *BeeBrainSlackHandler) handleMemberJoinedChannel(c echo.Context, ev *slackevents.MemberJoinedChannelEvent) error {
+	if ev.User != h.botUserID {
+		return c.NoContent(http.StatusOK)
+	}
+	h.conversationManager.JoinChannel(ev.Channel)
+
+	if h.joinGreeting == "" || h.isDuplicateEvent("member_joined_channel", ev.Channel+":"+ev.EventTimestamp) {
+		return c.NoContent(http.StatusOK)
+	}
+	greeting := strings.ReplaceAll(h.joinGreeting, "{bot}", "<@"+h.botUserID+">")
+	if err := h.conversationManager.postResponse(ev.Channel, greeting, "", false); err != nil {
+		h.logger.Errorf("Failed to greet channel %s: %v", ev.Channel, err)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// handleLinkShared indexes the pages behind links posted in a channel. They are
+// fetched in the background so Slack gets its response in time.
+func (h *BeeBrainSlackHandler) handleLinkShared(c echo.Context, ev *slackevents.LinkSharedEvent) error {
+	if h.isDuplicateEvent("link_shared", ev.EventTimestamp) {
+		return c.NoContent(http.StatusOK)
+	}
+
+	// Links being typed in the composer haven't been posted yet, their
+	// message_ts is a UUID rather than a Slack timestamp
+	if _, err := strconv.ParseFloat(ev.MessageTimeStamp, 64); err != nil {
+		return c.NoContent(http.StatusOK)
+	}
+
+	for _, link := range ev.Links {
+		link := link.URL
+		index := func() {
+			err := h.conversationManager.IndexLink(link, ev.Channel, ev.User, ev.MessageTimeStamp, ev.ThreadTimeStamp)
+			if errors.Is(err, ErrLinkNotAllowed) {
+				h.logger.Debugf("Not indexing link %s: %v", link, err)
+			} else if err != nil {
+				h.logger.Warnf("Failed to index link %s: %v", link, err)
+			}
+		}
+		if !h.conversationManager.QueueIndexing("link "+link, index) {
+			go index()
+		}
+	}
+	return c.NoContent(http.StatusOK)
+}
+
 // cleanupOldEvents removes events older than 1 hour from the processed events map
 func (h *BeeBrainSlackHandler) cleanupOldEvents() {
 	now := time.Now()
@@ -0,0 +1,51 @@
+package slack
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// historyPageSize is the number of messages asked for per page of channel
+// history, the most Slack recommends
+const historyPageSize = 200
+
+// conversationHistory pages through the messages of channel posted between
+// oldest and latest, newest first, until it has limit of them. A zero oldest
+// or latest leaves that end of the window open, and a limit of 0 fetches the
+// whole window.
+func (m *ConversationManager) conversationHistory(channel string, oldest, latest time.Time, limit int) ([]slack.Message, error) {
+	var messages []slack.Message
+	cursor := ""
+	for {
+		params := &slack.GetConversationHistoryParameters{
+			ChannelID: channel,
+			Cursor:    cursor,
+			Limit:     historyPageSize,
+		}
+		if !oldest.IsZero() {
+			params.Oldest = fmt.Sprintf("%d.000000", oldest.Unix())
+		}
+		if !latest.IsZero() {
+			params.Latest = fmt.Sprintf("%d.000000", latest.Unix())
+		}
+		if limit > 0 {
+			params.Limit = min(historyPageSize, limit-len(messages))
+		}
+		history, err := m.client.GetConversationHistory(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get conversation history: %w", err)
+		}
+		messages = append(messages, history.Messages...)
+
+		cursor = history.ResponseMetaData.NextCursor
+		if !history.HasMore || cursor == "" || (limit > 0 && len(messages) >= limit) {
+			break
+		}
+	}
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, nil
+}
@@ -0,0 +1,68 @@
+package slack
+
+import (
+	"sync"
+
+	"beebrain/internal/vectordb"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// inFlightAnswers tracks the mentions being answered, so a duplicate of a
+// mention arriving before it is answered waits for that answer instead of
+// asking the LLM again
+type inFlightAnswers struct {
+	mu      sync.Mutex
+	answers map[string]*inFlightAnswer
+}
+
+type inFlightAnswer struct {
+	done     chan struct{}
+	response string
+	sources  []vectordb.Message
+	err      error
+}
+
+func newInFlightAnswers() *inFlightAnswers {
+	return &inFlightAnswers{answers: make(map[string]*inFlightAnswer)}
+}
+
+// do answers the mention identified by key with answer, unless it is already
+// being answered. Then it waits for that answer and reports it as shared.
+func (f *inFlightAnswers) do(key string, answer func() (string, []vectordb.Message, error)) (*inFlightAnswer, bool) {
+	f.mu.Lock()
+	if a, ok := f.answers[key]; ok {
+		f.mu.Unlock()
+		<-a.done
+		return a, true
+	}
+	a := &inFlightAnswer{done: make(chan struct{})}
+	f.answers[key] = a
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		delete(f.answers, key)
+		f.mu.Unlock()
+		close(a.done)
+	}()
+	a.response, a.sources, a.err = answer()
+	return a, false
+}
+
+// answerMentionOnce answers a mention like answerMentionWithin. A duplicate of
+// a mention that is being answered, delivered in another event before the
+// first was deduped, gets the same answer and is reported as shared, so that
+// only the first posts it.
+func (h *BeeBrainSlackHandler) answerMentionOnce(ev *slackevents.AppMentionEvent, userInfo *slack.User) (*inFlightAnswer, bool) {
+	answer := func() (string, []vectordb.Message, error) {
+		return h.answerMentionWithin(ev, userInfo)
+	}
+	if h.inFlight == nil {
+		a := &inFlightAnswer{}
+		a.response, a.sources, a.err = answer()
+		return a, false
+	}
+	return h.inFlight.do(ev.Channel+":"+ev.TimeStamp, answer)
+}
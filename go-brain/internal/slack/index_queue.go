@@ -0,0 +1,90 @@
+package slack
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// indexQueueLogInterval is how often the depth of a non-empty index queue is
+// logged
+const indexQueueLogInterval = time.Minute
+
+// indexTask is a unit of background indexing work, such as embedding and
+// storing a message
+type indexTask struct {
+	name string
+	run  func()
+}
+
+// QueueIndexing hands task to the index workers and reports whether it was
+// queued. It is not when the queue is disabled or full, and the caller then
+// runs the task itself.
+func (m *ConversationManager) QueueIndexing(name string, task func()) bool {
+	if m.indexQueue == nil {
+		return false
+	}
+
+	select {
+	case m.indexQueue <- indexTask{name: name, run: task}:
+		if depth := len(m.indexQueue); depth >= cap(m.indexQueue)*4/5 {
+			m.logger.Warnf("Index queue is filling up: %d of %d tasks", depth, cap(m.indexQueue))
+		}
+		return true
+	default:
+		m.logger.Warnf("Index queue is full (%d tasks), indexing %s in place", cap(m.indexQueue), name)
+		return false
+	}
+}
+
+// IndexQueueDepth returns how many indexing tasks are waiting for a worker
+func (m *ConversationManager) IndexQueueDepth() int {
+	return len(m.indexQueue)
+}
+
+// StartIndexQueue runs the index workers until ctx is cancelled. It returns
+// straight away unless INDEX_QUEUE_SIZE is set.
+func (m *ConversationManager) StartIndexQueue(ctx context.Context) {
+	if m.indexQueue == nil {
+		return
+	}
+
+	workers := m.config.indexWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	m.logger.Infof("Starting %d index workers", workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case task := <-m.indexQueue:
+					task.run()
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(indexQueueLogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			if queued := len(m.indexQueue); queued > 0 {
+				m.logger.Warnf("Stopping with %d tasks left in the index queue", queued)
+			}
+			return
+		case <-ticker.C:
+			if depth := len(m.indexQueue); depth > 0 {
+				m.logger.Infof("Index queue depth: %d of %d tasks", depth, cap(m.indexQueue))
+			}
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"beebrain/internal/vectordb"
+
+	"github.com/labstack/echo/v4"
+	"github.com/slack-go/slack"
+)
+
+// Action IDs of the buttons attached to responses. The value of both is the
+// timestamp of the thread the response was posted in.
+const (
+	// ActionSummarizeThread summarizes the thread of the response
+	ActionSummarizeThread = "summarize_thread"
+	// ActionShowSources lists the indexed messages related to the question the
+	// response answered
+	ActionShowSources = "show_sources"
+)
+
+// sectionTextLimit is the most text Slack accepts in a section block
+const sectionTextLimit = 3000
+
+// responseBlocks lays out response as section blocks followed by the action
+// buttons for the thread it is posted in
+func (m *ConversationManager) responseBlocks(response, threadTimestamp string) []slack.Block {
+	blocks := textBlocks(response)
+
+	buttons := []slack.BlockElement{
+		slack.NewButtonBlockElement(ActionSummarizeThread, threadTimestamp, slack.NewTextBlockObject(slack.PlainTextType, "Summarize thread", false, false)),
+	}
+	// Sources only exist when answers are grounded in the index
+	if m.config.ragResults > 0 && m.vectorDB != nil {
+		buttons = append(buttons, slack.NewButtonBlockElement(ActionShowSources, threadTimestamp, slack.NewTextBlockObject(slack.PlainTextType, "Show sources", false, false)))
+	}
+	return append(blocks, slack.NewActionBlock("response_actions", buttons...))
+}
+
+// textBlocks lays out text as sections within Slack's size limit
+func textBlocks(text string) []slack.Block {
+	var blocks []slack.Block
+	for _, chunk := range splitText(text, sectionTextLimit) {
+		blocks = append(blocks, slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, chunk, false, false), nil, nil))
+	}
+	return blocks
+}
+
+// footerBlock is the small print under a response, such as a request to rate
+// it with a reaction
+func footerBlock(footer string) slack.Block {
+	return slack.NewContextBlock("response_footer", slack.NewTextBlockObject(slack.MarkdownType, footer, false, false))
+}
+
+// splitText cuts text into chunks of at most limit bytes, at line breaks when
+// possible
+func splitText(text string, limit int) []string {
+	var chunks []string
+	for len(text) > limit {
+		cut := strings.LastIndex(text[:limit], "\n")
+		if cut <= 0 {
+			cut = limit
+			// Don't split a multi-byte character
+			for cut > 0 && !isRuneStart(text[cut]) {
+				cut--
+			}
+		}
+		chunks = append(chunks, text[:cut])
+		text = strings.TrimPrefix(text[cut:], "\n")
+	}
+	return append(chunks, text)
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// SummarizeThread summarizes the messages of a thread
+func (m *ConversationManager) SummarizeThread(channel, threadTimestamp string) (string, error) {
+	messages, err := m.GetThreadContext(channel, threadTimestamp)
+	if err != nil {
+		return "", err
+	}
+	if len(messages) == 0 {
+		return "", fmt.Errorf("thread %s in channel %s has no messages", threadTimestamp, channel)
+	}
+
+	return m.checkResponse(m.llmClient.Summarize(messages, m.summaryOptions(channel)...))
+}
+
+// ThreadSources lists the indexed messages related to the last question asked
+// in a thread before the message at beforeTimestamp
+func (m *ConversationManager) ThreadSources(channel, threadTimestamp, beforeTimestamp string) (string, error) {
+	replies, _, _, err := m.client.GetConversationReplies(&slack.GetConversationRepliesParameters{
+		ChannelID: channel,
+		Timestamp: threadTimestamp,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get thread messages: %w", err)
+	}
+
+	before := slackTime(beforeTimestamp)
+	var question string
+	for _, msg := range replies {
+		if msg.BotID != "" || msg.SubType == "bot_message" {
+			continue
+		}
+		if !before.IsZero() && !slackTime(msg.Timestamp).Before(before) {
+			break
+		}
+		question = msg.Text
+	}
+	if question == "" {
+		return "", fmt.Errorf("no question found in thread %s", threadTimestamp)
+	}
+
+	sources, _ := m.retrieveSources(question)
+	if len(sources) == 0 {
+		return "I didn't find any related messages for that answer.", nil
+	}
+	return FormatSources(sources, m.citationLinks(sources)), nil
+}
+
+// FormatSources lists sources numbered like their citations, linking each
+// number to its message when there's a permalink
+func FormatSources(sources []vectordb.Message, links []string) string {
+	var list strings.Builder
+	list.WriteString("*Sources*")
+	for i, source := range sources {
+		marker := fmt.Sprintf("[%d]", i+1)
+		if i < len(links) && links[i] != "" {
+			marker = fmt.Sprintf("<%s|%s>", links[i], marker)
+		}
+		list.WriteString(fmt.Sprintf("\n%s <@%s>: %s", marker, source.UserID, source.Text))
+	}
+	return list.String()
+}
+
+// ParseInteraction reads the interaction callback from the form-encoded body
+// Slack posts to the interactivity request URL
+func ParseInteraction(body []byte) (*slack.InteractionCallback, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse interaction body: %w", err)
+	}
+	payload := values.Get("payload")
+	if payload == "" {
+		return nil, fmt.Errorf("interaction body has no payload")
+	}
+
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(payload), &callback); err != nil {
+		return nil, fmt.Errorf("failed to parse interaction payload: %w", err)
+	}
+	return &callback, nil
+}
+
+// HandleInteractions handles the Block Kit interactions of the response buttons
+func (h *BeeBrainSlackHandler) HandleInteractions(c echo.Context) error {
+	body, err := h.readBody(c)
+	if isBodyTooLarge(err) {
+		h.logger.Warnf("Rejected interaction body over %d bytes", h.maxBodyBytes)
+		return c.NoContent(http.StatusRequestEntityTooLarge)
+	}
+	if err != nil {
+		h.logger.Error("Failed to read request body:", err)
+		return c.String(http.StatusOK, "Invalid request")
+	}
+
+	if err := h.verifySignature(c.Request().Header, body); err != nil {
+		h.logger.Warnf("Rejected interaction: %v", err)
+		return c.NoContent(http.StatusUnauthorized)
+	}
+
+	callback, err := ParseInteraction(body)
+	if err != nil {
+		h.logger.Error("Failed to parse interaction:", err)
+		return c.String(http.StatusOK, "Invalid request")
+	}
+	if callback.Type != slack.InteractionTypeBlockActions {
+		h.logger.Debugf("Unhandled interaction type: %s", callback.Type)
+		return c.NoContent(http.StatusOK)
+	}
+
+	// Slack wants an answer within 3 seconds, which a summary can take
+	// longer than, so the actions run after it has been answered
+	for _, action := range callback.ActionCallback.BlockActions {
+		action := action
+		h.conversationManager.background(func(ctx context.Context) {
+			h.handleBlockAction(ctx, callback, action)
+		})
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// verifySignature checks the request was signed with the app's signing secret
+func (h *BeeBrainSlackHandler) verifySignature(header http.Header, body []byte) error {
+	if h.signingSecret == "" {
+		return fmt.Errorf("SLACK_SIGNING_SECRET is not set")
+	}
+	verifier, err := slack.NewSecretsVerifier(header, h.signingSecret)
+	if err != nil {
+		return fmt.Errorf("failed to verify signature: %w", err)
+	}
+	if _, err := verifier.Write(body); err != nil {
+		return fmt.Errorf("failed to verify signature: %w", err)
+	}
+	return verifier.Ensure()
+}
+
+// handleBlockAction runs the follow-up of a clicked response button and
+// posts its result in the thread, without buttons of its own, unless ctx is
+// cancelled by then
+func (h *BeeBrainSlackHandler) handleBlockAction(ctx context.Context, callback *slack.InteractionCallback, action *slack.BlockAction) {
+	channel := callback.Channel.ID
+	threadTimestamp := action.Value
+	h.logger.Infof("Button %s clicked by %s in channel %s", action.ActionID, callback.User.ID, channel)
+
+	var (
+		response string
+		err      error
+	)
+	switch action.ActionID {
+	case ActionSummarizeThread:
+		response, err = h.conversationManager.SummarizeThread(channel, threadTimestamp)
+	case ActionShowSources:
+		response, err = h.conversationManager.ThreadSources(channel, threadTimestamp, callback.Message.Timestamp)
+	default:
+		h.logger.Debugf("Unhandled action: %s", action.ActionID)
+		return
+	}
+	if err != nil {
+		h.logger.Errorf("Failed to handle %s: %v", action.ActionID, err)
+		response = "Sorry, I encountered an error processing your request."
+	}
+	if ctx.Err() != nil {
+		h.logger.Warnf("Not posting the result of %s in channel %s, shutting down", action.ActionID, channel)
+		return
+	}
+
+	if err := h.conversationManager.postResponse(channel, response, threadTimestamp, false); err != nil {
+		h.logger.Error("Failed to post message:", err)
+	}
+}
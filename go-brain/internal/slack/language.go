@@ -0,0 +1,53 @@
+package slack
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// languageDirective matches a trailing request for the language of the
+// answer, such as "(in Spanish)" or "(answer in Brazilian Portuguese)"
+var languageDirective = regexp.MustCompile(`(?i)\s*\((?:answer |reply |respond )?in (\p{L}[\p{L} -]{0,30}\p{L})\)\s*$`)
+
+// knownLanguages are the languages a directive can ask for. The last word of
+// the directive must be one, so "(in Brazilian Portuguese)" is a directive but
+// "(in staging)" or "(in Go)" are part of the question.
+var knownLanguages = map[string]bool{
+	"afrikaans": true, "albanian": true, "amharic": true, "arabic": true, "basque": true,
+	"belarusian": true, "bengali": true, "bosnian": true, "bulgarian": true, "cantonese": true,
+	"catalan": true, "chinese": true, "croatian": true, "czech": true, "danish": true,
+	"dutch": true, "english": true, "esperanto": true, "estonian": true, "farsi": true,
+	"filipino": true, "finnish": true, "french": true, "galician": true, "german": true,
+	"greek": true, "gujarati": true, "hausa": true, "hebrew": true, "hindi": true,
+	"hungarian": true, "icelandic": true, "indonesian": true, "irish": true, "italian": true,
+	"japanese": true, "kannada": true, "korean": true, "latin": true, "latvian": true,
+	"lithuanian": true, "macedonian": true, "malay": true, "malayalam": true, "mandarin": true,
+	"marathi": true, "nepali": true, "norwegian": true, "persian": true, "polish": true,
+	"portuguese": true, "punjabi": true, "romanian": true, "russian": true, "serbian": true,
+	"sinhala": true, "slovak": true, "slovenian": true, "spanish": true, "swahili": true,
+	"swedish": true, "tagalog": true, "tamil": true, "telugu": true, "thai": true,
+	"turkish": true, "ukrainian": true, "urdu": true, "vietnamese": true, "welsh": true,
+	"yoruba": true, "zulu": true,
+}
+
+// parseLanguageDirective splits a trailing language directive off text. It
+// returns text without the directive and the requested language, or text as
+// it is and an empty language when there is no directive.
+func parseLanguageDirective(text string) (string, string) {
+	match := languageDirective.FindStringSubmatchIndex(text)
+	if match == nil {
+		return text, ""
+	}
+	language := text[match[2]:match[3]]
+	words := strings.Fields(strings.ReplaceAll(language, "-", " "))
+	if !knownLanguages[strings.ToLower(words[len(words)-1])] {
+		return text, ""
+	}
+	return strings.TrimSpace(text[:match[0]]), language
+}
+
+// languagePrompt instructs the LLM to answer in language
+func languagePrompt(language string) string {
+	return fmt.Sprintf("Answer in %s, whatever language the question is asked in.", language)
+}
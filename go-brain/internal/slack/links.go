@@ -0,0 +1,289 @@
+package slack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+
+	"golang.org/x/net/html"
+)
+
+// ErrLinkNotAllowed is returned for links outside LINK_DOMAINS or disallowed
+// by the site's robots.txt
+var ErrLinkNotAllowed = errors.New("link is not allowed to be indexed")
+
+// linkUserAgent identifies the bot to the sites it fetches and in robots.txt
+const linkUserAgent = "BeeBrain"
+
+// linkTextLimit caps how much of a page is embedded, in characters
+const linkTextLimit = 4000
+
+// LinkFetcher downloads the content behind a shared link
+type LinkFetcher interface {
+	// Fetch returns the body of the page at url along with its content type
+	Fetch(url string) ([]byte, string, error)
+}
+
+// HTTPFetcher fetches links over HTTP, reading at most maxBytes of a page
+type HTTPFetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+func NewHTTPFetcher(timeout time.Duration, maxBytes int64) *HTTPFetcher {
+	return &HTTPFetcher{
+		client: &http.Client{
+			Timeout: timeout,
+			// Redirects must not lead away from the allowed domain
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return fmt.Errorf("stopped after %d redirects", len(via))
+				}
+				if req.URL.Hostname() != via[0].URL.Hostname() {
+					return fmt.Errorf("redirect to another host %s", req.URL.Hostname())
+				}
+				return nil
+			},
+		},
+		maxBytes: maxBytes,
+	}
+}
+
+func (f *HTTPFetcher) Fetch(link string) ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, link, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", linkUserAgent)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch %s: %w", link, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch %s: status %d", link, resp.StatusCode)
+	}
+
+	// Anything past maxBytes is dropped, the start of a page is what matters
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", link, err)
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// SetLinkFetcher sets the fetcher used to download shared links
+func (m *ConversationManager) SetLinkFetcher(fetcher LinkFetcher) {
+	m.linkFetcher = fetcher
+}
+
+// IndexLink fetches a link shared in a channel and stores its readable text
+// in the vector database, tagged with the link under the source_url metadata
+func (m *ConversationManager) IndexLink(link, channelID, userID, messageTS, threadTS string) error {
+	if m.vectorDB == nil {
+		return fmt.Errorf("vectorDB client is not initialized")
+	}
+
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("unsupported link %s", link)
+	}
+	if !m.linkDomainAllowed(u.Hostname()) {
+		return fmt.Errorf("%w: %s is not in LINK_DOMAINS", ErrLinkNotAllowed, u.Hostname())
+	}
+	if !m.robotsAllow(u) {
+		return fmt.Errorf("%w: disallowed by robots.txt", ErrLinkNotAllowed)
+	}
+
+	body, contentType, err := m.linkFetcher.Fetch(link)
+	if err != nil {
+		return err
+	}
+	text, err := ExtractText(body, contentType)
+	if err != nil {
+		return fmt.Errorf("failed to extract text from %s: %w", link, err)
+	}
+	if text == "" {
+		return fmt.Errorf("no text found at %s", link)
+	}
+	if runes := []rune(text); len(runes) > linkTextLimit {
+		text = string(runes[:linkTextLimit])
+	}
+
+	embedding, err := llm.EmbedDocument(m.embedder, text)
+	if err != nil {
+		return fmt.Errorf("failed to get embedding: %w", err)
+	}
+
+	sharedAt := slackTime(messageTS)
+	if sharedAt.IsZero() {
+		sharedAt = time.Now().UTC()
+	}
+
+	// The ID is derived from the link so sharing it again updates the page
+	msg := vectordb.Message{
+		ID:        messageID(channelID, link),
+		Text:      text,
+		UserID:    userID,
+		ChannelID: channelID,
+		Timestamp: sharedAt.Format(time.RFC3339),
+		ThreadID:  threadTS,
+		MessageTS: messageTS,
+		Metadata:  map[string]string{"source_url": link},
+		Embedding: embedding,
+	}
+	if err := m.vectorDB.StoreMessage(msg); err != nil {
+		return fmt.Errorf("failed to store link: %w", err)
+	}
+
+	m.logger.Infof("Indexed link %s shared in %s", link, channelID)
+	return nil
+}
+
+// linkDomainAllowed reports whether host is one of LINK_DOMAINS or a
+// subdomain of one
+func (m *ConversationManager) linkDomainAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range m.config.linkDomains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// robotsAllow checks the site's robots.txt for u. A site without one can be
+// fetched freely.
+func (m *ConversationManager) robotsAllow(u *url.URL) bool {
+	robotsURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	robots, _, err := m.linkFetcher.Fetch(robotsURL.String())
+	if err != nil {
+		m.logger.Debugf("No robots.txt for %s: %v", u.Host, err)
+		return true
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return robotsAllows(string(robots), path)
+}
+
+// robotsRule is an Allow or Disallow line of a robots.txt
+type robotsRule struct {
+	allow  bool
+	prefix string
+}
+
+// robotsAllows applies the rules of robots for our user agent, or for * when
+// there's no group for it, to path. The longest matching rule wins; wildcards
+// within paths aren't supported.
+func robotsAllows(robots, path string) bool {
+	groups := make(map[string][]robotsRule)
+	var agents []string
+	inRules := false
+	for _, line := range strings.Split(robots, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// A user-agent after rules starts a new group
+			if inRules {
+				agents = nil
+				inRules = false
+			}
+			agents = append(agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			for _, agent := range agents {
+				// An empty Disallow allows everything, but still makes a group
+				if value == "" {
+					groups[agent] = append(groups[agent], robotsRule{allow: true, prefix: "/"})
+					continue
+				}
+				groups[agent] = append(groups[agent], robotsRule{allow: key == "allow", prefix: value})
+			}
+		}
+	}
+
+	rules, ok := groups[strings.ToLower(linkUserAgent)]
+	if !ok {
+		rules = groups["*"]
+	}
+
+	allowed, longest := true, -1
+	for _, rule := range rules {
+		if strings.HasPrefix(path, rule.prefix) && len(rule.prefix) > longest {
+			allowed, longest = rule.allow, len(rule.prefix)
+		}
+	}
+	return allowed
+}
+
+// skippedElements hold no readable text
+var skippedElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+	"svg":      true,
+	"nav":      true,
+	"footer":   true,
+	"iframe":   true,
+}
+
+// ExtractText returns the readable text of an HTML or plain text page with
+// its whitespace collapsed
+func ExtractText(body []byte, contentType string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+
+	switch mediaType {
+	case "text/plain", "text/markdown":
+		return strings.Join(strings.Fields(string(body)), " "), nil
+	case "text/html", "application/xhtml+xml":
+		doc, err := html.Parse(bytes.NewReader(body))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse HTML: %w", err)
+		}
+		var words []string
+		var walk func(n *html.Node)
+		walk = func(n *html.Node) {
+			if n.Type == html.ElementNode && skippedElements[n.Data] {
+				return
+			}
+			if n.Type == html.TextNode {
+				words = append(words, strings.Fields(n.Data)...)
+			}
+			for child := n.FirstChild; child != nil; child = child.NextSibling {
+				walk(child)
+			}
+		}
+		walk(doc)
+		return strings.Join(words, " "), nil
+	default:
+		return "", fmt.Errorf("unsupported content type %s", mediaType)
+	}
+}
@@ -0,0 +1,73 @@
+package slack
+
+import (
+	"regexp"
+	"strings"
+)
+
+// slackMarkup matches the <...> sequences Slack encodes mentions, channel
+// links, broadcasts and URLs as
+var slackMarkup = regexp.MustCompile(`<([@#!]?)([^<>|\s]+)(?:\|([^<>]*))?>`)
+
+// slackEntities are the characters Slack escapes in message text
+var slackEntities = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&")
+
+// indexedText returns the text a message is embedded and stored with, and
+// its raw form when normalizing the markup changed it
+func (m *ConversationManager) indexedText(text string) (string, string) {
+	if !m.config.normalizeMarkup {
+		return text, ""
+	}
+	if normalized := m.NormalizeMarkup(text); normalized != text {
+		return normalized, text
+	}
+	return text, ""
+}
+
+// NormalizeMarkup replaces Slack markup in text with the readable form people
+// see: <@U123> becomes @name, <#C123|general> becomes #general, <!here>
+// becomes @here, <https://...|label> becomes label and escaped characters
+// such as &lt; are unescaped. Users are resolved through the user cache and
+// left as their ID when they can't be.
+func (m *ConversationManager) NormalizeMarkup(text string) string {
+	if !strings.ContainsAny(text, "<&") {
+		return text
+	}
+
+	text = slackMarkup.ReplaceAllStringFunc(text, func(markup string) string {
+		parts := slackMarkup.FindStringSubmatch(markup)
+		kind, target, label := parts[1], parts[2], parts[3]
+
+		switch kind {
+		case "@":
+			if label != "" {
+				return "@" + strings.TrimPrefix(label, "@")
+			}
+			if user, err := m.GetUserInfo(target); err == nil && user.Name != "" {
+				return "@" + user.Name
+			}
+			return "@" + target
+		case "#":
+			if label != "" {
+				return "#" + label
+			}
+			return "#" + target
+		case "!":
+			// <!subteam^S123|@team> carries its own label, <!here> doesn't
+			if label != "" {
+				return "@" + strings.TrimPrefix(label, "@")
+			}
+			return "@" + target
+		default:
+			// Only URLs are markup, anything else is left alone
+			if !strings.Contains(target, ":") {
+				return markup
+			}
+			if label != "" {
+				return label
+			}
+			return strings.TrimPrefix(target, "mailto:")
+		}
+	})
+	return slackEntities.Replace(text)
+}
@@ -0,0 +1,157 @@
+package slack
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"beebrain/internal/llm"
+
+	"github.com/slack-go/slack"
+)
+
+// minMentionNameLength keeps short names like "Al" or "me" from matching
+// ordinary words
+const minMentionNameLength = 3
+
+// protectedMarkup matches code and existing Slack markup, which names are
+// never replaced in
+var protectedMarkup = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`|<[^>\n]*>")
+
+// ResolveMentions turns the first occurrence of each name in text into a
+// mention of the user ID names maps it to, mentioning at most max users in
+// the order they appear. Names only match as whole words, case-insensitively,
+// and never inside code or existing markup. Longer names are matched first so
+// "Ana Lopez" wins over "Ana".
+func ResolveMentions(text string, names map[string]string, max int) string {
+	if max <= 0 || len(names) == 0 {
+		return text
+	}
+
+	ordered := make([]string, 0, len(names))
+	for name := range names {
+		if len([]rune(name)) >= minMentionNameLength {
+			ordered = append(ordered, name)
+		}
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if len(ordered[i]) != len(ordered[j]) {
+			return len(ordered[i]) > len(ordered[j])
+		}
+		return ordered[i] < ordered[j]
+	})
+
+	type mention struct {
+		start, end int
+		userID     string
+	}
+	var found []mention
+	for _, name := range ordered {
+		pattern := regexp.MustCompile(`(?i)@?\b` + regexp.QuoteMeta(name) + `\b`)
+		loc := firstUnprotected(text, pattern)
+		if loc == nil {
+			continue
+		}
+		overlaps := false
+		for _, other := range found {
+			if loc[0] < other.end && other.start < loc[1] {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			found = append(found, mention{start: loc[0], end: loc[1], userID: names[name]})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].start < found[j].start
+	})
+
+	mentioned := make(map[string]bool)
+	var b strings.Builder
+	last := 0
+	for _, m := range found {
+		if mentioned[m.userID] {
+			continue
+		}
+		if len(mentioned) >= max {
+			break
+		}
+		mentioned[m.userID] = true
+		b.WriteString(text[last:m.start])
+		b.WriteString(fmt.Sprintf("<@%s>", m.userID))
+		last = m.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// firstUnprotected returns the location of the first match of pattern outside
+// code and markup, or nil when there is none
+func firstUnprotected(text string, pattern *regexp.Regexp) []int {
+	start := 0
+	protected := append(protectedMarkup.FindAllStringIndex(text, -1), []int{len(text), len(text)})
+	for _, span := range protected {
+		if loc := pattern.FindStringIndex(text[start:span[0]]); loc != nil {
+			return []int{start + loc[0], start + loc[1]}
+		}
+		start = span[1]
+	}
+	return nil
+}
+
+// mentionNames maps the names of the people in a conversation to their user
+// IDs. Only people who took part can be mentioned, the bot is left out and
+// names shared by several people are dropped as ambiguous.
+func (m *ConversationManager) mentionNames(threadMessages []llm.Message, asker *slack.User) map[string]string {
+	users := make(map[string]*slack.User)
+	if asker != nil && asker.ID != "" {
+		users[asker.ID] = asker
+	}
+	for _, msg := range threadMessages {
+		if msg.Role != "user" || msg.User == nil || msg.User.SlackID == "" || msg.User.SlackID == m.botUserID {
+			continue
+		}
+		if _, ok := users[msg.User.SlackID]; ok {
+			continue
+		}
+		user, err := m.GetUserInfo(msg.User.SlackID)
+		if err != nil {
+			m.logger.Debugf("Not mentioning user %s: %v", msg.User.SlackID, err)
+			continue
+		}
+		users[msg.User.SlackID] = user
+	}
+
+	names := make(map[string]string)
+	ambiguous := make(map[string]bool)
+	for id, user := range users {
+		if user.IsBot {
+			continue
+		}
+		for _, name := range []string{user.Profile.DisplayName, user.RealName, user.Name} {
+			name = strings.TrimSpace(name)
+			key := strings.ToLower(name)
+			if name == "" || ambiguous[key] {
+				continue
+			}
+			if other, ok := names[key]; ok && other != id {
+				ambiguous[key] = true
+				delete(names, key)
+				continue
+			}
+			names[key] = id
+		}
+	}
+	return names
+}
+
+// resolveMentions mentions the people a response names when MENTION_RESOLVE
+// is enabled
+func (m *ConversationManager) resolveMentions(response string, threadMessages []llm.Message, asker *slack.User) string {
+	if !m.config.mentionResolve {
+		return response
+	}
+	return ResolveMentions(response, m.mentionNames(threadMessages, asker), m.config.mentionMax)
+}
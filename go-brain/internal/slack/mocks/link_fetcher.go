@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLinkFetcher is a mock implementation of LinkFetcher
+type MockLinkFetcher struct {
+	mock.Mock
+}
+
+func (m *MockLinkFetcher) Fetch(url string) ([]byte, string, error) {
+	args := m.Called(url)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).([]byte), args.String(1), args.Error(2)
+}
@@ -0,0 +1,20 @@
+package mocks
+
+import (
+	"beebrain/internal/vectordb"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockReranker is a mock implementation of Reranker
+type MockReranker struct {
+	mock.Mock
+}
+
+func (m *MockReranker) Rerank(query string, candidates []vectordb.Message) ([]vectordb.Message, error) {
+	args := m.Called(query, candidates)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]vectordb.Message), args.Error(1)
+}
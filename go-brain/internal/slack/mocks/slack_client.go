@@ -10,6 +10,14 @@ type MockSlackClient struct {
 	mock.Mock
 }
 
+func (m *MockSlackClient) AuthTest() (*slack.AuthTestResponse, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*slack.AuthTestResponse), args.Error(1)
+}
+
 func (m *MockSlackClient) GetConversationHistory(params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
 	args := m.Called(params)
 	if args.Get(0) == nil {
@@ -27,3 +35,60 @@ func (m *MockSlackClient) PostMessage(channelID string, options ...slack.MsgOpti
 	args := m.Called(channelID, options)
 	return args.String(0), args.String(1), args.Error(2)
 }
+
+func (m *MockSlackClient) UpdateMessage(channelID, timestamp string, options ...slack.MsgOption) (string, string, string, error) {
+	args := m.Called(channelID, timestamp, options)
+	return args.String(0), args.String(1), args.String(2), args.Error(3)
+}
+
+func (m *MockSlackClient) PostEphemeral(channelID, userID string, options ...slack.MsgOption) (string, error) {
+	args := m.Called(channelID, userID, options)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSlackClient) GetUserInfo(userID string) (*slack.User, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*slack.User), args.Error(1)
+}
+
+func (m *MockSlackClient) GetConversationInfo(input *slack.GetConversationInfoInput) (*slack.Channel, error) {
+	args := m.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*slack.Channel), args.Error(1)
+}
+
+func (m *MockSlackClient) GetEmoji() (map[string]string, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]string), args.Error(1)
+}
+
+func (m *MockSlackClient) AddReaction(name string, item slack.ItemRef) error {
+	args := m.Called(name, item)
+	return args.Error(0)
+}
+
+func (m *MockSlackClient) RemoveReaction(name string, item slack.ItemRef) error {
+	args := m.Called(name, item)
+	return args.Error(0)
+}
+
+func (m *MockSlackClient) GetPermalink(params *slack.PermalinkParameters) (string, error) {
+	args := m.Called(params)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockSlackClient) UploadFile(params slack.FileUploadParameters) (*slack.File, error) {
+	args := m.Called(params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*slack.File), args.Error(1)
+}
@@ -0,0 +1,93 @@
+package slack
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+
+	"beebrain/internal/config"
+	"beebrain/internal/llm"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultOutputFilterMask = "[REDACTED]"
+
+// OutputFilter masks matches of its patterns in responses before they are
+// posted
+type OutputFilter struct {
+	Patterns []*regexp.Regexp
+	Mask     string
+}
+
+// loadOutputFilter returns the configured filter, or nil when no words,
+// patterns or PII masking are configured. OUTPUT_FILTER_WORDS are matched as
+// whole words regardless of case, OUTPUT_FILTER_PATTERNS_FILE holds one
+// regular expression per line, and OUTPUT_FILTER_PII masks emails, tokens and
+// API keys.
+func loadOutputFilter(logger *logrus.Logger) *OutputFilter {
+	var patterns []*regexp.Regexp
+	if config.Bool(logger, "OUTPUT_FILTER_PII", false) {
+		patterns = append(patterns, llm.SensitivePatterns()...)
+	}
+	if words := config.List("OUTPUT_FILTER_WORDS"); len(words) > 0 {
+		quoted := make([]string, 0, len(words))
+		for _, word := range words {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+		patterns = append(patterns, regexp.MustCompile(`(?i)\b(?:`+strings.Join(quoted, "|")+`)\b`))
+	}
+	if path := os.Getenv("OUTPUT_FILTER_PATTERNS_FILE"); path != "" {
+		patterns = append(patterns, loadPatternsFile(logger, path)...)
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	return &OutputFilter{
+		Patterns: patterns,
+		Mask:     config.String("OUTPUT_FILTER_MASK", defaultOutputFilterMask),
+	}
+}
+
+// loadPatternsFile compiles the regular expression on each line of a file,
+// skipping blank lines, # comments and invalid patterns
+func loadPatternsFile(logger *logrus.Logger, path string) []*regexp.Regexp {
+	file, err := os.Open(path)
+	if err != nil {
+		logger.Warnf("Failed to read output filter patterns, filtering without them: %v", err)
+		return nil
+	}
+	defer file.Close()
+
+	var patterns []*regexp.Regexp
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, err := regexp.Compile(line)
+		if err != nil {
+			logger.Warnf("Skipping invalid output filter pattern '%s': %v", line, err)
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Warnf("Failed to read output filter patterns from %s: %v", path, err)
+	}
+	return patterns
+}
+
+// Apply replaces every match of the filter's patterns in response with the
+// mask. Responses without matches are returned unchanged.
+func (f *OutputFilter) Apply(response string) string {
+	if f == nil {
+		return response
+	}
+	for _, pattern := range f.Patterns {
+		response = pattern.ReplaceAllLiteralString(response, f.Mask)
+	}
+	return response
+}
@@ -0,0 +1,44 @@
+package slack
+
+import (
+	"errors"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+
+	"github.com/slack-go/slack"
+)
+
+// recoverOverflow answers a question whose prompt overflowed the model's
+// context window. The older thread messages are summarized to make room and
+// the question asked again; when that isn't possible or configured, the
+// answer to the truncated prompt is used.
+func (m *ConversationManager) recoverOverflow(channel string, err error, threadMessages []llm.Message, sources []vectordb.Message, text string, userInfo *slack.User) (string, error) {
+	var overflow *llm.ContextOverflowError
+	if !errors.As(err, &overflow) {
+		return "", err
+	}
+
+	keep := m.config.overflowKeep
+	if keep < 0 {
+		keep = 0
+	}
+	if !m.config.overflowSummarize || len(threadMessages) <= keep {
+		return overflow.Response, nil
+	}
+
+	older, recent := threadMessages[:len(threadMessages)-keep], threadMessages[len(threadMessages)-keep:]
+	summary, err := m.llmClient.Summarize(older, m.summaryOptions(channel)...)
+	if err != nil {
+		m.logger.Warnf("Failed to summarize context after overflow: %v", err)
+		return overflow.Response, nil
+	}
+
+	m.logger.Infof("Retrying with %d older thread messages summarized", len(older))
+	compacted := append([]llm.Message{{Role: "system", Content: "Summary of the earlier conversation:\n" + summary, Label: m.config.contextLabel}}, recent...)
+	response, err := m.getLLMResponse(channel, m.buildPrompt(channel, compacted, sources, text, userInfo))
+	if errors.As(err, &overflow) {
+		return overflow.Response, nil
+	}
+	return response, err
+}
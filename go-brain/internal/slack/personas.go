@@ -0,0 +1,74 @@
+package slack
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"beebrain/internal/config"
+	"beebrain/internal/llm"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultPersonaMaxTokens = 1000
+
+// loadChannelPersonas reads the persona of each channel from the file
+// CHANNEL_PERSONAS maps it to. Channels whose file can't be read or is empty
+// keep the default persona.
+func loadChannelPersonas(logger *logrus.Logger, files map[string]string) map[string]string {
+	personas := make(map[string]string, len(files))
+	for channel, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			logger.Warnf("Failed to read persona of channel %s, using the default persona: %v", channel, err)
+			continue
+		}
+		persona := strings.TrimSpace(string(content))
+		if persona == "" {
+			logger.Warnf("Persona file %s of channel %s is empty, using the default persona", path, channel)
+			continue
+		}
+		personas[channel] = persona
+	}
+	return personas
+}
+
+// CheckPersonaSizes estimates the tokens of the default persona and of the
+// channel personas of CHANNEL_PERSONAS, one of which is sent with every
+// answer, against PERSONA_MAX_TOKENS. Oversized personas are warned about,
+// or returned as an error when PERSONA_MAX_TOKENS_FAIL is set. A maximum of
+// 0 disables the check.
+func CheckPersonaSizes(logger *logrus.Logger) error {
+	maxTokens := config.Int(logger, "PERSONA_MAX_TOKENS", defaultPersonaMaxTokens)
+	if maxTokens <= 0 {
+		return nil
+	}
+
+	// Unreadable files are warned about when the personas are loaded
+	personas := map[string]string{"default persona": llm.DefaultPersona}
+	for channel, path := range config.Map(logger, "CHANNEL_PERSONAS") {
+		if content, err := os.ReadFile(path); err == nil {
+			personas[fmt.Sprintf("persona %s of channel %s", path, channel)] = string(content)
+		}
+	}
+
+	var oversized []string
+	for name, persona := range personas {
+		if tokens := llm.EstimateTokens(strings.TrimSpace(persona)); tokens > maxTokens {
+			oversized = append(oversized, fmt.Sprintf("%s (about %d tokens)", name, tokens))
+		}
+	}
+	if len(oversized) == 0 {
+		return nil
+	}
+	sort.Strings(oversized)
+
+	err := fmt.Errorf("personas over PERSONA_MAX_TOKENS=%d take up context needed for the conversation: %s", maxTokens, strings.Join(oversized, ", "))
+	if config.Bool(logger, "PERSONA_MAX_TOKENS_FAIL", false) {
+		return err
+	}
+	logger.Warn(err)
+	return nil
+}
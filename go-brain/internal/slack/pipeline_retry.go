@@ -0,0 +1,104 @@
+package slack
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// errMentionTimeout is returned when answering a mention took longer than
+// MENTION_TIMEOUT
+var errMentionTimeout = errors.New("answering the mention took too long")
+
+const mentionTimeoutResponse = "Sorry, this is taking too long. Please try again in a bit."
+
+// answerMentionWithin answers a mention like answerMention, giving up with
+// errMentionTimeout once mentionTimeout has passed, when it is set. An answer
+// that arrives after that is dropped.
+func (h *BeeBrainSlackHandler) answerMentionWithin(ev *slackevents.AppMentionEvent, userInfo *slack.User) (string, []vectordb.Message, error) {
+	if h.mentionTimeout <= 0 {
+		return h.answerMention(ev, userInfo)
+	}
+
+	type answer struct {
+		response string
+		sources  []vectordb.Message
+		err      error
+	}
+	done := make(chan answer, 1)
+	go func() {
+		response, sources, err := h.answerMention(ev, userInfo)
+		done <- answer{response: response, sources: sources, err: err}
+	}()
+
+	timer := time.NewTimer(h.mentionTimeout)
+	defer timer.Stop()
+	select {
+	case a := <-done:
+		return a.response, a.sources, a.err
+	case <-timer.C:
+		return "", nil, fmt.Errorf("%w, gave up after %s", errMentionTimeout, h.mentionTimeout)
+	}
+}
+
+// answerMention gets the thread context of a mention and the answer to it,
+// retrying both up to PIPELINE_RETRIES times with a backoff doubling from
+// PIPELINE_RETRY_BACKOFF. Nothing is posted until an answer is ready, so a
+// retry can't leave a half-finished reply behind.
+func (h *BeeBrainSlackHandler) answerMention(ev *slackevents.AppMentionEvent, userInfo *slack.User) (string, []vectordb.Message, error) {
+	wait := h.pipelineBackoff
+	for attempt := 0; ; attempt++ {
+		retryable := attempt < h.pipelineRetries
+		response, sources, err := h.tryAnswerMention(ev, userInfo, retryable)
+		// A busy LLM gets a busy response rather than a longer wait
+		if err == nil || errors.Is(err, ErrEmptyResponse) || errors.Is(err, llm.ErrBusy) || !retryable {
+			return response, sources, err
+		}
+
+		h.logger.Warnf("Failed to answer mention, retrying in %s (attempt %d/%d): %v", wait, attempt+1, h.pipelineRetries, err)
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// tryAnswerMention makes one attempt at answering a mention. A failure to get
+// the thread context fails the attempt while retries remain, after that the
+// mention is answered without it.
+func (h *BeeBrainSlackHandler) tryAnswerMention(ev *slackevents.AppMentionEvent, userInfo *slack.User, retryable bool) (string, []vectordb.Message, error) {
+	threadMessages, err := h.conversationManager.GetThreadContext(ev.Channel, ev.ThreadTimeStamp)
+	if err != nil {
+		if retryable {
+			return "", nil, fmt.Errorf("failed to get thread context: %w", err)
+		}
+		h.logger.Error("Failed to get thread context:", err)
+	}
+
+	if isActionItemsRequest(ev.Text) {
+		response, err := h.conversationManager.ProcessActionItems(threadMessages)
+		return response, nil, err
+	}
+	return h.conversationManager.ProcessMessageWithSources(ev.Channel, threadMessages, ev.Text, userInfo)
+}
+
+// postAnswer posts the answer to the mention with timestamp mentionTS,
+// retrying a failed post like the rest of the pipeline. Only the post is
+// retried, with the same answer. The answer is posted once per mention, so
+// answering the same mention again updates the earlier answer.
+func (h *BeeBrainSlackHandler) postAnswer(channel, response, threadTimestamp, mentionTS string) error {
+	wait := h.pipelineBackoff
+	err := h.conversationManager.PostResponseOnce(channel, response, threadTimestamp, mentionTS)
+	for attempt := 1; err != nil && attempt <= h.pipelineRetries; attempt++ {
+		h.logger.Warnf("Failed to post answer, retrying in %s (attempt %d/%d): %v", wait, attempt, h.pipelineRetries, err)
+		time.Sleep(wait)
+		wait *= 2
+
+		err = h.conversationManager.PostResponseOnce(channel, response, threadTimestamp, mentionTS)
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// transientSlackErrors are the Slack API errors worth retrying a post on,
+// every other error the API returns would fail the same way again
+var transientSlackErrors = map[string]bool{
+	"internal_error":      true,
+	"fatal_error":         true,
+	"service_unavailable": true,
+	"request_timeout":     true,
+	"ratelimited":         true,
+}
+
+// failedPost is a response waiting in the post retry queue with the error of
+// its last attempt
+type failedPost struct {
+	channel         string
+	threadTimestamp string
+	requestID       string
+	opts            []slack.MsgOption
+	err             error
+}
+
+// retryablePost reports whether a post that failed with err may succeed
+// later, as it does after an outage but not when Slack refused the message
+func retryablePost(err error) bool {
+	var apiErr slack.SlackErrorResponse
+	if errors.As(err, &apiErr) {
+		return transientSlackErrors[apiErr.Err]
+	}
+	return true
+}
+
+// queuePost hands a failed post to the retry worker and reports whether it
+// did. When the queue is full the oldest post waiting is dropped to make room.
+func (m *ConversationManager) queuePost(post failedPost) bool {
+	if m.postQueue == nil || !retryablePost(post.err) {
+		return false
+	}
+
+	for {
+		select {
+		case m.postQueue <- post:
+			m.logger.Warnf("Failed to post message to channel %s, queued it for retry: %v", post.channel, post.err)
+			return true
+		default:
+		}
+
+		select {
+		case dropped := <-m.postQueue:
+			m.logger.Warnf("Post retry queue is full, dropping the oldest message waiting for channel %s", dropped.channel)
+		default:
+		}
+	}
+}
+
+// retryPost retries a failed post up to POST_RETRIES times, doubling the wait
+// between attempts from POST_RETRY_BACKOFF. It gives up early when ctx is
+// cancelled or Slack refuses the message, and returns the last error.
+func (m *ConversationManager) retryPost(ctx context.Context, post failedPost) error {
+	err := post.err
+	wait := m.config.postRetryBackoff
+	for attempt := 1; attempt <= m.config.postRetries && retryablePost(err); attempt++ {
+		m.logger.Warnf("Retrying post to channel %s in %s (attempt %d/%d): %v", post.channel, wait, attempt, m.config.postRetries, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+
+		if _, err = m.deliver(post.channel, post.threadTimestamp, post.requestID, post.opts); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// StartPostQueue retries the responses that failed to post until ctx is
+// cancelled. It returns straight away unless POST_RETRY_QUEUE_SIZE is set.
+func (m *ConversationManager) StartPostQueue(ctx context.Context) {
+	if m.postQueue == nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if queued := len(m.postQueue); queued > 0 {
+				m.logger.Warnf("Stopping with %d messages left in the post retry queue", queued)
+			}
+			return
+		case post := <-m.postQueue:
+			if err := m.retryPost(ctx, post); err != nil {
+				m.logger.Errorf("Dropping message for channel %s after retrying to post it: %v", post.channel, err)
+				continue
+			}
+			m.logger.Infof("Posted queued message to channel %s", post.channel)
+		}
+	}
+}
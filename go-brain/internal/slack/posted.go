@@ -0,0 +1,119 @@
+package slack
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// postedResponseTTL is how long a posted response is remembered for retries
+// of the request that posted it
+const postedResponseTTL = time.Hour
+
+// postKey identifies the response to one request, such as a mention, in a
+// channel or thread
+type postKey struct {
+	channel   string
+	thread    string
+	requestID string
+}
+
+type postedResponse struct {
+	timestamp string
+	postedAt  time.Time
+}
+
+// responseLog remembers the timestamps of posted responses by request, so a
+// retried request updates its response instead of posting it again
+type responseLog struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[postKey]postedResponse
+}
+
+func newResponseLog(ttl time.Duration) *responseLog {
+	return &responseLog{
+		ttl:     ttl,
+		entries: make(map[postKey]postedResponse),
+	}
+}
+
+func (l *responseLog) get(key postKey) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[key]
+	if !ok || time.Since(entry.postedAt) > l.ttl {
+		return "", false
+	}
+	return entry.timestamp, true
+}
+
+// record remembers a posted response, forgetting the expired ones
+func (l *responseLog) record(key postKey, timestamp string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for k, entry := range l.entries {
+		if now.Sub(entry.postedAt) > l.ttl {
+			delete(l.entries, k)
+		}
+	}
+	l.entries[key] = postedResponse{timestamp: timestamp, postedAt: now}
+}
+
+// PostResponseOnce posts response like PostResponse, once per requestID in a
+// channel or thread. When the request already posted a response, a retry
+// updates that message instead of posting a second one, and a snippet already
+// uploaded is left as it is. The response is indexed when INDEX_RESPONSES is
+// set.
+func (m *ConversationManager) PostResponseOnce(channel, response, threadTimestamp, requestID string) error {
+	timestamp, err := m.sendResponse(channel, response, threadTimestamp, requestID, true)
+	if err != nil {
+		return err
+	}
+	m.indexResponse(channel, response, threadTimestamp, timestamp)
+	return nil
+}
+
+// postedBefore reports whether a response to requestID was already posted or
+// uploaded in a channel or thread
+func (m *ConversationManager) postedBefore(channel, threadTimestamp, requestID string) bool {
+	if requestID == "" {
+		return false
+	}
+	_, ok := m.postedResponses.get(postKey{channel: channel, thread: threadTimestamp, requestID: requestID})
+	return ok
+}
+
+// deliver posts a response message, or updates the one posted earlier for
+// requestID, and returns its timestamp
+func (m *ConversationManager) deliver(channel, threadTimestamp, requestID string, opts []slack.MsgOption) (string, error) {
+	key := postKey{channel: channel, thread: threadTimestamp, requestID: requestID}
+	if requestID != "" {
+		if timestamp, ok := m.postedResponses.get(key); ok {
+			if timestamp == "" {
+				m.logger.Infof("The response to request %s in channel %s was uploaded as a snippet, leaving it", requestID, channel)
+				return "", nil
+			}
+			m.logger.Infof("Updating the response already posted for request %s in channel %s", requestID, channel)
+			if _, _, _, err := m.client.UpdateMessage(channel, timestamp, opts...); err != nil {
+				return "", fmt.Errorf("failed to update response %s: %w", timestamp, err)
+			}
+			return timestamp, nil
+		}
+	}
+
+	if threadTimestamp != "" {
+		opts = append(opts, slack.MsgOptionTS(threadTimestamp))
+	}
+	_, timestamp, err := m.client.PostMessage(channel, opts...)
+	if err != nil {
+		return "", err
+	}
+	if requestID != "" {
+		m.postedResponses.record(key, timestamp)
+	}
+	return timestamp, nil
+}
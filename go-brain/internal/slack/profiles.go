@@ -0,0 +1,188 @@
+package slack
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"beebrain/internal/llm"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	// maxProfileTopics bounds the keywords counted per user, the ones seen
+	// only once are forgotten past it
+	maxProfileTopics = 100
+	// profileTopics is how many recurring topics go into a prompt
+	profileTopics = 5
+)
+
+// profileMarkup matches mentions and links, which aren't topics
+var profileMarkup = regexp.MustCompile(`<[^<>]*>`)
+
+// profileStopWords are common words that say nothing about a topic
+var profileStopWords = map[string]bool{
+	"about": true, "after": true, "again": true, "also": true, "been": true, "could": true,
+	"does": true, "doing": true, "from": true, "have": true, "here": true, "into": true,
+	"just": true, "know": true, "like": true, "make": true, "more": true, "need": true,
+	"please": true, "should": true, "some": true, "that": true, "their": true, "them": true,
+	"then": true, "there": true, "these": true, "they": true, "thing": true, "think": true,
+	"this": true, "want": true, "were": true, "what": true, "when": true, "where": true,
+	"which": true, "while": true, "will": true, "with": true, "would": true, "your": true,
+}
+
+// UserProfile is what the bot has learned about a user, injected into the
+// prompts answering them
+type UserProfile struct {
+	UserID        string
+	PreferredName string
+	Role          string
+	// Topics counts the keywords of the user's questions
+	Topics    map[string]int
+	UpdatedAt time.Time
+}
+
+// RecurringTopics returns up to n keywords the user asked about more than
+// once, most frequent first
+func (p UserProfile) RecurringTopics(n int) []string {
+	topics := make([]string, 0, len(p.Topics))
+	for topic, count := range p.Topics {
+		if count > 1 {
+			topics = append(topics, topic)
+		}
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		if p.Topics[topics[i]] != p.Topics[topics[j]] {
+			return p.Topics[topics[i]] > p.Topics[topics[j]]
+		}
+		return topics[i] < topics[j]
+	})
+	if len(topics) > n {
+		topics = topics[:n]
+	}
+	return topics
+}
+
+// profileStore keeps user profiles in memory, keyed by user ID
+type profileStore struct {
+	mu       sync.Mutex
+	profiles map[string]*UserProfile
+}
+
+func newProfileStore() *profileStore {
+	return &profileStore{profiles: make(map[string]*UserProfile)}
+}
+
+// GetUserProfile returns the profile of userID, false when there's none or
+// profiles are disabled
+func (m *ConversationManager) GetUserProfile(userID string) (UserProfile, bool) {
+	if m.profiles == nil {
+		return UserProfile{}, false
+	}
+
+	m.profiles.mu.Lock()
+	defer m.profiles.mu.Unlock()
+	profile, ok := m.profiles.profiles[userID]
+	if !ok {
+		return UserProfile{}, false
+	}
+	return profile.copy(), true
+}
+
+// UpdateUserProfile refreshes the profile of user with their Slack profile and
+// the keywords of a question they asked, and returns the updated profile
+func (m *ConversationManager) UpdateUserProfile(user *slack.User, question string) UserProfile {
+	if m.profiles == nil || user == nil || user.ID == "" {
+		return UserProfile{}
+	}
+
+	m.profiles.mu.Lock()
+	defer m.profiles.mu.Unlock()
+
+	profile, ok := m.profiles.profiles[user.ID]
+	if !ok {
+		profile = &UserProfile{UserID: user.ID, Topics: make(map[string]int)}
+		m.profiles.profiles[user.ID] = profile
+	}
+
+	if name := preferredName(user); name != "" {
+		profile.PreferredName = name
+	}
+	if user.Profile.Title != "" {
+		profile.Role = user.Profile.Title
+	}
+	for _, keyword := range questionKeywords(question) {
+		profile.Topics[keyword]++
+	}
+	if len(profile.Topics) > maxProfileTopics {
+		for topic, count := range profile.Topics {
+			if count == 1 {
+				delete(profile.Topics, topic)
+			}
+		}
+	}
+	profile.UpdatedAt = time.Now()
+	return profile.copy()
+}
+
+func (p *UserProfile) copy() UserProfile {
+	c := *p
+	c.Topics = make(map[string]int, len(p.Topics))
+	for topic, count := range p.Topics {
+		c.Topics[topic] = count
+	}
+	return c
+}
+
+func preferredName(user *slack.User) string {
+	for _, name := range []string{user.Profile.DisplayName, user.Profile.RealName, user.RealName} {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// questionKeywords returns the distinct words of a question that could be a
+// topic
+func questionKeywords(question string) []string {
+	words := strings.FieldsFunc(strings.ToLower(profileMarkup.ReplaceAllString(question, " ")), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+
+	seen := make(map[string]bool, len(words))
+	keywords := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.Trim(word, "-")
+		if len([]rune(word)) < 4 || profileStopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+	}
+	return keywords
+}
+
+// profileMessage describes the asking user to the LLM, false when nothing is
+// known about them
+func profileMessage(profile UserProfile) (llm.Message, bool) {
+	var details []string
+	if profile.PreferredName != "" {
+		details = append(details, fmt.Sprintf("They prefer to be called %s.", profile.PreferredName))
+	}
+	if profile.Role != "" {
+		details = append(details, fmt.Sprintf("Their role is %s.", profile.Role))
+	}
+	if topics := profile.RecurringTopics(profileTopics); len(topics) > 0 {
+		details = append(details, fmt.Sprintf("They often ask about %s.", strings.Join(topics, ", ")))
+	}
+	if len(details) == 0 {
+		return llm.Message{}, false
+	}
+	return llm.Message{Role: "system", Content: "About the person asking: " + strings.Join(details, " ")}, true
+}
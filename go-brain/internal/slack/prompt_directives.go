@@ -0,0 +1,43 @@
+package slack
+
+import (
+	"regexp"
+	"strings"
+)
+
+// promptDirectiveBlock matches a leading block of directives for the answer,
+// such as "[answer concisely; use bullet points]", after any mentions of the
+// bot the message starts with
+var promptDirectiveBlock = regexp.MustCompile(`^((?:\s*<@\w+>)*)\s*\[([^\[\]]{1,300})\]\s*`)
+
+// parsePromptDirectives splits a leading directive block off text. It returns
+// text without the block and the directives in it, separated by semicolons,
+// or text as it is and no directives when there is no block or nothing
+// follows it.
+func parsePromptDirectives(text string) (string, []string) {
+	match := promptDirectiveBlock.FindStringSubmatchIndex(text)
+	if match == nil {
+		return text, nil
+	}
+	rest := strings.TrimSpace(text[match[1]:])
+	if rest == "" {
+		return text, nil
+	}
+
+	var directives []string
+	for _, directive := range strings.Split(text[match[4]:match[5]], ";") {
+		if directive = strings.TrimSpace(directive); directive != "" {
+			directives = append(directives, directive)
+		}
+	}
+	if len(directives) == 0 {
+		return text, nil
+	}
+	return strings.TrimSpace(text[match[2]:match[3]] + " " + rest), directives
+}
+
+// promptDirectivesPrompt instructs the LLM to follow directives for this
+// answer only
+func promptDirectivesPrompt(directives []string) string {
+	return "For this answer only, follow these instructions from the user:\n- " + strings.Join(directives, "\n- ")
+}
@@ -0,0 +1,100 @@
+package slack
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"beebrain/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// QuietHours is a daily window during which proactive posts such as digests
+// are held back. Interactive replies to mentions are not affected.
+type QuietHours struct {
+	// Start and End are offsets from midnight in Location. A window whose
+	// End is before its Start wraps past midnight, e.g. 22:00-08:00.
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// loadQuietHours returns the configured quiet hours, or nil when they are
+// not configured
+func loadQuietHours(logger *logrus.Logger) *QuietHours {
+	start, hasStart := config.TimeOfDay(logger, "QUIET_HOURS_START")
+	end, hasEnd := config.TimeOfDay(logger, "QUIET_HOURS_END")
+	if !hasStart || !hasEnd || start == end {
+		return nil
+	}
+
+	location := time.Local
+	if name := os.Getenv("QUIET_HOURS_TIMEZONE"); name != "" {
+		loaded, err := time.LoadLocation(name)
+		if err != nil {
+			logger.Warnf("Invalid QUIET_HOURS_TIMEZONE '%s', defaulting to local time", name)
+		} else {
+			location = loaded
+		}
+	}
+
+	return &QuietHours{Start: start, End: end, Location: location}
+}
+
+// Contains reports whether t falls inside the quiet window
+func (q *QuietHours) Contains(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+
+	local := t.In(q.Location)
+	offset := local.Sub(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, q.Location))
+	if q.Start < q.End {
+		return offset >= q.Start && offset < q.End
+	}
+	return offset >= q.Start || offset < q.End
+}
+
+// NextActive returns t if it is outside the quiet window, or the time the
+// window ends otherwise
+func (q *QuietHours) NextActive(t time.Time) time.Time {
+	if !q.Contains(t) {
+		return t
+	}
+
+	local := t.In(q.Location)
+	end := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, q.Location).Add(q.End)
+	if !end.After(local) {
+		end = time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, q.Location).Add(q.End)
+	}
+	return end
+}
+
+// PostProactive posts a message the bot sends on its own initiative, without
+// the footer and buttons of an answer. During quiet hours the post is
+// deferred until the window ends, in which case the returned bool is true; a
+// deferred post is dropped when the manager's context is cancelled first.
+func (m *ConversationManager) PostProactive(channel, text string) (bool, error) {
+	now := time.Now()
+	if !m.quietHours.Contains(now) {
+		return false, m.postResponse(channel, text, "", false)
+	}
+
+	delay := m.quietHours.NextActive(now).Sub(now)
+	m.logger.Infof("Quiet hours in effect, deferring post to channel %s by %s", channel, delay.Round(time.Minute))
+	m.background(func(ctx context.Context) {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			m.logger.Infof("Shutting down, dropping the post deferred to channel %s", channel)
+			return
+		}
+		if err := m.postResponse(channel, text, "", false); err != nil {
+			m.logger.Errorf("Failed to post deferred message to channel %s: %v", channel, err)
+		}
+	})
+	return true, nil
+}
@@ -0,0 +1,178 @@
+package slack
+
+import (
+	"errors"
+	"time"
+
+	"beebrain/internal/config"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+// rateLimitRetrier retries Slack API calls that fail with HTTP 429, waiting
+// for the Retry-After period Slack asks for
+type rateLimitRetrier struct {
+	logger     *logrus.Logger
+	maxRetries int
+	maxWait    time.Duration
+}
+
+func newRateLimitRetrier(logger *logrus.Logger) rateLimitRetrier {
+	return rateLimitRetrier{
+		logger:     logger,
+		maxRetries: config.Int(logger, "SLACK_MAX_RETRIES", 3),
+		maxWait:    config.Duration(logger, "SLACK_MAX_RETRY_WAIT", 30*time.Second),
+	}
+}
+
+// do runs call, retrying it while Slack rate limits it up to maxRetries times
+func (r rateLimitRetrier) do(operation string, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := call()
+
+		var rateLimited *slack.RateLimitedError
+		if !errors.As(err, &rateLimited) || attempt >= r.maxRetries {
+			return err
+		}
+
+		wait := rateLimited.RetryAfter
+		if wait > r.maxWait {
+			wait = r.maxWait
+		}
+		r.logger.Warnf("Slack rate limited %s, retrying in %s (attempt %d/%d)", operation, wait, attempt+1, r.maxRetries)
+		time.Sleep(wait)
+	}
+}
+
+// rateLimitedClient wraps a SlackClient so every call retries on rate limits
+type rateLimitedClient struct {
+	client  SlackClient
+	retrier rateLimitRetrier
+}
+
+func (c *rateLimitedClient) AuthTest() (*slack.AuthTestResponse, error) {
+	var auth *slack.AuthTestResponse
+	err := c.retrier.do("AuthTest", func() error {
+		var err error
+		auth, err = c.client.AuthTest()
+		return err
+	})
+	return auth, err
+}
+
+func (c *rateLimitedClient) GetConversationHistory(params *slack.GetConversationHistoryParameters) (*slack.GetConversationHistoryResponse, error) {
+	var history *slack.GetConversationHistoryResponse
+	err := c.retrier.do("GetConversationHistory", func() error {
+		var err error
+		history, err = c.client.GetConversationHistory(params)
+		return err
+	})
+	return history, err
+}
+
+func (c *rateLimitedClient) GetConversationReplies(params *slack.GetConversationRepliesParameters) ([]slack.Message, bool, string, error) {
+	var (
+		messages []slack.Message
+		hasMore  bool
+		cursor   string
+	)
+	err := c.retrier.do("GetConversationReplies", func() error {
+		var err error
+		messages, hasMore, cursor, err = c.client.GetConversationReplies(params)
+		return err
+	})
+	return messages, hasMore, cursor, err
+}
+
+func (c *rateLimitedClient) PostMessage(channelID string, options ...slack.MsgOption) (string, string, error) {
+	var channel, timestamp string
+	err := c.retrier.do("PostMessage", func() error {
+		var err error
+		channel, timestamp, err = c.client.PostMessage(channelID, options...)
+		return err
+	})
+	return channel, timestamp, err
+}
+
+func (c *rateLimitedClient) UpdateMessage(channelID, timestamp string, options ...slack.MsgOption) (string, string, string, error) {
+	var channel, updated, text string
+	err := c.retrier.do("UpdateMessage", func() error {
+		var err error
+		channel, updated, text, err = c.client.UpdateMessage(channelID, timestamp, options...)
+		return err
+	})
+	return channel, updated, text, err
+}
+
+func (c *rateLimitedClient) PostEphemeral(channelID, userID string, options ...slack.MsgOption) (string, error) {
+	var timestamp string
+	err := c.retrier.do("PostEphemeral", func() error {
+		var err error
+		timestamp, err = c.client.PostEphemeral(channelID, userID, options...)
+		return err
+	})
+	return timestamp, err
+}
+
+func (c *rateLimitedClient) GetUserInfo(userID string) (*slack.User, error) {
+	var user *slack.User
+	err := c.retrier.do("GetUserInfo", func() error {
+		var err error
+		user, err = c.client.GetUserInfo(userID)
+		return err
+	})
+	return user, err
+}
+
+func (c *rateLimitedClient) GetConversationInfo(input *slack.GetConversationInfoInput) (*slack.Channel, error) {
+	var channel *slack.Channel
+	err := c.retrier.do("GetConversationInfo", func() error {
+		var err error
+		channel, err = c.client.GetConversationInfo(input)
+		return err
+	})
+	return channel, err
+}
+
+func (c *rateLimitedClient) GetEmoji() (map[string]string, error) {
+	var emoji map[string]string
+	err := c.retrier.do("GetEmoji", func() error {
+		var err error
+		emoji, err = c.client.GetEmoji()
+		return err
+	})
+	return emoji, err
+}
+
+func (c *rateLimitedClient) AddReaction(name string, item slack.ItemRef) error {
+	return c.retrier.do("AddReaction", func() error {
+		return c.client.AddReaction(name, item)
+	})
+}
+
+func (c *rateLimitedClient) RemoveReaction(name string, item slack.ItemRef) error {
+	return c.retrier.do("RemoveReaction", func() error {
+		return c.client.RemoveReaction(name, item)
+	})
+}
+
+func (c *rateLimitedClient) GetPermalink(params *slack.PermalinkParameters) (string, error) {
+	var permalink string
+	err := c.retrier.do("GetPermalink", func() error {
+		var err error
+		permalink, err = c.client.GetPermalink(params)
+		return err
+	})
+	return permalink, err
+}
+
+func (c *rateLimitedClient) UploadFile(params slack.FileUploadParameters) (*slack.File, error) {
+	var file *slack.File
+	err := c.retrier.do("UploadFile", func() error {
+		var err error
+		file, err = c.client.UploadFile(params)
+		return err
+	})
+	return file, err
+}
@@ -0,0 +1,96 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Reranker reorders retrieved messages by relevance to a query, most relevant
+// first. It may drop messages that aren't relevant at all.
+type Reranker interface {
+	Rerank(query string, candidates []vectordb.Message) ([]vectordb.Message, error)
+}
+
+const rerankPrompt = `Rank the following messages by how relevant they are to the question.
+Respond with ONLY a JSON array of the message numbers, most relevant first, for example [3, 1, 2]. Leave out messages that are not relevant at all.
+
+Question: %s
+
+Messages:
+%s`
+
+// LLMReranker asks the LLM to rank retrieved messages, which is slower but a
+// lot more precise than cosine similarity alone
+type LLMReranker struct {
+	llmClient llm.LLMClient
+	logger    *logrus.Logger
+}
+
+func NewLLMReranker(llmClient llm.LLMClient, logger *logrus.Logger) *LLMReranker {
+	return &LLMReranker{
+		llmClient: llmClient,
+		logger:    logger,
+	}
+}
+
+func (r *LLMReranker) Rerank(query string, candidates []vectordb.Message) ([]vectordb.Message, error) {
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	var messages strings.Builder
+	for i, candidate := range candidates {
+		messages.WriteString(fmt.Sprintf("[%d] %s\n", i+1, candidate.Text))
+	}
+
+	response, err := r.llmClient.Generate(fmt.Sprintf(rerankPrompt, query, messages.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to rerank messages: %w", err)
+	}
+
+	ranking, err := parseRanking(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ranking: %w", err)
+	}
+
+	// Ignore numbers the LLM made up or repeated
+	reranked := make([]vectordb.Message, 0, len(ranking))
+	seen := make(map[int]bool, len(ranking))
+	for _, n := range ranking {
+		if n < 1 || n > len(candidates) || seen[n] {
+			continue
+		}
+		seen[n] = true
+		reranked = append(reranked, candidates[n-1])
+	}
+
+	r.logger.Debugf("Reranked %d candidates into %d relevant messages", len(candidates), len(reranked))
+	return reranked, nil
+}
+
+// parseRanking parses the JSON array of message numbers in an LLM response
+func parseRanking(response string) ([]int, error) {
+	start := strings.Index(response, "[")
+	end := strings.LastIndex(response, "]")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("no JSON array found in response")
+	}
+
+	var ranking []int
+	if err := json.Unmarshal([]byte(response[start:end+1]), &ranking); err != nil {
+		return nil, err
+	}
+	return ranking, nil
+}
+
+// SetReranker sets the reranker applied to retrieved messages, nil disables
+// reranking
+func (m *ConversationManager) SetReranker(reranker Reranker) {
+	m.reranker = reranker
+}
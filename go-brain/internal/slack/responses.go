@@ -0,0 +1,62 @@
+package slack
+
+import (
+	"time"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+)
+
+// assistantRole is the role metadata of indexed bot answers
+const assistantRole = "assistant"
+
+// indexResponse stores an answer the bot posted when INDEX_RESPONSES is set,
+// tagged with the assistant role, so later questions can be grounded in it.
+// Its ID comes from the posted message, so an updated answer replaces the
+// earlier one.
+func (m *ConversationManager) indexResponse(channel, response, threadTimestamp, timestamp string) {
+	if !m.config.indexResponses || m.vectorDB == nil || timestamp == "" {
+		return
+	}
+
+	index := func() {
+		text, rawText := m.indexedText(m.outputFilter.Apply(response))
+		embedding, err := llm.EmbedDocument(m.embedder, text)
+		if err != nil {
+			m.logger.Errorf("Failed to get embedding for response: %v", err)
+			return
+		}
+
+		m.storeMessage(vectordb.Message{
+			ID:        messageID(channel, timestamp),
+			Text:      text,
+			RawText:   rawText,
+			UserID:    m.botUserID,
+			ChannelID: channel,
+			Timestamp: slackTime(timestamp).Format(time.RFC3339),
+			ThreadID:  threadTimestamp,
+			MessageTS: timestamp,
+			Metadata:  map[string]string{"role": assistantRole},
+			Embedding: embedding,
+		})
+	}
+	if !m.QueueIndexing("response "+timestamp, index) {
+		index()
+	}
+}
+
+// withoutResponses drops indexed bot answers from search results when
+// RAG_EXCLUDE_RESPONSES is set, so answers aren't grounded in earlier answers
+// that may have been wrong
+func (m *ConversationManager) withoutResponses(results []vectordb.Message) []vectordb.Message {
+	if !m.config.excludeResponses {
+		return results
+	}
+	kept := make([]vectordb.Message, 0, len(results))
+	for _, result := range results {
+		if result.Metadata["role"] != assistantRole {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
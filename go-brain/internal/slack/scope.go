@@ -0,0 +1,129 @@
+package slack
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"beebrain/internal/llm"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Ways of deciding whether a question is in scope
+const (
+	scopeGuardLLM      = "llm"
+	scopeGuardKeywords = "keywords"
+)
+
+const defaultScopeDecline = "Sorry, that's outside what I can help with here."
+
+// ScopeClassifier decides whether a question is within the domain the bot is
+// meant to answer
+type ScopeClassifier interface {
+	InScope(question string) (bool, error)
+}
+
+const scopePrompt = `Decide whether the following question is about %s.
+Respond with ONLY yes or no.
+
+Question: %s`
+
+// LLMScopeClassifier asks the LLM whether a question is about the domain
+type LLMScopeClassifier struct {
+	llmClient llm.LLMClient
+	domain    string
+}
+
+func NewLLMScopeClassifier(llmClient llm.LLMClient, domain string) *LLMScopeClassifier {
+	return &LLMScopeClassifier{
+		llmClient: llmClient,
+		domain:    domain,
+	}
+}
+
+func (c *LLMScopeClassifier) InScope(question string) (bool, error) {
+	response, err := c.llmClient.Generate(fmt.Sprintf(scopePrompt, c.domain, question))
+	if err != nil {
+		return false, fmt.Errorf("failed to classify question scope: %w", err)
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(response))
+	switch {
+	case strings.HasPrefix(answer, "yes"):
+		return true, nil
+	case strings.HasPrefix(answer, "no"):
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected scope classification: %.50s", response)
+	}
+}
+
+// KeywordScopeClassifier considers a question in scope when it contains any of
+// the keywords, which is cruder than asking the LLM but free
+type KeywordScopeClassifier struct {
+	keywords []string
+}
+
+func NewKeywordScopeClassifier(keywords []string) *KeywordScopeClassifier {
+	lowered := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		lowered[i] = strings.ToLower(keyword)
+	}
+	return &KeywordScopeClassifier{keywords: lowered}
+}
+
+func (c *KeywordScopeClassifier) InScope(question string) (bool, error) {
+	question = strings.ToLower(question)
+	for _, keyword := range c.keywords {
+		if strings.Contains(question, keyword) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// loadScopeClassifier returns the classifier configured by SCOPE_GUARD, or nil
+// when questions aren't checked
+func loadScopeClassifier(llmClient llm.LLMClient, logger *logrus.Logger, cfg managerConfig) ScopeClassifier {
+	switch cfg.scopeGuard {
+	case "":
+		return nil
+	case scopeGuardLLM:
+		if cfg.scopeDomain == "" {
+			logger.Warn("SCOPE_GUARD=llm needs SCOPE_DOMAIN, not checking question scope")
+			return nil
+		}
+		return NewLLMScopeClassifier(llmClient, cfg.scopeDomain)
+	case scopeGuardKeywords:
+		if len(cfg.scopeKeywords) == 0 {
+			logger.Warn("SCOPE_GUARD=keywords needs SCOPE_KEYWORDS, not checking question scope")
+			return nil
+		}
+		return NewKeywordScopeClassifier(cfg.scopeKeywords)
+	default:
+		logger.Warnf("Invalid SCOPE_GUARD '%s', not checking question scope", cfg.scopeGuard)
+		return nil
+	}
+}
+
+// inScope reports whether the bot should answer question in channel. When the
+// classifier fails the question is answered rather than wrongly declined.
+func (m *ConversationManager) inScope(channel, question string) bool {
+	if m.scope == nil {
+		return true
+	}
+	if len(m.config.scopeChannels) > 0 && !slices.Contains(m.config.scopeChannels, channel) {
+		return true
+	}
+
+	inScope, err := m.scope.InScope(question)
+	if err != nil {
+		m.logger.Warnf("Failed to check question scope, answering anyway: %v", err)
+		return true
+	}
+	if !inScope {
+		m.logger.Infof("Declining off-topic question in channel %s", channel)
+	}
+	return inScope
+}
@@ -0,0 +1,38 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Coarse sentiments messages are tagged with
+const (
+	SentimentPositive = "positive"
+	SentimentNeutral  = "neutral"
+	SentimentNegative = "negative"
+)
+
+const sentimentPrompt = `Classify the sentiment of the following Slack message as positive, neutral or negative.
+Respond with ONLY one of those three words.
+
+Message: %s`
+
+// classifySentiment asks the LLM for the coarse sentiment of text. It returns
+// "" when the LLM fails or gives an answer that isn't one of the sentiments.
+func (m *ConversationManager) classifySentiment(text string) string {
+	response, err := m.llmClient.Generate(fmt.Sprintf(sentimentPrompt, text))
+	if err != nil {
+		m.logger.Warnf("Failed to classify message sentiment: %v", err)
+		return ""
+	}
+
+	response = strings.ToLower(response)
+	for _, sentiment := range []string{SentimentNegative, SentimentPositive, SentimentNeutral} {
+		if strings.Contains(response, sentiment) {
+			return sentiment
+		}
+	}
+
+	m.logger.Warnf("Unexpected sentiment classification: %.50s", response)
+	return ""
+}
@@ -0,0 +1,103 @@
+package slack
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// codeFence matches a fenced code block and its optional language tag
+var codeFence = regexp.MustCompile("(?s)```([A-Za-z0-9_+#-]*)\n(.*?)```")
+
+// snippetCodeShare is the share of a response's non-whitespace characters that
+// must be code for it to be posted as a snippet
+const snippetCodeShare = 0.6
+
+// snippetFiletypes maps common code fence languages to Slack snippet types
+var snippetFiletypes = map[string]string{
+	"golang":     "go",
+	"py":         "python",
+	"js":         "javascript",
+	"ts":         "typescript",
+	"sh":         "shell",
+	"bash":       "shell",
+	"zsh":        "shell",
+	"yml":        "yaml",
+	"rb":         "ruby",
+	"rs":         "rust",
+	"kt":         "kotlin",
+	"c++":        "cpp",
+	"cs":         "csharp",
+	"c#":         "csharp",
+	"dockerfile": "dockerfile",
+}
+
+// codeSnippet is the code block of a response that is mostly code
+type codeSnippet struct {
+	code     string
+	filetype string
+	// comment is the prose around the code block
+	comment string
+}
+
+// detectCodeSnippet returns the largest code block of response when the
+// response is predominantly code and the block has at least minLines lines
+func detectCodeSnippet(response string, minLines int) (codeSnippet, bool) {
+	var largest []int
+	for _, match := range codeFence.FindAllStringSubmatchIndex(response, -1) {
+		if largest == nil || match[5]-match[4] > largest[5]-largest[4] {
+			largest = match
+		}
+	}
+	if largest == nil {
+		return codeSnippet{}, false
+	}
+
+	code := strings.TrimRight(response[largest[4]:largest[5]], "\n")
+	if strings.Count(code, "\n")+1 < minLines {
+		return codeSnippet{}, false
+	}
+	if float64(nonSpaceLen(code)) < snippetCodeShare*float64(nonSpaceLen(response)) {
+		return codeSnippet{}, false
+	}
+
+	return codeSnippet{
+		code:     code,
+		filetype: snippetFiletype(response[largest[2]:largest[3]]),
+		comment:  strings.TrimSpace(response[:largest[0]] + response[largest[1]:]),
+	}, true
+}
+
+func snippetFiletype(language string) string {
+	language = strings.ToLower(language)
+	if language == "" {
+		return "text"
+	}
+	if filetype, ok := snippetFiletypes[language]; ok {
+		return filetype
+	}
+	return language
+}
+
+func nonSpaceLen(text string) int {
+	return len(strings.Join(strings.Fields(text), ""))
+}
+
+// UploadSnippet posts code to channel as a Slack snippet of the given file
+// type, with comment as the message that goes with it
+func (m *ConversationManager) UploadSnippet(channel, threadTimestamp, code, filetype, comment string) error {
+	_, err := m.client.UploadFile(slack.FileUploadParameters{
+		Content:         code,
+		Filetype:        filetype,
+		Title:           "Answer",
+		InitialComment:  comment,
+		Channels:        []string{channel},
+		ThreadTimestamp: threadTimestamp,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload snippet: %w", err)
+	}
+	return nil
+}
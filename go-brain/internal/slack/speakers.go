@@ -0,0 +1,54 @@
+package slack
+
+import (
+	"strings"
+
+	"beebrain/internal/llm"
+)
+
+// withSpeakerNames prefixes what people said with their names, e.g.
+// "alice: ...", because the chat API drops the User field and the model can't
+// otherwise tell the people in a thread apart. The bot's own messages and
+// system messages are left as they are.
+func (m *ConversationManager) withSpeakerNames(messages []llm.Message) []llm.Message {
+	named := make([]llm.Message, len(messages))
+	names := make(map[string]string)
+	for i, msg := range messages {
+		named[i] = msg
+		if msg.Role != "user" || msg.User == nil {
+			continue
+		}
+		if name := m.speakerName(msg.User, names); name != "" {
+			named[i].Content = name + ": " + msg.Content
+		}
+	}
+	return named
+}
+
+// speakerName is the name a message's author goes by, looked up once per
+// prompt in names when the message doesn't carry it
+func (m *ConversationManager) speakerName(user *llm.User, names map[string]string) string {
+	if name := strings.TrimSpace(user.SlackName); name != "" {
+		return name
+	}
+	if user.SlackID == "" {
+		return ""
+	}
+	if name, ok := names[user.SlackID]; ok {
+		return name
+	}
+
+	name := user.SlackID
+	if info, err := m.GetUserInfo(user.SlackID); err != nil {
+		m.logger.Debugf("Naming user %s by ID: %v", user.SlackID, err)
+	} else {
+		for _, candidate := range []string{info.Profile.DisplayName, info.RealName, info.Name} {
+			if candidate = strings.TrimSpace(candidate); candidate != "" {
+				name = candidate
+				break
+			}
+		}
+	}
+	names[user.SlackID] = name
+	return name
+}
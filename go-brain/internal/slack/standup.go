@@ -0,0 +1,222 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"beebrain/internal/config"
+	"beebrain/internal/llm"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+const (
+	defaultStandupPrompt = "Good morning! What did you work on yesterday, what are you working on today, and is anything blocking you? Reply in this thread."
+	noStandupReplies     = "No one replied to this standup."
+)
+
+// StandupConfig controls when the standup question is posted and when the
+// replies to it are summarized
+type StandupConfig struct {
+	// Channel is where the standup question is posted, empty disables
+	// standups
+	Channel string
+	// Prompt is the standup question
+	Prompt string
+	// At is the offset from local midnight the question is posted at
+	At time.Duration
+	// Days are the days of the week the question is posted on
+	Days []time.Weekday
+	// SummaryDelay is how long after the question its replies are summarized
+	SummaryDelay time.Duration
+	// CheckInterval is how often the scheduler checks whether a run is due
+	CheckInterval time.Duration
+}
+
+// pendingStandup is a posted standup question waiting for its replies to be
+// summarized
+type pendingStandup struct {
+	channel   string
+	timestamp string
+	summaryAt time.Time
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func loadStandupConfig(logger *logrus.Logger) StandupConfig {
+	at, ok := config.TimeOfDay(logger, "STANDUP_AT")
+	if !ok {
+		at = 9 * time.Hour
+	}
+	return StandupConfig{
+		Channel:       os.Getenv("STANDUP_CHANNEL"),
+		Prompt:        config.String("STANDUP_PROMPT", defaultStandupPrompt),
+		At:            at,
+		Days:          loadWeekdays(logger, "STANDUP_DAYS", "mon,tue,wed,thu,fri"),
+		SummaryDelay:  config.Duration(logger, "STANDUP_SUMMARY_DELAY", 4*time.Hour),
+		CheckInterval: config.Duration(logger, "STANDUP_CHECK_INTERVAL", time.Minute),
+	}
+}
+
+// loadWeekdays parses a comma-separated list of days like "mon,wed",
+// skipping and warning about unknown days
+func loadWeekdays(logger *logrus.Logger, key, def string) []time.Weekday {
+	names := config.List(key)
+	if len(names) == 0 {
+		names = strings.Split(def, ",")
+	}
+
+	days := make([]time.Weekday, 0, len(names))
+	for _, name := range names {
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			logger.Warnf("Ignoring unknown day '%s' in %s, expected one of mon, tue, wed, thu, fri, sat or sun", name, key)
+			continue
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+func (c StandupConfig) enabled() bool {
+	return c.Channel != "" && len(c.Days) > 0
+}
+
+func (c StandupConfig) postsOn(day time.Weekday) bool {
+	for _, d := range c.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// NextRun returns the first standup scheduled strictly after the given time,
+// or the zero time when no days are configured
+func (c StandupConfig) NextRun(after time.Time) time.Time {
+	midnight := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, after.Location())
+	for i := 0; i <= 7; i++ {
+		run := midnight.AddDate(0, 0, i).Add(c.At)
+		if run.After(after) && c.postsOn(run.Weekday()) {
+			return run
+		}
+	}
+	return time.Time{}
+}
+
+// PostStandup posts the standup question and schedules the summary of its
+// replies. It returns the timestamp of the question, which its replies are
+// threaded under.
+func (m *ConversationManager) PostStandup(now time.Time) (string, error) {
+	if m.standup.Channel == "" {
+		return "", fmt.Errorf("standup channel is not configured")
+	}
+
+	_, timestamp, err := m.client.PostMessage(m.standup.Channel,
+		slack.MsgOptionText(m.standup.Prompt, false),
+		slack.MsgOptionAsUser(true),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to post standup to channel %s: %w", m.standup.Channel, err)
+	}
+
+	m.standupMu.Lock()
+	m.pendingStandups = append(m.pendingStandups, pendingStandup{
+		channel:   m.standup.Channel,
+		timestamp: timestamp,
+		summaryAt: now.Add(m.standup.SummaryDelay),
+	})
+	m.standupMu.Unlock()
+
+	m.logger.Infof("Posted standup to channel %s, summarizing replies at %s", m.standup.Channel, now.Add(m.standup.SummaryDelay).Format(time.RFC3339))
+	return timestamp, nil
+}
+
+// SummarizeStandup summarizes the replies to the standup question posted at
+// timestamp and posts the summary in its thread, or a note when nobody
+// replied
+func (m *ConversationManager) SummarizeStandup(channel, timestamp string) error {
+	thread, err := m.GetThreadContext(channel, timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to get standup replies: %w", err)
+	}
+
+	// The question itself and anything bots posted aren't standup updates
+	replies := make([]llm.Message, 0, len(thread))
+	for _, msg := range thread {
+		if msg.Role == "user" {
+			replies = append(replies, msg)
+		}
+	}
+	if len(replies) == 0 {
+		m.logger.Infof("No replies to standup %s in channel %s", timestamp, channel)
+		return m.postResponse(channel, noStandupReplies, timestamp, false)
+	}
+
+	summary, err := m.checkResponse(m.llmClient.Summarize(replies, m.summaryOptions(channel)...))
+	if err != nil {
+		return fmt.Errorf("failed to summarize standup %s: %w", timestamp, err)
+	}
+	return m.postResponse(channel, fmt.Sprintf("*Standup summary* (%d replies)\n%s", len(replies), summary), timestamp, false)
+}
+
+// RunStandups summarizes every standup whose summary is due at now
+func (m *ConversationManager) RunStandups(now time.Time) {
+	m.standupMu.Lock()
+	var due []pendingStandup
+	waiting := m.pendingStandups[:0]
+	for _, standup := range m.pendingStandups {
+		if now.Before(standup.summaryAt) {
+			waiting = append(waiting, standup)
+			continue
+		}
+		due = append(due, standup)
+	}
+	m.pendingStandups = waiting
+	m.standupMu.Unlock()
+
+	for _, standup := range due {
+		if err := m.SummarizeStandup(standup.channel, standup.timestamp); err != nil {
+			m.logger.Errorf("Failed to summarize standup in channel %s: %v", standup.channel, err)
+		}
+	}
+}
+
+// StartStandups posts the standup question on the configured schedule and
+// summarizes the replies to it until ctx is cancelled. Standups waiting for
+// their summary are lost on restart.
+func (m *ConversationManager) StartStandups(ctx context.Context) {
+	if !m.standup.enabled() {
+		m.logger.Debug("Standups are not configured")
+		return
+	}
+
+	next := m.standup.NextRun(time.Now())
+	m.logger.Infof("Posting standups to %s at %s, next at %s",
+		m.standup.Channel, time.Time{}.Add(m.standup.At).Format("15:04"), next.Format(time.RFC3339))
+
+	ticker := time.NewTicker(m.standup.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if !now.Before(next) {
+				if _, err := m.PostStandup(now); err != nil {
+					m.logger.Errorf("Failed to post standup: %v", err)
+				}
+				next = m.standup.NextRun(now)
+			}
+			m.RunStandups(now)
+		}
+	}
+}
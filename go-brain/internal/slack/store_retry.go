@@ -0,0 +1,93 @@
+package slack
+
+import (
+	"context"
+	"time"
+
+	"beebrain/internal/vectordb"
+)
+
+// What to do with a message that fails to store in the vector database
+const (
+	// storeFailureDrop logs the failure and loses the message
+	storeFailureDrop = "drop"
+	// storeFailureRetry retries the store in place with exponential backoff
+	storeFailureRetry = "retry"
+	// storeFailureQueue hands the message to a background worker that
+	// retries it, so indexing doesn't block on a struggling vector database
+	storeFailureQueue = "queue"
+)
+
+// failedStore is a message waiting in the retry queue with the error of its
+// last attempt
+type failedStore struct {
+	msg vectordb.Message
+	err error
+}
+
+// storeMessage stores an indexed message in the vector database, handling a
+// failure as STORE_FAILURE_STRATEGY says
+func (m *ConversationManager) storeMessage(msg vectordb.Message) {
+	err := m.vectorDB.StoreMessage(msg)
+	if err != nil {
+		switch m.config.storeFailure {
+		case storeFailureRetry:
+			err = m.retryStore(msg, err)
+		case storeFailureQueue:
+			select {
+			case m.storeQueue <- failedStore{msg: msg, err: err}:
+				m.logger.Warnf("Failed to store message in vectorDB, queued it for retry: %v", err)
+			default:
+				m.logger.Errorf("Failed to store message in vectorDB and the retry queue is full, dropping it: %v", err)
+			}
+			return
+		}
+	}
+	if err != nil {
+		m.logger.Errorf("Failed to store message in vectorDB: %v", err)
+		return
+	}
+
+	m.logger.Infof("Successfully stored message in vectorDB for channel %s", msg.ChannelID)
+}
+
+// retryStore retries a failed store up to STORE_RETRIES times, doubling the
+// wait between attempts from STORE_RETRY_BACKOFF. It returns the last error
+// when every attempt failed.
+func (m *ConversationManager) retryStore(msg vectordb.Message, err error) error {
+	wait := m.config.storeRetryBackoff
+	for attempt := 1; attempt <= m.config.storeRetries; attempt++ {
+		m.logger.Warnf("Failed to store message in vectorDB, retrying in %s (attempt %d/%d): %v", wait, attempt, m.config.storeRetries, err)
+		time.Sleep(wait)
+		wait *= 2
+
+		if err = m.vectorDB.StoreMessage(msg); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// StartStoreQueue retries the messages that failed to store until ctx is
+// cancelled. It returns straight away unless STORE_FAILURE_STRATEGY is queue.
+func (m *ConversationManager) StartStoreQueue(ctx context.Context) {
+	if m.storeQueue == nil {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if queued := len(m.storeQueue); queued > 0 {
+				m.logger.Warnf("Stopping with %d messages left in the store retry queue", queued)
+			}
+			return
+		case failed := <-m.storeQueue:
+			if err := m.retryStore(failed.msg, failed.err); err != nil {
+				m.logger.Errorf("Dropping message for channel %s after %d retries: %v", failed.msg.ChannelID, m.config.storeRetries, err)
+				continue
+			}
+			m.logger.Infof("Stored queued message in vectorDB for channel %s", failed.msg.ChannelID)
+		}
+	}
+}
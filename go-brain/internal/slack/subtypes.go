@@ -0,0 +1,70 @@
+package slack
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"beebrain/internal/config"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack/slackevents"
+)
+
+const defaultWelcomeMessage = "Welcome to the channel, {user}! Mention me if you have any questions."
+
+const defaultJoinGreeting = "Hi everyone, I'm {bot}! Mention me with a question and I'll answer it using what has been discussed in the workspace."
+
+// subtypeHandler handles message events of one subtype, such as
+// channel_join
+type subtypeHandler func(c echo.Context, ev *slackevents.MessageEvent) error
+
+// loadSubtypeHandlers returns the handlers of the message subtypes that are
+// switched on, the others are logged and ignored. WELCOME_ON_JOIN welcomes
+// people joining a channel with WELCOME_MESSAGE, in WELCOME_CHANNELS or
+// everywhere when that is empty. ME_MESSAGES handles /me messages like
+// normal ones.
+func (h *BeeBrainSlackHandler) loadSubtypeHandlers(logger *logrus.Logger) map[string]subtypeHandler {
+	handlers := make(map[string]subtypeHandler)
+	if config.Bool(logger, "WELCOME_ON_JOIN", false) {
+		h.welcomeMessage = config.String("WELCOME_MESSAGE", defaultWelcomeMessage)
+		h.welcomeChannels = config.List("WELCOME_CHANNELS")
+		handlers["channel_join"] = h.handleChannelJoinMessage
+	}
+	if h.conversationManager.config.meMessages {
+		handlers["me_message"] = h.handleMeMessage
+	}
+	return handlers
+}
+
+// handleMeMessage handles a /me message like a normal message. One that
+// mentions the bot is indexed and answered like a broadcast thread reply
+// mentioning it, as Slack may not send an app_mention for it.
+func (h *BeeBrainSlackHandler) handleMeMessage(c echo.Context, ev *slackevents.MessageEvent) error {
+	if strings.Contains(ev.Text, fmt.Sprintf("<@%s>", h.botUserID)) {
+		return h.handleThreadBroadcast(c, ev)
+	}
+	return h.handleIncommingMessage(c, ev)
+}
+
+// handleChannelJoinMessage welcomes someone who joined a channel
+func (h *BeeBrainSlackHandler) handleChannelJoinMessage(c echo.Context, ev *slackevents.MessageEvent) error {
+	if h.isDuplicateEvent("channel_join", ev.EventTimeStamp) {
+		return c.NoContent(http.StatusOK)
+	}
+	// The bot joining is not someone to welcome
+	if ev.User == "" || ev.User == h.botUserID {
+		return c.NoContent(http.StatusOK)
+	}
+	if len(h.welcomeChannels) > 0 && !slices.Contains(h.welcomeChannels, ev.Channel) {
+		return c.NoContent(http.StatusOK)
+	}
+
+	welcome := strings.ReplaceAll(h.welcomeMessage, "{user}", "<@"+ev.User+">")
+	if err := h.conversationManager.postResponse(ev.Channel, welcome, "", false); err != nil {
+		h.logger.Errorf("Failed to welcome %s to channel %s: %v", ev.User, ev.Channel, err)
+	}
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExtractActionItems(t *testing.T) {
+	thread := []llm.Message{
+		{Role: "user", Content: "Alice, can you update the docs by Friday?", User: &llm.User{SlackName: "Bob"}},
+		{Role: "user", Content: "Sure, and Bob will fix the build", User: &llm.User{SlackName: "Alice"}},
+	}
+
+	wellFormed := "```json\n{\"action_items\":[{\"owner\":\"Alice\",\"task\":\"Update the docs\",\"due\":\"Friday\"},{\"owner\":\"Bob\",\"task\":\"Fix the build\",\"due\":\"\"},{\"owner\":\"Bob\",\"task\":\" \",\"due\":\"\"}]}\n```"
+	malformed := "Here are the action items: {\"action_items\": [{\"owner\": \"Alice\", \"task\": \"Update the docs\",]"
+	repaired := "{\"action_items\":[{\"owner\":\"Alice\",\"task\":\"Update the docs\",\"due\":\"Friday\"}]}"
+
+	isRepair := func(prompt string) bool { return strings.Contains(prompt, "failed to parse") }
+	jsonMode := mock.MatchedBy(func(opts []llm.Option) bool { return llm.ApplyOptions(opts...).JSON })
+
+	tests := []struct {
+		name      string
+		response  string
+		repair    string
+		wantItems []slackinternal.ActionItem
+		wantError bool
+	}{
+		{
+			name:     "Well-formed JSON",
+			response: wellFormed,
+			wantItems: []slackinternal.ActionItem{
+				{Owner: "Alice", Task: "Update the docs", Due: "Friday"},
+				{Owner: "Bob", Task: "Fix the build"},
+			},
+		},
+		{
+			name:      "Malformed JSON repaired on retry",
+			response:  malformed,
+			repair:    repaired,
+			wantItems: []slackinternal.ActionItem{{Owner: "Alice", Task: "Update the docs", Due: "Friday"}},
+		},
+		{
+			name:      "Malformed JSON after repair",
+			response:  malformed,
+			repair:    "still not JSON",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSlackClient := &slackmocks.MockSlackClient{}
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+
+			cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+			mockLLMClient.On("Generate", mock.MatchedBy(func(prompt string) bool {
+				return !isRepair(prompt) && strings.Contains(prompt, "Alice, can you update the docs by Friday?")
+			}), jsonMode).Return(tt.response, nil).Once()
+			if tt.repair != "" {
+				mockLLMClient.On("Generate", mock.MatchedBy(isRepair), jsonMode).Return(tt.repair, nil).Once()
+			}
+
+			items, err := cm.ExtractActionItems(thread)
+			if tt.wantError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantItems, items)
+			}
+			mockLLMClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExtractActionItemsLLMError(t *testing.T) {
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+
+	mockLLMClient.On("Generate", mock.Anything, mock.Anything).Return("", errors.New("connection refused"))
+
+	_, err := cm.ExtractActionItems([]llm.Message{{Role: "user", Content: "hello"}})
+	assert.Error(t, err)
+	mockLLMClient.AssertNumberOfCalls(t, "Generate", 1)
+}
+
+func TestFormatActionItems(t *testing.T) {
+	checklist := slackinternal.FormatActionItems([]slackinternal.ActionItem{
+		{Owner: "Alice", Task: "Update the docs", Due: "Friday"},
+		{Task: "Book a room"},
+	})
+	assert.Equal(t, "*Action items*\n☐ *Alice*: Update the docs _(due Friday)_\n☐ Book a room", checklist)
+
+	assert.Equal(t, "I couldn't find any action items in this conversation.", slackinternal.FormatActionItems(nil))
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProcessMessageReferencesEarlierAnswer(t *testing.T) {
+	thread := []llm.Message{
+		{Role: "user", Content: "How do I deploy?", User: &llm.User{SlackID: "U1"}},
+		{Role: "assistant", Content: "Run make deploy\nfrom the main branch"},
+		{Role: "user", Content: "What about staging?", User: &llm.User{SlackID: "U2"}},
+	}
+
+	tests := []struct {
+		name      string
+		enabled   string
+		question  string
+		embedding []float32
+		want      string
+	}{
+		{
+			name:      "Matched question points to the earlier answer",
+			enabled:   "true",
+			question:  "how can I deploy?",
+			embedding: []float32{0.99, 0.1},
+			want:      "I answered this earlier in the thread:\n> Run make deploy\n> from the main branch",
+		},
+		{
+			name:      "Unmatched question is answered",
+			enabled:   "true",
+			question:  "Who owns the database?",
+			embedding: []float32{0, 1},
+			want:      "A fresh answer",
+		},
+		{
+			name:      "Off by default",
+			enabled:   "",
+			question:  "how can I deploy?",
+			embedding: []float32{0.99, 0.1},
+			want:      "A fresh answer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ANSWERED_DETECTION", tt.enabled)
+
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "chat", nil)
+
+			mockEmbedder.On("GetEmbedding", "How do I deploy?").Return([]float32{1, 0}, nil)
+			// The unanswered question is never compared
+			mockEmbedder.On("GetEmbedding", tt.question).Return(tt.embedding, nil)
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Return("A fresh answer", nil)
+
+			response, err := cm.ProcessMessage("C1", thread, tt.question, &slack.User{ID: "U3", Name: "carol"})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, response)
+			mockEmbedder.AssertNotCalled(t, "GetEmbedding", "What about staging?")
+			if tt.want != "A fresh answer" {
+				mockLLMClient.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
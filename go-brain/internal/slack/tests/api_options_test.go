@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	slackinternal "beebrain/internal/slack"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientOptionsUseSlackAPIURL(t *testing.T) {
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true, "user_id": "UBOT"}`))
+	}))
+	defer server.Close()
+
+	// The trailing slash the client needs is added
+	t.Setenv("SLACK_API_URL", server.URL+"/api")
+
+	client := slack.New("xoxb-test", slackinternal.ClientOptions(logrus.New())...)
+	auth, err := client.AuthTest()
+	assert.NoError(t, err)
+	assert.Equal(t, "UBOT", auth.UserID)
+	assert.Equal(t, "/api/auth.test", requested)
+}
+
+func TestClientOptionsDefaultToPublicAPI(t *testing.T) {
+	t.Setenv("SLACK_API_URL", "")
+	assert.Empty(t, slackinternal.ClientOptions(logrus.New()))
+}
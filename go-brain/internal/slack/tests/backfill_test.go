@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// countingEmbedder records how many embeddings run at once and fails for the
+// texts in fail
+type countingEmbedder struct {
+	mu       sync.Mutex
+	inFlight int
+	maxSeen  int
+	calls    int
+	fail     map[string]bool
+}
+
+func (e *countingEmbedder) GetEmbedding(text string) ([]float32, error) {
+	e.mu.Lock()
+	e.calls++
+	e.inFlight++
+	if e.inFlight > e.maxSeen {
+		e.maxSeen = e.inFlight
+	}
+	e.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	e.mu.Lock()
+	e.inFlight--
+	e.mu.Unlock()
+
+	if e.fail[text] {
+		return nil, errors.New("embedding failed")
+	}
+	return []float32{0.1, 0.2}, nil
+}
+
+func TestBackfillChannelEmbedsConcurrently(t *testing.T) {
+	t.Setenv("BACKFILL_WORKERS", "3")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	embedder := &countingEmbedder{fail: map[string]bool{"message 4": true}}
+
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, embedder, logrus.New(), "chat", mockVectorDBClient)
+
+	var history []slack.Message
+	for i := 0; i < 9; i++ {
+		history = append(history, slack.Message{Msg: slack.Msg{User: "U1", Text: fmt.Sprintf("message %d", i), Timestamp: fmt.Sprintf("1700000000.00010%d", i)}})
+	}
+	// Not something anyone wrote, so never embedded
+	history = append(history, slack.Message{Msg: slack.Msg{SubType: "channel_join", Text: "<@U2> has joined the channel", Timestamp: "1700000001.000100"}})
+
+	mockSlackClient.On("GetConversationHistory", mock.MatchedBy(func(params *slack.GetConversationHistoryParameters) bool {
+		return params.ChannelID == "C123"
+	})).Return(&slack.GetConversationHistoryResponse{Messages: history}, nil)
+
+	var stored []vectordb.Message
+	mockVectorDBClient.On("StoreMessages", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).([]vectordb.Message)
+	}).Return(nil).Once()
+
+	indexed, err := cm.BackfillChannel("C123")
+	assert.NoError(t, err)
+
+	// The failed message is skipped without aborting the others
+	assert.Equal(t, 8, indexed)
+	assert.Len(t, stored, 8)
+	assert.Equal(t, 9, embedder.calls)
+	for _, msg := range stored {
+		assert.NotEqual(t, "message 4", msg.Text)
+		assert.NotEmpty(t, msg.ID)
+		assert.Equal(t, "C123", msg.ChannelID)
+	}
+
+	assert.Greater(t, embedder.maxSeen, 1)
+	assert.LessOrEqual(t, embedder.maxSeen, 3)
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// tokens returns text estimated at n tokens
+func tokens(label string, n int) string {
+	return label + strings.Repeat(".", n*4-len(label))
+}
+
+func TestProcessMessageSplitsTokenBudget(t *testing.T) {
+	t.Setenv("RAG_RESULTS", "5")
+	t.Setenv("PROMPT_TOKEN_BUDGET", "100")
+	t.Setenv("PROMPT_HISTORY_SHARE", "0.7")
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+	// 100 tokens of history for a 70 token share
+	var history []llm.Message
+	for i := 0; i < 10; i++ {
+		history = append(history, llm.Message{Role: "user", Content: tokens(fmt.Sprintf("h%d", i), 10)})
+	}
+	// 50 tokens of sources for a 30 token share
+	var sources []vectordb.Message
+	for i := 0; i < 5; i++ {
+		sources = append(sources, vectordb.Message{UserID: "U1", Text: tokens(fmt.Sprintf("s%d", i), 10)})
+	}
+
+	embedding := []float32{0.1, 0.2}
+	mockEmbedder.On("GetEmbedding", "question").Return(embedding, nil)
+	mockVectorDBClient.On("SearchSimilar", mock.Anything, embedding, uint64(5)).Return(sources, nil)
+
+	var prompt []llm.Message
+	mockLLMClient.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		prompt = args.Get(0).([]llm.Message)
+	}).Return("answer", nil)
+
+	_, err := cm.ProcessMessage("C1", history, "question", &slack.User{ID: "U2", Name: "bob"})
+	assert.NoError(t, err)
+
+	// The 7 most recent history messages, the sources and the question
+	assert.Len(t, prompt, 9)
+	assert.True(t, strings.HasPrefix(prompt[0].Content, "h3"))
+	assert.True(t, strings.HasPrefix(prompt[6].Content, "h9"))
+
+	// The 3 best ranked sources
+	assert.Contains(t, prompt[7].Content, "[3] <@U1>: s2")
+	assert.NotContains(t, prompt[7].Content, "s3")
+	assert.Equal(t, "question", prompt[8].Content)
+}
+
+func TestProcessMessageGivesHistoryWholeBudgetWithoutSources(t *testing.T) {
+	t.Setenv("PROMPT_TOKEN_BUDGET", "50")
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	var history []llm.Message
+	for i := 0; i < 10; i++ {
+		history = append(history, llm.Message{Role: "user", Content: tokens(fmt.Sprintf("h%d", i), 10)})
+	}
+
+	var prompt []llm.Message
+	mockLLMClient.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		prompt = args.Get(0).([]llm.Message)
+	}).Return("answer", nil)
+
+	_, err := cm.ProcessMessage("C1", history, "question", &slack.User{ID: "U2", Name: "bob"})
+	assert.NoError(t, err)
+
+	// 5 history messages fill the budget, then the question
+	assert.Len(t, prompt, 6)
+	assert.True(t, strings.HasPrefix(prompt[0].Content, "h5"))
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func channelWithTopic(topic, purpose string) *slack.Channel {
+	channel := &slack.Channel{}
+	channel.ID = "C1"
+	channel.Name = "deploys"
+	channel.Topic.Value = topic
+	channel.Purpose.Value = purpose
+	return channel
+}
+
+func TestChannelTopicIsAddedToPrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled string
+		channel *slack.Channel
+		err     error
+		want    string
+	}{
+		{
+			name:    "Topic and purpose",
+			enabled: "true",
+			channel: channelWithTopic("Release train for the API", "Coordinating deploys"),
+			want:    "About the #deploys channel: Its topic is: Release train for the API\nIts purpose is: Coordinating deploys",
+		},
+		{
+			name:    "Topic only",
+			enabled: "true",
+			channel: channelWithTopic("Release train for the API", ""),
+			want:    "About the #deploys channel: Its topic is: Release train for the API",
+		},
+		{name: "No topic or purpose", enabled: "true", channel: channelWithTopic("", "")},
+		{name: "Lookup fails", enabled: "true", err: errors.New("channel_not_found")},
+		{name: "Disabled", channel: channelWithTopic("Release train for the API", "")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CHANNEL_TOPIC_CONTEXT", tt.enabled)
+			mockSlackClient := &slackmocks.MockSlackClient{}
+			mockLLMClient := &mocks.MockLLMClient{}
+			cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+			mockSlackClient.On("GetConversationInfo", mock.MatchedBy(func(input *slack.GetConversationInfoInput) bool {
+				return input.ChannelID == "C1"
+			})).Return(tt.channel, tt.err)
+
+			var sent []llm.Message
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				sent = args.Get(0).([]llm.Message)
+			}).Return("Fridays.", nil)
+
+			_, err := cm.ProcessMessage("C1", nil, "When do we deploy?", &slack.User{ID: "U1", Name: "alice"})
+			assert.NoError(t, err)
+
+			var notes []string
+			for _, msg := range sent {
+				if msg.Role == "system" {
+					notes = append(notes, msg.Content)
+				}
+			}
+			if tt.want == "" {
+				assert.Empty(t, notes)
+			} else {
+				assert.Equal(t, []string{tt.want}, notes)
+			}
+			if tt.enabled == "" {
+				mockSlackClient.AssertNotCalled(t, "GetConversationInfo", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestChannelTopicLookupIsCached(t *testing.T) {
+	t.Setenv("CHANNEL_TOPIC_CONTEXT", "true")
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	mockSlackClient.On("GetConversationInfo", mock.Anything).Return(channelWithTopic("Release train for the API", ""), nil)
+	mockLLMClient.On("Chat", mock.Anything, mock.Anything).Return("Fridays.", nil)
+
+	for i := 0; i < 3; i++ {
+		_, err := cm.ProcessMessage("C1", nil, "When do we deploy?", &slack.User{ID: "U1", Name: "alice"})
+		assert.NoError(t, err)
+	}
+	mockSlackClient.AssertNumberOfCalls(t, "GetConversationInfo", 1)
+}
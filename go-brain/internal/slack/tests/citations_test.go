@@ -0,0 +1,251 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRenderCitations(t *testing.T) {
+	links := []string{"https://acme.slack.com/archives/C1/p1", "", "https://acme.slack.com/archives/C1/p3"}
+
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{
+			name:     "Valid citations become links",
+			response: "We ship on Fridays [1] and deploy with Argo [3].",
+			want:     "We ship on Fridays <https://acme.slack.com/archives/C1/p1|[1]> and deploy with Argo <https://acme.slack.com/archives/C1/p3|[3]>.",
+		},
+		{
+			name:     "Adjacent citations",
+			response: "Agreed [1][3]",
+			want:     "Agreed <https://acme.slack.com/archives/C1/p1|[1]><https://acme.slack.com/archives/C1/p3|[3]>",
+		},
+		{
+			name:     "Citation without a permalink stays plain",
+			response: "See the thread [2].",
+			want:     "See the thread [2].",
+		},
+		{
+			name:     "Out of range citations stay plain",
+			response: "Made up [7] and zero [0].",
+			want:     "Made up [7] and zero [0].",
+		},
+		{
+			name:     "Indexes are not citations",
+			response: "Take items[0] and grid[1][3], as said [1].",
+			want:     "Take items[0] and grid[1][3], as said <https://acme.slack.com/archives/C1/p1|[1]>.",
+		},
+		{
+			name:     "Code is left alone",
+			response: "Use `[1]` or\n```\nfirst := [1]\nnext := items [3]\n```\nlike the docs [3].",
+			want:     "Use `[1]` or\n```\nfirst := [1]\nnext := items [3]\n```\nlike the docs <https://acme.slack.com/archives/C1/p3|[3]>.",
+		},
+		{
+			name:     "No citations",
+			response: "Nothing to cite here.",
+			want:     "Nothing to cite here.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, slackinternal.RenderCitations(tt.response, links))
+		})
+	}
+}
+
+func TestPermalink(t *testing.T) {
+	assert.Equal(t, "https://acme.slack.com/archives/C123/p1700000000000100",
+		slackinternal.Permalink("https://acme.slack.com/", vectordb.Message{ChannelID: "C123", MessageTS: "1700000000.000100"}))
+
+	assert.Equal(t, "https://acme.slack.com/archives/C123/p1700000000000200?thread_ts=1700000000.000100&cid=C123",
+		slackinternal.Permalink("https://acme.slack.com/", vectordb.Message{ChannelID: "C123", MessageTS: "1700000000.000200", ThreadID: "1700000000.000100"}))
+
+	// Messages indexed without a Slack timestamp can't be linked
+	assert.Empty(t, slackinternal.Permalink("https://acme.slack.com/", vectordb.Message{ChannelID: "C123"}))
+	assert.Empty(t, slackinternal.Permalink("", vectordb.Message{ChannelID: "C123", MessageTS: "1700000000.000100"}))
+
+	// A permalink stored at index time is used as is
+	assert.Equal(t, "https://acme.enterprise.slack.com/archives/C123/p1700000000000100",
+		slackinternal.Permalink("", vectordb.Message{ChannelID: "C123", MessageTS: "1700000000.000100", Permalink: "https://acme.enterprise.slack.com/archives/C123/p1700000000000100"}))
+}
+
+func TestProcessIncommingMessageStoresPermalink(t *testing.T) {
+	const link = "https://acme.slack.com/archives/C123/p1700000000000100"
+
+	tests := []struct {
+		name          string
+		enabled       string
+		text          string
+		wantPermalink string
+	}{
+		{name: "Off by default", enabled: "", text: "Deploys happen on Fridays after standup", wantPermalink: ""},
+		{name: "Substantive message", enabled: "true", text: "Deploys happen on Fridays after standup", wantPermalink: link},
+		{name: "Short message", enabled: "true", text: "thanks!", wantPermalink: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("INDEX_PERMALINKS", tt.enabled)
+
+			mockSlackClient := &slackmocks.MockSlackClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+			cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+			mockSlackClient.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+			mockSlackClient.On("GetPermalink", &slack.PermalinkParameters{Channel: "C123", Ts: "1700000000.000100"}).Return(link, nil)
+			mockEmbedder.On("GetEmbedding", tt.text).Return([]float32{0.1, 0.2}, nil)
+
+			var stored vectordb.Message
+			mockVectorDBClient.On("StoreMessage", mock.Anything).Run(func(args mock.Arguments) {
+				stored = args.Get(0).(vectordb.Message)
+			}).Return(nil)
+
+			cm.ProcessIncommingMessage(tt.text, &slack.User{ID: "U1"}, "C123", "1700000000.000100", "")
+
+			assert.Equal(t, tt.wantPermalink, stored.Permalink)
+			if tt.wantPermalink == "" {
+				mockSlackClient.AssertNotCalled(t, "GetPermalink", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestProcessMessageCitesRetrievedMessages(t *testing.T) {
+	t.Setenv("RAG_RESULTS", "2")
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+	cm.SetWorkspaceURL("https://acme.slack.com/")
+
+	embedding := []float32{0.1, 0.2}
+	mockEmbedder.On("GetEmbedding", "When do we deploy?").Return(embedding, nil)
+	mockVectorDBClient.On("SearchSimilar", mock.Anything, embedding, uint64(2)).Return([]vectordb.Message{
+		{Text: "Deploys happen on Fridays", UserID: "U1", ChannelID: "C1", MessageTS: "1700000000.000100"},
+		{Text: "Not on holidays", UserID: "U2", ChannelID: "C1", MessageTS: "1700000000.000200"},
+	}, nil)
+
+	// The retrieved messages are numbered in the prompt
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		for _, msg := range messages {
+			if strings.Contains(msg.Content, "[1] <@U1>: Deploys happen on Fridays") && strings.Contains(msg.Content, "[2] <@U2>: Not on holidays") {
+				return true
+			}
+		}
+		return false
+	}), mock.Anything).Return("On Fridays [1], except holidays [2] [5].", nil)
+
+	response, err := cm.ProcessMessage("C1", nil, "When do we deploy?", &slack.User{ID: "U3", Name: "carol"})
+	assert.NoError(t, err)
+	assert.Equal(t, "On Fridays <https://acme.slack.com/archives/C1/p1700000000000100|[1]>, except holidays <https://acme.slack.com/archives/C1/p1700000000000200|[2]> [5].", response)
+}
+
+func TestProcessMessageGroundingThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		score    float32
+		answered bool
+	}{
+		{name: "Below threshold says it doesn't know", score: 0.4, answered: false},
+		{name: "Above threshold answers", score: 0.8, answered: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RAG_RESULTS", "1")
+			t.Setenv("GROUNDING_MIN_SCORE", "0.6")
+
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+			embedding := []float32{0.1, 0.2}
+			mockEmbedder.On("GetEmbedding", "When do we deploy?").Return(embedding, nil)
+			mockVectorDBClient.On("SearchSimilar", mock.Anything, embedding, uint64(1)).Return([]vectordb.Message{
+				{Text: "Lunch is at noon", UserID: "U1", ChannelID: "C1", Score: tt.score},
+			}, nil)
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Return("On Fridays", nil)
+
+			response, err := cm.ProcessMessage("C1", nil, "When do we deploy?", &slack.User{ID: "U3", Name: "carol"})
+			assert.NoError(t, err)
+			if tt.answered {
+				assert.Equal(t, "On Fridays", response)
+				mockLLMClient.AssertCalled(t, "Chat", mock.Anything, mock.Anything)
+			} else {
+				assert.Equal(t, slackinternal.NoGroundingResponse, response)
+				mockLLMClient.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestHandleAppMentionSendsSourcesEphemerally(t *testing.T) {
+	tests := []struct {
+		name      string
+		ephemeral string
+	}{
+		{name: "Enabled sends sources to the asker", ephemeral: "true"},
+		{name: "Disabled keeps them in the answer", ephemeral: "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RAG_RESULTS", "1")
+			t.Setenv("RAG_SOURCES_EPHEMERAL", tt.ephemeral)
+			handler, m := newTestHandler(t, "chat")
+
+			m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+			m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+			m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+			m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+
+			embedding := []float32{0.1, 0.2}
+			m.embedder.On("GetEmbedding", "<@UBOT> when do we deploy?").Return(embedding, nil)
+			m.vectorDB.On("SearchSimilar", mock.Anything, embedding, uint64(1)).Return([]vectordb.Message{
+				{Text: "Deploys happen on Fridays", UserID: "U1", ChannelID: "C1", MessageTS: "1700000000.000050"},
+			}, nil)
+			m.llm.On("Chat", mock.Anything, mock.Anything).Return("On Fridays [1]", nil)
+			m.slack.On("PostMessage", "C123", mock.MatchedBy(func(options []slack.MsgOption) bool {
+				return postedText(t, options) == "On Fridays [1]"
+			})).Return("C123", "1700000000.000200", nil)
+
+			var options []slack.MsgOption
+			m.slack.On("PostEphemeral", "C123", "U123", mock.Anything).Run(func(args mock.Arguments) {
+				options = args.Get(2).([]slack.MsgOption)
+			}).Return("1700000000.000300", nil)
+
+			postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> when do we deploy?","ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000100"}}`)
+
+			m.slack.AssertCalled(t, "PostMessage", "C123", mock.Anything)
+			if tt.ephemeral != "true" {
+				m.slack.AssertNotCalled(t, "PostEphemeral", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+			assert.NoError(t, err)
+			assert.Equal(t, "*Sources*\n[1] <@U1>: Deploys happen on Fridays", values.Get("text"))
+			assert.Equal(t, "1700000000.000100", values.Get("thread_ts"))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mentionEventWithText is an app_mention with the given text, escaped for
+// JSON by the caller
+func mentionEventWithText(text string) string {
+	return fmt.Sprintf(`{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":%q,"ts":"1700000000.000300","channel":"C123","event_ts":"1700000000.000300"}}`, text)
+}
+
+func TestContentlessMentionsSkipTheLLM(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "Bare mention", text: "<@UBOT>"},
+		{name: "Shortcode emoji", text: "<@UBOT> :thumbsup::skin-tone-2:"},
+		{name: "Unicode emoji", text: "<@UBOT> 👍🏽 🎉"},
+		{name: "Punctuation", text: "<@UBOT> ?!"},
+		{name: "Other mentions", text: "<@UBOT> <@U456> <!here> :wave:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, m := newTestHandler(t, "chat")
+
+			var posted []string
+			m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+				posted = append(posted, postedText(t, args.Get(1).([]slack.MsgOption)))
+			}).Return("C123", "1700000000.000400", nil)
+
+			postEvent(t, handler, mentionEventWithText(tt.text))
+
+			assert.Equal(t, []string{"Hi! Ask me a question and I'll do my best to answer it."}, posted)
+			m.llm.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+			m.slack.AssertNotCalled(t, "AddReaction", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestContentlessMentionModes(t *testing.T) {
+	t.Run("Custom reply", func(t *testing.T) {
+		t.Setenv("CONTENTLESS_MENTION_REPLY", "What's up?")
+		handler, m := newTestHandler(t, "chat")
+
+		var posted []string
+		m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+			posted = append(posted, postedText(t, args.Get(1).([]slack.MsgOption)))
+		}).Return("C123", "1700000000.000400", nil)
+
+		postEvent(t, handler, mentionEventWithText("<@UBOT> :wave:"))
+
+		assert.Equal(t, []string{"What's up?"}, posted)
+	})
+
+	t.Run("Ignore", func(t *testing.T) {
+		t.Setenv("CONTENTLESS_MENTIONS", "ignore")
+		handler, m := newTestHandler(t, "chat")
+
+		postEvent(t, handler, mentionEventWithText("<@UBOT> :wave:"))
+
+		m.slack.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+		m.llm.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Answer", func(t *testing.T) {
+		t.Setenv("CONTENTLESS_MENTIONS", "answer")
+		handler, m := newTestHandler(t, "chat")
+
+		m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+		m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+		m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+		m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+		m.llm.On("Chat", mock.Anything, mock.Anything).Return("👋", nil)
+		m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000400", nil)
+
+		postEvent(t, handler, mentionEventWithText("<@UBOT> :wave:"))
+
+		m.llm.AssertNumberOfCalls(t, "Chat", 1)
+	})
+}
+
+func TestMentionsWithContentAreAnswered(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("42", nil)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000400", nil)
+
+	postEvent(t, handler, mentionEventWithText("<@UBOT> 6*7? :thinking_face:"))
+
+	m.llm.AssertNumberOfCalls(t, "Chat", 1)
+}
@@ -1,6 +1,9 @@
 package tests
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"beebrain/internal/llm"
@@ -20,6 +23,7 @@ import (
 var (
 	_ slackinternal.SlackClient = (*slackmocks.MockSlackClient)(nil)
 	_ llm.LLMClient             = (*mocks.MockLLMClient)(nil)
+	_ llm.Embedder              = (*mocks.MockEmbedder)(nil)
 	_ vectordb.VectorDBClient   = (*vectordbmocks.MockVectorDBClient)(nil)
 )
 
@@ -27,6 +31,7 @@ func TestNewConversationManager(t *testing.T) {
 	// Create mock dependencies
 	mockSlackClient := &slackmocks.MockSlackClient{}
 	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
 	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
 	logger := logrus.New()
 
@@ -43,16 +48,17 @@ func TestNewConversationManager(t *testing.T) {
 			wantError: false,
 		},
 		{
+			// Indexing is disabled, the manager works as a plain chat bot
 			name:      "Nil vectorDB",
 			vectorDB:  nil,
-			wantNil:   true,
-			wantError: true,
+			wantNil:   false,
+			wantError: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, logger, "chat", tt.vectorDB)
+			cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logger, "chat", tt.vectorDB)
 			if tt.wantNil {
 				assert.Nil(t, cm)
 			} else {
@@ -66,11 +72,12 @@ func TestProcessIncommingMessage(t *testing.T) {
 	// Create mock dependencies
 	mockSlackClient := &slackmocks.MockSlackClient{}
 	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
 	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
 	logger := logrus.New()
 
 	// Create conversation manager
-	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, logger, "chat", mockVectorDBClient)
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logger, "chat", mockVectorDBClient)
 	assert.NotNil(t, cm)
 
 	// Test data
@@ -90,13 +97,13 @@ func TestProcessIncommingMessage(t *testing.T) {
 	}, nil)
 
 	// Set up expectations for storing message
-	mockLLMClient.On("GetEmbedding", text).Return(embedding, nil)
+	mockEmbedder.On("GetEmbedding", text).Return(embedding, nil)
 	mockVectorDBClient.On("StoreMessage", mock.MatchedBy(func(msg vectordb.Message) bool {
-		return msg.Text == text && msg.UserID == user.ID && msg.ChannelID == channelID
+		return msg.Text == text && msg.UserID == user.ID && msg.ChannelID == channelID && msg.MessageTS == "1700000000.000100"
 	})).Return(nil)
 
 	// Test ProcessIncommingMessage
-	cm.ProcessIncommingMessage(text, user, channelID)
+	cm.ProcessIncommingMessage(text, user, channelID, "1700000000.000100", "")
 
 	// Verify expectations
 	mockSlackClient.AssertExpectations(t)
@@ -108,11 +115,12 @@ func TestGetLastHourConversation(t *testing.T) {
 	// Create mock dependencies
 	mockSlackClient := &slackmocks.MockSlackClient{}
 	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
 	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
 	logger := logrus.New()
 
 	// Create conversation manager
-	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, logger, "chat", mockVectorDBClient)
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logger, "chat", mockVectorDBClient)
 	assert.NotNil(t, cm)
 
 	// Test data
@@ -158,11 +166,12 @@ func TestGetThreadContext(t *testing.T) {
 	// Create mock dependencies
 	mockSlackClient := &slackmocks.MockSlackClient{}
 	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
 	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
 	logger := logrus.New()
 
 	// Create conversation manager
-	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, logger, "chat", mockVectorDBClient)
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logger, "chat", mockVectorDBClient)
 	assert.NotNil(t, cm)
 
 	// Test data
@@ -200,3 +209,242 @@ func TestGetThreadContext(t *testing.T) {
 	// Verify expectations
 	mockSlackClient.AssertExpectations(t)
 }
+
+func TestMessageRolesWithBotIdentity(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+	cm.SetBotIdentity("UBOT", "BBOT")
+
+	threadMessages := []slack.Message{
+		{Msg: slack.Msg{Text: "Question", User: "U123456"}},
+		{Msg: slack.Msg{Text: "Our answer", User: "UBOT", BotID: "BBOT"}},
+		{Msg: slack.Msg{Text: "Another bot", User: "UOTHER", BotID: "BOTHER"}},
+		{Msg: slack.Msg{Text: "Integration post", BotID: "BOTHER", SubType: "bot_message"}},
+		{Msg: slack.Msg{Text: "Our legacy post", BotID: "BBOT", SubType: "bot_message"}},
+	}
+	mockSlackClient.On("GetConversationReplies", mock.AnythingOfType("*slack.GetConversationRepliesParameters")).
+		Return(threadMessages, false, "", nil)
+
+	messages, err := cm.GetThreadContext("C123456", "1234567890.123456")
+	assert.NoError(t, err)
+
+	roles := make([]string, len(messages))
+	for i, msg := range messages {
+		roles[i] = msg.Role
+	}
+	assert.Equal(t, []string{"user", "assistant", "user", "user", "assistant"}, roles)
+}
+
+func TestLeaveChannelClearsCachedState(t *testing.T) {
+	// Create mock dependencies
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	logger := logrus.New()
+
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logger, "chat", mockVectorDBClient)
+	assert.NotNil(t, cm)
+
+	channelID := "C123456"
+	user := &slack.User{ID: "U123456", Name: "Test User"}
+
+	mockSlackClient.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{
+		Messages: []slack.Message{},
+	}, nil)
+	mockEmbedder.On("GetEmbedding", mock.Anything).Return([]float32{0.1, 0.2}, nil)
+	mockVectorDBClient.On("StoreMessage", mock.Anything).Return(nil)
+
+	// The first message loads and caches the channel history
+	cm.ProcessIncommingMessage("before leaving", user, channelID, "1700000000.000100", "")
+	mockSlackClient.AssertNumberOfCalls(t, "GetConversationHistory", 1)
+	mockVectorDBClient.AssertNumberOfCalls(t, "StoreMessage", 1)
+
+	cm.LeaveChannel(channelID)
+
+	// Messages from a left channel are not indexed and nothing is posted there
+	cm.ProcessIncommingMessage("after leaving", user, channelID, "1700000000.000100", "")
+	mockVectorDBClient.AssertNumberOfCalls(t, "StoreMessage", 1)
+	assert.Error(t, cm.PostResponse(channelID, "hello", ""))
+	mockSlackClient.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+
+	// After rejoining, the evicted history has to be loaded again
+	cm.JoinChannel(channelID)
+	cm.ProcessIncommingMessage("after rejoining", user, channelID, "1700000000.000100", "")
+	mockSlackClient.AssertNumberOfCalls(t, "GetConversationHistory", 2)
+	mockVectorDBClient.AssertNumberOfCalls(t, "StoreMessage", 2)
+}
+
+func TestProcessMessageEmptyResponse(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	logger := logrus.New()
+
+	user := &slack.User{ID: "U123456", Name: "Test User"}
+
+	tests := []struct {
+		name     string
+		llmMode  string
+		response string
+	}{
+		{name: "Empty chat response", llmMode: "chat", response: ""},
+		{name: "Whitespace generate response", llmMode: "generate", response: "  \n "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logger, tt.llmMode, mockVectorDBClient)
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Return(tt.response, nil).Maybe()
+			mockLLMClient.On("Generate", mock.Anything, mock.Anything).Return(tt.response, nil).Maybe()
+
+			response, err := cm.ProcessMessage("C123", nil, "Hello?", user)
+			assert.ErrorIs(t, err, slackinternal.ErrEmptyResponse)
+			assert.Empty(t, response)
+		})
+	}
+
+	// Empty messages are never posted
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logger, "chat", mockVectorDBClient)
+	assert.Error(t, cm.PostResponse("C123456", " ", ""))
+	mockSlackClient.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+}
+
+func TestProcessMessageUsesChannelModel(t *testing.T) {
+	t.Setenv("CHANNEL_MODELS", "CENG=codellama,CSALES=mistral")
+
+	user := &slack.User{ID: "U123456", Name: "Test User"}
+
+	tests := []struct {
+		name      string
+		llmMode   string
+		channel   string
+		wantModel string
+	}{
+		{name: "Chat in overridden channel", llmMode: "chat", channel: "CENG", wantModel: "codellama"},
+		{name: "Generate in overridden channel", llmMode: "generate", channel: "CSALES", wantModel: "mistral"},
+		{name: "Default model elsewhere", llmMode: "chat", channel: "CRANDOM", wantModel: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLLMClient := &mocks.MockLLMClient{}
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), tt.llmMode, &vectordbmocks.MockVectorDBClient{})
+
+			usesModel := mock.MatchedBy(func(opts []llm.Option) bool {
+				return llm.ApplyOptions(opts...).Model == tt.wantModel
+			})
+			mockLLMClient.On("Chat", mock.Anything, usesModel).Return("Hi!", nil).Maybe()
+			mockLLMClient.On("Generate", mock.Anything, usesModel).Return("Hi!", nil).Maybe()
+
+			response, err := cm.ProcessMessage(tt.channel, nil, "Hello?", user)
+			assert.NoError(t, err)
+			assert.Equal(t, "Hi!", response)
+		})
+	}
+}
+
+func TestProcessMessageUsesChannelPersona(t *testing.T) {
+	dir := t.TempDir()
+	support := filepath.Join(dir, "support.txt")
+	assert.NoError(t, os.WriteFile(support, []byte("Answer formally, in full sentences.\n"), 0o644))
+	t.Setenv("CHANNEL_PERSONAS", "CSUPPORT="+support+",CBROKEN="+filepath.Join(dir, "missing.txt"))
+
+	user := &slack.User{ID: "U123456", Name: "Test User"}
+
+	tests := []struct {
+		name        string
+		channel     string
+		wantPersona string
+	}{
+		{name: "Channel with a persona", channel: "CSUPPORT", wantPersona: "Answer formally, in full sentences."},
+		{name: "Default persona elsewhere", channel: "CRANDOM", wantPersona: ""},
+		{name: "Unreadable persona falls back to the default", channel: "CBROKEN", wantPersona: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLLMClient := &mocks.MockLLMClient{}
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+			usesPersona := mock.MatchedBy(func(opts []llm.Option) bool {
+				return llm.ApplyOptions(opts...).Persona == tt.wantPersona
+			})
+			mockLLMClient.On("Chat", mock.Anything, usesPersona).Return("Hi!", nil)
+
+			response, err := cm.ProcessMessage(tt.channel, nil, "Hello?", user)
+			assert.NoError(t, err)
+			assert.Equal(t, "Hi!", response)
+		})
+	}
+}
+
+func TestProcessMessageWithoutContext(t *testing.T) {
+	user := &slack.User{ID: "U123456", Name: "Test User"}
+	history := []llm.Message{{Role: "user", Content: "We ship on Fridays", User: &llm.User{SlackName: "alice"}}}
+
+	tests := []struct {
+		name     string
+		behavior string
+		history  []llm.Message
+		wantNote bool
+	}{
+		{name: "Proceed asks just the question", behavior: "proceed", wantNote: false},
+		{name: "Note is added without context", behavior: "note", wantNote: true},
+		{name: "No note when there is context", behavior: "note", history: history, wantNote: false},
+		{name: "Invalid behavior proceeds", behavior: "guess", wantNote: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_CONTEXT_BEHAVIOR", tt.behavior)
+
+			mockLLMClient := &mocks.MockLLMClient{}
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+			var messages []llm.Message
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				messages = args.Get(0).([]llm.Message)
+			}).Return("Hi!", nil)
+
+			_, err := cm.ProcessMessage("C1", tt.history, "When do we ship?", user)
+			assert.NoError(t, err)
+
+			hasNote := messages[0].Role == "system" && strings.Contains(messages[0].Content, "There is no earlier conversation")
+			assert.Equal(t, tt.wantNote, hasNote)
+			assert.Equal(t, "When do we ship?", messages[len(messages)-1].Content)
+		})
+	}
+}
+
+func TestConversationManagerWithoutVectorDB(t *testing.T) {
+	// Retrieval is configured but there is nothing to retrieve from
+	t.Setenv("RAG_RESULTS", "3")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logrus.New(), "chat", nil)
+	assert.NotNil(t, cm)
+
+	user := &slack.User{ID: "U123456", Name: "Test User"}
+
+	// Messages are not indexed
+	mockSlackClient.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	cm.ProcessIncommingMessage("Hello, world!", user, "C123456", "1700000000.000100", "")
+
+	// Questions are answered without retrieval
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return len(messages) == 1 && messages[0].Content == "Hello?"
+	}), mock.Anything).Return("Hi!", nil)
+	response, err := cm.ProcessMessage("C123456", nil, "Hello?", user)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hi!", response)
+
+	_, err = cm.BackfillChannel("C123456")
+	assert.Error(t, err)
+
+	mockEmbedder.AssertNotCalled(t, "GetEmbedding", mock.Anything)
+}
@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostChannelDigestPostsToTargetChannel(t *testing.T) {
+	t.Setenv("DIGEST_CHANNEL", "CDIGEST")
+	t.Setenv("DIGEST_SOURCE_CHANNELS", "CSOURCE")
+
+	// Create mock dependencies
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	logger := logrus.New()
+
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logger, "chat", mockVectorDBClient)
+	assert.NotNil(t, cm)
+
+	mockSlackClient.On("GetConversationHistory", mock.MatchedBy(func(params *slack.GetConversationHistoryParameters) bool {
+		return params.ChannelID == "CSOURCE"
+	})).Return(&slack.GetConversationHistoryResponse{
+		Messages: []slack.Message{
+			{Msg: slack.Msg{Text: "We shipped the release", User: "U123456", Username: "User1"}},
+		},
+	}, nil)
+
+	mockLLMClient.On("Summarize", mock.MatchedBy(func(messages []llm.Message) bool {
+		return len(messages) == 1 && messages[0].Content == "We shipped the release"
+	}), mock.Anything).Return("• Release shipped", nil)
+
+	// The digest must go to the digest channel, not the source channel
+	mockSlackClient.On("PostMessage", "CDIGEST", mock.Anything).Return("CDIGEST", "1234567890.123456", nil)
+
+	err := cm.PostChannelDigest("CSOURCE", time.Now().Add(-24*time.Hour), time.Now())
+	assert.NoError(t, err)
+
+	mockSlackClient.AssertExpectations(t)
+	mockSlackClient.AssertNotCalled(t, "PostMessage", "CSOURCE", mock.Anything)
+	mockLLMClient.AssertExpectations(t)
+}
+
+func TestPostChannelDigestSkipsEmptyChannel(t *testing.T) {
+	t.Setenv("DIGEST_CHANNEL", "CDIGEST")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+	mockSlackClient.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+
+	assert.NoError(t, cm.PostChannelDigest("CSOURCE", time.Now().Add(-24*time.Hour), time.Now()))
+	mockLLMClient.AssertNotCalled(t, "Summarize", mock.Anything, mock.Anything)
+	mockSlackClient.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+}
+
+func TestDigestConfigSchedule(t *testing.T) {
+	cfg := slackinternal.DigestConfig{
+		Interval: 24 * time.Hour,
+		At:       9 * time.Hour,
+	}
+
+	beforeRun := time.Date(2024, 3, 10, 8, 30, 0, 0, time.UTC)
+	afterRun := time.Date(2024, 3, 10, 9, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC), cfg.NextRun(beforeRun))
+	assert.Equal(t, time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC), cfg.NextRun(afterRun))
+	assert.Equal(t, time.Date(2024, 3, 9, 9, 0, 0, 0, time.UTC), cfg.PreviousRun(beforeRun))
+	assert.Equal(t, time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC), cfg.PreviousRun(afterRun))
+
+	// Sub-daily intervals are anchored to the same time of day
+	cfg.Interval = 6 * time.Hour
+	assert.Equal(t, time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC), cfg.NextRun(afterRun))
+	assert.Equal(t, time.Date(2024, 3, 10, 3, 0, 0, 0, time.UTC), cfg.PreviousRun(beforeRun))
+}
+
+func TestRunDigestsRequestsScheduledWindow(t *testing.T) {
+	t.Setenv("DIGEST_CHANNEL", "CDIGEST")
+	t.Setenv("DIGEST_SOURCE_CHANNELS", "CSOURCE1,CSOURCE2")
+	t.Setenv("DIGEST_INTERVAL", "24h")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+	runAt := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	oldest := fmt.Sprintf("%d.000000", runAt.Add(-24*time.Hour).Unix())
+	latest := fmt.Sprintf("%d.000000", runAt.Unix())
+
+	for _, channel := range []string{"CSOURCE1", "CSOURCE2"} {
+		channel := channel
+		mockSlackClient.On("GetConversationHistory", mock.MatchedBy(func(params *slack.GetConversationHistoryParameters) bool {
+			return params.ChannelID == channel && params.Oldest == oldest && params.Latest == latest
+		})).Return(&slack.GetConversationHistoryResponse{}, nil).Once()
+	}
+
+	assert.True(t, cm.RunDigests(runAt))
+	mockSlackClient.AssertExpectations(t)
+}
+
+func TestRunDigestsSkipsOverlappingRun(t *testing.T) {
+	t.Setenv("DIGEST_CHANNEL", "CDIGEST")
+	t.Setenv("DIGEST_SOURCE_CHANNELS", "CSOURCE")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mockSlackClient.On("GetConversationHistory", mock.Anything).Run(func(args mock.Arguments) {
+		close(started)
+		<-release
+	}).Return(&slack.GetConversationHistoryResponse{}, nil).Once()
+
+	done := make(chan bool)
+	go func() {
+		done <- cm.RunDigests(time.Now())
+	}()
+
+	<-started
+	// A run triggered while the first is still going is skipped
+	assert.False(t, cm.RunDigests(time.Now()))
+
+	close(release)
+	assert.True(t, <-done)
+	mockSlackClient.AssertNumberOfCalls(t, "GetConversationHistory", 1)
+}
+
+func TestDigestCatchUpSkipsDigestsAlreadyPosted(t *testing.T) {
+	t.Setenv("DIGEST_CHANNEL", "CDIGEST")
+	t.Setenv("DIGEST_SOURCE_CHANNELS", "CSOURCE1,CSOURCE2")
+	t.Setenv("DIGEST_CATCH_UP", "true")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+	cm.SetBotIdentity("UBOT", "B1")
+
+	// Before the restart the bot posted the digest of CSOURCE1, someone else
+	// only quoted the one of CSOURCE2
+	mockSlackClient.On("GetConversationHistory", mock.MatchedBy(func(params *slack.GetConversationHistoryParameters) bool {
+		return params.ChannelID == "CDIGEST"
+	})).Return(&slack.GetConversationHistoryResponse{
+		Messages: []slack.Message{
+			{Msg: slack.Msg{Text: "*Digest for <#CSOURCE2>* when?", User: "U123"}},
+			{Msg: slack.Msg{Text: "*Digest for <#CSOURCE1|general>*\nWe shipped", User: "UBOT", BotID: "B1"}},
+		},
+	}, nil).Once()
+	mockSlackClient.On("GetConversationHistory", mock.MatchedBy(func(params *slack.GetConversationHistoryParameters) bool {
+		return params.ChannelID == "CSOURCE2"
+	})).Return(&slack.GetConversationHistoryResponse{
+		Messages: []slack.Message{{Msg: slack.Msg{Text: "Release is out", User: "U123", Username: "alice"}}},
+	}, nil).Once()
+	mockLLMClient.On("Summarize", mock.Anything, mock.Anything).Return("The release is out.", nil)
+	var posted []string
+	mockSlackClient.On("PostMessage", "CDIGEST", mock.Anything).Run(func(args mock.Arguments) {
+		posted = append(posted, postedText(t, args.Get(1).([]slack.MsgOption)))
+	}).Return("CDIGEST", "1700000000.000400", nil)
+
+	// The catch-up happens before the schedule starts
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cm.StartDigests(ctx)
+
+	mockSlackClient.AssertExpectations(t)
+	assert.Equal(t, []string{"*Digest for <#CSOURCE2>*\nThe release is out."}, posted)
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func reactionEvent(reaction, ts string) string {
+	return fmt.Sprintf(`{"token":"verification-token","type":"event_callback","event":{"type":"reaction_added","user":"U123","reaction":%q,"item_user":"UBOT","item":{"type":"message","channel":"C123","ts":"1700000000.000100"},"event_ts":%q}}`, reaction, ts)
+}
+
+var customEmoji = map[string]string{
+	"shipit":      "https://emoji.slack-edge.com/T123/shipit/abc.png",
+	"partyparrot": "https://emoji.slack-edge.com/T123/partyparrot/def.gif",
+	"thumbs":      "alias:thumbsup",
+	"yay":         "alias:shipit",
+}
+
+func TestCustomEmojiReactions(t *testing.T) {
+	tests := []struct {
+		name       string
+		resolve    string
+		reaction   string
+		wantPrompt string
+	}{
+		{name: "Resolution disabled", reaction: "partyparrot", wantPrompt: "User reacted with :partyparrot: to my message"},
+		{name: "Standard emoji", resolve: "true", reaction: "tada", wantPrompt: "User reacted with :tada: to my message"},
+		{name: "Alias of a standard emoji", resolve: "true", reaction: "thumbs", wantPrompt: "User reacted with :thumbsup: to my message"},
+		{name: "Custom emoji with a meaning", resolve: "true", reaction: "shipit", wantPrompt: "User reacted with :shipit: (a custom emoji meaning approval to ship) to my message"},
+		{name: "Alias of a custom emoji with a meaning", resolve: "true", reaction: "yay", wantPrompt: "User reacted with :shipit: (a custom emoji meaning approval to ship) to my message"},
+		{name: "Decorative custom emoji", resolve: "true", reaction: "partyparrot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CUSTOM_EMOJI_RESOLVE", tt.resolve)
+			t.Setenv("CUSTOM_EMOJI_MEANINGS", "shipit=approval to ship")
+			handler, m := newTestHandler(t, "chat")
+
+			m.slack.On("GetEmoji").Return(customEmoji, nil)
+			m.llm.On("Generate", mock.Anything, mock.Anything).Return("Thanks!", nil)
+			m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000400", nil)
+
+			postEvent(t, handler, reactionEvent(tt.reaction, "1700000000.000300"))
+
+			if tt.wantPrompt == "" {
+				m.llm.AssertNotCalled(t, "Generate", mock.Anything, mock.Anything)
+				m.slack.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+				return
+			}
+			m.llm.AssertCalled(t, "Generate", tt.wantPrompt, mock.Anything)
+			if tt.resolve == "" {
+				m.slack.AssertNotCalled(t, "GetEmoji")
+			}
+		})
+	}
+}
+
+func TestCustomEmojiAreCachedUntilTheyChange(t *testing.T) {
+	t.Setenv("CUSTOM_EMOJI_RESOLVE", "true")
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("GetEmoji").Return(customEmoji, nil)
+	m.llm.On("Generate", mock.Anything, mock.Anything).Return("Thanks!", nil)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000400", nil)
+
+	postEvent(t, handler, reactionEvent("tada", "1700000000.000300"))
+	postEvent(t, handler, reactionEvent("tada", "1700000000.000301"))
+	m.slack.AssertNumberOfCalls(t, "GetEmoji", 1)
+
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"emoji_changed","subtype":"add","name":"newparrot","value":"https://emoji.slack-edge.com/T123/newparrot/ghi.gif","event_ts":"1700000000.000302"}}`)
+	postEvent(t, handler, reactionEvent("tada", "1700000000.000303"))
+	m.slack.AssertNumberOfCalls(t, "GetEmoji", 2)
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGeneratePromptLabelsSyntheticMessages(t *testing.T) {
+	t.Setenv("RAG_RESULTS", "1")
+	t.Setenv("NO_CONTEXT_BEHAVIOR", "note")
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "generate", mockVectorDBClient)
+
+	embedding := []float32{0.1, 0.2}
+	mockEmbedder.On("GetEmbedding", "When is the launch?").Return(embedding, nil)
+	mockVectorDBClient.On("SearchSimilar", mock.Anything, embedding, uint64(1)).Return([]vectordb.Message{
+		{ID: "1", Text: "Launch moved to Friday", UserID: "U2", Score: 0.9},
+	}, nil)
+
+	var prompt string
+	mockLLMClient.On("Generate", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		prompt = args.String(0)
+	}).Return("Friday.", nil)
+
+	_, err := cm.ProcessMessage("C1", nil, "When is the launch?", &slack.User{ID: "U1", Name: "alice"})
+	assert.NoError(t, err)
+
+	assert.Contains(t, prompt, "system: There is no earlier conversation")
+	assert.Contains(t, prompt, "context: Relevant messages from this workspace:")
+	assert.Contains(t, prompt, "U1|alice: When is the launch?")
+	assert.NotContains(t, prompt, "|: ")
+}
+
+func TestGeneratePromptCustomLabels(t *testing.T) {
+	t.Setenv("RAG_RESULTS", "1")
+	t.Setenv("GENERATE_CONTEXT_LABEL", "Background")
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "generate", mockVectorDBClient)
+
+	embedding := []float32{0.1, 0.2}
+	mockEmbedder.On("GetEmbedding", "When is the launch?").Return(embedding, nil)
+	mockVectorDBClient.On("SearchSimilar", mock.Anything, embedding, uint64(1)).Return([]vectordb.Message{
+		{ID: "1", Text: "Launch moved to Friday", UserID: "U2", Score: 0.9},
+	}, nil)
+
+	var prompt string
+	mockLLMClient.On("Generate", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		prompt = args.String(0)
+	}).Return("Friday.", nil)
+
+	_, err := cm.ProcessMessage("C1", nil, "When is the launch?", &slack.User{ID: "U1", Name: "alice"})
+	assert.NoError(t, err)
+
+	assert.Contains(t, prompt, "Background: Relevant messages from this workspace:")
+}
+
+func TestGeneratePromptNamesUnknownAuthorsByRole(t *testing.T) {
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "generate", nil)
+
+	var prompt string
+	mockLLMClient.On("Generate", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		prompt = args.String(0)
+	}).Return("Sure.", nil)
+
+	thread := []llm.Message{
+		{Role: "assistant", Content: "I posted the release notes", User: &llm.User{}},
+		{Role: "user", Content: "Thanks", User: &llm.User{SlackID: "U1", SlackName: "alice"}},
+	}
+	_, err := cm.ProcessMessage("C1", thread, "Link them?", &slack.User{ID: "U1", Name: "alice"})
+	assert.NoError(t, err)
+
+	assert.Contains(t, prompt, "assistant: I posted the release notes\n")
+	assert.Contains(t, prompt, "U1|alice: Thanks\n")
+	assert.NotContains(t, prompt, "|: ")
+}
@@ -0,0 +1,456 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testVerificationToken = "verification-token"
+	testBotUserID         = "UBOT"
+)
+
+// handlerMocks bundles the mocked dependencies of a handler under test
+type handlerMocks struct {
+	slack    *slackmocks.MockSlackClient
+	llm      *mocks.MockLLMClient
+	embedder *mocks.MockEmbedder
+	vectorDB *vectordbmocks.MockVectorDBClient
+}
+
+func newTestHandler(t *testing.T, llmMode string) (*slackinternal.BeeBrainSlackHandler, *handlerMocks) {
+	m := &handlerMocks{
+		slack:    &slackmocks.MockSlackClient{},
+		llm:      &mocks.MockLLMClient{},
+		embedder: &mocks.MockEmbedder{},
+		vectorDB: &vectordbmocks.MockVectorDBClient{},
+	}
+	m.slack.On("AuthTest").Return(&slack.AuthTestResponse{UserID: testBotUserID}, nil)
+
+	handler := slackinternal.NewBeeBrainSlackHandler(m.slack, m.llm, m.embedder, m.vectorDB, logrus.New(), "", testVerificationToken, llmMode)
+	assert.NotNil(t, handler)
+	return handler, m
+}
+
+// postedText returns the text of a message from the options passed to
+// PostMessage
+func postedText(t *testing.T, options []slack.MsgOption) string {
+	_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+	assert.NoError(t, err)
+	return values.Get("text")
+}
+
+// postEvent sends a raw Slack event payload through HandleSlackEvents
+func postEvent(t *testing.T, handler *slackinternal.BeeBrainSlackHandler, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, handler.HandleSlackEvents(e.NewContext(req, rec)))
+	return rec
+}
+
+func TestHandleURLVerification(t *testing.T) {
+	handler, _ := newTestHandler(t, "chat")
+
+	rec := postEvent(t, handler, `{"token":"verification-token","type":"url_verification","challenge":"abc123"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"challenge":"abc123"}`, rec.Body.String())
+}
+
+func TestHandleEventRejectsOversizedBody(t *testing.T) {
+	t.Setenv("MAX_REQUEST_BODY_BYTES", "128")
+	handler, m := newTestHandler(t, "chat")
+
+	body := fmt.Sprintf(`{"token":"verification-token","type":"url_verification","challenge":%q}`, strings.Repeat("a", 256))
+	rec := postEvent(t, handler, body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	m.slack.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+
+	// Bodies within the limit are handled as usual
+	rec = postEvent(t, handler, `{"token":"verification-token","type":"url_verification","challenge":"abc123"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandleEventRejectsInvalidToken(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	rec := postEvent(t, handler, `{"token":"wrong-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"hi","ts":"1.0","channel":"C123","event_ts":"1.0"}}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	m.slack.AssertNotCalled(t, "AddReaction", mock.Anything, mock.Anything)
+	m.llm.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+}
+
+func TestHandleAppMention(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	item := slack.ItemRef{Channel: "C123", Timestamp: "1700000000.000100"}
+	m.slack.On("AddReaction", "eyes", item).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", item).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.llm.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		last := messages[len(messages)-1]
+		return last.Content == "<@UBOT> what is BeeBrain?" && last.User.SlackName == "alice"
+	}), mock.Anything).Return("A Slack bot.", nil)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000200", nil)
+
+	rec := postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> what is BeeBrain?","ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000100"}}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	m.slack.AssertExpectations(t)
+	m.llm.AssertExpectations(t)
+}
+
+func TestHandleAppMentionRepliesToTheMention(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		threadTS string
+	}{
+		{
+			name:     "top-level mention starts a thread",
+			event:    `{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> hi","ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000100"}}`,
+			threadTS: "1700000000.000100",
+		},
+		{
+			name:     "mention in a thread replies in the thread",
+			event:    `{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> hi","ts":"1700000000.000300","thread_ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000300"}}`,
+			threadTS: "1700000000.000100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, m := newTestHandler(t, "chat")
+
+			m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+			m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+			m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+			m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+			m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+			m.llm.On("Chat", mock.Anything, mock.Anything).Return("Hello!", nil)
+
+			var options []slack.MsgOption
+			m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+				options = args.Get(1).([]slack.MsgOption)
+			}).Return("C123", "1700000000.000400", nil)
+
+			postEvent(t, handler, tt.event)
+
+			_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.threadTS, values.Get("thread_ts"))
+		})
+	}
+}
+
+func TestHandleMessageFollowsThreadAfterMention(t *testing.T) {
+	t.Setenv("THREAD_FOLLOW_WINDOW", "100ms")
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetUserInfo", "UBOT").Return(&slack.User{ID: "UBOT", Name: "beebrain"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+	m.embedder.On("GetEmbedding", mock.Anything).Return([]float32{0.1, 0.2}, nil)
+	m.vectorDB.On("StoreMessage", mock.Anything).Return(nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("Sure.", nil)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000900", nil)
+
+	message := func(ts, threadTS, text string) string {
+		return fmt.Sprintf(`{"token":"verification-token","type":"event_callback","event":{"type":"message","user":"U123","text":%q,"ts":%q,"thread_ts":%q,"channel":"C123","event_ts":%q}}`, text, ts, threadTS, ts)
+	}
+
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> can you help?","ts":"1700000000.000200","thread_ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000200"}}`)
+	m.slack.AssertNumberOfCalls(t, "PostMessage", 1)
+
+	// Follow-ups in the thread are answered without a mention
+	postEvent(t, handler, message("1700000000.000300", "1700000000.000100", "What about staging?"))
+	m.slack.AssertNumberOfCalls(t, "PostMessage", 2)
+
+	// Other threads still need a mention
+	postEvent(t, handler, message("1700000000.000400", "1700000000.000050", "What about staging?"))
+	m.slack.AssertNumberOfCalls(t, "PostMessage", 2)
+
+	// The thread is no longer followed once the window passes
+	time.Sleep(150 * time.Millisecond)
+	postEvent(t, handler, message("1700000000.000500", "1700000000.000100", "And production?"))
+	m.slack.AssertNumberOfCalls(t, "PostMessage", 2)
+}
+
+func TestHandleMessageIgnoresContentlessFollowUps(t *testing.T) {
+	t.Setenv("THREAD_FOLLOW_WINDOW", "1m")
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetUserInfo", "UBOT").Return(&slack.User{ID: "UBOT", Name: "beebrain"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+	m.embedder.On("GetEmbedding", mock.Anything).Return([]float32{0.1, 0.2}, nil)
+	m.vectorDB.On("StoreMessage", mock.Anything).Return(nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("Sure.", nil)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000900", nil)
+
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> can you help?","ts":"1700000000.000200","thread_ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000200"}}`)
+	m.slack.AssertNumberOfCalls(t, "PostMessage", 1)
+
+	// Emoji replies in the followed thread get no canned reply
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"message","user":"U123","text":"👍","ts":"1700000000.000300","thread_ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000300"}}`)
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"message","user":"U123","text":":+1:","ts":"1700000000.000400","thread_ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000400"}}`)
+	m.slack.AssertNumberOfCalls(t, "PostMessage", 1)
+
+	// A mention with nothing to answer still does
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> :+1:","ts":"1700000000.000500","thread_ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000500"}}`)
+	m.slack.AssertNumberOfCalls(t, "PostMessage", 2)
+}
+
+func TestHandleAppMentionLLMErrorPostsApology(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("", assert.AnError)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000200", nil)
+
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> hi","ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000100"}}`)
+
+	m.slack.AssertCalled(t, "PostMessage", "C123", mock.Anything)
+}
+
+func TestHandleReactionAddedSkipsNonBotMessages(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	rec := postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"reaction_added","user":"U123","reaction":"thumbsup","item_user":"U999","item":{"type":"message","channel":"C123","ts":"1700000000.000100"},"event_ts":"1700000000.000300"}}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	m.llm.AssertNotCalled(t, "Generate", mock.Anything, mock.Anything)
+	m.slack.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+}
+
+func TestHandleReactionAddedOnBotMessage(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	m.llm.On("Generate", "User reacted with :thumbsup: to my message", mock.Anything).Return("Glad it helped!", nil)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000400", nil)
+
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"reaction_added","user":"U123","reaction":"thumbsup","item_user":"UBOT","item":{"type":"message","channel":"C123","ts":"1700000000.000100"},"event_ts":"1700000000.000300"}}`)
+
+	m.llm.AssertExpectations(t)
+	m.slack.AssertExpectations(t)
+}
+
+func TestHandleReactionAddedWhitelist(t *testing.T) {
+	tests := []struct {
+		name      string
+		reaction  string
+		processed bool
+	}{
+		{name: "Whitelisted reaction", reaction: "thumbsup", processed: true},
+		{name: "Whitelisted reaction with skin tone", reaction: "thumbsup::skin-tone-3", processed: true},
+		{name: "Other reaction", reaction: "eyes", processed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REACTION_WHITELIST", "thumbsup, :question:")
+			handler, m := newTestHandler(t, "chat")
+
+			m.llm.On("Generate", mock.Anything, mock.Anything).Return("Glad it helped!", nil)
+			m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000400", nil)
+
+			postEvent(t, handler, fmt.Sprintf(`{"token":"verification-token","type":"event_callback","event":{"type":"reaction_added","user":"U123","reaction":%q,"item_user":"UBOT","item":{"type":"message","channel":"C123","ts":"1700000000.000100"},"event_ts":"1700000000.000300"}}`, tt.reaction))
+
+			if tt.processed {
+				m.llm.AssertNumberOfCalls(t, "Generate", 1)
+				m.slack.AssertNumberOfCalls(t, "PostMessage", 1)
+			} else {
+				m.llm.AssertNotCalled(t, "Generate", mock.Anything, mock.Anything)
+				m.slack.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestHandleAppMentionEmptyResponsePostsFallback(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("", nil)
+	m.slack.On("PostMessage", "C123", mock.MatchedBy(func(options []slack.MsgOption) bool {
+		return postedText(t, options) == "Sorry, I couldn't come up with an answer to that. Could you try rephrasing your question?"
+	})).Return("C123", "1700000000.000200", nil)
+
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> hi","ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000100"}}`)
+
+	m.slack.AssertExpectations(t)
+}
+
+func TestHandleThreadBroadcastIndexesAndAnswersInThread(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	text := "<@UBOT> can you sum this thread up?"
+	embedding := []float32{0.1, 0.2}
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetUserInfo", "UBOT").Return(&slack.User{ID: "UBOT", Name: "beebrain"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.embedder.On("GetEmbedding", "@beebrain can you sum this thread up?").Return(embedding, nil)
+	m.vectorDB.On("StoreMessage", mock.MatchedBy(func(msg vectordb.Message) bool {
+		return msg.Text == "@beebrain can you sum this thread up?" && msg.RawText == text &&
+			msg.MessageTS == "1700000000.000200" && msg.ThreadID == "1700000000.000100"
+	})).Return(nil)
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetConversationReplies", mock.MatchedBy(func(params *slack.GetConversationRepliesParameters) bool {
+		return params.Timestamp == "1700000000.000100"
+	})).Return([]slack.Message{}, false, "", nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("Here's the gist.", nil)
+	m.slack.On("PostMessage", "C123", mock.MatchedBy(func(options []slack.MsgOption) bool {
+		_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+		return err == nil && values.Get("thread_ts") == "1700000000.000100"
+	})).Return("C123", "1700000000.000300", nil)
+
+	body := `{"token":"verification-token","type":"event_callback","event":{"type":"message","subtype":"thread_broadcast","user":"U123","text":"<@UBOT> can you sum this thread up?","ts":"1700000000.000200","thread_ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000200"}}`
+	postEvent(t, handler, body)
+
+	// The app_mention event Slack sends for the same message is not answered twice
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> can you sum this thread up?","ts":"1700000000.000200","thread_ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000200"}}`)
+
+	m.vectorDB.AssertExpectations(t)
+	m.slack.AssertExpectations(t)
+	m.slack.AssertNumberOfCalls(t, "PostMessage", 1)
+}
+
+func TestHandleThreadBroadcastWithoutMentionIsOnlyIndexed(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.embedder.On("GetEmbedding", "Shipped, see thread").Return([]float32{0.1, 0.2}, nil)
+	m.vectorDB.On("StoreMessage", mock.Anything).Return(nil)
+
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"message","subtype":"thread_broadcast","user":"U123","text":"Shipped, see thread","ts":"1700000000.000200","thread_ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000200"}}`)
+
+	m.vectorDB.AssertNumberOfCalls(t, "StoreMessage", 1)
+	m.llm.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+	m.slack.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+}
+
+func TestHandleMessageStartingWithTriggerWordIsAnswered(t *testing.T) {
+	t.Setenv("TRIGGER_WORDS", "beebrain, bb")
+	handler, m := newTestHandler(t, "chat")
+
+	item := slack.ItemRef{Channel: "C123", Timestamp: "1700000000.000100"}
+	m.slack.On("AddReaction", "eyes", item).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", item).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.embedder.On("GetEmbedding", "BeeBrain, what's up?").Return([]float32{0.1, 0.2}, nil)
+	m.vectorDB.On("StoreMessage", mock.Anything).Return(nil)
+	m.llm.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return messages[len(messages)-1].Content == "BeeBrain, what's up?"
+	}), mock.Anything).Return("Not much.", nil)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000200", nil)
+
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"message","user":"U123","text":"BeeBrain, what's up?","ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000100"}}`)
+
+	m.vectorDB.AssertNumberOfCalls(t, "StoreMessage", 1)
+	m.slack.AssertExpectations(t)
+	m.llm.AssertExpectations(t)
+}
+
+func TestHandleMessageWithoutTriggerWordIsOnlyIndexed(t *testing.T) {
+	t.Setenv("TRIGGER_WORDS", "beebrain")
+
+	for i, text := range []string{
+		"I asked beebrain yesterday",
+		"beebrains are the best",
+		"hello team",
+	} {
+		handler, m := newTestHandler(t, "chat")
+
+		m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+		m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+		m.embedder.On("GetEmbedding", text).Return([]float32{0.1, 0.2}, nil)
+		m.vectorDB.On("StoreMessage", mock.Anything).Return(nil)
+
+		postEvent(t, handler, fmt.Sprintf(`{"token":"verification-token","type":"event_callback","event":{"type":"message","user":"U123","text":%q,"ts":"1700000000.00010%d","channel":"C123","event_ts":"1700000000.00010%d"}}`, text, i, i))
+
+		m.vectorDB.AssertNumberOfCalls(t, "StoreMessage", 1)
+		m.llm.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+		m.slack.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+	}
+}
+
+func TestHandleEventsBoundedUnderBurst(t *testing.T) {
+	t.Setenv("EVENT_WORKERS", "1")
+	t.Setenv("EVENT_QUEUE_SIZE", "2")
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000001.000000", nil)
+
+	// The first answer blocks the only worker until the burst is over
+	started := make(chan struct{}, 5)
+	release := make(chan struct{})
+	m.llm.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		started <- struct{}{}
+		<-release
+	}).Return("Hello!", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.StartEventWorkers(ctx)
+
+	mention := func(i int) string {
+		return fmt.Sprintf(`{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> hi","ts":"1700000000.00010%d","thread_ts":"1700000000.000001","channel":"C123","event_ts":"1700000000.00010%d"}}`, i, i)
+	}
+
+	// Wait for the worker to pick up the first mention so the queue is empty
+	assert.Equal(t, http.StatusOK, postEvent(t, handler, mention(0)).Code)
+	<-started
+
+	// Two more fit in the queue, the rest are dropped but still answered
+	for i := 1; i <= 4; i++ {
+		assert.Equal(t, http.StatusOK, postEvent(t, handler, mention(i)).Code)
+	}
+	assert.Equal(t, int64(2), handler.DroppedEvents())
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return len(started) == 2
+	}, time.Second, 10*time.Millisecond)
+	m.llm.AssertNumberOfCalls(t, "Chat", 3)
+}
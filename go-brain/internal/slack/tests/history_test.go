@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// historyPage is a page of channel history, newest first, followed by the
+// page at next unless next is empty
+func historyPage(next string, texts ...string) *slack.GetConversationHistoryResponse {
+	page := &slack.GetConversationHistoryResponse{HasMore: next != ""}
+	page.ResponseMetaData.NextCursor = next
+	for _, text := range texts {
+		page.Messages = append(page.Messages, slack.Message{Msg: slack.Msg{Text: text, User: "U123"}})
+	}
+	return page
+}
+
+func TestGetConversationSinceQueriesBoundedWindow(t *testing.T) {
+	oldest := time.Date(2024, 3, 9, 9, 0, 0, 0, time.UTC)
+	latest := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		latest     time.Time
+		wantLatest string
+	}{
+		{name: "Both bounds", latest: latest, wantLatest: fmt.Sprintf("%d.000000", latest.Unix())},
+		{name: "Up to now", wantLatest: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSlackClient := &slackmocks.MockSlackClient{}
+			cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+
+			mockSlackClient.On("GetConversationHistory", mock.MatchedBy(func(params *slack.GetConversationHistoryParameters) bool {
+				return params.ChannelID == "C123" &&
+					params.Oldest == fmt.Sprintf("%d.000000", oldest.Unix()) &&
+					params.Latest == tt.wantLatest &&
+					params.Cursor == ""
+			})).Return(historyPage("", "Second", "First"), nil).Once()
+
+			messages, err := cm.GetConversationSince("C123", oldest, tt.latest)
+			assert.NoError(t, err)
+			mockSlackClient.AssertExpectations(t)
+			if assert.Len(t, messages, 2) {
+				assert.Equal(t, "First", messages[0].Content)
+				assert.Equal(t, "Second", messages[1].Content)
+			}
+		})
+	}
+}
+
+func TestGetConversationSincePaginatesWithinWindow(t *testing.T) {
+	oldest := time.Date(2024, 3, 9, 9, 0, 0, 0, time.UTC)
+	latest := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+
+	page := func(cursor string) interface{} {
+		return mock.MatchedBy(func(params *slack.GetConversationHistoryParameters) bool {
+			// Every page keeps the bounds of the window
+			return params.ChannelID == "C123" &&
+				params.Oldest == fmt.Sprintf("%d.000000", oldest.Unix()) &&
+				params.Latest == fmt.Sprintf("%d.000000", latest.Unix()) &&
+				params.Cursor == cursor
+		})
+	}
+	mockSlackClient.On("GetConversationHistory", page("")).Return(historyPage("page2", "Fourth", "Third"), nil).Once()
+	mockSlackClient.On("GetConversationHistory", page("page2")).Return(historyPage("page3", "Second"), nil).Once()
+	mockSlackClient.On("GetConversationHistory", page("page3")).Return(historyPage("", "First"), nil).Once()
+
+	messages, err := cm.GetConversationSince("C123", oldest, latest)
+	assert.NoError(t, err)
+	mockSlackClient.AssertExpectations(t)
+
+	var texts []string
+	for _, msg := range messages {
+		texts = append(texts, msg.Content)
+	}
+	assert.Equal(t, []string{"First", "Second", "Third", "Fourth"}, texts)
+}
+
+func TestGetLastHourConversationStopsAtCap(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+
+	texts := make([]string, 60)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("Message %d", i)
+	}
+	mockSlackClient.On("GetConversationHistory", mock.MatchedBy(func(params *slack.GetConversationHistoryParameters) bool {
+		return params.Cursor == "" && params.Limit == 100 && params.Latest == ""
+	})).Return(historyPage("page2", texts...), nil).Once()
+	mockSlackClient.On("GetConversationHistory", mock.MatchedBy(func(params *slack.GetConversationHistoryParameters) bool {
+		return params.Cursor == "page2" && params.Limit == 40
+	})).Return(historyPage("page3", texts[:40]...), nil).Once()
+
+	// The context is capped at the 100 most recent messages
+	messages, err := cm.GetLastHourConversation("C123")
+	assert.NoError(t, err)
+	assert.Len(t, messages, 100)
+	mockSlackClient.AssertExpectations(t)
+}
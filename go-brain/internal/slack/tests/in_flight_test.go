@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mentionDelivery is an app_mention of the same message, delivered in the
+// event with eventTS
+func mentionDelivery(eventTS string) string {
+	return fmt.Sprintf(`{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> when do we deploy?","ts":"1700000000.000100","channel":"C123","event_ts":%q}}`, eventTS)
+}
+
+func TestConcurrentDuplicateMentionsShareOneAnswer(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   string
+		wantCalls int
+	}{
+		{name: "In-flight dedup", wantCalls: 1},
+		{name: "In-flight dedup disabled", enabled: "false", wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DEDUP_IN_FLIGHT", tt.enabled)
+			handler, m := newTestHandler(t, "chat")
+
+			arrived := make(chan struct{}, 2)
+			started := make(chan struct{}, 2)
+			release := make(chan struct{})
+			// Every delivery adds the reaction before it is answered
+			m.slack.On("AddReaction", "eyes", mock.Anything).Run(func(args mock.Arguments) {
+				arrived <- struct{}{}
+			}).Return(nil)
+			m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+			m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+			m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+			m.llm.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				started <- struct{}{}
+				<-release
+			}).Return("On Tuesdays.", nil)
+			m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000400", nil)
+			// Answering the mention again updates the answer posted first
+			m.slack.On("UpdateMessage", "C123", "1700000000.000400", mock.Anything).Return("C123", "1700000000.000400", "", nil)
+
+			var wg sync.WaitGroup
+			deliver := func(eventTS string) {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					postEvent(t, handler, mentionDelivery(eventTS))
+				}()
+			}
+			deliver("1700000000.000200")
+			<-arrived
+			<-started
+			// The duplicate arrives while the first delivery is being answered
+			deliver("1700000000.000201")
+			<-arrived
+			if tt.wantCalls > 1 {
+				<-started
+			} else {
+				assert.Never(t, func() bool { return len(started) > 0 }, 50*time.Millisecond, 5*time.Millisecond)
+			}
+			close(release)
+			wg.Wait()
+
+			m.llm.AssertNumberOfCalls(t, "Chat", tt.wantCalls)
+			m.slack.AssertNumberOfCalls(t, "PostMessage", 1)
+			m.slack.AssertNumberOfCalls(t, "UpdateMessage", tt.wantCalls-1)
+		})
+	}
+}
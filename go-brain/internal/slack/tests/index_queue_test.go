@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"beebrain/internal/vectordb"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleMessageIndexesInBackground(t *testing.T) {
+	t.Setenv("INDEX_QUEUE_SIZE", "10")
+	t.Setenv("INDEX_WORKERS", "2")
+	handler, m := newTestHandler(t, "chat")
+
+	stored := make(chan string, 3)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.embedder.On("GetEmbedding", mock.Anything).Return([]float32{0.1, 0.2}, nil)
+	m.vectorDB.On("StoreMessage", mock.Anything).Run(func(args mock.Arguments) {
+		stored <- args.Get(0).(vectordb.Message).MessageTS
+	}).Return(nil)
+
+	// Events are only queued while no worker runs
+	for _, ts := range []string{"1700000000.000100", "1700000000.000200", "1700000000.000300"} {
+		postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"message","user":"U123","text":"Deploys happen on Fridays","ts":"`+ts+`","channel":"C123","event_ts":"`+ts+`"}}`)
+	}
+	m.vectorDB.AssertNotCalled(t, "StoreMessage", mock.Anything)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handler.StartIndexQueue(ctx)
+
+	var timestamps []string
+	for len(timestamps) < 3 {
+		select {
+		case ts := <-stored:
+			timestamps = append(timestamps, ts)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("only %d of 3 queued messages were indexed", len(timestamps))
+		}
+	}
+	assert.ElementsMatch(t, []string{"1700000000.000100", "1700000000.000200", "1700000000.000300"}, timestamps)
+}
@@ -0,0 +1,277 @@
+package tests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testSigningSecret = "signing-secret"
+
+// buttonClick is the interaction payload of a response button clicked by U123
+func buttonClick(actionID, threadTimestamp string) string {
+	payload := fmt.Sprintf(`{"type":"block_actions","user":{"id":"U123"},"channel":{"id":"C123"},"message":{"ts":"1700000000.000300","thread_ts":%q},"actions":[{"action_id":%q,"block_id":"response_actions","value":%q,"type":"button"}]}`,
+		threadTimestamp, actionID, threadTimestamp)
+	return url.Values{"payload": {payload}}.Encode()
+}
+
+// postInteraction sends an interaction body through HandleInteractions,
+// signed with secret
+func postInteraction(t *testing.T, handler *slackinternal.BeeBrainSlackHandler, body, secret string) *httptest.ResponseRecorder {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/interactions", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, handler.HandleInteractions(e.NewContext(req, rec)))
+	return rec
+}
+
+func newInteractiveHandler(t *testing.T) (*slackinternal.BeeBrainSlackHandler, *handlerMocks) {
+	m := &handlerMocks{
+		slack:    &slackmocks.MockSlackClient{},
+		llm:      &mocks.MockLLMClient{},
+		embedder: &mocks.MockEmbedder{},
+		vectorDB: &vectordbmocks.MockVectorDBClient{},
+	}
+	m.slack.On("AuthTest").Return(&slack.AuthTestResponse{UserID: testBotUserID}, nil)
+
+	handler := slackinternal.NewBeeBrainSlackHandler(m.slack, m.llm, m.embedder, m.vectorDB, logrus.New(), testSigningSecret, testVerificationToken, "chat")
+	return handler, m
+}
+
+func TestParseInteraction(t *testing.T) {
+	callback, err := slackinternal.ParseInteraction([]byte(buttonClick(slackinternal.ActionSummarizeThread, "1700000000.000100")))
+	assert.NoError(t, err)
+	assert.Equal(t, slack.InteractionTypeBlockActions, callback.Type)
+	assert.Equal(t, "C123", callback.Channel.ID)
+	assert.Equal(t, "U123", callback.User.ID)
+	assert.Len(t, callback.ActionCallback.BlockActions, 1)
+	assert.Equal(t, slackinternal.ActionSummarizeThread, callback.ActionCallback.BlockActions[0].ActionID)
+	assert.Equal(t, "1700000000.000100", callback.ActionCallback.BlockActions[0].Value)
+
+	_, err = slackinternal.ParseInteraction([]byte("payload=not-json"))
+	assert.Error(t, err)
+	_, err = slackinternal.ParseInteraction([]byte("other=1"))
+	assert.Error(t, err)
+}
+
+func TestHandleInteractionsRejectsBadSignature(t *testing.T) {
+	handler, m := newInteractiveHandler(t)
+
+	rec := postInteraction(t, handler, buttonClick(slackinternal.ActionSummarizeThread, "1700000000.000100"), "wrong-secret")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	m.slack.AssertNotCalled(t, "GetConversationReplies", mock.Anything)
+	m.slack.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+}
+
+func TestHandleInteractionsSummarizesThread(t *testing.T) {
+	handler, m := newInteractiveHandler(t)
+
+	m.slack.On("GetConversationReplies", mock.MatchedBy(func(params *slack.GetConversationRepliesParameters) bool {
+		return params.ChannelID == "C123" && params.Timestamp == "1700000000.000100"
+	})).Return([]slack.Message{
+		{Msg: slack.Msg{User: "U123", Text: "Should we ship on Friday?", Timestamp: "1700000000.000100"}},
+		{Msg: slack.Msg{User: "U456", Text: "Only with a rollback plan", Timestamp: "1700000000.000200"}},
+	}, false, "", nil)
+	m.llm.On("Summarize", mock.MatchedBy(func(messages []llm.Message) bool {
+		return len(messages) == 2
+	}), mock.Anything).Return("Friday ships need a rollback plan.", nil)
+
+	posted := make(chan []slack.MsgOption, 1)
+	m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+		posted <- args.Get(1).([]slack.MsgOption)
+	}).Return("C123", "1700000000.000400", nil)
+
+	rec := postInteraction(t, handler, buttonClick(slackinternal.ActionSummarizeThread, "1700000000.000100"), testSigningSecret)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	options := waitForPost(t, posted)
+	_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+	assert.NoError(t, err)
+	assert.Equal(t, "Friday ships need a rollback plan.", values.Get("text"))
+	assert.Equal(t, "1700000000.000100", values.Get("thread_ts"))
+}
+
+func TestHandleInteractionsShowsSources(t *testing.T) {
+	t.Setenv("RAG_RESULTS", "2")
+	handler, m := newInteractiveHandler(t)
+
+	// The question is the last one asked before the clicked response
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{
+		{Msg: slack.Msg{User: "U123", Text: "When do we deploy?", Timestamp: "1700000000.000100"}},
+		{Msg: slack.Msg{BotID: "B1", Text: "On Fridays [1]", Timestamp: "1700000000.000300"}},
+		{Msg: slack.Msg{User: "U123", Text: "Thanks!", Timestamp: "1700000000.000500"}},
+	}, false, "", nil)
+
+	embedding := []float32{0.1, 0.2}
+	m.embedder.On("GetEmbedding", "When do we deploy?").Return(embedding, nil)
+	m.vectorDB.On("SearchSimilar", mock.Anything, embedding, uint64(2)).Return([]vectordb.Message{
+		{Text: "Deploys happen on Fridays", UserID: "U1", ChannelID: "C1"},
+	}, nil)
+	posted := make(chan []slack.MsgOption, 1)
+	m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+		posted <- args.Get(1).([]slack.MsgOption)
+	}).Return("C123", "1700000000.000400", nil)
+
+	rec := postInteraction(t, handler, buttonClick(slackinternal.ActionShowSources, "1700000000.000100"), testSigningSecret)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "*Sources*\n[1] <@U1>: Deploys happen on Fridays", postedText(t, waitForPost(t, posted)))
+}
+
+func TestHandleInteractionsAnswersBeforeTheAction(t *testing.T) {
+	handler, m := newInteractiveHandler(t)
+
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{
+		{Msg: slack.Msg{User: "U123", Text: "Should we ship on Friday?", Timestamp: "1700000000.000100"}},
+	}, false, "", nil)
+	release := make(chan struct{})
+	m.llm.On("Summarize", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		<-release
+	}).Return("Friday ships need a rollback plan.", nil)
+	posted := make(chan []slack.MsgOption, 1)
+	m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+		posted <- args.Get(1).([]slack.MsgOption)
+	}).Return("C123", "1700000000.000400", nil)
+
+	// Slack gets its answer while the summary is still being written
+	rec := postInteraction(t, handler, buttonClick(slackinternal.ActionSummarizeThread, "1700000000.000100"), testSigningSecret)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, posted)
+
+	close(release)
+	assert.Equal(t, "Friday ships need a rollback plan.", postedText(t, waitForPost(t, posted)))
+}
+
+func TestHandleInteractionsSkipsActionsAfterShutdown(t *testing.T) {
+	handler, m := newInteractiveHandler(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	handler.SetContext(ctx)
+	cancel()
+
+	rec := postInteraction(t, handler, buttonClick(slackinternal.ActionSummarizeThread, "1700000000.000100"), testSigningSecret)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	m.slack.AssertNotCalled(t, "GetConversationReplies", mock.Anything)
+	m.llm.AssertNotCalled(t, "Summarize", mock.Anything, mock.Anything)
+}
+
+// waitForPost returns the options of the message posted on posted, failing
+// the test when nothing is posted within a second
+func waitForPost(t *testing.T, posted <-chan []slack.MsgOption) []slack.MsgOption {
+	t.Helper()
+	select {
+	case options := <-posted:
+		return options
+	case <-time.After(time.Second):
+		t.Fatal("nothing was posted")
+		return nil
+	}
+}
+
+func TestPostResponseAttachesButtonsInThreads(t *testing.T) {
+	t.Setenv("RESPONSE_BUTTONS", "true")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	var options []slack.MsgOption
+	mockSlackClient.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+		options = args.Get(1).([]slack.MsgOption)
+	}).Return("C123", "1700000000.000200", nil)
+
+	assert.NoError(t, cm.PostResponse("C123", "Here you go", "1700000000.000100"))
+	_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+	assert.NoError(t, err)
+	assert.Equal(t, "Here you go", values.Get("text"))
+	blocks := values.Get("blocks")
+	assert.Contains(t, blocks, slackinternal.ActionSummarizeThread)
+	// Without retrieval there are no sources to show
+	assert.NotContains(t, blocks, slackinternal.ActionShowSources)
+
+	// Top-level responses have no thread to act on
+	assert.NoError(t, cm.PostResponse("C123", "Hello", ""))
+	_, values, err = slack.UnsafeApplyMsgOptions("", "", "", options...)
+	assert.NoError(t, err)
+	assert.Empty(t, values.Get("blocks"))
+}
+
+func TestPostResponseFooter(t *testing.T) {
+	const footer = "React :+1: or :-1: to rate this answer"
+
+	tests := []struct {
+		name       string
+		footer     string
+		buttons    string
+		threadTS   string
+		wantBlocks []string
+	}{
+		{name: "No footer by default", footer: "", threadTS: "1700000000.000100", wantBlocks: nil},
+		{name: "Footer under a top-level response", footer: footer, wantBlocks: []string{"section", "context"}},
+		{name: "Footer under the buttons", footer: footer, buttons: "true", threadTS: "1700000000.000100", wantBlocks: []string{"section", "actions", "context"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RESPONSE_FOOTER", tt.footer)
+			t.Setenv("RESPONSE_BUTTONS", tt.buttons)
+
+			mockSlackClient := &slackmocks.MockSlackClient{}
+			cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+			var options []slack.MsgOption
+			mockSlackClient.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+				options = args.Get(1).([]slack.MsgOption)
+			}).Return("C123", "1700000000.000200", nil)
+
+			assert.NoError(t, cm.PostResponse("C123", "Here you go", tt.threadTS))
+			_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+			assert.NoError(t, err)
+			// The footer is only in the blocks, the text stays the answer
+			assert.Equal(t, "Here you go", values.Get("text"))
+
+			if tt.wantBlocks == nil {
+				assert.Empty(t, values.Get("blocks"))
+				return
+			}
+			var blocks slack.Blocks
+			assert.NoError(t, json.Unmarshal([]byte(values.Get("blocks")), &blocks))
+			types := make([]string, len(blocks.BlockSet))
+			for i, block := range blocks.BlockSet {
+				types[i] = string(block.BlockType())
+			}
+			assert.Equal(t, tt.wantBlocks, types)
+
+			context := blocks.BlockSet[len(blocks.BlockSet)-1].(*slack.ContextBlock)
+			assert.Equal(t, footer, context.ContextElements.Elements[0].(*slack.TextBlockObject).Text)
+		})
+	}
+}
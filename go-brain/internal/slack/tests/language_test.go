@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLanguageDirectiveSetsAnswerLanguage(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      string
+		text         string
+		wantQuestion string
+		wantPrompt   string
+	}{
+		{
+			name:         "Trailing directive",
+			enabled:      "true",
+			text:         "How do deploys work? (in Spanish)",
+			wantQuestion: "How do deploys work?",
+			wantPrompt:   "Answer in Spanish, whatever language the question is asked in.",
+		},
+		{
+			name:         "Longer directive",
+			enabled:      "true",
+			text:         "Wie funktionieren Deploys? (answer in Brazilian Portuguese)",
+			wantQuestion: "Wie funktionieren Deploys?",
+			wantPrompt:   "Answer in Brazilian Portuguese, whatever language the question is asked in.",
+		},
+		{
+			name:         "Parenthesis in the middle",
+			enabled:      "true",
+			text:         "Do deploys (in staging) need approval?",
+			wantQuestion: "Do deploys (in staging) need approval?",
+		},
+		{
+			name:         "Trailing parenthesis that isn't a language",
+			enabled:      "true",
+			text:         "Should I run the migration first? (in staging)",
+			wantQuestion: "Should I run the migration first? (in staging)",
+		},
+		{
+			name:         "Trailing programming language",
+			enabled:      "true",
+			text:         "How do I sort a map? (in Go)",
+			wantQuestion: "How do I sort a map? (in Go)",
+		},
+		{
+			name:         "Directives disabled",
+			enabled:      "false",
+			text:         "How do deploys work? (in Spanish)",
+			wantQuestion: "How do deploys work? (in Spanish)",
+		},
+		{
+			name:         "Directives off by default",
+			text:         "How do deploys work? (in Spanish)",
+			wantQuestion: "How do deploys work? (in Spanish)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LANGUAGE_DIRECTIVES", tt.enabled)
+			t.Setenv("RAG_RESULTS", "3")
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+			// Retrieval searches for the question without the directive
+			mockEmbedder.On("GetEmbedding", tt.wantQuestion).Return([]float32{0.1, 0.2}, nil)
+			mockVectorDBClient.On("SearchSimilar", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+			var sent []llm.Message
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				sent = args.Get(0).([]llm.Message)
+			}).Return("Así funcionan.", nil)
+
+			_, err := cm.ProcessMessage("C1", nil, tt.text, &slack.User{ID: "U1", Name: "alice"})
+			assert.NoError(t, err)
+			mockEmbedder.AssertExpectations(t)
+
+			question := sent[len(sent)-1]
+			assert.Equal(t, tt.wantQuestion, question.Content)
+
+			var instructions []string
+			for _, msg := range sent[:len(sent)-1] {
+				if msg.Role == "system" {
+					instructions = append(instructions, msg.Content)
+				}
+			}
+			if tt.wantPrompt == "" {
+				assert.Empty(t, instructions)
+			} else {
+				assert.Equal(t, []string{tt.wantPrompt}, instructions)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const linkedPage = `<html>
+<head><title>Deploy guide</title><style>body { color: red; }</style></head>
+<body>
+<nav>Home | Docs</nav>
+<h1>Deploying</h1>
+<p>We deploy   on
+Fridays with Argo.</p>
+<script>track()</script>
+</body>
+</html>`
+
+func newLinkManager(t *testing.T) (*slackinternal.ConversationManager, *slackmocks.MockLinkFetcher, *mocks.MockEmbedder, *vectordbmocks.MockVectorDBClient) {
+	t.Setenv("LINK_DOMAINS", "acme.com")
+
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	mockFetcher := &slackmocks.MockLinkFetcher{}
+
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, &mocks.MockLLMClient{}, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+	cm.SetLinkFetcher(mockFetcher)
+	return cm, mockFetcher, mockEmbedder, mockVectorDBClient
+}
+
+func TestIndexLinkStoresPageText(t *testing.T) {
+	cm, mockFetcher, mockEmbedder, mockVectorDBClient := newLinkManager(t)
+
+	mockFetcher.On("Fetch", "https://docs.acme.com/robots.txt").Return([]byte("User-agent: *\nDisallow: /private\n"), "text/plain", nil)
+	mockFetcher.On("Fetch", "https://docs.acme.com/deploy").Return([]byte(linkedPage), "text/html; charset=utf-8", nil)
+
+	text := "Deploy guide Deploying We deploy on Fridays with Argo."
+	embedding := []float32{0.1, 0.2}
+	mockEmbedder.On("GetEmbedding", text).Return(embedding, nil)
+	mockVectorDBClient.On("StoreMessage", mock.MatchedBy(func(msg vectordb.Message) bool {
+		return msg.Text == text &&
+			msg.UserID == "U1" &&
+			msg.ChannelID == "C1" &&
+			msg.MessageTS == "1700000000.000100" &&
+			msg.Timestamp == "2023-11-14T22:13:20Z" &&
+			msg.Metadata["source_url"] == "https://docs.acme.com/deploy" &&
+			assert.ObjectsAreEqual(embedding, msg.Embedding)
+	})).Return(nil)
+
+	err := cm.IndexLink("https://docs.acme.com/deploy", "C1", "U1", "1700000000.000100", "")
+	assert.NoError(t, err)
+	mockVectorDBClient.AssertExpectations(t)
+}
+
+func TestIndexLinkRejectsDomainsOutsideAllowlist(t *testing.T) {
+	cm, mockFetcher, _, mockVectorDBClient := newLinkManager(t)
+
+	err := cm.IndexLink("https://evil-acme.com/page", "C1", "U1", "1700000000.000100", "")
+	assert.ErrorIs(t, err, slackinternal.ErrLinkNotAllowed)
+	mockFetcher.AssertNotCalled(t, "Fetch", mock.Anything)
+	mockVectorDBClient.AssertNotCalled(t, "StoreMessage", mock.Anything)
+}
+
+func TestIndexLinkRespectsRobots(t *testing.T) {
+	cm, mockFetcher, _, mockVectorDBClient := newLinkManager(t)
+
+	robots := "User-agent: *\nDisallow:\n\nUser-agent: BeeBrain\nDisallow: /private\nAllow: /private/public\n"
+	mockFetcher.On("Fetch", "https://acme.com/robots.txt").Return([]byte(robots), "text/plain", nil)
+
+	err := cm.IndexLink("https://acme.com/private/notes", "C1", "U1", "1700000000.000100", "")
+	assert.ErrorIs(t, err, slackinternal.ErrLinkNotAllowed)
+	mockFetcher.AssertNotCalled(t, "Fetch", "https://acme.com/private/notes")
+	mockVectorDBClient.AssertNotCalled(t, "StoreMessage", mock.Anything)
+}
+
+func TestIndexLinkSkipsUnsupportedContent(t *testing.T) {
+	cm, mockFetcher, mockEmbedder, mockVectorDBClient := newLinkManager(t)
+
+	// A missing robots.txt allows everything
+	mockFetcher.On("Fetch", "https://acme.com/robots.txt").Return(nil, "", errors.New("status 404"))
+	mockFetcher.On("Fetch", "https://acme.com/logo.png").Return([]byte{0x89, 'P', 'N', 'G'}, "image/png", nil)
+
+	err := cm.IndexLink("https://acme.com/logo.png", "C1", "U1", "1700000000.000100", "")
+	assert.Error(t, err)
+	mockEmbedder.AssertNotCalled(t, "GetEmbedding", mock.Anything)
+	mockVectorDBClient.AssertNotCalled(t, "StoreMessage", mock.Anything)
+}
+
+func TestExtractText(t *testing.T) {
+	text, err := slackinternal.ExtractText([]byte(linkedPage), "text/html")
+	assert.NoError(t, err)
+	assert.Equal(t, "Deploy guide Deploying We deploy on Fridays with Argo.", text)
+
+	text, err = slackinternal.ExtractText([]byte("plain\n\ttext  here"), "text/plain; charset=utf-8")
+	assert.NoError(t, err)
+	assert.Equal(t, "plain text here", text)
+
+	_, err = slackinternal.ExtractText([]byte("{}"), "application/json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNormalizeMarkup(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockSlackClient.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	mockSlackClient.On("GetUserInfo", "U999").Return(nil, errors.New("user_not_found"))
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "User mention", text: "<@U123> can you review?", want: "@alice can you review?"},
+		{name: "Unknown user keeps its ID", text: "ping <@U999>", want: "ping @U999"},
+		{name: "Channel link", text: "see <#C123|general> and <#C456>", want: "see #general and #C456"},
+		{name: "Broadcasts", text: "<!here> and <!channel>", want: "@here and @channel"},
+		{name: "User group", text: "<!subteam^S123|@oncall> help", want: "@oncall help"},
+		{name: "Links", text: "docs at <https://acme.com/docs|the wiki> or <https://acme.com>", want: "docs at the wiki or https://acme.com"},
+		{name: "Email", text: "mail <mailto:bob@acme.com|bob@acme.com>", want: "mail bob@acme.com"},
+		{name: "Escaped characters", text: "3 &lt; 4 &amp;&amp; 5 &gt; 2", want: "3 < 4 && 5 > 2"},
+		{name: "Plain text", text: "nothing to see here", want: "nothing to see here"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cm.NormalizeMarkup(tt.text))
+		})
+	}
+}
+
+func TestProcessIncommingMessageIndexesNormalizedText(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		wantText string
+		wantRaw  string
+	}{
+		{name: "Normalized", env: "true", wantText: "@alice moved it to #deploys", wantRaw: "<@U123> moved it to <#C9|deploys>"},
+		{name: "Disabled", env: "false", wantText: "<@U123> moved it to <#C9|deploys>", wantRaw: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("INDEX_NORMALIZE_MARKUP", tt.env)
+
+			mockSlackClient := &slackmocks.MockSlackClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+			cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+			mockSlackClient.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+			mockSlackClient.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+			mockEmbedder.On("GetEmbedding", tt.wantText).Return([]float32{0.1, 0.2}, nil)
+			mockVectorDBClient.On("StoreMessage", mock.MatchedBy(func(msg vectordb.Message) bool {
+				return msg.Text == tt.wantText && msg.RawText == tt.wantRaw
+			})).Return(nil)
+
+			cm.ProcessIncommingMessage("<@U123> moved it to <#C9|deploys>", &slack.User{ID: "U2"}, "C1", "1700000000.000100", "")
+			mockVectorDBClient.AssertExpectations(t)
+		})
+	}
+}
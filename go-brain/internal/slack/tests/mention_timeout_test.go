@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleAppMentionTimesOut(t *testing.T) {
+	t.Setenv("MENTION_TIMEOUT", "20ms")
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).After(200*time.Millisecond).Return("Too late!", nil)
+
+	var posted []string
+	m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+		posted = append(posted, postedText(t, args.Get(1).([]slack.MsgOption)))
+	}).Return("C123", "1700000000.000400", nil)
+
+	postEvent(t, handler, threadMentionEvent)
+
+	assert.Len(t, posted, 1)
+	assert.Contains(t, posted[0], "taking too long")
+	m.slack.AssertCalled(t, "RemoveReaction", "eyes", mock.Anything)
+
+	// The late answer is dropped rather than posted
+	time.Sleep(250 * time.Millisecond)
+	assert.Len(t, posted, 1)
+}
+
+func TestHandleAppMentionWithinTimeout(t *testing.T) {
+	t.Setenv("MENTION_TIMEOUT", "1s")
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("Hello!", nil)
+
+	var posted []string
+	m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+		posted = append(posted, postedText(t, args.Get(1).([]slack.MsgOption)))
+	}).Return("C123", "1700000000.000400", nil)
+
+	postEvent(t, handler, threadMentionEvent)
+
+	assert.Equal(t, []string{"Hello!"}, posted)
+}
+
+func TestHandleAppMentionBusyLLM(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		wantText string
+	}{
+		{name: "Default busy response", wantText: "I'm answering a lot of questions right now, please try again in a minute."},
+		{name: "Configured busy response", message: "Busy, back soon!", wantText: "Busy, back soon!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LLM_BUSY_MESSAGE", tt.message)
+			t.Setenv("PIPELINE_RETRIES", "2")
+			t.Setenv("PIPELINE_RETRY_BACKOFF", "1ms")
+			handler, m := newTestHandler(t, "chat")
+
+			m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+			m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+			m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+			m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+			m.llm.On("Chat", mock.Anything, mock.Anything).Return("", fmt.Errorf("%w: no free slot after 30s", llm.ErrBusy))
+
+			var posted []string
+			m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+				posted = append(posted, postedText(t, args.Get(1).([]slack.MsgOption)))
+			}).Return("C123", "1700000000.000400", nil)
+
+			postEvent(t, handler, threadMentionEvent)
+
+			assert.Equal(t, []string{tt.wantText}, posted)
+			// Waiting longer is what the busy response avoids, so it isn't retried
+			m.llm.AssertNumberOfCalls(t, "Chat", 1)
+			m.slack.AssertCalled(t, "RemoveReaction", "eyes", mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestResolveMentions(t *testing.T) {
+	names := map[string]string{
+		"alice":     "UALICE",
+		"bob":       "UBOB",
+		"ana lopez": "UANA",
+		"ana":       "UANA2",
+		"al":        "UAL",
+	}
+
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want string
+	}{
+		{
+			name: "Names become mentions",
+			text: "Alice set up the deploy, ask bob.",
+			max:  2,
+			want: "<@UALICE> set up the deploy, ask <@UBOB>.",
+		},
+		{
+			name: "Only the first occurrence is mentioned",
+			text: "Ask Alice. Alice knows.",
+			max:  2,
+			want: "Ask <@UALICE>. Alice knows.",
+		},
+		{
+			name: "At signs are absorbed",
+			text: "Ping @alice about it",
+			max:  2,
+			want: "Ping <@UALICE> about it",
+		},
+		{
+			name: "Whole words only",
+			text: "Malice and bobcats",
+			max:  2,
+			want: "Malice and bobcats",
+		},
+		{
+			name: "Longer names win",
+			text: "Ana Lopez wrote it",
+			max:  2,
+			want: "<@UANA> wrote it",
+		},
+		{
+			name: "Short names are ignored",
+			text: "Al said so",
+			max:  2,
+			want: "Al said so",
+		},
+		{
+			name: "Code and markup are left alone",
+			text: "Run `alice deploy` as <@UBOB>, then ask alice",
+			max:  2,
+			want: "Run `alice deploy` as <@UBOB>, then ask <@UALICE>",
+		},
+		{
+			name: "Mentions are capped",
+			text: "Alice, Bob and Ana Lopez",
+			max:  1,
+			want: "<@UALICE>, Bob and Ana Lopez",
+		},
+		{
+			name: "Zero max disables mentions",
+			text: "Alice and Bob",
+			max:  0,
+			want: "Alice and Bob",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, slackinternal.ResolveMentions(tt.text, names, tt.max))
+		})
+	}
+}
+
+func TestProcessMessageResolvesMentions(t *testing.T) {
+	asker := &slack.User{ID: "UASKER", Name: "carol"}
+	history := []llm.Message{
+		{Role: "user", Content: "I'll handle the release", User: &llm.User{SlackID: "UDAVE"}},
+		{Role: "user", Content: "Thanks", User: &llm.User{SlackID: "UERIN"}},
+	}
+
+	tests := []struct {
+		name    string
+		enabled string
+		want    string
+	}{
+		{name: "Off by default", enabled: "", want: "dave is on the release, erin and carol can help"},
+		{name: "Enabled", enabled: "true", want: "<@UDAVE> is on the release, <@UERIN> and carol can help"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MENTION_RESOLVE", tt.enabled)
+			t.Setenv("MENTION_MAX", "2")
+
+			mockSlackClient := &slackmocks.MockSlackClient{}
+			mockLLMClient := &mocks.MockLLMClient{}
+			cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+			mockSlackClient.On("GetUserInfo", "UDAVE").Return(&slack.User{ID: "UDAVE", Name: "dave"}, nil)
+			mockSlackClient.On("GetUserInfo", "UERIN").Return(&slack.User{ID: "UERIN", Name: "erin"}, nil)
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Return("dave is on the release, erin and carol can help", nil)
+
+			response, err := cm.ProcessMessage("C1", history, "Who is on the release?", asker)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, response)
+		})
+	}
+}
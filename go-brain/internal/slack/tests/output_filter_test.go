@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostResponseMasksFilteredContent(t *testing.T) {
+	patterns := filepath.Join(t.TempDir(), "patterns.txt")
+	assert.NoError(t, os.WriteFile(patterns, []byte("# Employee IDs\nEMP-[0-9]{4}\n\n[invalid\n"), 0o600))
+
+	tests := []struct {
+		name     string
+		words    string
+		pii      string
+		file     string
+		response string
+		want     string
+	}{
+		{
+			name:     "No filter by default",
+			response: "Mail jane@example.com, darn it",
+			want:     "Mail jane@example.com, darn it",
+		},
+		{
+			name:     "Words are masked regardless of case",
+			words:    "darn,heck",
+			response: "Darn, the build broke again. What the heck.",
+			want:     "[REDACTED], the build broke again. What the [REDACTED].",
+		},
+		{
+			name:     "Words inside other words are kept",
+			words:    "darn",
+			response: "Darnell fixed the build.",
+			want:     "Darnell fixed the build.",
+		},
+		{
+			name:     "PII is masked",
+			pii:      "true",
+			response: "Ask jane@example.com for the token xoxb-1234-abcd",
+			want:     "Ask [REDACTED] for the token [REDACTED]",
+		},
+		{
+			name:     "Patterns from a file are masked",
+			file:     patterns,
+			response: "Ticket owner is EMP-1234.",
+			want:     "Ticket owner is [REDACTED].",
+		},
+		{
+			name:     "Clean content passes unchanged",
+			words:    "darn",
+			pii:      "true",
+			file:     patterns,
+			response: "Deploys happen on Fridays, see <https://wiki.example.com|the wiki>.",
+			want:     "Deploys happen on Fridays, see <https://wiki.example.com|the wiki>.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OUTPUT_FILTER_WORDS", tt.words)
+			t.Setenv("OUTPUT_FILTER_PII", tt.pii)
+			t.Setenv("OUTPUT_FILTER_PATTERNS_FILE", tt.file)
+
+			mockSlackClient := &slackmocks.MockSlackClient{}
+			cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+			var options []slack.MsgOption
+			mockSlackClient.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+				options = args.Get(1).([]slack.MsgOption)
+			}).Return("C123", "1700000000.000200", nil)
+
+			assert.NoError(t, cm.PostResponse("C123", tt.response, ""))
+			_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, values.Get("text"))
+		})
+	}
+}
+
+func TestOutputFilterCustomMask(t *testing.T) {
+	t.Setenv("OUTPUT_FILTER_WORDS", "darn")
+	t.Setenv("OUTPUT_FILTER_MASK", "***")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	var options []slack.MsgOption
+	mockSlackClient.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+		options = args.Get(1).([]slack.MsgOption)
+	}).Return("C123", "1700000000.000200", nil)
+
+	assert.NoError(t, cm.PostResponse("C123", "darn it", ""))
+	_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+	assert.NoError(t, err)
+	assert.Equal(t, "*** it", values.Get("text"))
+}
+
+func TestPostSourcesMasksFilteredContent(t *testing.T) {
+	t.Setenv("RAG_SOURCES_EPHEMERAL", "true")
+	t.Setenv("OUTPUT_FILTER_PII", "true")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	var options []slack.MsgOption
+	mockSlackClient.On("PostEphemeral", "C123", "U123", mock.Anything).Run(func(args mock.Arguments) {
+		options = args.Get(2).([]slack.MsgOption)
+	}).Return("1700000000.000300", nil)
+
+	err := cm.PostSources("C123", "U123", "", []vectordb.Message{
+		{Text: "Mail jane@example.com for access", UserID: "U1", ChannelID: "C1", MessageTS: "1700000000.000050"},
+	})
+	assert.NoError(t, err)
+	_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+	assert.NoError(t, err)
+	assert.Equal(t, "*Sources*\n[1] <@U1>: Mail [REDACTED] for access", values.Get("text"))
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func overflowThread() []llm.Message {
+	return []llm.Message{
+		{Role: "user", Content: "We moved deploys to Fridays", User: &llm.User{SlackName: "alice"}},
+		{Role: "user", Content: "Because of the freeze", User: &llm.User{SlackName: "bob"}},
+		{Role: "user", Content: "What about holidays?", User: &llm.User{SlackName: "alice"}},
+	}
+}
+
+func TestProcessMessageSummarizesContextOnOverflow(t *testing.T) {
+	t.Setenv("CONTEXT_OVERFLOW_KEEP_MESSAGES", "1")
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	overflow := &llm.ContextOverflowError{PromptTokens: 2048, ContextSize: 2048, Response: "Truncated answer"}
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return len(messages) == 4
+	}), mock.Anything).Return("", overflow).Once()
+	mockLLMClient.On("Summarize", mock.MatchedBy(func(messages []llm.Message) bool {
+		return len(messages) == 2 && messages[0].Content == "We moved deploys to Fridays"
+	}), mock.Anything).Return("Deploys moved to Fridays because of the freeze.", nil)
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return len(messages) == 3 &&
+			messages[0].Content == "Summary of the earlier conversation:\nDeploys moved to Fridays because of the freeze." &&
+			messages[1].Content == "What about holidays?"
+	}), mock.Anything).Return("No deploys on holidays.", nil).Once()
+
+	response, err := cm.ProcessMessage("C1", overflowThread(), "When do we deploy?", &slack.User{ID: "U3", Name: "carol"})
+	assert.NoError(t, err)
+	assert.Equal(t, "No deploys on holidays.", response)
+	mockLLMClient.AssertExpectations(t)
+}
+
+func TestProcessMessageUsesTruncatedAnswerWithoutSummarizing(t *testing.T) {
+	t.Setenv("CONTEXT_OVERFLOW_SUMMARIZE", "false")
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	overflow := &llm.ContextOverflowError{PromptTokens: 2048, ContextSize: 2048, Response: "Truncated answer"}
+	mockLLMClient.On("Chat", mock.Anything, mock.Anything).Return("", overflow)
+
+	response, err := cm.ProcessMessage("C1", overflowThread(), "When do we deploy?", &slack.User{ID: "U3", Name: "carol"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Truncated answer", response)
+	mockLLMClient.AssertNotCalled(t, "Summarize", mock.Anything, mock.Anything)
+	mockLLMClient.AssertNumberOfCalls(t, "Chat", 1)
+}
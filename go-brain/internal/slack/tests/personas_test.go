@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	slackinternal "beebrain/internal/slack"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// writePersona writes a persona file of about tokens tokens and returns its
+// path
+func writePersona(t *testing.T, name string, tokens int) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Repeat("word", tokens)), 0o600))
+	return path
+}
+
+func TestCheckPersonaSizesWarnsAboutOversizedPersona(t *testing.T) {
+	t.Setenv("PERSONA_MAX_TOKENS", "500")
+	t.Setenv("CHANNEL_PERSONAS", "CBIG="+writePersona(t, "big.txt", 800)+",CSMALL="+writePersona(t, "small.txt", 100))
+	logger, hook := test.NewNullLogger()
+
+	assert.NoError(t, slackinternal.CheckPersonaSizes(logger))
+
+	if assert.Len(t, hook.AllEntries(), 1) {
+		entry := hook.LastEntry()
+		assert.Equal(t, logrus.WarnLevel, entry.Level)
+		assert.Contains(t, entry.Message, "big.txt of channel CBIG (about 800 tokens)")
+		assert.NotContains(t, entry.Message, "CSMALL")
+		assert.NotContains(t, entry.Message, "default persona")
+	}
+}
+
+func TestCheckPersonaSizesFailsWhenConfigured(t *testing.T) {
+	t.Setenv("PERSONA_MAX_TOKENS", "500")
+	t.Setenv("PERSONA_MAX_TOKENS_FAIL", "true")
+	t.Setenv("CHANNEL_PERSONAS", "CBIG="+writePersona(t, "big.txt", 800))
+
+	err := slackinternal.CheckPersonaSizes(logrus.New())
+	assert.ErrorContains(t, err, "PERSONA_MAX_TOKENS=500")
+	assert.ErrorContains(t, err, "channel CBIG (about 800 tokens)")
+}
+
+func TestCheckPersonaSizesDefaults(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxTokens string
+		wantWarn  bool
+	}{
+		// The built-in persona fits the default maximum
+		{name: "Default maximum", maxTokens: "", wantWarn: false},
+		{name: "Default persona oversized", maxTokens: "10", wantWarn: true},
+		{name: "Check disabled", maxTokens: "0", wantWarn: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PERSONA_MAX_TOKENS", tt.maxTokens)
+			logger, hook := test.NewNullLogger()
+
+			assert.NoError(t, slackinternal.CheckPersonaSizes(logger))
+
+			if tt.wantWarn {
+				assert.Contains(t, hook.LastEntry().Message, "default persona")
+			} else {
+				assert.Empty(t, hook.AllEntries())
+			}
+		})
+	}
+}
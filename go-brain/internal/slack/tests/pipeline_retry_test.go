@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const threadMentionEvent = `{"token":"verification-token","type":"event_callback","event":{"type":"app_mention","user":"U123","text":"<@UBOT> hi","ts":"1700000000.000300","thread_ts":"1700000000.000100","channel":"C123","event_ts":"1700000000.000300"}}`
+
+func TestHandleAppMentionRetriesTransientFailures(t *testing.T) {
+	t.Setenv("PIPELINE_RETRIES", "2")
+	t.Setenv("PIPELINE_RETRY_BACKOFF", "1ms")
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	// The thread context fails first, then the LLM
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message(nil), false, "", assert.AnError).Once()
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("", assert.AnError).Once()
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("Hello!", nil)
+
+	var posted []string
+	m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+		posted = append(posted, postedText(t, args.Get(1).([]slack.MsgOption)))
+	}).Return("C123", "1700000000.000400", nil)
+
+	postEvent(t, handler, threadMentionEvent)
+
+	// Only the final answer is posted, once
+	assert.Equal(t, []string{"Hello!"}, posted)
+	m.slack.AssertNumberOfCalls(t, "GetConversationReplies", 3)
+	m.llm.AssertNumberOfCalls(t, "Chat", 2)
+}
+
+func TestHandleAppMentionRetriesFailedPostWithoutRegenerating(t *testing.T) {
+	t.Setenv("PIPELINE_RETRIES", "2")
+	t.Setenv("PIPELINE_RETRY_BACKOFF", "1ms")
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("Hello!", nil)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("", "", assert.AnError).Once()
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000400", nil)
+
+	postEvent(t, handler, threadMentionEvent)
+
+	m.slack.AssertNumberOfCalls(t, "PostMessage", 2)
+	m.llm.AssertNumberOfCalls(t, "Chat", 1)
+	m.slack.AssertCalled(t, "RemoveReaction", "eyes", mock.Anything)
+}
+
+func TestHandleAppMentionGivesUpAfterRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   string
+		wantCalls int
+	}{
+		{name: "No retries by default", retries: "", wantCalls: 1},
+		{name: "Bounded retries", retries: "2", wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PIPELINE_RETRIES", tt.retries)
+			t.Setenv("PIPELINE_RETRY_BACKOFF", "1ms")
+			handler, m := newTestHandler(t, "chat")
+
+			m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+			m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+			m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+			m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+			m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+			m.llm.On("Chat", mock.Anything, mock.Anything).Return("", assert.AnError)
+
+			var posted []string
+			m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+				posted = append(posted, postedText(t, args.Get(1).([]slack.MsgOption)))
+			}).Return("C123", "1700000000.000400", nil)
+
+			postEvent(t, handler, threadMentionEvent)
+
+			m.llm.AssertNumberOfCalls(t, "Chat", tt.wantCalls)
+			assert.Equal(t, []string{"Sorry, I encountered an error processing your request."}, posted)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newPostRetryManager(t *testing.T, queueSize string) (*slackinternal.ConversationManager, *slackmocks.MockSlackClient) {
+	t.Setenv("POST_RETRY_QUEUE_SIZE", queueSize)
+	t.Setenv("POST_RETRIES", "3")
+	t.Setenv("POST_RETRY_BACKOFF", "1ms")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+	return cm, mockSlackClient
+}
+
+func TestFailedPostIsRetriedInBackground(t *testing.T) {
+	cm, mockSlackClient := newPostRetryManager(t, "10")
+
+	delivered := make(chan string, 1)
+	mockSlackClient.On("PostMessage", "C1", mock.Anything).Return("", "", errors.New("connection reset by peer")).Twice()
+	mockSlackClient.On("PostMessage", "C1", mock.Anything).Run(func(args mock.Arguments) {
+		delivered <- postedText(t, args.Get(1).([]slack.MsgOption))
+	}).Return("C1", "1700000000.000200", nil).Once()
+
+	// The answer is queued rather than lost
+	assert.NoError(t, cm.PostResponse("C1", "Deploys happen on Fridays", "1700000000.000100"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cm.StartPostQueue(ctx)
+
+	select {
+	case text := <-delivered:
+		assert.Equal(t, "Deploys happen on Fridays", text)
+	case <-time.After(time.Second):
+		t.Fatal("queued post was not delivered")
+	}
+	mockSlackClient.AssertNumberOfCalls(t, "PostMessage", 3)
+}
+
+func TestPostRetryQueueDropsOldestWhenFull(t *testing.T) {
+	cm, mockSlackClient := newPostRetryManager(t, "1")
+
+	mockSlackClient.On("PostMessage", "C1", mock.Anything).Return("", "", errors.New("connection refused")).Twice()
+	assert.NoError(t, cm.PostResponse("C1", "first answer", ""))
+	assert.NoError(t, cm.PostResponse("C1", "second answer", ""))
+
+	delivered := make(chan string, 2)
+	mockSlackClient.On("PostMessage", "C1", mock.Anything).Run(func(args mock.Arguments) {
+		delivered <- postedText(t, args.Get(1).([]slack.MsgOption))
+	}).Return("C1", "1700000000.000200", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cm.StartPostQueue(ctx)
+
+	select {
+	case text := <-delivered:
+		assert.Equal(t, "second answer", text)
+	case <-time.After(time.Second):
+		t.Fatal("queued post was not delivered")
+	}
+	assert.Never(t, func() bool { return len(delivered) > 0 }, 50*time.Millisecond, 5*time.Millisecond)
+}
+
+func TestRefusedPostIsNotQueued(t *testing.T) {
+	tests := []struct {
+		name      string
+		queueSize string
+		err       error
+	}{
+		{name: "Slack refused the message", queueSize: "10", err: slack.SlackErrorResponse{Err: "channel_not_found"}},
+		{name: "Queue disabled", queueSize: "0", err: errors.New("connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm, mockSlackClient := newPostRetryManager(t, tt.queueSize)
+			mockSlackClient.On("PostMessage", "C1", mock.Anything).Return("", "", tt.err)
+
+			assert.Error(t, cm.PostResponse("C1", "Deploys happen on Fridays", ""))
+			mockSlackClient.AssertNumberOfCalls(t, "PostMessage", 1)
+		})
+	}
+}
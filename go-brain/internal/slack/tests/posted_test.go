@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostResponseOnceUpdatesRetriedResponse(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	mockSlackClient.On("PostMessage", "C1", mock.Anything).Return("C1", "1700000000.000200", nil).Once()
+	var updated string
+	mockSlackClient.On("UpdateMessage", "C1", "1700000000.000200", mock.Anything).Run(func(args mock.Arguments) {
+		updated = postedText(t, args.Get(2).([]slack.MsgOption))
+	}).Return("C1", "1700000000.000200", "Second try", nil)
+
+	assert.NoError(t, cm.PostResponseOnce("C1", "First try", "1700000000.000100", "1700000000.000150"))
+	assert.NoError(t, cm.PostResponseOnce("C1", "Second try", "1700000000.000100", "1700000000.000150"))
+
+	mockSlackClient.AssertNumberOfCalls(t, "PostMessage", 1)
+	mockSlackClient.AssertNumberOfCalls(t, "UpdateMessage", 1)
+	assert.Equal(t, "Second try", updated)
+}
+
+func TestPostResponseOncePostsPerRequest(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	mockSlackClient.On("PostMessage", "C1", mock.Anything).Return("C1", "1700000000.000200", nil)
+
+	// Other requests, threads and plain posts each get their own message
+	assert.NoError(t, cm.PostResponseOnce("C1", "One", "1700000000.000100", "1700000000.000150"))
+	assert.NoError(t, cm.PostResponseOnce("C1", "Two", "1700000000.000100", "1700000000.000160"))
+	assert.NoError(t, cm.PostResponseOnce("C1", "Three", "1700000000.000300", "1700000000.000150"))
+	assert.NoError(t, cm.PostResponse("C1", "Four", "1700000000.000100"))
+	assert.NoError(t, cm.PostResponse("C1", "Four", "1700000000.000100"))
+
+	mockSlackClient.AssertNumberOfCalls(t, "PostMessage", 5)
+	mockSlackClient.AssertNotCalled(t, "UpdateMessage", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostResponseOnceRetriesFailedPost(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	// A post that failed left nothing to update
+	mockSlackClient.On("PostMessage", "C1", mock.Anything).Return("", "", assert.AnError).Once()
+	mockSlackClient.On("PostMessage", "C1", mock.Anything).Return("C1", "1700000000.000200", nil)
+
+	assert.Error(t, cm.PostResponseOnce("C1", "Answer", "", "1700000000.000150"))
+	assert.NoError(t, cm.PostResponseOnce("C1", "Answer", "", "1700000000.000150"))
+
+	mockSlackClient.AssertNumberOfCalls(t, "PostMessage", 2)
+	mockSlackClient.AssertNotCalled(t, "UpdateMessage", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleAppMentionAnsweredAgainUpdatesAnswer(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("Hello!", nil).Once()
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("Hello again!", nil)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000400", nil)
+	m.slack.On("UpdateMessage", "C123", "1700000000.000400", mock.Anything).Return("C123", "1700000000.000400", "Hello again!", nil)
+
+	// The same mention delivered again under another event timestamp
+	postEvent(t, handler, threadMentionEvent)
+	postEvent(t, handler, strings.Replace(threadMentionEvent, `"event_ts":"1700000000.000300"`, `"event_ts":"1700000000.000301"`, 1))
+
+	m.slack.AssertNumberOfCalls(t, "PostMessage", 1)
+	m.slack.AssertNumberOfCalls(t, "UpdateMessage", 1)
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func profileUser() *slack.User {
+	return &slack.User{ID: "U1", Name: "asmith", Profile: slack.UserProfile{DisplayName: "Alex", Title: "SRE"}}
+}
+
+func TestUpdateUserProfile(t *testing.T) {
+	t.Setenv("USER_PROFILES", "true")
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	_, ok := cm.GetUserProfile("U1")
+	assert.False(t, ok)
+
+	cm.UpdateUserProfile(profileUser(), "<@UBOT> how do I restart the Kubernetes ingress?")
+	cm.UpdateUserProfile(profileUser(), "Why is the kubernetes ingress down again?")
+	cm.UpdateUserProfile(profileUser(), "What's on the lunch menu?")
+
+	profile, ok := cm.GetUserProfile("U1")
+	assert.True(t, ok)
+	assert.Equal(t, "Alex", profile.PreferredName)
+	assert.Equal(t, "SRE", profile.Role)
+	assert.Equal(t, []string{"ingress", "kubernetes"}, profile.RecurringTopics(5))
+	assert.NotContains(t, profile.Topics, "ubot")
+
+	// Profiles handed out are copies
+	profile.Topics["ingress"] = 100
+	stored, _ := cm.GetUserProfile("U1")
+	assert.Equal(t, 2, stored.Topics["ingress"])
+}
+
+func TestUserProfilesDisabled(t *testing.T) {
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	cm.UpdateUserProfile(profileUser(), "How do I restart the ingress?")
+	_, ok := cm.GetUserProfile("U1")
+	assert.False(t, ok)
+}
+
+func TestProcessMessageInjectsUserProfile(t *testing.T) {
+	t.Setenv("USER_PROFILES", "true")
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+	cm.UpdateUserProfile(profileUser(), "Is the ingress healthy?")
+
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		profile := messages[len(messages)-2]
+		return profile.Role == "system" &&
+			strings.Contains(profile.Content, "They prefer to be called Alex.") &&
+			strings.Contains(profile.Content, "Their role is SRE.") &&
+			strings.Contains(profile.Content, "They often ask about ingress.")
+	}), mock.Anything).Return("It's healthy.", nil)
+
+	response, err := cm.ProcessMessage("C1", nil, "Did the ingress restart?", profileUser())
+	assert.NoError(t, err)
+	assert.Equal(t, "It's healthy.", response)
+	mockLLMClient.AssertExpectations(t)
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPromptDirectivesAugmentSystemPrompt(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      string
+		text         string
+		wantQuestion string
+		wantPrompts  []string
+	}{
+		{
+			name:         "Leading directive block",
+			enabled:      "true",
+			text:         "[answer concisely; use bullet points] How do deploys work?",
+			wantQuestion: "How do deploys work?",
+			wantPrompts:  []string{"For this answer only, follow these instructions from the user:\n- answer concisely\n- use bullet points"},
+		},
+		{
+			name:         "Block after a mention",
+			enabled:      "true",
+			text:         "<@UBOT> [answer concisely] How do deploys work?",
+			wantQuestion: "<@UBOT> How do deploys work?",
+			wantPrompts:  []string{"For this answer only, follow these instructions from the user:\n- answer concisely"},
+		},
+		{
+			name:         "With a language directive",
+			enabled:      "true",
+			text:         "[answer concisely] How do deploys work? (in Spanish)",
+			wantQuestion: "How do deploys work?",
+			wantPrompts: []string{
+				"For this answer only, follow these instructions from the user:\n- answer concisely",
+				"Answer in Spanish, whatever language the question is asked in.",
+			},
+		},
+		{
+			name:         "Brackets in the middle",
+			enabled:      "true",
+			text:         "Is [staging] deployed on merge?",
+			wantQuestion: "Is [staging] deployed on merge?",
+		},
+		{
+			name:         "Nothing after the block",
+			enabled:      "true",
+			text:         "[answer concisely]",
+			wantQuestion: "[answer concisely]",
+		},
+		{
+			name:         "Directives disabled",
+			text:         "[answer concisely] How do deploys work?",
+			wantQuestion: "[answer concisely] How do deploys work?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROMPT_DIRECTIVES", tt.enabled)
+			t.Setenv("LANGUAGE_DIRECTIVES", "true")
+			t.Setenv("RAG_RESULTS", "3")
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+			// Retrieval searches for the question without the directives
+			mockEmbedder.On("GetEmbedding", tt.wantQuestion).Return([]float32{0.1, 0.2}, nil)
+			mockVectorDBClient.On("SearchSimilar", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+			var sent []llm.Message
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				sent = args.Get(0).([]llm.Message)
+			}).Return("- Merge to main\n- Wait for CI", nil)
+
+			_, err := cm.ProcessMessage("C1", nil, tt.text, &slack.User{ID: "U1", Name: "alice"})
+			assert.NoError(t, err)
+			mockEmbedder.AssertExpectations(t)
+
+			question := sent[len(sent)-1]
+			assert.Equal(t, tt.wantQuestion, question.Content)
+
+			var instructions []string
+			for _, msg := range sent[:len(sent)-1] {
+				if msg.Role == "system" {
+					instructions = append(instructions, msg.Content)
+				}
+			}
+			assert.Equal(t, tt.wantPrompts, instructions)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestQuietHoursWindow(t *testing.T) {
+	lisbon, err := time.LoadLocation("Europe/Lisbon")
+	assert.NoError(t, err)
+
+	// 22:00-08:00 wraps past midnight
+	quiet := &slackinternal.QuietHours{Start: 22 * time.Hour, End: 8 * time.Hour, Location: lisbon}
+
+	evening := time.Date(2024, 6, 10, 23, 0, 0, 0, lisbon)
+	morning := time.Date(2024, 6, 11, 7, 59, 0, 0, lisbon)
+	daytime := time.Date(2024, 6, 11, 12, 0, 0, 0, lisbon)
+
+	assert.True(t, quiet.Contains(evening))
+	assert.True(t, quiet.Contains(morning))
+	assert.False(t, quiet.Contains(daytime))
+
+	// The window is evaluated in its own time zone
+	assert.True(t, quiet.Contains(evening.UTC()))
+
+	assert.Equal(t, time.Date(2024, 6, 11, 8, 0, 0, 0, lisbon), quiet.NextActive(evening))
+	assert.Equal(t, time.Date(2024, 6, 11, 8, 0, 0, 0, lisbon), quiet.NextActive(morning))
+	assert.Equal(t, daytime, quiet.NextActive(daytime))
+}
+
+func TestPostProactiveHonorsQuietHours(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name         string
+		start        time.Time
+		end          time.Time
+		wantDeferred bool
+	}{
+		{
+			name:         "During quiet hours",
+			start:        now.Add(-time.Hour),
+			end:          now.Add(time.Hour),
+			wantDeferred: true,
+		},
+		{
+			name:         "During active hours",
+			start:        now.Add(time.Hour),
+			end:          now.Add(2 * time.Hour),
+			wantDeferred: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("QUIET_HOURS_START", tt.start.Format("15:04"))
+			t.Setenv("QUIET_HOURS_END", tt.end.Format("15:04"))
+			t.Setenv("QUIET_HOURS_TIMEZONE", "UTC")
+			t.Setenv("RESPONSE_FOOTER", "Answered by BeeBrain")
+
+			mockSlackClient := &slackmocks.MockSlackClient{}
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+
+			cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+			var options []slack.MsgOption
+			mockSlackClient.On("PostMessage", "CDIGEST", mock.Anything).Run(func(args mock.Arguments) {
+				options = args.Get(1).([]slack.MsgOption)
+			}).Return("CDIGEST", "1234567890.123456", nil)
+
+			deferred, err := cm.PostProactive("CDIGEST", "Daily digest")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantDeferred, deferred)
+
+			if tt.wantDeferred {
+				mockSlackClient.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+			} else {
+				mockSlackClient.AssertNumberOfCalls(t, "PostMessage", 1)
+				// Proactive posts aren't answers, so they get no footer
+				_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+				assert.NoError(t, err)
+				assert.Equal(t, "Daily digest", values.Get("text"))
+				assert.Empty(t, values.Get("blocks"))
+			}
+		})
+	}
+}
+
+func TestDeferredPostIsDroppedOnShutdown(t *testing.T) {
+	now := time.Now().UTC()
+	t.Setenv("QUIET_HOURS_START", now.Add(-time.Hour).Format("15:04"))
+	t.Setenv("QUIET_HOURS_END", now.Add(time.Hour).Format("15:04"))
+	t.Setenv("QUIET_HOURS_TIMEZONE", "UTC")
+
+	logger, hook := test.NewNullLogger()
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logger, "chat", &vectordbmocks.MockVectorDBClient{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cm.SetContext(ctx)
+
+	deferred, err := cm.PostProactive("CDIGEST", "Daily digest")
+	assert.NoError(t, err)
+	assert.True(t, deferred)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		for _, entry := range hook.AllEntries() {
+			if strings.Contains(entry.Message, "dropping the post deferred to channel CDIGEST") {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+	mockSlackClient.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSlackCallsRetryOnRateLimit(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+	// The first call is throttled, the second succeeds
+	mockSlackClient.On("GetConversationHistory", mock.Anything).
+		Return(nil, &slack.RateLimitedError{RetryAfter: time.Millisecond}).Once()
+	mockSlackClient.On("GetConversationHistory", mock.Anything).
+		Return(&slack.GetConversationHistoryResponse{
+			Messages: []slack.Message{{Msg: slack.Msg{Text: "Hello", User: "U123456"}}},
+		}, nil).Once()
+
+	mockSlackClient.On("PostMessage", "C123456", mock.Anything).
+		Return("", "", &slack.RateLimitedError{RetryAfter: time.Millisecond}).Once()
+	mockSlackClient.On("PostMessage", "C123456", mock.Anything).
+		Return("C123456", "1234567890.123456", nil).Once()
+
+	messages, err := cm.GetLastHourConversation("C123456")
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+
+	assert.NoError(t, cm.PostResponse("C123456", "Hi!", ""))
+
+	mockSlackClient.AssertExpectations(t)
+	mockSlackClient.AssertNumberOfCalls(t, "GetConversationHistory", 2)
+	mockSlackClient.AssertNumberOfCalls(t, "PostMessage", 2)
+}
+
+func TestSlackCallsGiveUpAfterMaxRetries(t *testing.T) {
+	t.Setenv("SLACK_MAX_RETRIES", "2")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+
+	mockSlackClient.On("GetConversationHistory", mock.Anything).
+		Return(nil, &slack.RateLimitedError{RetryAfter: time.Millisecond})
+
+	_, err := cm.GetLastHourConversation("C123456")
+	assert.Error(t, err)
+
+	// One initial attempt plus two retries
+	mockSlackClient.AssertNumberOfCalls(t, "GetConversationHistory", 3)
+}
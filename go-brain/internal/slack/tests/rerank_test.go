@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var rerankCandidates = []vectordb.Message{
+	{Text: "alpha", UserID: "U1"},
+	{Text: "bravo", UserID: "U2"},
+	{Text: "charlie", UserID: "U3"},
+}
+
+// chatPrompt returns the retrieved-messages prompt passed to Chat
+func chatPrompt(messages []llm.Message) string {
+	for _, msg := range messages {
+		if strings.HasPrefix(msg.Content, "Relevant messages") {
+			return msg.Content
+		}
+	}
+	return ""
+}
+
+func TestProcessMessageReranksRetrievedMessages(t *testing.T) {
+	tests := []struct {
+		name      string
+		reranked  []vectordb.Message
+		rerankErr error
+		want      []string
+		notWant   string
+	}{
+		{
+			name:     "Reranked order is used",
+			reranked: []vectordb.Message{rerankCandidates[2], rerankCandidates[0], rerankCandidates[1]},
+			want:     []string{"[1] <@U3>: charlie", "[2] <@U1>: alpha"},
+			notWant:  "bravo",
+		},
+		{
+			name:      "Similarity order on reranker failure",
+			rerankErr: errors.New("reranker down"),
+			want:      []string{"[1] <@U1>: alpha", "[2] <@U2>: bravo"},
+			notWant:   "charlie",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RAG_RESULTS", "2")
+			t.Setenv("RERANK_CANDIDATES", "3")
+
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+			mockReranker := &slackmocks.MockReranker{}
+
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+			cm.SetReranker(mockReranker)
+
+			embedding := []float32{0.1, 0.2}
+			mockEmbedder.On("GetEmbedding", "Which one?").Return(embedding, nil)
+			// More candidates than RAG_RESULTS are fetched for the reranker
+			mockVectorDBClient.On("SearchSimilar", mock.Anything, embedding, uint64(3)).Return(rerankCandidates, nil)
+			if tt.rerankErr != nil {
+				mockReranker.On("Rerank", "Which one?", rerankCandidates).Return(nil, tt.rerankErr)
+			} else {
+				mockReranker.On("Rerank", "Which one?", rerankCandidates).Return(tt.reranked, nil)
+			}
+
+			var prompt string
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				prompt = chatPrompt(args.Get(0).([]llm.Message))
+			}).Return("That one.", nil)
+
+			_, err := cm.ProcessMessage("C1", nil, "Which one?", &slack.User{ID: "U9", Name: "zed"})
+			assert.NoError(t, err)
+
+			for _, want := range tt.want {
+				assert.Contains(t, prompt, want)
+			}
+			assert.NotContains(t, prompt, tt.notWant)
+			mockReranker.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRetrievalBoostsRecentMessages(t *testing.T) {
+	t.Setenv("RAG_RESULTS", "1")
+	t.Setenv("RERANK_CANDIDATES", "2")
+	t.Setenv("RAG_RECENCY_HALF_LIFE", "720h")
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+	embedding := []float32{0.1, 0.2}
+	mockEmbedder.On("GetEmbedding", "Which one?").Return(embedding, nil)
+	// Equally similar, the second one posted recently
+	candidates := []vectordb.Message{
+		{ID: "1", Text: "stale answer", UserID: "U1", Score: 0.8, Timestamp: time.Now().Add(-365 * 24 * time.Hour).Format(time.RFC3339)},
+		{ID: "2", Text: "fresh answer", UserID: "U2", Score: 0.8, Timestamp: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+	}
+	mockVectorDBClient.On("SearchSimilar", mock.Anything, embedding, uint64(2)).Return(candidates, nil)
+
+	var prompt string
+	mockLLMClient.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		prompt = chatPrompt(args.Get(0).([]llm.Message))
+	}).Return("That one.", nil)
+
+	_, err := cm.ProcessMessage("C1", nil, "Which one?", &slack.User{ID: "U9", Name: "zed"})
+	assert.NoError(t, err)
+
+	assert.Contains(t, prompt, "fresh answer")
+	assert.NotContains(t, prompt, "stale answer")
+}
+
+func TestLLMRerankerOrdersByRanking(t *testing.T) {
+	mockLLMClient := &mocks.MockLLMClient{}
+	reranker := slackinternal.NewLLMReranker(mockLLMClient, logrus.New())
+
+	mockLLMClient.On("Generate", mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "Question: Which one?") && strings.Contains(prompt, "[3] charlie")
+	}), mock.Anything).Return("Sure, here you go: [3, 1, 9, 1]", nil)
+
+	// Out of range and repeated numbers are ignored, unranked messages dropped
+	reranked, err := reranker.Rerank("Which one?", rerankCandidates)
+	assert.NoError(t, err)
+	assert.Equal(t, []vectordb.Message{rerankCandidates[2], rerankCandidates[0]}, reranked)
+}
+
+func TestLLMRerankerMalformedResponse(t *testing.T) {
+	mockLLMClient := &mocks.MockLLMClient{}
+	reranker := slackinternal.NewLLMReranker(mockLLMClient, logrus.New())
+
+	mockLLMClient.On("Generate", mock.Anything, mock.Anything).Return("The third one is best.", nil)
+
+	_, err := reranker.Rerank("Which one?", rerankCandidates)
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestResponsesAreIndexedWhenEnabled(t *testing.T) {
+	t.Setenv("INDEX_RESPONSES", "true")
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+	cm.SetBotIdentity("UBOT", "B1")
+
+	embedding := []float32{0.1, 0.2}
+	mockSlackClient.On("PostMessage", "C1", mock.Anything).Return("C1", "1700000000.000200", nil)
+	mockEmbedder.On("GetEmbedding", "Restart the worker.").Return(embedding, nil)
+	var stored vectordb.Message
+	mockVectorDBClient.On("StoreMessage", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(vectordb.Message)
+	}).Return(nil)
+
+	assert.NoError(t, cm.PostResponseOnce("C1", "Restart the worker.", "1700000000.000100", "1700000000.000150"))
+
+	mockVectorDBClient.AssertNumberOfCalls(t, "StoreMessage", 1)
+	assert.Equal(t, map[string]string{"role": "assistant"}, stored.Metadata)
+	assert.Equal(t, "Restart the worker.", stored.Text)
+	assert.Equal(t, "UBOT", stored.UserID)
+	assert.Equal(t, "C1", stored.ChannelID)
+	assert.Equal(t, "1700000000.000200", stored.MessageTS)
+	assert.Equal(t, "1700000000.000100", stored.ThreadID)
+	assert.Equal(t, embedding, stored.Embedding)
+	assert.NotEmpty(t, stored.ID)
+}
+
+func TestResponsesAreNotIndexedByDefault(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", mockVectorDBClient)
+
+	mockSlackClient.On("PostMessage", "C1", mock.Anything).Return("C1", "1700000000.000200", nil)
+
+	assert.NoError(t, cm.PostResponseOnce("C1", "Restart the worker.", "1700000000.000100", "1700000000.000150"))
+
+	mockVectorDBClient.AssertNotCalled(t, "StoreMessage", mock.Anything)
+}
+
+func TestRetrievalExcludesIndexedResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		exclude string
+		want    []string
+		notWant []string
+	}{
+		{name: "Responses used by default", want: []string{"the bot's answer", "a person's message"}},
+		{name: "Responses excluded", exclude: "true", want: []string{"a person's message"}, notWant: []string{"the bot's answer"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RAG_RESULTS", "2")
+			t.Setenv("RAG_EXCLUDE_RESPONSES", tt.exclude)
+
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+			embedding := []float32{0.1, 0.2}
+			mockEmbedder.On("GetEmbedding", "How do I fix it?").Return(embedding, nil)
+			mockVectorDBClient.On("SearchSimilar", mock.Anything, embedding, uint64(2)).Return([]vectordb.Message{
+				{ID: "1", Text: "the bot's answer", UserID: "UBOT", Score: 0.9, Metadata: map[string]string{"role": "assistant"}},
+				{ID: "2", Text: "a person's message", UserID: "U2", Score: 0.8},
+			}, nil)
+
+			var prompt string
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				prompt = chatPrompt(args.Get(0).([]llm.Message))
+			}).Return("Like this.", nil)
+
+			_, err := cm.ProcessMessage("C1", nil, "How do I fix it?", &slack.User{ID: "U9", Name: "zed"})
+			assert.NoError(t, err)
+
+			for _, want := range tt.want {
+				assert.Contains(t, prompt, want)
+			}
+			for _, notWant := range tt.notWant {
+				assert.NotContains(t, prompt, notWant)
+			}
+		})
+	}
+}
+
+func TestOwnMessageEventsAreNotIndexedAgain(t *testing.T) {
+	t.Setenv("INDEX_RESPONSES", "true")
+	handler, m := newTestHandler(t, "chat")
+
+	// The answer was indexed as it was posted, its message event is skipped
+	postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"message","user":"UBOT","bot_id":"B1","text":"Restart the worker.","ts":"1700000000.000200","channel":"C123","event_ts":"1700000000.000200"}}`)
+
+	m.embedder.AssertNotCalled(t, "GetEmbedding", mock.Anything)
+	m.vectorDB.AssertNotCalled(t, "StoreMessage", mock.Anything)
+}
+
+func TestRedeliveredMessagesKeepTheirID(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.embedder.On("GetEmbedding", mock.Anything).Return([]float32{0.1, 0.2}, nil)
+	var ids []string
+	m.vectorDB.On("StoreMessage", mock.Anything).Run(func(args mock.Arguments) {
+		ids = append(ids, args.Get(0).(vectordb.Message).ID)
+	}).Return(nil)
+
+	// The same message delivered in two events
+	for _, eventTS := range []string{"1700000000.000100", "1700000000.000101"} {
+		postEvent(t, handler, `{"token":"verification-token","type":"event_callback","event":{"type":"message","user":"U123","text":"Deploys happen on Fridays","ts":"1700000000.000100","channel":"C123","event_ts":"`+eventTS+`"}}`)
+	}
+
+	if assert.Len(t, ids, 2) {
+		assert.NotEmpty(t, ids[0])
+		assert.Equal(t, ids[0], ids[1])
+	}
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLLMScopeClassifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		inScope  bool
+		wantErr  bool
+	}{
+		{name: "In scope", response: "Yes.", inScope: true},
+		{name: "Out of scope", response: "no", inScope: false},
+		{name: "Unexpected answer", response: "Maybe", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockLLMClient.On("Generate", mock.MatchedBy(func(prompt string) bool {
+				return strings.Contains(prompt, "about the billing API") && strings.Contains(prompt, "Question: How do refunds work?")
+			}), mock.Anything).Return(tt.response, nil)
+
+			inScope, err := slackinternal.NewLLMScopeClassifier(mockLLMClient, "the billing API").InScope("How do refunds work?")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.inScope, inScope)
+		})
+	}
+}
+
+func TestKeywordScopeClassifier(t *testing.T) {
+	classifier := slackinternal.NewKeywordScopeClassifier([]string{"Invoice", "refund"})
+
+	inScope, err := classifier.InScope("Where do I find my invoices?")
+	assert.NoError(t, err)
+	assert.True(t, inScope)
+
+	inScope, err = classifier.InScope("What's for lunch?")
+	assert.NoError(t, err)
+	assert.False(t, inScope)
+}
+
+func TestProcessMessageDeclinesOffTopicQuestions(t *testing.T) {
+	tests := []struct {
+		name     string
+		question string
+		channel  string
+		declined bool
+	}{
+		{name: "In-scope question is answered", question: "How do refunds work?", channel: "C1"},
+		{name: "Off-topic question is declined", question: "Who won the game?", channel: "C1", declined: true},
+		{name: "Channels outside the guard answer anything", question: "Who won the game?", channel: "C2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SCOPE_GUARD", "llm")
+			t.Setenv("SCOPE_DOMAIN", "the billing API")
+			t.Setenv("SCOPE_CHANNELS", "C1")
+			t.Setenv("SCOPE_DECLINE_MESSAGE", "I only answer billing questions.")
+
+			mockLLMClient := &mocks.MockLLMClient{}
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+			mockLLMClient.On("Generate", mock.MatchedBy(func(prompt string) bool {
+				return strings.Contains(prompt, "refunds")
+			}), mock.Anything).Return("yes", nil)
+			mockLLMClient.On("Generate", mock.Anything, mock.Anything).Return("no", nil)
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Return("Here's the answer.", nil)
+
+			response, err := cm.ProcessMessage(tt.channel, nil, tt.question, &slack.User{ID: "U1", Name: "alice"})
+			assert.NoError(t, err)
+			if tt.declined {
+				assert.Equal(t, "I only answer billing questions.", response)
+				mockLLMClient.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+			} else {
+				assert.Equal(t, "Here's the answer.", response)
+			}
+		})
+	}
+}
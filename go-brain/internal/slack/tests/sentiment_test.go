@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProcessIncommingMessageSentimentTagging(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      string
+		classified   string
+		wantMetadata map[string]string
+	}{
+		{name: "Enabled", enabled: "true", classified: "Negative.", wantMetadata: map[string]string{"sentiment": slackinternal.SentimentNegative}},
+		{name: "Enabled with unusable answer", enabled: "true", classified: "I can't tell", wantMetadata: nil},
+		{name: "Disabled", enabled: "false", wantMetadata: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SENTIMENT_TAGGING", tt.enabled)
+
+			mockSlackClient := &slackmocks.MockSlackClient{}
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+
+			cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+			text := "The deploy broke again, this is so frustrating"
+			mockSlackClient.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+			mockEmbedder.On("GetEmbedding", text).Return([]float32{0.1, 0.2}, nil)
+			mockLLMClient.On("Generate", mock.MatchedBy(func(prompt string) bool {
+				return strings.Contains(prompt, "Message: "+text)
+			}), mock.Anything).Return(tt.classified, nil).Maybe()
+			mockVectorDBClient.On("StoreMessage", mock.MatchedBy(func(msg vectordb.Message) bool {
+				if tt.wantMetadata == nil {
+					return msg.Metadata == nil
+				}
+				return msg.Metadata["sentiment"] == tt.wantMetadata["sentiment"]
+			})).Return(nil)
+
+			cm.ProcessIncommingMessage(text, &slack.User{ID: "U123", Name: "alice"}, "C123", "1700000000.000100", "")
+
+			mockVectorDBClient.AssertExpectations(t)
+			if tt.enabled == "true" {
+				mockLLMClient.AssertNumberOfCalls(t, "Generate", 1)
+			} else {
+				mockLLMClient.AssertNotCalled(t, "Generate", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
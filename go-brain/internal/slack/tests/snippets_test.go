@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// goCode returns a Go function with the given number of lines
+func goCode(lines int) string {
+	var code strings.Builder
+	code.WriteString("func sum(values []int) int {\n")
+	for i := 0; i < lines-2; i++ {
+		code.WriteString(fmt.Sprintf("\ttotal += values[%d]\n", i))
+	}
+	code.WriteString("}")
+	return code.String()
+}
+
+func newSnippetManager(t *testing.T) (*slackinternal.ConversationManager, *slackmocks.MockSlackClient) {
+	t.Setenv("SNIPPET_MIN_LINES", "10")
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+	return cm, mockSlackClient
+}
+
+func TestPostResponseUploadsCodeAsSnippet(t *testing.T) {
+	cm, mockSlackClient := newSnippetManager(t)
+
+	code := goCode(20)
+	response := "Here you go:\n```golang\n" + code + "\n```"
+
+	mockSlackClient.On("UploadFile", slack.FileUploadParameters{
+		Content:         code,
+		Filetype:        "go",
+		Title:           "Answer",
+		InitialComment:  "Here you go:",
+		Channels:        []string{"C123"},
+		ThreadTimestamp: "1700000000.000100",
+	}).Return(&slack.File{ID: "F123"}, nil)
+
+	assert.NoError(t, cm.PostResponse("C123", response, "1700000000.000100"))
+	mockSlackClient.AssertExpectations(t)
+	mockSlackClient.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+}
+
+func TestPostResponseFallsBackWhenUploadFails(t *testing.T) {
+	cm, mockSlackClient := newSnippetManager(t)
+
+	response := "```go\n" + goCode(20) + "\n```"
+	mockSlackClient.On("UploadFile", mock.Anything).Return(nil, errors.New("missing_scope"))
+	mockSlackClient.On("PostMessage", "C123", mock.MatchedBy(func(options []slack.MsgOption) bool {
+		return postedText(t, options) == response
+	})).Return("C123", "1700000000.000200", nil)
+
+	assert.NoError(t, cm.PostResponse("C123", response, ""))
+	mockSlackClient.AssertExpectations(t)
+}
+
+func TestPostResponseKeepsShortOrProseAnswersAsMessages(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{name: "Short code block", response: "Use this:\n```go\n" + goCode(3) + "\n```"},
+		{name: "Mostly prose", response: strings.Repeat("This explains the approach in a lot of detail. ", 40) + "\n```go\n" + goCode(12) + "\n```"},
+		{name: "No code", response: "Deploys happen on Fridays."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm, mockSlackClient := newSnippetManager(t)
+			mockSlackClient.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000200", nil)
+
+			assert.NoError(t, cm.PostResponse("C123", tt.response, ""))
+			mockSlackClient.AssertNotCalled(t, "UploadFile", mock.Anything)
+			mockSlackClient.AssertNumberOfCalls(t, "PostMessage", 1)
+		})
+	}
+}
+
+func TestPostResponseSnippetsAreOffByDefault(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+	mockSlackClient.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000200", nil)
+
+	assert.NoError(t, cm.PostResponse("C123", "```go\n"+goCode(40)+"\n```", ""))
+	mockSlackClient.AssertNotCalled(t, "UploadFile", mock.Anything)
+	mockSlackClient.AssertNumberOfCalls(t, "PostMessage", 1)
+}
+
+func TestPostResponseOnceUploadsSnippetOnce(t *testing.T) {
+	cm, mockSlackClient := newSnippetManager(t)
+	mockSlackClient.On("UploadFile", mock.Anything).Return(&slack.File{ID: "F123"}, nil)
+
+	response := "```go\n" + goCode(20) + "\n```"
+	assert.NoError(t, cm.PostResponseOnce("C123", response, "1700000000.000100", "1700000000.000100"))
+	assert.NoError(t, cm.PostResponseOnce("C123", response, "1700000000.000100", "1700000000.000100"))
+
+	mockSlackClient.AssertNumberOfCalls(t, "UploadFile", 1)
+	mockSlackClient.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+	mockSlackClient.AssertNotCalled(t, "UpdateMessage", mock.Anything, mock.Anything, mock.Anything)
+}
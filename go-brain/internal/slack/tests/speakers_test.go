@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var speakerThread = []llm.Message{
+	{Role: "user", Content: "Deploy is broken", User: &llm.User{SlackID: "U1"}},
+	{Role: "assistant", Content: "Which service?", User: &llm.User{SlackID: "UBOT"}},
+	{Role: "user", Content: "The API", User: &llm.User{SlackName: "bob", SlackID: "U2"}},
+}
+
+func TestChatPrefixesSpeakerNames(t *testing.T) {
+	t.Setenv("CHAT_SPEAKER_NAMES", "true")
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	// Looked up once for the name the message doesn't carry
+	alice := &slack.User{ID: "U1", Name: "alice", Profile: slack.UserProfile{DisplayName: "Alice"}}
+	mockSlackClient.On("GetUserInfo", "U1").Return(alice, nil).Once()
+
+	var sent []llm.Message
+	mockLLMClient.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		sent = args.Get(0).([]llm.Message)
+	}).Return("Rolling it back.", nil)
+
+	thread := append([]llm.Message{}, speakerThread...)
+	_, err := cm.ProcessMessage("C1", thread, "Any news?", &slack.User{ID: "U1", Name: "alice"})
+	assert.NoError(t, err)
+
+	var contents []string
+	for _, msg := range sent {
+		contents = append(contents, msg.Content)
+	}
+	assert.Equal(t, []string{"Alice: Deploy is broken", "Which service?", "bob: The API", "alice: Any news?"}, contents)
+	// The thread given isn't modified
+	assert.Equal(t, speakerThread, thread)
+	mockSlackClient.AssertExpectations(t)
+}
+
+func TestChatLeavesSpeakerNamesOutByDefault(t *testing.T) {
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	var sent []llm.Message
+	mockLLMClient.On("Chat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		sent = args.Get(0).([]llm.Message)
+	}).Return("Rolling it back.", nil)
+
+	_, err := cm.ProcessMessage("C1", speakerThread, "Any news?", &slack.User{ID: "U1", Name: "alice"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Deploy is broken", sent[0].Content)
+	assert.Equal(t, "Any news?", sent[len(sent)-1].Content)
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStandupNextRunSkipsDaysOff(t *testing.T) {
+	cfg := slackinternal.StandupConfig{
+		At:   9*time.Hour + 30*time.Minute,
+		Days: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	}
+
+	tests := []struct {
+		name  string
+		after time.Time
+		want  time.Time
+	}{
+		{
+			name:  "Later the same day",
+			after: time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC), // Tuesday
+			want:  time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "Next day once today's has run",
+			after: time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC),
+			want:  time.Date(2024, 3, 6, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "Monday after a Friday",
+			after: time.Date(2024, 3, 8, 10, 0, 0, 0, time.UTC),
+			want:  time.Date(2024, 3, 11, 9, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cfg.NextRun(tt.after))
+		})
+	}
+
+	assert.True(t, slackinternal.StandupConfig{}.NextRun(time.Now()).IsZero())
+}
+
+// newStandupManager returns a manager posting standups to CSTANDUP that
+// summarizes the replies two hours later
+func newStandupManager(t *testing.T) (*slackinternal.ConversationManager, *slackmocks.MockSlackClient, *mocks.MockLLMClient) {
+	t.Setenv("STANDUP_CHANNEL", "CSTANDUP")
+	t.Setenv("STANDUP_PROMPT", "What are you up to?")
+	t.Setenv("STANDUP_SUMMARY_DELAY", "2h")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+	return cm, mockSlackClient, mockLLMClient
+}
+
+func TestStandupRepliesAreSummarizedInItsThread(t *testing.T) {
+	cm, mockSlackClient, mockLLMClient := newStandupManager(t)
+
+	var posted []slack.MsgOption
+	mockSlackClient.On("PostMessage", "CSTANDUP", mock.Anything).Run(func(args mock.Arguments) {
+		posted = args.Get(1).([]slack.MsgOption)
+	}).Return("CSTANDUP", "1700000000.000100", nil).Once()
+
+	start := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+	timestamp, err := cm.PostStandup(start)
+	assert.NoError(t, err)
+	assert.Equal(t, "1700000000.000100", timestamp)
+	assert.Equal(t, "What are you up to?", postedText(t, posted))
+
+	// Nothing is summarized before the delay has passed
+	cm.RunStandups(start.Add(time.Hour))
+	mockSlackClient.AssertNotCalled(t, "GetConversationReplies", mock.Anything)
+
+	mockSlackClient.On("GetConversationReplies", mock.MatchedBy(func(params *slack.GetConversationRepliesParameters) bool {
+		return params.ChannelID == "CSTANDUP" && params.Timestamp == "1700000000.000100"
+	})).Return([]slack.Message{
+		{Msg: slack.Msg{Text: "What are you up to?", BotID: "B1", Timestamp: "1700000000.000100"}},
+		{Msg: slack.Msg{Text: "Fixing the deploy script", User: "U1", Timestamp: "1700000000.000200"}},
+		{Msg: slack.Msg{Text: "Reviewing PRs, blocked on CI", User: "U2", Timestamp: "1700000000.000300"}},
+	}, false, "", nil)
+	mockLLMClient.On("Summarize", mock.MatchedBy(func(messages []llm.Message) bool {
+		return len(messages) == 2 && messages[0].Content == "Fixing the deploy script" && messages[1].Content == "Reviewing PRs, blocked on CI"
+	}), mock.Anything).Return("• Deploy script\n• CI is blocking reviews", nil)
+
+	var summary []slack.MsgOption
+	mockSlackClient.On("PostMessage", "CSTANDUP", mock.Anything).Run(func(args mock.Arguments) {
+		summary = args.Get(1).([]slack.MsgOption)
+	}).Return("CSTANDUP", "1700000000.000400", nil).Once()
+
+	cm.RunStandups(start.Add(2 * time.Hour))
+
+	_, values, err := slack.UnsafeApplyMsgOptions("", "", "", summary...)
+	assert.NoError(t, err)
+	assert.Equal(t, "1700000000.000100", values.Get("thread_ts"))
+	assert.Equal(t, "*Standup summary* (2 replies)\n• Deploy script\n• CI is blocking reviews", values.Get("text"))
+
+	// Each standup is summarized once
+	cm.RunStandups(start.Add(3 * time.Hour))
+	mockSlackClient.AssertNumberOfCalls(t, "GetConversationReplies", 1)
+	mockSlackClient.AssertNumberOfCalls(t, "PostMessage", 2)
+}
+
+func TestStandupWithoutRepliesPostsANote(t *testing.T) {
+	cm, mockSlackClient, mockLLMClient := newStandupManager(t)
+
+	mockSlackClient.On("GetConversationReplies", mock.Anything).Return([]slack.Message{
+		{Msg: slack.Msg{Text: "What are you up to?", BotID: "B1", Timestamp: "1700000000.000100"}},
+	}, false, "", nil)
+
+	var options []slack.MsgOption
+	mockSlackClient.On("PostMessage", "CSTANDUP", mock.Anything).Run(func(args mock.Arguments) {
+		options = args.Get(1).([]slack.MsgOption)
+	}).Return("CSTANDUP", "1700000000.000400", nil)
+
+	assert.NoError(t, cm.SummarizeStandup("CSTANDUP", "1700000000.000100"))
+
+	_, values, err := slack.UnsafeApplyMsgOptions("", "", "", options...)
+	assert.NoError(t, err)
+	assert.Equal(t, "1700000000.000100", values.Get("thread_ts"))
+	assert.Equal(t, "No one replied to this standup.", values.Get("text"))
+	mockLLMClient.AssertNotCalled(t, "Summarize", mock.Anything, mock.Anything)
+}
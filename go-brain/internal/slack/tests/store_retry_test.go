@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/mock"
+)
+
+func newStoreManager(t *testing.T, strategy string) (*slackinternal.ConversationManager, *vectordbmocks.MockVectorDBClient) {
+	t.Setenv("STORE_FAILURE_STRATEGY", strategy)
+	t.Setenv("STORE_RETRIES", "2")
+	t.Setenv("STORE_RETRY_BACKOFF", "1ms")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+
+	mockSlackClient.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	mockEmbedder.On("GetEmbedding", mock.Anything).Return([]float32{0.1, 0.2}, nil)
+
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+	return cm, mockVectorDBClient
+}
+
+func TestStoreRetrySucceedsOnLaterAttempt(t *testing.T) {
+	cm, mockVectorDBClient := newStoreManager(t, "retry")
+
+	mockVectorDBClient.On("StoreMessage", mock.Anything).Return(errors.New("unavailable")).Once()
+	mockVectorDBClient.On("StoreMessage", mock.Anything).Return(nil).Once()
+
+	cm.ProcessIncommingMessage("hello", &slack.User{ID: "U1"}, "C1", "1700000000.000100", "")
+	mockVectorDBClient.AssertNumberOfCalls(t, "StoreMessage", 2)
+}
+
+func TestStoreRetryDropsAfterExhaustion(t *testing.T) {
+	cm, mockVectorDBClient := newStoreManager(t, "retry")
+
+	mockVectorDBClient.On("StoreMessage", mock.Anything).Return(errors.New("unavailable"))
+
+	// The first attempt and both retries fail, then the message is dropped
+	cm.ProcessIncommingMessage("hello", &slack.User{ID: "U1"}, "C1", "1700000000.000100", "")
+	mockVectorDBClient.AssertNumberOfCalls(t, "StoreMessage", 3)
+}
+
+func TestStoreDropDoesNotRetry(t *testing.T) {
+	cm, mockVectorDBClient := newStoreManager(t, "drop")
+
+	mockVectorDBClient.On("StoreMessage", mock.Anything).Return(errors.New("unavailable"))
+
+	cm.ProcessIncommingMessage("hello", &slack.User{ID: "U1"}, "C1", "1700000000.000100", "")
+	mockVectorDBClient.AssertNumberOfCalls(t, "StoreMessage", 1)
+}
+
+func TestStoreQueueRetriesInBackground(t *testing.T) {
+	cm, mockVectorDBClient := newStoreManager(t, "queue")
+
+	stored := make(chan struct{})
+	mockVectorDBClient.On("StoreMessage", mock.Anything).Return(errors.New("unavailable")).Once()
+	mockVectorDBClient.On("StoreMessage", mock.Anything).Run(func(args mock.Arguments) {
+		close(stored)
+	}).Return(nil).Once()
+
+	// Indexing returns after the failed attempt, leaving the retry to the queue
+	cm.ProcessIncommingMessage("hello", &slack.User{ID: "U1"}, "C1", "1700000000.000100", "")
+	mockVectorDBClient.AssertNumberOfCalls(t, "StoreMessage", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cm.StartStoreQueue(ctx)
+
+	select {
+	case <-stored:
+	case <-time.After(time.Second):
+		t.Fatal("queued message was not retried")
+	}
+}
@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"beebrain/internal/vectordb"
+
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func subtypeEvent(subtype, user, channel, ts string) string {
+	return fmt.Sprintf(`{"token":"verification-token","type":"event_callback","event":{"type":"message","subtype":%q,"user":%q,"text":"<@%s> has joined the channel","ts":%q,"channel":%q,"event_ts":%q}}`, subtype, user, user, ts, channel, ts)
+}
+
+func TestChannelJoinIsWelcomed(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     string
+		channels    string
+		user        string
+		channel     string
+		wantWelcome string
+	}{
+		{
+			name:        "Default welcome",
+			user:        "U999",
+			channel:     "C123",
+			wantWelcome: "Welcome to the channel, <@U999>! Mention me if you have any questions.",
+		},
+		{
+			name:        "Configured welcome",
+			message:     "Hi {user}, check the pinned FAQ first.",
+			user:        "U999",
+			channel:     "C123",
+			wantWelcome: "Hi <@U999>, check the pinned FAQ first.",
+		},
+		{
+			name:     "Channel without welcomes",
+			channels: "CWELCOME",
+			user:     "U999",
+			channel:  "C123",
+		},
+		{
+			name:    "The bot joining",
+			user:    "UBOT",
+			channel: "C123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WELCOME_ON_JOIN", "true")
+			t.Setenv("WELCOME_MESSAGE", tt.message)
+			t.Setenv("WELCOME_CHANNELS", tt.channels)
+			handler, m := newTestHandler(t, "chat")
+
+			var posted []string
+			m.slack.On("PostMessage", tt.channel, mock.Anything).Run(func(args mock.Arguments) {
+				posted = append(posted, postedText(t, args.Get(1).([]slack.MsgOption)))
+			}).Return(tt.channel, "1700000000.000200", nil)
+
+			rec := postEvent(t, handler, subtypeEvent("channel_join", tt.user, tt.channel, "1700000000.000100"))
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			if tt.wantWelcome == "" {
+				assert.Empty(t, posted)
+				return
+			}
+			assert.Equal(t, []string{tt.wantWelcome}, posted)
+
+			// A retried event isn't welcomed twice
+			postEvent(t, handler, subtypeEvent("channel_join", tt.user, tt.channel, "1700000000.000100"))
+			assert.Len(t, posted, 1)
+		})
+	}
+}
+
+func TestUnconfiguredSubtypesAreIgnored(t *testing.T) {
+	tests := []struct {
+		name    string
+		welcome string
+		subtype string
+	}{
+		{name: "Joins without WELCOME_ON_JOIN", subtype: "channel_join"},
+		{name: "Topic changes", welcome: "true", subtype: "channel_topic"},
+		{name: "Bots added", welcome: "true", subtype: "bot_add"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WELCOME_ON_JOIN", tt.welcome)
+			handler, m := newTestHandler(t, "chat")
+
+			rec := postEvent(t, handler, subtypeEvent(tt.subtype, "U999", "C123", "1700000000.000100"))
+			assert.Equal(t, http.StatusOK, rec.Code)
+			m.slack.AssertNotCalled(t, "PostMessage", mock.Anything, mock.Anything)
+			m.llm.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func meMessageEvent(text string) string {
+	return fmt.Sprintf(`{"token":"verification-token","type":"event_callback","event":{"type":"message","subtype":"me_message","user":"U123","text":%q,"ts":"1700000000.000200","channel":"C123","event_ts":"1700000000.000200"}}`, text)
+}
+
+func TestMeMessageIsIndexedWhenEnabled(t *testing.T) {
+	t.Setenv("ME_MESSAGES", "true")
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.embedder.On("GetEmbedding", "is deploying the API").Return([]float32{0.1, 0.2}, nil)
+	m.vectorDB.On("StoreMessage", mock.MatchedBy(func(msg vectordb.Message) bool {
+		return msg.Text == "is deploying the API" && msg.MessageTS == "1700000000.000200"
+	})).Return(nil)
+
+	postEvent(t, handler, meMessageEvent("is deploying the API"))
+
+	m.vectorDB.AssertNumberOfCalls(t, "StoreMessage", 1)
+	m.llm.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+}
+
+func TestMeMessageMentioningBotIsAnswered(t *testing.T) {
+	t.Setenv("ME_MESSAGES", "true")
+	handler, m := newTestHandler(t, "chat")
+
+	m.slack.On("GetUserInfo", "U123").Return(&slack.User{ID: "U123", Name: "alice"}, nil)
+	m.slack.On("GetUserInfo", "UBOT").Return(&slack.User{ID: "UBOT", Name: "beebrain"}, nil)
+	m.slack.On("GetConversationHistory", mock.Anything).Return(&slack.GetConversationHistoryResponse{}, nil)
+	m.embedder.On("GetEmbedding", mock.Anything).Return([]float32{0.1, 0.2}, nil)
+	m.vectorDB.On("StoreMessage", mock.Anything).Return(nil)
+	m.slack.On("AddReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("RemoveReaction", "eyes", mock.Anything).Return(nil)
+	m.slack.On("GetConversationReplies", mock.Anything).Return([]slack.Message{}, false, "", nil)
+	m.llm.On("Chat", mock.Anything, mock.Anything).Return("Good luck!", nil)
+	m.slack.On("PostMessage", "C123", mock.Anything).Return("C123", "1700000000.000300", nil)
+
+	postEvent(t, handler, meMessageEvent("waves at <@UBOT>, any deploy tips?"))
+
+	m.vectorDB.AssertNumberOfCalls(t, "StoreMessage", 1)
+	m.llm.AssertNumberOfCalls(t, "Chat", 1)
+	m.slack.AssertNumberOfCalls(t, "PostMessage", 1)
+}
+
+func TestMeMessageIgnoredByDefault(t *testing.T) {
+	handler, m := newTestHandler(t, "chat")
+
+	rec := postEvent(t, handler, meMessageEvent("is deploying the API"))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	m.vectorDB.AssertNotCalled(t, "StoreMessage", mock.Anything)
+	m.llm.AssertNotCalled(t, "Chat", mock.Anything, mock.Anything)
+}
+
+func memberJoinedEvent(user, channel, ts string) string {
+	return fmt.Sprintf(`{"token":"verification-token","type":"event_callback","event":{"type":"member_joined_channel","user":%q,"channel":%q,"channel_type":"C","team":"T123","event_ts":%q}}`, user, channel, ts)
+}
+
+func TestBotJoiningChannelIsGreeted(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      string
+		greeting     string
+		user         string
+		wantGreeting string
+	}{
+		{
+			name:         "Default greeting",
+			enabled:      "true",
+			user:         "UBOT",
+			wantGreeting: "Hi everyone, I'm <@UBOT>! Mention me with a question and I'll answer it using what has been discussed in the workspace.",
+		},
+		{
+			name:         "Configured greeting",
+			enabled:      "true",
+			greeting:     "{bot} here, ask me about deploys.",
+			user:         "UBOT",
+			wantGreeting: "<@UBOT> here, ask me about deploys.",
+		},
+		{name: "Someone else joining", enabled: "true", user: "U999"},
+		{name: "Greeting disabled", user: "UBOT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GREET_ON_JOIN", tt.enabled)
+			t.Setenv("JOIN_GREETING", tt.greeting)
+			handler, m := newTestHandler(t, "chat")
+
+			var posted []string
+			m.slack.On("PostMessage", "C123", mock.Anything).Run(func(args mock.Arguments) {
+				posted = append(posted, postedText(t, args.Get(1).([]slack.MsgOption)))
+			}).Return("C123", "1700000000.000200", nil)
+
+			rec := postEvent(t, handler, memberJoinedEvent(tt.user, "C123", "1700000000.000100"))
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			if tt.wantGreeting == "" {
+				assert.Empty(t, posted)
+				return
+			}
+			assert.Equal(t, []string{tt.wantGreeting}, posted)
+
+			// A retried event doesn't greet twice
+			postEvent(t, handler, memberJoinedEvent(tt.user, "C123", "1700000000.000100"))
+			assert.Len(t, posted, 1)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// lastContent returns the content of the last message
+func lastContent(messages []llm.Message) string {
+	return messages[len(messages)-1].Content
+}
+
+func TestProcessMessageCallsRegisteredTool(t *testing.T) {
+	t.Setenv("TOOLS_ENABLED", "true")
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+
+	invoked := false
+	assert.NoError(t, cm.RegisterTool(llm.Tool{
+		Name:        "count_open_prs",
+		Description: "Counts open pull requests.",
+		Handler: func(json.RawMessage) (string, error) {
+			invoked = true
+			return "7", nil
+		},
+	}))
+
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return lastContent(messages) == "How many open PRs?"
+	}), mock.Anything).Return(`{"tool": "count_open_prs", "arguments": {}}`, nil).Once()
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return lastContent(messages) == "Result of count_open_prs: 7"
+	}), mock.Anything).Return("There are 7 open PRs.", nil).Once()
+
+	response, err := cm.ProcessMessage("C1", nil, "How many open PRs?", &slack.User{ID: "U1", Name: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, "There are 7 open PRs.", response)
+	assert.True(t, invoked)
+}
+
+func TestProcessMessageSearchTool(t *testing.T) {
+	t.Setenv("TOOLS_ENABLED", "true")
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockEmbedder := &mocks.MockEmbedder{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+	embedding := []float32{0.1, 0.2}
+	mockEmbedder.On("GetEmbedding", "deploy day").Return(embedding, nil)
+	mockVectorDBClient.On("SearchSimilar", mock.Anything, embedding, uint64(5)).Return([]vectordb.Message{
+		{UserID: "U2", Text: "We deploy on Fridays"},
+	}, nil)
+
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return !strings.HasPrefix(lastContent(messages), "Result of")
+	}), mock.Anything).Return(`{"tool": "search_messages", "arguments": {"query": "deploy day"}}`, nil).Once()
+	mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+		return lastContent(messages) == "Result of search_messages: <@U2>: We deploy on Fridays"
+	}), mock.Anything).Return("On Fridays.", nil).Once()
+
+	response, err := cm.ProcessMessage("C1", nil, "When do we deploy?", &slack.User{ID: "U1", Name: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, "On Fridays.", response)
+}
+
+func TestSearchToolFollowsRetrievalSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		minScore string
+		want     string
+	}{
+		{name: "Answers are left out", want: "<@U2>: We deploy on Fridays"},
+		{name: "Nothing above the grounding threshold", minScore: "0.9", want: "no related messages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TOOLS_ENABLED", "true")
+			t.Setenv("RAG_EXCLUDE_RESPONSES", "true")
+			t.Setenv("GROUNDING_MIN_SCORE", tt.minScore)
+
+			mockLLMClient := &mocks.MockLLMClient{}
+			mockEmbedder := &mocks.MockEmbedder{}
+			mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, mockEmbedder, logrus.New(), "chat", mockVectorDBClient)
+
+			embedding := []float32{0.1, 0.2}
+			mockEmbedder.On("GetEmbedding", "deploy day").Return(embedding, nil)
+			mockVectorDBClient.On("SearchSimilar", mock.Anything, embedding, uint64(5)).Return([]vectordb.Message{
+				{UserID: "UBOT", Text: "Deploys are on Mondays", Score: 0.95, Metadata: map[string]string{"role": "assistant"}},
+				{UserID: "U2", Text: "We deploy on Fridays", Score: 0.6},
+			}, nil)
+
+			mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+				return !strings.HasPrefix(lastContent(messages), "Result of")
+			}), mock.Anything).Return(`{"tool": "search_messages", "arguments": {"query": "deploy day"}}`, nil).Once()
+			mockLLMClient.On("Chat", mock.MatchedBy(func(messages []llm.Message) bool {
+				return lastContent(messages) == "Result of search_messages: "+tt.want
+			}), mock.Anything).Return("On Fridays.", nil).Once()
+
+			_, err := cm.ProcessMessage("C1", nil, "When do we deploy?", &slack.User{ID: "U1", Name: "alice"})
+			assert.NoError(t, err)
+			mockLLMClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRegisterToolWhenDisabled(t *testing.T) {
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+	assert.Error(t, cm.RegisterTool(llm.Tool{Name: "noop", Handler: func(json.RawMessage) (string, error) { return "", nil }}))
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	"beebrain/internal/vectordb"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var recentTopicMessages = []vectordb.Message{
+	{ID: "1", ChannelID: "C1", Text: "The deploy failed again", Embedding: []float32{1, 0, 0}},
+	{ID: "2", ChannelID: "C1", Text: "Where do we order lunch?", Embedding: []float32{0, 1, 0}},
+	{ID: "3", ChannelID: "C1", Text: "Deploy pipeline is red", Embedding: []float32{0.95, 0.05, 0}},
+	{ID: "4", ChannelID: "C1", Text: "Rolling back the deploy", Embedding: []float32{0.9, 0, 0.1}},
+	{ID: "5", ChannelID: "C1", Text: "Pizza for lunch?", Embedding: []float32{0.1, 0.9, 0}},
+	{ID: "6", ChannelID: "C1", Text: "Who is on call?", Embedding: []float32{0, 0, 1}},
+}
+
+func TestTopTopicsClustersAndLabels(t *testing.T) {
+	t.Setenv("TOPICS_SIMILARITY", "0.9")
+	t.Setenv("TOPICS_MIN_SIZE", "2")
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", mockVectorDBClient)
+
+	since := time.Now().Add(-7 * 24 * time.Hour)
+	mockVectorDBClient.On("RecentMessages", mock.Anything, "C1", since, 500).Return(recentTopicMessages, nil)
+	mockLLMClient.On("Generate", mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "- The deploy failed again\n- Deploy pipeline is red\n- Rolling back the deploy")
+	}), mock.Anything).Return(" \"Failing deploys.\"\n", nil)
+	mockLLMClient.On("Generate", mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "lunch")
+	}), mock.Anything).Return("Lunch orders", nil)
+
+	topics, err := cm.TopTopics(context.Background(), "C1", since)
+	assert.NoError(t, err)
+
+	// The on-call question alone isn't a topic
+	if assert.Len(t, topics, 2) {
+		assert.Equal(t, "Failing deploys", topics[0].Label)
+		assert.Len(t, topics[0].Messages, 3)
+		assert.Equal(t, "Lunch orders", topics[1].Label)
+		assert.Len(t, topics[1].Messages, 2)
+	}
+	mockLLMClient.AssertNumberOfCalls(t, "Generate", 2)
+}
+
+func TestTopTopicsHonorsMaxAndLabelFallback(t *testing.T) {
+	t.Setenv("TOPICS_SIMILARITY", "0.9")
+	t.Setenv("TOPICS_MIN_SIZE", "1")
+	t.Setenv("TOPICS_MAX", "1")
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", mockVectorDBClient)
+
+	mockVectorDBClient.On("RecentMessages", mock.Anything, "C1", mock.Anything, 500).Return(recentTopicMessages, nil)
+	mockLLMClient.On("Generate", mock.Anything, mock.Anything).Return("", errors.New("model unavailable"))
+
+	topics, err := cm.TopTopics(context.Background(), "C1", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	if assert.Len(t, topics, 1) {
+		assert.Equal(t, "The deploy failed again", topics[0].Label)
+	}
+}
+
+func TestTopTopicsRejectsNonPositiveSimilarity(t *testing.T) {
+	t.Setenv("TOPICS_SIMILARITY", "0")
+	t.Setenv("TOPICS_MIN_SIZE", "2")
+	mockLLMClient := &mocks.MockLLMClient{}
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", mockVectorDBClient)
+
+	// A message embedded by another model mid-migration
+	messages := append([]vectordb.Message{}, recentTopicMessages...)
+	messages = append(messages, vectordb.Message{ID: "7", ChannelID: "C1", Text: "Deploy is green now", Embedding: []float32{1, 0, 0, 0}})
+	mockVectorDBClient.On("RecentMessages", mock.Anything, "C1", mock.Anything, 500).Return(messages, nil)
+	mockLLMClient.On("Generate", mock.Anything, mock.Anything).Return("Topic", nil)
+
+	// The default similarity applies, instead of one topic of everything
+	topics, err := cm.TopTopics(context.Background(), "C1", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	if assert.Len(t, topics, 2) {
+		assert.Len(t, topics[0].Messages, 3)
+		assert.Len(t, topics[1].Messages, 2)
+	}
+}
+
+func TestTopTopicsReturnsFetchErrors(t *testing.T) {
+	mockVectorDBClient := &vectordbmocks.MockVectorDBClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", mockVectorDBClient)
+	mockVectorDBClient.On("RecentMessages", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("unavailable"))
+
+	_, err := cm.TopTopics(context.Background(), "C1", time.Now().Add(-time.Hour))
+	assert.Error(t, err)
+}
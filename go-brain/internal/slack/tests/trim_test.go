@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"regexp"
+	"testing"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProcessMessageTrimsFiller(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{
+			name:     "Exclamation preamble",
+			response: "Sure! Deploys happen on Fridays.",
+			want:     "Deploys happen on Fridays.",
+		},
+		{
+			name:     "Here's line preamble",
+			response: "Certainly, here's a summary of the thread:\n- Ship Friday\n- Roll back if needed",
+			want:     "- Ship Friday\n- Roll back if needed",
+		},
+		{
+			name:     "Sign-off",
+			response: "Deploys happen on Fridays.\n\nHope this helps!",
+			want:     "Deploys happen on Fridays.",
+		},
+		{
+			name:     "Both",
+			response: "Of course! Here is the answer:\nUse `make deploy`.\nLet me know if you have any other questions.",
+			want:     "Use `make deploy`.",
+		},
+		{
+			name:     "Sure as content is kept",
+			response: "Sure thing is, nobody knows.",
+			want:     "Sure thing is, nobody knows.",
+		},
+		{
+			name:     "Here's mid-line is kept",
+			response: "Here's what I found: deploys happen on Fridays.",
+			want:     "Here's what I found: deploys happen on Fridays.",
+		},
+		{
+			name:     "Feel free in the middle is kept",
+			response: "Feel free to deploy on Fridays.\nJust not on holidays.",
+			want:     "Feel free to deploy on Fridays.\nJust not on holidays.",
+		},
+		{
+			name:     "Nothing but filler is kept",
+			response: "Sure!",
+			want:     "Sure!",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RESPONSE_TRIM", "true")
+
+			mockLLMClient := &mocks.MockLLMClient{}
+			cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+			mockLLMClient.On("Chat", mock.Anything, mock.Anything).Return(tt.response, nil)
+
+			response, err := cm.ProcessMessage("C1", nil, "When do we deploy?", &slack.User{ID: "U1", Name: "alice"})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, response)
+		})
+	}
+}
+
+func TestProcessMessageKeepsFillerWhenTrimmingIsOff(t *testing.T) {
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+	mockLLMClient.On("Chat", mock.Anything, mock.Anything).Return("Sure! On Fridays.", nil)
+
+	response, err := cm.ProcessMessage("C1", nil, "When do we deploy?", &slack.User{ID: "U1", Name: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Sure! On Fridays.", response)
+}
+
+func TestResponseTrimmerCustomPatterns(t *testing.T) {
+	trimmer := &slackinternal.ResponseTrimmer{
+		Preamble: regexp.MustCompile(`^As an AI[^.]*\.\s*`),
+		SignOff:  regexp.MustCompile(`\s*Cheers!$`),
+	}
+	assert.Equal(t, "Fridays.", trimmer.Trim("As an AI model, I think so. Fridays. Cheers!"))
+
+	// Patterns only apply at the edges of the response
+	assert.Equal(t, "Fridays. As an AI model, I think so.", trimmer.Trim("Fridays. As an AI model, I think so."))
+}
+
+func TestProcessMessageTrimsConfiguredPatterns(t *testing.T) {
+	t.Setenv("RESPONSE_TRIM", "true")
+	t.Setenv("RESPONSE_SIGNOFF_PATTERN", `\s*-- BeeBrain$`)
+
+	mockLLMClient := &mocks.MockLLMClient{}
+	cm := slackinternal.NewConversationManager(&slackmocks.MockSlackClient{}, mockLLMClient, &mocks.MockEmbedder{}, logrus.New(), "chat", nil)
+	mockLLMClient.On("Chat", mock.Anything, mock.Anything).Return("Sure! On Fridays.\n-- BeeBrain", nil)
+
+	response, err := cm.ProcessMessage("C1", nil, "When do we deploy?", &slack.User{ID: "U1", Name: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, "On Fridays.", response)
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"beebrain/internal/llm/mocks"
+	slackinternal "beebrain/internal/slack"
+	slackmocks "beebrain/internal/slack/mocks"
+	vectordbmocks "beebrain/internal/vectordb/mocks"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUserInfoCachesWithinTTL(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+
+	alice := &slack.User{ID: "U1", Name: "alice"}
+	bob := &slack.User{ID: "U2", Name: "bob"}
+	mockSlackClient.On("GetUserInfo", "U1").Return(alice, nil)
+	mockSlackClient.On("GetUserInfo", "U2").Return(bob, nil)
+
+	// First lookup is a miss, the rest are hits
+	for i := 0; i < 3; i++ {
+		user, err := cm.GetUserInfo("U1")
+		assert.NoError(t, err)
+		assert.Equal(t, alice, user)
+	}
+	mockSlackClient.AssertNumberOfCalls(t, "GetUserInfo", 1)
+
+	// Different users are cached separately
+	user, err := cm.GetUserInfo("U2")
+	assert.NoError(t, err)
+	assert.Equal(t, bob, user)
+	mockSlackClient.AssertNumberOfCalls(t, "GetUserInfo", 2)
+}
+
+func TestGetUserInfoCacheExpires(t *testing.T) {
+	t.Setenv("USER_CACHE_TTL", "20ms")
+
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+
+	mockSlackClient.On("GetUserInfo", "U1").Return(&slack.User{ID: "U1", Name: "alice"}, nil)
+
+	_, err := cm.GetUserInfo("U1")
+	assert.NoError(t, err)
+	_, err = cm.GetUserInfo("U1")
+	assert.NoError(t, err)
+	mockSlackClient.AssertNumberOfCalls(t, "GetUserInfo", 1)
+
+	time.Sleep(30 * time.Millisecond)
+
+	_, err = cm.GetUserInfo("U1")
+	assert.NoError(t, err)
+	mockSlackClient.AssertNumberOfCalls(t, "GetUserInfo", 2)
+}
+
+func TestGetUserInfoDoesNotCacheErrors(t *testing.T) {
+	mockSlackClient := &slackmocks.MockSlackClient{}
+	cm := slackinternal.NewConversationManager(mockSlackClient, &mocks.MockLLMClient{}, &mocks.MockEmbedder{}, logrus.New(), "chat", &vectordbmocks.MockVectorDBClient{})
+
+	mockSlackClient.On("GetUserInfo", "U1").Return(nil, errors.New("user_not_found")).Once()
+	mockSlackClient.On("GetUserInfo", "U1").Return(&slack.User{ID: "U1"}, nil).Once()
+
+	_, err := cm.GetUserInfo("U1")
+	assert.Error(t, err)
+	_, err = cm.GetUserInfo("U1")
+	assert.NoError(t, err)
+	mockSlackClient.AssertNumberOfCalls(t, "GetUserInfo", 2)
+}
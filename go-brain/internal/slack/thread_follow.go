@@ -0,0 +1,42 @@
+package slack
+
+import (
+	"time"
+)
+
+// followThread has the bot answer the follow-up messages of a thread it was
+// mentioned in, without another mention, until the follow window passes
+// without it answering there
+func (h *BeeBrainSlackHandler) followThread(channel, threadTimestamp string) {
+	if h.followWindow <= 0 || threadTimestamp == "" {
+		return
+	}
+
+	now := time.Now()
+	h.followedThreads.Range(func(key, value interface{}) bool {
+		if now.After(value.(time.Time)) {
+			h.followedThreads.Delete(key)
+		}
+		return true
+	})
+	h.followedThreads.Store(channel+":"+threadTimestamp, now.Add(h.followWindow))
+}
+
+// followingThread reports whether the bot still answers follow-ups in a thread
+func (h *BeeBrainSlackHandler) followingThread(channel, threadTimestamp string) bool {
+	if h.followWindow <= 0 || threadTimestamp == "" {
+		return false
+	}
+
+	key := channel + ":" + threadTimestamp
+	expiry, ok := h.followedThreads.Load(key)
+	if !ok {
+		return false
+	}
+	if time.Now().After(expiry.(time.Time)) {
+		h.followedThreads.Delete(key)
+		h.logger.Debugf("Stopped following thread %s in channel %s", threadTimestamp, channel)
+		return false
+	}
+	return true
+}
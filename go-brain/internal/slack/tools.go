@@ -0,0 +1,54 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"beebrain/internal/llm"
+)
+
+// searchToolResults is how many messages the search_messages tool returns
+const searchToolResults = 5
+
+// RegisterTool makes tool available to the model when answering in chat mode
+func (m *ConversationManager) RegisterTool(tool llm.Tool) error {
+	if m.tools == nil {
+		return fmt.Errorf("tool calling is disabled, set TOOLS_ENABLED to use tools")
+	}
+	return m.tools.Register(tool)
+}
+
+// searchMessagesTool lets the model search the indexed messages itself, for
+// questions retrieval up front didn't cover
+func (m *ConversationManager) searchMessagesTool() llm.Tool {
+	return llm.Tool{
+		Name:        "search_messages",
+		Description: "Searches the Slack messages of this workspace for ones related to a query.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"query":{"type":"string"}},"required":["query"]}`),
+		Handler: func(args json.RawMessage) (string, error) {
+			var params struct {
+				Query string `json:"query"`
+			}
+			if err := json.Unmarshal(args, &params); err != nil || params.Query == "" {
+				return "", fmt.Errorf("expected arguments like {\"query\": \"...\"}")
+			}
+
+			// Searched like retrieval up front, so the tool can't bring back
+			// the bot's own answers or messages below GROUNDING_MIN_SCORE
+			results, grounded, err := m.searchSources(params.Query, searchToolResults)
+			if err != nil {
+				return "", err
+			}
+			if !grounded || len(results) == 0 {
+				return "no related messages", nil
+			}
+
+			var found strings.Builder
+			for _, result := range results {
+				found.WriteString(fmt.Sprintf("<@%s>: %s\n", result.UserID, result.Text))
+			}
+			return strings.TrimSuffix(found.String(), "\n"), nil
+		},
+	}
+}
@@ -0,0 +1,74 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"beebrain/internal/vectordb"
+)
+
+const (
+	// topicSamples is how many messages of a cluster the LLM sees to name it
+	topicSamples = 10
+	// topicLabelMaxRunes cuts off a message standing in for a topic label
+	topicLabelMaxRunes = 60
+)
+
+const topicLabelPrompt = "The following Slack messages are about the same topic. Name the topic in at most five words. Reply with the name only.\n\n"
+
+// Topic is a recurring subject of a channel: a label named by the LLM and the
+// messages about it, most recent first
+type Topic struct {
+	Label    string
+	Messages []vectordb.Message
+}
+
+// TopTopics clusters the messages of a channel posted since the given time by
+// similarity and names the largest clusters with the LLM. Clusters of fewer
+// than TOPICS_MIN_SIZE messages aren't topics, and at most TOPICS_MAX are
+// returned, largest first.
+func (m *ConversationManager) TopTopics(ctx context.Context, channelID string, since time.Time) ([]Topic, error) {
+	if m.vectorDB == nil {
+		return nil, errors.New("topics need a vectorDB client")
+	}
+
+	messages, err := m.vectorDB.RecentMessages(ctx, channelID, since, m.config.topicsMaxMessages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recent messages of channel %s: %w", channelID, err)
+	}
+
+	var topics []Topic
+	for _, cluster := range vectordb.ClusterMessages(messages, m.config.topicsSimilarity) {
+		if len(cluster) < m.config.topicsMinSize || len(topics) >= m.config.topicsMax {
+			break
+		}
+		topics = append(topics, Topic{Label: m.topicLabel(cluster), Messages: cluster})
+	}
+
+	m.logger.Infof("Found %d topics in %d messages of channel %s", len(topics), len(messages), channelID)
+	return topics, nil
+}
+
+// topicLabel asks the LLM to name the topic of a cluster of messages, falling
+// back to the text of its first message when that fails
+func (m *ConversationManager) topicLabel(cluster []vectordb.Message) string {
+	var prompt strings.Builder
+	prompt.WriteString(topicLabelPrompt)
+	for _, msg := range cluster[:min(len(cluster), topicSamples)] {
+		prompt.WriteString("- " + strings.ReplaceAll(msg.Text, "\n", " ") + "\n")
+	}
+
+	label, err := m.llmClient.Generate(prompt.String(), m.modelOptions(cluster[0].ChannelID)...)
+	label = strings.Trim(strings.TrimSpace(label), `"'.`)
+	if err != nil || label == "" {
+		m.logger.Warnf("Failed to name topic, using its first message instead: %v", err)
+		label = strings.TrimSpace(cluster[0].Text)
+		if runes := []rune(label); len(runes) > topicLabelMaxRunes {
+			label = strings.TrimSpace(string(runes[:topicLabelMaxRunes])) + "…"
+		}
+	}
+	return label
+}
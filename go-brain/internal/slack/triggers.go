@@ -0,0 +1,25 @@
+package slack
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// matchesTrigger reports whether text starts with one of the trigger words,
+// ignoring case, as a whole word. Requiring the start of the message keeps
+// messages that merely talk about the bot from being answered.
+func matchesTrigger(text string, triggers []string) bool {
+	text = strings.TrimSpace(text)
+	for _, trigger := range triggers {
+		if len(text) < len(trigger) || !strings.EqualFold(text[:len(trigger)], trigger) {
+			continue
+		}
+		// "beebrain, hi" matches but "beebrains are" doesn't
+		next, _ := utf8.DecodeRuneInString(text[len(trigger):])
+		if next == utf8.RuneError || !(unicode.IsLetter(next) || unicode.IsDigit(next)) {
+			return true
+		}
+	}
+	return false
+}
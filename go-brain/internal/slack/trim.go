@@ -0,0 +1,74 @@
+package slack
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"beebrain/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Filler models put around answers despite the persona prompt. A preamble is
+// an opening exclamation like "Sure!" and/or a "Here's ...:" line; a sign-off
+// is a closing line like "Hope this helps!".
+const (
+	defaultPreamblePattern = `(?i)^\s*(?:(?:sure|certainly|of course|absolutely|great question|good question)[!.,]+\s*)?(?:here(?:'s| is| are)\b[^\n]*:[ \t]*\n)?`
+	defaultSignOffPattern  = `(?i)\n\s*(?:i )?(?:hope (?:this|that) helps|let me know if [^\n]*|feel free to [^\n]*|happy to help[^\n]*)[!.]*\s*$`
+)
+
+// ResponseTrimmer strips preambles and sign-offs from LLM responses
+type ResponseTrimmer struct {
+	Preamble *regexp.Regexp
+	SignOff  *regexp.Regexp
+}
+
+// loadResponseTrimmer returns the configured trimmer, or nil when trimming is
+// not enabled
+func loadResponseTrimmer(logger *logrus.Logger) *ResponseTrimmer {
+	if !config.Bool(logger, "RESPONSE_TRIM", false) {
+		return nil
+	}
+	return &ResponseTrimmer{
+		Preamble: loadPattern(logger, "RESPONSE_PREAMBLE_PATTERN", defaultPreamblePattern),
+		SignOff:  loadPattern(logger, "RESPONSE_SIGNOFF_PATTERN", defaultSignOffPattern),
+	}
+}
+
+func loadPattern(logger *logrus.Logger, key, def string) *regexp.Regexp {
+	if value := os.Getenv(key); value != "" {
+		pattern, err := regexp.Compile(value)
+		if err == nil {
+			return pattern
+		}
+		logger.Warnf("Invalid %s '%s', using the default pattern: %v", key, value, err)
+	}
+	return regexp.MustCompile(def)
+}
+
+// Trim removes the preamble at the start and the sign-off at the end of
+// response. A response that is nothing but filler is returned unchanged.
+func (t *ResponseTrimmer) Trim(response string) string {
+	if t == nil {
+		return response
+	}
+
+	trimmed := response
+	if t.Preamble != nil {
+		if loc := t.Preamble.FindStringIndex(trimmed); loc != nil && loc[0] == 0 {
+			trimmed = trimmed[loc[1]:]
+		}
+	}
+	if t.SignOff != nil {
+		if loc := t.SignOff.FindStringIndex(trimmed); loc != nil && loc[1] == len(trimmed) {
+			trimmed = trimmed[:loc[0]]
+		}
+	}
+
+	trimmed = strings.TrimSpace(trimmed)
+	if trimmed == "" {
+		return response
+	}
+	return trimmed
+}
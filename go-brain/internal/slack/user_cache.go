@@ -0,0 +1,68 @@
+package slack
+
+import (
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+type userCacheEntry struct {
+	user      *slack.User
+	expiresAt time.Time
+}
+
+// userCache is a TTL cache of Slack user lookups keyed by user ID
+type userCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]userCacheEntry
+}
+
+func newUserCache(ttl time.Duration) *userCache {
+	return &userCache{
+		ttl:     ttl,
+		entries: make(map[string]userCacheEntry),
+	}
+}
+
+func (c *userCache) get(userID string) (*slack.User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[userID]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, userID)
+		return nil, false
+	}
+	return entry.user, true
+}
+
+func (c *userCache) set(userID string, user *slack.User) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[userID] = userCacheEntry{user: user, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// GetUserInfo returns the Slack user with the given ID, serving repeated
+// lookups from a cache until they expire
+func (m *ConversationManager) GetUserInfo(userID string) (*slack.User, error) {
+	if user, ok := m.users.get(userID); ok {
+		return user, nil
+	}
+
+	user, err := m.client.GetUserInfo(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.users.set(userID, user)
+	return user, nil
+}
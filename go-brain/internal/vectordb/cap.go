@@ -0,0 +1,240 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+)
+
+const capPageSize = 256
+
+// storedChannels returns the distinct channels of a batch of messages
+func storedChannels(msgs []Message) []string {
+	seen := make(map[string]bool)
+	channels := make([]string, 0, 1)
+	for _, msg := range msgs {
+		if msg.ChannelID != "" && !seen[msg.ChannelID] {
+			seen[msg.ChannelID] = true
+			channels = append(channels, msg.ChannelID)
+		}
+	}
+	return channels
+}
+
+// oldestBeyondCap returns the messages to evict to bring a channel down to
+// max messages, the earliest posted first
+func oldestBeyondCap(msgs []Message, max int) []Message {
+	if max <= 0 || len(msgs) <= max {
+		return nil
+	}
+	sorted := make([]Message, len(msgs))
+	copy(sorted, msgs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return earlierMessage(sorted[i], sorted[j])
+	})
+	return sorted[:len(sorted)-max]
+}
+
+// enforceChannelCaps evicts the oldest messages of the channels just stored
+// to that are over maxPerChannel. The new messages are already stored, so a
+// failure is only logged.
+func (c *Client) enforceChannelCaps(msgs []Message) {
+	if c.maxPerChannel <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, channelID := range storedChannels(msgs) {
+		evicted, err := c.evictOldest(ctx, channelID)
+		if err != nil {
+			c.logger.Warnf("Failed to cap messages of channel %s: %v", channelID, err)
+			continue
+		}
+		if evicted > 0 {
+			c.logger.Infof("Evicted %d oldest messages of channel %s to stay within %d", evicted, channelID, c.maxPerChannel)
+		}
+	}
+}
+
+// evictOldest deletes the oldest messages of a channel beyond maxPerChannel
+// and returns how many were deleted. The channel is counted first so a store
+// within the cap costs one request, and only the excess is fetched to evict.
+func (c *Client) evictOldest(ctx context.Context, channelID string) (int, error) {
+	total, err := c.countPoints(ctx, channelFilter(channelID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count channel %s: %w", channelID, err)
+	}
+	if total <= c.maxPerChannel {
+		return 0, nil
+	}
+
+	oldest, err := c.oldestOfChannel(ctx, channelID, total-c.maxPerChannel)
+	if err != nil {
+		return 0, err
+	}
+	if len(oldest) == 0 {
+		return 0, nil
+	}
+	ids := make([]*go_client.PointId, 0, len(oldest))
+	for _, msg := range oldest {
+		ids = append(ids, pointID(msg.ID))
+	}
+	if err := c.deletePoints(ctx, ids); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// oldestOfChannel returns the n earliest posted messages of a channel. Points
+// stored before posted_at was written predate every other one and go first,
+// in no particular order.
+// The Qdrant client has no ordered scroll, so the posted_at cutoff below which
+// the rest lie is found by bisecting counts, and only the points before it are
+// scrolled.
+func (c *Client) oldestOfChannel(ctx context.Context, channelID string, n int) ([]Message, error) {
+	undated, err := c.scrollChannel(ctx, channelID, &go_client.Condition{
+		ConditionOneOf: &go_client.Condition_IsEmpty{IsEmpty: &go_client.IsEmptyCondition{Key: postedAtKey}},
+	}, n)
+	if err != nil {
+		return nil, err
+	}
+	remaining := n - len(undated)
+	if remaining == 0 {
+		return undated, nil
+	}
+
+	// Find the smallest cutoff with at least remaining points posted before it
+	postedBefore := func(cutoff int64) (int, error) {
+		filter := channelFilter(channelID)
+		filter.Must = append(filter.Must, postedBeforeCondition(cutoff))
+		return c.countPoints(ctx, filter)
+	}
+	lo, hi := int64(0), time.Now().Unix()+1
+	if count, err := postedBefore(hi); err != nil {
+		return nil, fmt.Errorf("failed to count channel %s: %w", channelID, err)
+	} else if count < remaining {
+		hi = math.MaxInt64
+	}
+	for hi != math.MaxInt64 && hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		count, err := postedBefore(mid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count channel %s: %w", channelID, err)
+		}
+		if count >= remaining {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	dated, err := c.scrollChannel(ctx, channelID, postedBeforeCondition(hi), 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(dated) > remaining {
+		dated = oldestBeyondCap(dated, len(dated)-remaining)
+	}
+	return append(undated, dated...), nil
+}
+
+// scrollChannel returns the messages of a channel that match condition, at
+// most limit of them unless limit is 0, with only the payload fields that
+// order them
+func (c *Client) scrollChannel(ctx context.Context, channelID string, condition *go_client.Condition, limit int) ([]Message, error) {
+	filter := channelFilter(channelID)
+	filter.Must = append(filter.Must, condition)
+
+	var msgs []Message
+	var offset *go_client.PointId
+	for {
+		pageSize := uint32(capPageSize)
+		if limit > 0 && limit-len(msgs) < capPageSize {
+			pageSize = uint32(limit - len(msgs))
+		}
+		page, err := c.pointsClient.Scroll(ctx, &go_client.ScrollPoints{
+			CollectionName: c.collection,
+			Filter:         filter,
+			Offset:         offset,
+			Limit:          &pageSize,
+			WithPayload: &go_client.WithPayloadSelector{SelectorOptions: &go_client.WithPayloadSelector_Include{
+				Include: &go_client.PayloadIncludeSelector{Fields: []string{"timestamp", "message_ts"}},
+			}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scroll channel %s: %w", channelID, err)
+		}
+		for _, point := range page.Result {
+			msgs = append(msgs, messageFromPoint(point.Id, point.Payload, nil))
+		}
+
+		if page.NextPageOffset == nil || (limit > 0 && len(msgs) >= limit) {
+			return msgs, nil
+		}
+		offset = page.NextPageOffset
+	}
+}
+
+// countPoints returns the exact number of points matching filter
+func (c *Client) countPoints(ctx context.Context, filter *go_client.Filter) (int, error) {
+	exact := true
+	resp, err := c.pointsClient.Count(ctx, &go_client.CountPoints{
+		CollectionName: c.collection,
+		Filter:         filter,
+		Exact:          &exact,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(resp.GetResult().GetCount()), nil
+}
+
+// postedBeforeCondition matches the points posted before cutoff, in Unix
+// seconds
+func postedBeforeCondition(cutoff int64) *go_client.Condition {
+	before := float64(cutoff)
+	return &go_client.Condition{
+		ConditionOneOf: &go_client.Condition_Field{Field: &go_client.FieldCondition{
+			Key:   postedAtKey,
+			Range: &go_client.Range{Lt: &before},
+		}},
+	}
+}
+
+// enforceChannelCap evicts the oldest messages of a channel beyond
+// maxPerChannel. The caller holds the lock.
+func (c *MemoryClient) enforceChannelCap(channelID string) {
+	if c.maxPerChannel <= 0 || channelID == "" {
+		return
+	}
+
+	var channel []Message
+	for _, msg := range c.messages {
+		if msg.ChannelID == channelID {
+			channel = append(channel, msg)
+		}
+	}
+	oldest := oldestBeyondCap(channel, c.maxPerChannel)
+	if len(oldest) == 0 {
+		return
+	}
+
+	evict := make(map[string]bool, len(oldest))
+	for _, msg := range oldest {
+		evict[msg.ID] = true
+	}
+	kept := c.messages[:0]
+	for _, msg := range c.messages {
+		if !evict[msg.ID] {
+			kept = append(kept, msg)
+		}
+	}
+	c.messages = kept
+	c.logger.Debugf("Evicted %d oldest messages of channel %s from memory store", len(oldest), channelID)
+}
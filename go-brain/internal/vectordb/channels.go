@@ -0,0 +1,71 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+)
+
+const listChannelsPageSize = 1000
+
+// ChannelCount is how many messages of a channel are indexed
+type ChannelCount struct {
+	ChannelID string
+	Count     int
+}
+
+// ListIndexedChannels returns the channels with messages in the collection,
+// most messages first. Qdrant 1.7 has no facet API, so this scrolls through
+// the channel_id payload of every point.
+func (c *Client) ListIndexedChannels(ctx context.Context) ([]ChannelCount, error) {
+	if c.closed.Load() {
+		return nil, ErrClosed
+	}
+
+	counts := make(map[string]int)
+	limit := uint32(listChannelsPageSize)
+	var offset *go_client.PointId
+	for {
+		page, err := c.pointsClient.Scroll(ctx, &go_client.ScrollPoints{
+			CollectionName: c.collection,
+			Offset:         offset,
+			Limit:          &limit,
+			WithPayload: &go_client.WithPayloadSelector{SelectorOptions: &go_client.WithPayloadSelector_Include{
+				Include: &go_client.PayloadIncludeSelector{Fields: []string{"channel_id"}},
+			}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scroll collection %s: %w", c.collection, err)
+		}
+
+		for _, point := range page.Result {
+			if channelID := payloadString(point.Payload, "channel_id", ""); channelID != "" {
+				counts[channelID]++
+			}
+		}
+
+		if page.NextPageOffset == nil {
+			break
+		}
+		offset = page.NextPageOffset
+	}
+
+	return sortedChannelCounts(counts), nil
+}
+
+// sortedChannelCounts orders counts by message count, then channel ID
+func sortedChannelCounts(counts map[string]int) []ChannelCount {
+	channels := make([]ChannelCount, 0, len(counts))
+	for channelID, count := range counts {
+		channels = append(channels, ChannelCount{ChannelID: channelID, Count: count})
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].Count != channels[j].Count {
+			return channels[i].Count > channels[j].Count
+		}
+		return channels[i].ChannelID < channels[j].ChannelID
+	})
+	return channels
+}
@@ -2,32 +2,63 @@ package vectordb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"beebrain/internal/config"
+
 	"github.com/google/uuid"
 	go_client "github.com/qdrant/go-client/qdrant"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 const (
-	collectionName = "slack_messages"
-	vectorSize     = 4096 // Size of embeddings from Ollama
+	collectionName    = "slack_messages"
+	defaultVectorSize = 4096 // Size of embeddings from Ollama
 )
 
+// ErrClosed is returned by operations on a client that has been closed
+var ErrClosed = errors.New("vectordb client is closed")
+
+// ErrNotFound is returned by GetMessage when no message has the ID
+var ErrNotFound = errors.New("message not found")
+
 // VectorDBClient interface defines the methods for vector database operations
 type VectorDBClient interface {
 	StoreMessage(msg Message) error
+	StoreMessages(msgs []Message) error
 	SearchSimilar(ctx context.Context, embedding []float32, limit uint64) ([]Message, error)
+	GetMessage(ctx context.Context, id string, withVector bool) (Message, error)
+	SetPayload(ctx context.Context, id string, payload map[string]string) error
+	RecentMessages(ctx context.Context, channelID string, since time.Time, limit int) ([]Message, error)
+	ListIndexedChannels(ctx context.Context) ([]ChannelCount, error)
+	RecreateCollection(ctx context.Context) error
+	Close() error
 }
 
 type Client struct {
 	collectionsClient go_client.CollectionsClient
 	pointsClient      go_client.PointsClient
+	conn              *grpc.ClientConn
+	closed            atomic.Bool
+	collection        string
 	logger            *logrus.Logger
+	waitForWrites     bool
+	vectorSize        uint64
+	distance          go_client.Distance
+	maxPerChannel     int
+	slowSearch        time.Duration
+	maxAge            time.Duration
+	skipMismatched    bool
 }
 
 func NewClient(logger *logrus.Logger) (*Client, error) {
@@ -60,11 +91,45 @@ func NewClient(logger *logrus.Logger) (*Client, error) {
 
 	logger.Info("Successfully connected to Qdrant")
 
+	return NewClientFromConn(conn, logger), nil
+}
+
+// NewClientFromConn creates a client on an existing gRPC connection, which
+// the client takes ownership of and closes on Close
+func NewClientFromConn(conn *grpc.ClientConn, logger *logrus.Logger) *Client {
+	client := NewClientFromServices(go_client.NewCollectionsClient(conn), go_client.NewPointsClient(conn), logger)
+	client.conn = conn
+	return client
+}
+
+// NewClientFromServices creates a client on top of existing Qdrant service
+// clients, which lets tests substitute mocks for a real connection
+func NewClientFromServices(collectionsClient go_client.CollectionsClient, pointsClient go_client.PointsClient, logger *logrus.Logger) *Client {
 	return &Client{
-		collectionsClient: go_client.NewCollectionsClient(conn),
-		pointsClient:      go_client.NewPointsClient(conn),
+		collectionsClient: collectionsClient,
+		pointsClient:      pointsClient,
+		collection:        config.String("QDRANT_COLLECTION", collectionName),
 		logger:            logger,
-	}, nil
+		// Wait for upserts to be applied so a following search sees them
+		waitForWrites: config.Bool(logger, "QDRANT_WAIT", false),
+		// Must match the dimension of the configured embedder
+		vectorSize: uint64(config.Int(logger, "QDRANT_VECTOR_SIZE", defaultVectorSize)),
+		distance:   loadDistance(logger),
+		// Oldest messages are evicted beyond this many per channel, 0 keeps all
+		maxPerChannel: config.Int(logger, "MAX_MESSAGES_PER_CHANNEL", 0),
+		// Searches slower than this are logged, 0 disables the log
+		slowSearch: config.Duration(logger, "SLOW_SEARCH_THRESHOLD", time.Second),
+		// Only messages posted within this are searched, 0 searches them all
+		maxAge: config.Duration(logger, "SEARCH_MAX_AGE", 0),
+		// Embeddings of another dimension than vectorSize are skipped rather
+		// than failing, while migrating to a new embedding model
+		skipMismatched: config.Bool(logger, "SKIP_DIMENSION_MISMATCH", false),
+	}
+}
+
+// VectorSize returns the dimension of the vectors in the collection
+func (c *Client) VectorSize() uint64 {
+	return c.vectorSize
 }
 
 type Message struct {
@@ -74,65 +139,220 @@ type Message struct {
 	ChannelID string
 	Timestamp string
 	ThreadID  string
+	// MessageTS is the Slack timestamp identifying the message
+	MessageTS string
+	// RawText is the text as posted, with Slack markup, when Text has been
+	// normalized for embedding
+	RawText string
+	// Permalink is the Slack link to the message, when it was fetched at
+	// index time
+	Permalink string
+	// Metadata holds arbitrary tags, such as the source of a message, stored
+	// alongside the fixed fields
+	Metadata  map[string]string
 	Embedding []float32
+	// Score is the similarity to the query, higher meaning closer whatever the
+	// distance metric, set on SearchSimilar results
+	Score float32
+}
+
+// Close closes the underlying gRPC connection. Operations on a closed client
+// return ErrClosed, and closing it again is a no-op.
+func (c *Client) Close() error {
+	if !c.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	if c.conn == nil {
+		return nil
+	}
+
+	c.logger.Info("Closing connection to Qdrant")
+	if err := c.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close Qdrant connection: %w", err)
+	}
+	return nil
+}
+
+// Collection returns the name of the collection the client reads and writes
+func (c *Client) Collection() string {
+	return c.collection
 }
 
 func (c *Client) InitializeCollection(ctx context.Context) error {
-	// Check if collection exists
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	return c.ensureCollection(ctx, c.collection)
+}
+
+// ensureCollection creates the named collection with the client's vector size
+// unless it already exists
+func (c *Client) ensureCollection(ctx context.Context, name string) error {
+	exists, err := c.collectionExists(ctx, name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	// Another replica starting at the same time may have created it since
+	err = c.createCollection(ctx, name)
+	if alreadyExists(err) {
+		c.logger.Infof("Collection %s was created by someone else in the meantime, using it", name)
+		return nil
+	}
+	return err
+}
+
+// alreadyExists reports whether err is Qdrant refusing to create a
+// collection that exists. Qdrant reports it as an invalid argument, so the
+// message is checked as well as the status code.
+func alreadyExists(err error) bool {
+	if err == nil {
+		return false
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	return st.Code() == codes.AlreadyExists ||
+		(st.Code() == codes.InvalidArgument && strings.Contains(st.Message(), "already exists"))
+}
+
+// collectionExists reports whether the named collection exists
+func (c *Client) collectionExists(ctx context.Context, name string) (bool, error) {
 	collections, err := c.collectionsClient.List(ctx, &go_client.ListCollectionsRequest{})
 	if err != nil {
-		return fmt.Errorf("failed to list collections: %w", err)
+		return false, fmt.Errorf("failed to list collections: %w", err)
 	}
 
-	exists := false
 	for _, collection := range collections.Collections {
-		if collection.Name == collectionName {
-			exists = true
-			break
+		if collection.Name == name {
+			return true, nil
 		}
 	}
+	return false, nil
+}
 
-	if !exists {
-		// Create collection if it doesn't exist
-		_, err := c.collectionsClient.Create(ctx, &go_client.CreateCollection{
-			CollectionName: collectionName,
-			VectorsConfig: &go_client.VectorsConfig{
-				Config: &go_client.VectorsConfig_Params{
-					Params: &go_client.VectorParams{
-						Size:     vectorSize,
-						Distance: go_client.Distance_Cosine,
-					},
+// createCollection creates the named collection with the client's vector size
+// and distance
+func (c *Client) createCollection(ctx context.Context, name string) error {
+	_, err := c.collectionsClient.Create(ctx, &go_client.CreateCollection{
+		CollectionName: name,
+		VectorsConfig: &go_client.VectorsConfig{
+			Config: &go_client.VectorsConfig_Params{
+				Params: &go_client.VectorParams{
+					Size:     c.vectorSize,
+					Distance: c.distance,
 				},
 			},
-		})
-		if err != nil {
-			return fmt.Errorf("failed to create collection: %w", err)
-		}
-		c.logger.Infof("Created new collection for slack messages with vector size %d", vectorSize)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
 	}
-
+	c.logger.Infof("Created new collection %s for slack messages with vector size %d and %s distance", name, c.vectorSize, c.distance)
 	return nil
 }
 
+// RecreateCollection deletes the collection, and every message in it, if it
+// exists and creates it again empty with the current vector size and
+// distance. It is meant for development and resets, see cmd/recreate.
+func (c *Client) RecreateCollection(ctx context.Context) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+
+	exists, err := c.collectionExists(ctx, c.collection)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if _, err := c.collectionsClient.Delete(ctx, &go_client.DeleteCollection{CollectionName: c.collection}); err != nil {
+			return fmt.Errorf("failed to delete collection %s: %w", c.collection, err)
+		}
+		c.logger.Warnf("Deleted collection %s and every message in it", c.collection)
+	}
+	return c.createCollection(ctx, c.collection)
+}
+
 func (c *Client) StoreMessage(msg Message) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+
 	// Generate a valid UUID for the message ID if not provided
 	if msg.ID == "" {
 		msg.ID = uuid.New().String()
 	}
+	if len(c.withMatchingDimension([]Message{msg})) == 0 {
+		return nil
+	}
 
 	c.logger.Debugf("Storing message with ID: %s, Text: %s", msg.ID, msg.Text)
+	c.logger.Debugf("Upserting point to collection: %s with ID: %s", c.collection, msg.ID)
+
+	if err := c.upsert([]*go_client.PointStruct{newPoint(msg)}); err != nil {
+		return err
+	}
+	c.enforceChannelCaps([]Message{msg})
 
+	c.logger.Debugf("Successfully stored message in Qdrant: %s", msg.ID)
+	return nil
+}
+
+// StoreMessages stores a batch of messages with a single upsert
+func (c *Client) StoreMessages(msgs []Message) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	if msgs = c.withMatchingDimension(msgs); len(msgs) == 0 {
+		return nil
+	}
+
+	points := make([]*go_client.PointStruct, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.ID == "" {
+			msg.ID = uuid.New().String()
+		}
+		points = append(points, newPoint(msg))
+	}
+
+	c.logger.Debugf("Upserting %d points to collection: %s", len(points), c.collection)
+	if err := c.upsert(points); err != nil {
+		return err
+	}
+	c.enforceChannelCaps(msgs)
+
+	c.logger.Debugf("Successfully stored %d messages in Qdrant", len(points))
+	return nil
+}
+
+func (c *Client) upsert(points []*go_client.PointStruct) error {
 	// Create a new background context for the upsert operation
 	upsertCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Convert message to Qdrant point
+	upsertRequest := &go_client.UpsertPoints{
+		CollectionName: c.collection,
+		Points:         points,
+	}
+	if c.waitForWrites {
+		upsertRequest.Wait = &c.waitForWrites
+	}
+	upsertResponse, err := c.pointsClient.Upsert(upsertCtx, upsertRequest)
+	if err != nil {
+		c.logger.Errorf("Failed to upsert point: %v, Response: %+v", err, upsertResponse)
+		return fmt.Errorf("failed to upsert point: %w", err)
+	}
+	return nil
+}
+
+// newPoint converts a message with an ID to a Qdrant point
+func newPoint(msg Message) *go_client.PointStruct {
 	point := &go_client.PointStruct{
-		Id: &go_client.PointId{
-			PointIdOptions: &go_client.PointId_Uuid{
-				Uuid: msg.ID,
-			},
-		},
+		Id: pointID(msg.ID),
 		Vectors: &go_client.Vectors{
 			VectorsOptions: &go_client.Vectors_Vector{
 				Vector: &go_client.Vector{
@@ -146,35 +366,47 @@ func (c *Client) StoreMessage(msg Message) error {
 			"channel_id": {Kind: &go_client.Value_StringValue{StringValue: msg.ChannelID}},
 			"timestamp":  {Kind: &go_client.Value_StringValue{StringValue: msg.Timestamp}},
 			"thread_id":  {Kind: &go_client.Value_StringValue{StringValue: msg.ThreadID}},
+			"message_ts": {Kind: &go_client.Value_StringValue{StringValue: msg.MessageTS}},
 		},
 	}
 
-	c.logger.Debugf("Upserting point to collection: %s with ID: %s", collectionName, msg.ID)
-
-	// Upsert the point
-	upsertResponse, err := c.pointsClient.Upsert(upsertCtx, &go_client.UpsertPoints{
-		CollectionName: collectionName,
-		Points:         []*go_client.PointStruct{point},
-	})
-	if err != nil {
-		c.logger.Errorf("Failed to upsert point: %v, Response: %+v", err, upsertResponse)
-		return fmt.Errorf("failed to upsert point: %w", err)
+	if posted := messageTime(msg); !posted.IsZero() {
+		point.Payload[postedAtKey] = &go_client.Value{Kind: &go_client.Value_IntegerValue{IntegerValue: posted.Unix()}}
 	}
-
-	c.logger.Debugf("Successfully stored message in Qdrant: %s", msg.ID)
-	return nil
+	if msg.RawText != "" {
+		point.Payload["raw_text"] = &go_client.Value{Kind: &go_client.Value_StringValue{StringValue: msg.RawText}}
+	}
+	if msg.Permalink != "" {
+		point.Payload["permalink"] = &go_client.Value{Kind: &go_client.Value_StringValue{StringValue: msg.Permalink}}
+	}
+	if len(msg.Metadata) > 0 {
+		point.Payload["metadata"] = metadataValue(msg.Metadata)
+	}
+	return point
 }
 
 func (c *Client) SearchSimilar(ctx context.Context, embedding []float32, limit uint64) ([]Message, error) {
+	if c.closed.Load() {
+		return nil, ErrClosed
+	}
+	if c.mismatched(embedding) {
+		// No stored vector can be compared with it
+		c.logger.Warnf("Not searching with a %d-dimensional embedding, the collection %s takes %d dimensions", len(embedding), c.collection, c.vectorSize)
+		return nil, nil
+	}
+
 	// Create a new context with timeout for the search operation
 	searchCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	// Search for similar points
+	started := time.Now()
 	searchResult, err := c.pointsClient.Search(searchCtx, &go_client.SearchPoints{
-		CollectionName: collectionName,
+		CollectionName: c.collection,
 		Vector:         embedding,
 		Limit:          limit,
+		Filter:         maxAgeFilter(c.maxAge, time.Now()),
+		WithPayload:    &go_client.WithPayloadSelector{SelectorOptions: &go_client.WithPayloadSelector_Enable{Enable: true}},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to search points: %w", err)
@@ -183,17 +415,44 @@ func (c *Client) SearchSimilar(ctx context.Context, embedding []float32, limit u
 	// Convert results to Message structs
 	messages := make([]Message, 0, len(searchResult.Result))
 	for _, result := range searchResult.Result {
-		payload := result.Payload
-		messages = append(messages, Message{
-			ID:        result.Id.GetUuid(),
-			Text:      payload["text"].GetStringValue(),
-			UserID:    payload["user_id"].GetStringValue(),
-			ChannelID: payload["channel_id"].GetStringValue(),
-			Timestamp: payload["timestamp"].GetStringValue(),
-			ThreadID:  payload["thread_id"].GetStringValue(),
-			Embedding: result.Vectors.GetVector().Data,
-		})
+		msg := messageFromPoint(result.Id, result.Payload, result.Vectors)
+		msg.Score = similarity(c.distance, result.Score)
+		messages = append(messages, msg)
 	}
 
+	logSlowSearch(c.logger, c.slowSearch, time.Since(started), c.collection, limit, messages)
 	return messages, nil
 }
+
+// GetMessage fetches the stored message with the given ID, along with its
+// embedding when withVector is set
+func (c *Client) GetMessage(ctx context.Context, id string, withVector bool) (Message, error) {
+	if c.closed.Load() {
+		return Message{}, ErrClosed
+	}
+
+	result, err := c.pointsClient.Get(ctx, &go_client.GetPoints{
+		CollectionName: c.collection,
+		Ids:            []*go_client.PointId{pointID(id)},
+		WithPayload:    &go_client.WithPayloadSelector{SelectorOptions: &go_client.WithPayloadSelector_Enable{Enable: true}},
+		WithVectors:    &go_client.WithVectorsSelector{SelectorOptions: &go_client.WithVectorsSelector_Enable{Enable: withVector}},
+	})
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to get point %s: %w", id, err)
+	}
+	if len(result.Result) == 0 {
+		return Message{}, fmt.Errorf("%w: %s in collection %s", ErrNotFound, id, c.collection)
+	}
+
+	point := result.Result[0]
+	return messageFromPoint(point.Id, point.Payload, point.Vectors), nil
+}
+
+// pointID turns a message ID back into a point ID, which is numeric for
+// points not created by this client
+func pointID(id string) *go_client.PointId {
+	if num, err := strconv.ParseUint(id, 10, 64); err == nil {
+		return &go_client.PointId{PointIdOptions: &go_client.PointId_Num{Num: num}}
+	}
+	return &go_client.PointId{PointIdOptions: &go_client.PointId_Uuid{Uuid: id}}
+}
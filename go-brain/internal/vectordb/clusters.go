@@ -0,0 +1,54 @@
+package vectordb
+
+import "sort"
+
+// ClusterMessages groups messages whose embeddings are at least threshold
+// similar to the centroid of a group, largest group first. Each message joins
+// the most similar group or starts a new one, so the result depends on the
+// order of messages. Messages without an embedding are skipped, and a message
+// only joins groups of its own dimension, as during a model migration some
+// embeddings have another.
+func ClusterMessages(messages []Message, threshold float64) [][]Message {
+	type cluster struct {
+		messages []Message
+		sum      []float64
+		centroid []float32
+	}
+
+	var clusters []*cluster
+	for _, msg := range messages {
+		if len(msg.Embedding) == 0 {
+			continue
+		}
+
+		var best *cluster
+		bestScore := threshold
+		for _, c := range clusters {
+			if len(msg.Embedding) != len(c.centroid) {
+				continue
+			}
+			if score := CosineSimilarity(msg.Embedding, c.centroid); score >= bestScore {
+				best, bestScore = c, score
+			}
+		}
+		if best == nil {
+			best = &cluster{sum: make([]float64, len(msg.Embedding)), centroid: make([]float32, len(msg.Embedding))}
+			clusters = append(clusters, best)
+		}
+
+		best.messages = append(best.messages, msg)
+		for i, v := range msg.Embedding {
+			best.sum[i] += float64(v)
+			best.centroid[i] = float32(best.sum[i] / float64(len(best.messages)))
+		}
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return len(clusters[i].messages) > len(clusters[j].messages)
+	})
+	groups := make([][]Message, 0, len(clusters))
+	for _, c := range clusters {
+		groups = append(groups, c.messages)
+	}
+	return groups
+}
@@ -0,0 +1,210 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+)
+
+const (
+	dedupPageSize = 100
+	// dedupNeighbours is how many similar messages are looked up per message
+	dedupNeighbours = 10
+)
+
+// DuplicateCluster is a group of near-identical messages. Keep is the
+// earliest of them and Duplicates are the ones to remove.
+type DuplicateCluster struct {
+	Keep       Message
+	Duplicates []Message
+}
+
+// FindDuplicates groups messages with the stored messages at least threshold
+// similar to them, found with SearchSimilar on db. Similarity isn't
+// transitive, so every duplicate in a cluster is at least threshold similar
+// to the message kept: when b is close to both a and c but a and c are far
+// apart, only one of a and c ends up with b. Messages without an embedding
+// are skipped.
+func FindDuplicates(ctx context.Context, db VectorDBClient, messages []Message, threshold float32) ([]DuplicateCluster, error) {
+	return findDuplicates(ctx, db, messages, threshold, nil)
+}
+
+// findDuplicates is FindDuplicates leaving out the stored messages in skip,
+// such as the ones an earlier page of a dedup removed
+func findDuplicates(ctx context.Context, db VectorDBClient, messages []Message, threshold float32, skip map[string]bool) ([]DuplicateCluster, error) {
+	found := make(map[string]Message)
+	// similar holds the pairs of IDs at least threshold similar, both ways
+	similar := make(map[[2]string]bool)
+
+	for _, msg := range messages {
+		if len(msg.Embedding) == 0 {
+			continue
+		}
+		neighbours, err := db.SearchSimilar(ctx, msg.Embedding, dedupNeighbours)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search messages similar to %s: %w", msg.ID, err)
+		}
+
+		if _, ok := found[msg.ID]; !ok {
+			found[msg.ID] = msg
+		}
+		for _, other := range neighbours {
+			if other.ID == msg.ID || other.Score < threshold || skip[other.ID] {
+				continue
+			}
+			if _, ok := found[other.ID]; !ok {
+				found[other.ID] = other
+			}
+			similar[[2]string{msg.ID, other.ID}] = true
+			similar[[2]string{other.ID, msg.ID}] = true
+		}
+	}
+
+	ordered := make([]Message, 0, len(found))
+	for _, msg := range found {
+		ordered = append(ordered, msg)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return earlierMessage(ordered[i], ordered[j])
+	})
+
+	// The earliest message not yet in a cluster keeps the ones similar to it
+	clusters := make([]DuplicateCluster, 0)
+	clustered := make(map[string]bool)
+	for i, keep := range ordered {
+		if clustered[keep.ID] {
+			continue
+		}
+		var duplicates []Message
+		for _, other := range ordered[i+1:] {
+			if !clustered[other.ID] && similar[[2]string{keep.ID, other.ID}] {
+				clustered[other.ID] = true
+				duplicates = append(duplicates, other)
+			}
+		}
+		if len(duplicates) > 0 {
+			clustered[keep.ID] = true
+			clusters = append(clusters, DuplicateCluster{Keep: keep, Duplicates: duplicates})
+		}
+	}
+	return clusters, nil
+}
+
+// earlierMessage reports whether a was posted before b. Messages without a
+// known time sort last, and ties are broken by ID to keep the order stable.
+func earlierMessage(a, b Message) bool {
+	ta, tb := messageTime(a), messageTime(b)
+	switch {
+	case ta.IsZero() != tb.IsZero():
+		return !ta.IsZero()
+	case !ta.Equal(tb):
+		return ta.Before(tb)
+	default:
+		return a.ID < b.ID
+	}
+}
+
+// messageTime is when a message was posted, from its Slack timestamp or its
+// stored timestamp, which is either RFC 3339 or a Slack timestamp
+func messageTime(msg Message) time.Time {
+	for _, ts := range []string{msg.MessageTS, msg.Timestamp} {
+		if ts == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			return t
+		}
+		if seconds, err := strconv.ParseFloat(ts, 64); err == nil {
+			return time.Unix(0, int64(seconds*float64(time.Second)))
+		}
+	}
+	return time.Time{}
+}
+
+// Dedup collapses the clusters of stored messages at least threshold similar
+// to each other, keeping the earliest message of each. With dryRun nothing is
+// deleted. It returns the number of messages removed, or that would be.
+func (c *Client) Dedup(ctx context.Context, threshold float32, dryRun bool) (int, error) {
+	if c.closed.Load() {
+		return 0, ErrClosed
+	}
+
+	c.logger.Infof("Collapsing messages at least %.2f similar in collection %s", threshold, c.collection)
+
+	removed := 0
+	deleted := make(map[string]bool)
+	limit := uint32(dedupPageSize)
+	var offset *go_client.PointId
+	for {
+		page, err := c.pointsClient.Scroll(ctx, &go_client.ScrollPoints{
+			CollectionName: c.collection,
+			Offset:         offset,
+			Limit:          &limit,
+			WithPayload:    &go_client.WithPayloadSelector{SelectorOptions: &go_client.WithPayloadSelector_Enable{Enable: true}},
+			WithVectors:    &go_client.WithVectorsSelector{SelectorOptions: &go_client.WithVectorsSelector_Enable{Enable: true}},
+		})
+		if err != nil {
+			return removed, fmt.Errorf("failed to scroll collection %s: %w", c.collection, err)
+		}
+
+		messages := make([]Message, 0, len(page.Result))
+		for _, point := range page.Result {
+			// A dry run doesn't delete, so skip what an earlier page would
+			// have, here and among the neighbours
+			if msg := messageFromPoint(point.Id, point.Payload, point.Vectors); !deleted[msg.ID] {
+				messages = append(messages, msg)
+			}
+		}
+
+		clusters, err := findDuplicates(ctx, c, messages, threshold, deleted)
+		if err != nil {
+			return removed, err
+		}
+
+		ids := make([]*go_client.PointId, 0)
+		for _, cluster := range clusters {
+			for _, duplicate := range cluster.Duplicates {
+				if deleted[duplicate.ID] {
+					continue
+				}
+				deleted[duplicate.ID] = true
+				ids = append(ids, pointID(duplicate.ID))
+			}
+			c.logger.Debugf("Keeping message %s over %d duplicates", cluster.Keep.ID, len(cluster.Duplicates))
+		}
+		if len(ids) > 0 && !dryRun {
+			if err := c.deletePoints(ctx, ids); err != nil {
+				return removed, err
+			}
+		}
+		removed += len(ids)
+
+		if page.NextPageOffset == nil {
+			break
+		}
+		offset = page.NextPageOffset
+	}
+
+	c.logger.Infof("Collapsed %d duplicate messages in collection %s", removed, c.collection)
+	return removed, nil
+}
+
+// deletePoints removes points from the collection, waiting for it so that
+// later searches no longer find them
+func (c *Client) deletePoints(ctx context.Context, ids []*go_client.PointId) error {
+	wait := true
+	if _, err := c.pointsClient.Delete(ctx, &go_client.DeletePoints{
+		CollectionName: c.collection,
+		Wait:           &wait,
+		Points: &go_client.PointsSelector{PointsSelectorOneOf: &go_client.PointsSelector_Points{
+			Points: &go_client.PointsIdsList{Ids: ids},
+		}},
+	}); err != nil {
+		return fmt.Errorf("failed to delete points: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package vectordb
+
+// mismatched reports whether embedding doesn't have the dimension of the
+// collection, which only matters when such vectors are skipped rather than
+// sent for Qdrant to reject
+func (c *Client) mismatched(embedding []float32) bool {
+	return c.skipMismatched && uint64(len(embedding)) != c.vectorSize
+}
+
+// withMatchingDimension returns msgs without the messages whose embedding
+// doesn't have the dimension of the collection, logging each one it skips
+func (c *Client) withMatchingDimension(msgs []Message) []Message {
+	kept := msgs[:0:0]
+	for _, msg := range msgs {
+		if c.mismatched(msg.Embedding) {
+			c.logger.Warnf("Skipping message %s with a %d-dimensional embedding, the collection %s takes %d dimensions", msg.ID, len(msg.Embedding), c.collection, c.vectorSize)
+			continue
+		}
+		kept = append(kept, msg)
+	}
+	return kept
+}
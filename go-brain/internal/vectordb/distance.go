@@ -0,0 +1,52 @@
+package vectordb
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+)
+
+// ParseDistance maps a QDRANT_DISTANCE value (cosine, dot or euclid) to the
+// Qdrant distance metric
+func ParseDistance(name string) (go_client.Distance, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "cosine":
+		return go_client.Distance_Cosine, nil
+	case "dot":
+		return go_client.Distance_Dot, nil
+	case "euclid":
+		return go_client.Distance_Euclid, nil
+	default:
+		return go_client.Distance_UnknownDistance, fmt.Errorf("unknown distance %q, expected cosine, dot or euclid", name)
+	}
+}
+
+// loadDistance returns the configured distance metric, cosine by default.
+// It only applies when a collection is created, so changing it means
+// recreating the collection.
+func loadDistance(logger *logrus.Logger) go_client.Distance {
+	value := os.Getenv("QDRANT_DISTANCE")
+	if value == "" {
+		return go_client.Distance_Cosine
+	}
+	distance, err := ParseDistance(value)
+	if err != nil {
+		logger.Warnf("Invalid QDRANT_DISTANCE: %v, defaulting to cosine", err)
+		return go_client.Distance_Cosine
+	}
+	return distance
+}
+
+// similarity turns a search score under distance into a similarity, higher
+// meaning closer, which is what everything ranking or thresholding results
+// expects. Euclidean scores are distances, lower meaning closer, so they are
+// mapped to 1/(1+d), which is 1 for identical vectors and falls towards 0.
+func similarity(distance go_client.Distance, score float32) float32 {
+	if distance == go_client.Distance_Euclid {
+		return 1 / (1 + score)
+	}
+	return score
+}
@@ -0,0 +1,193 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"beebrain/internal/llm"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+)
+
+const (
+	// exportVersion is bumped when the export format changes incompatibly
+	exportVersion      = 1
+	exportPageSize     = 256
+	defaultImportBatch = 100
+)
+
+// ChannelExport is the JSON document a channel is exported to
+type ChannelExport struct {
+	Version    int               `json:"version"`
+	ChannelID  string            `json:"channel_id"`
+	ExportedAt time.Time         `json:"exported_at"`
+	Messages   []ExportedMessage `json:"messages"`
+}
+
+// ExportedMessage is a stored message in an export. The embedding is left out
+// of exports taken without vectors and recomputed on import.
+type ExportedMessage struct {
+	ID        string            `json:"id"`
+	Text      string            `json:"text"`
+	RawText   string            `json:"raw_text,omitempty"`
+	UserID    string            `json:"user_id"`
+	ChannelID string            `json:"channel_id"`
+	Timestamp string            `json:"timestamp"`
+	ThreadID  string            `json:"thread_id,omitempty"`
+	MessageTS string            `json:"message_ts,omitempty"`
+	Permalink string            `json:"permalink,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Embedding []float32         `json:"embedding,omitempty"`
+}
+
+func exportedMessage(msg Message) ExportedMessage {
+	return ExportedMessage{
+		ID:        msg.ID,
+		Text:      msg.Text,
+		RawText:   msg.RawText,
+		UserID:    msg.UserID,
+		ChannelID: msg.ChannelID,
+		Timestamp: msg.Timestamp,
+		ThreadID:  msg.ThreadID,
+		MessageTS: msg.MessageTS,
+		Permalink: msg.Permalink,
+		Metadata:  msg.Metadata,
+		Embedding: msg.Embedding,
+	}
+}
+
+// Message returns the stored message the export entry was made from
+func (e ExportedMessage) Message() Message {
+	return Message{
+		ID:        e.ID,
+		Text:      e.Text,
+		RawText:   e.RawText,
+		UserID:    e.UserID,
+		ChannelID: e.ChannelID,
+		Timestamp: e.Timestamp,
+		ThreadID:  e.ThreadID,
+		MessageTS: e.MessageTS,
+		Permalink: e.Permalink,
+		Metadata:  e.Metadata,
+		Embedding: e.Embedding,
+	}
+}
+
+// ExportChannel writes every message indexed for channelID to w as a JSON
+// ChannelExport, with their embeddings when withVectors is set. It returns
+// the number of messages exported.
+func (c *Client) ExportChannel(ctx context.Context, channelID string, w io.Writer, withVectors bool) (int, error) {
+	if c.closed.Load() {
+		return 0, ErrClosed
+	}
+
+	export := ChannelExport{
+		Version:    exportVersion,
+		ChannelID:  channelID,
+		ExportedAt: time.Now().UTC(),
+		Messages:   []ExportedMessage{},
+	}
+
+	limit := uint32(exportPageSize)
+	var offset *go_client.PointId
+	for {
+		page, err := c.pointsClient.Scroll(ctx, &go_client.ScrollPoints{
+			CollectionName: c.collection,
+			Filter:         channelFilter(channelID),
+			Offset:         offset,
+			Limit:          &limit,
+			WithPayload:    &go_client.WithPayloadSelector{SelectorOptions: &go_client.WithPayloadSelector_Enable{Enable: true}},
+			WithVectors:    &go_client.WithVectorsSelector{SelectorOptions: &go_client.WithVectorsSelector_Enable{Enable: withVectors}},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to scroll channel %s: %w", channelID, err)
+		}
+
+		for _, point := range page.Result {
+			export.Messages = append(export.Messages, exportedMessage(messageFromPoint(point.Id, point.Payload, point.Vectors)))
+		}
+
+		if page.NextPageOffset == nil {
+			break
+		}
+		offset = page.NextPageOffset
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return 0, fmt.Errorf("failed to write export of channel %s: %w", channelID, err)
+	}
+
+	c.logger.Infof("Exported %d messages of channel %s from collection %s", len(export.Messages), channelID, c.collection)
+	return len(export.Messages), nil
+}
+
+// ImportChannel stores the messages of a ChannelExport read from r, keeping
+// their IDs so importing twice doesn't duplicate them. Messages exported
+// without embeddings are embedded with embedder, which may be nil when the
+// export has them all. It returns the number of messages imported.
+func (c *Client) ImportChannel(ctx context.Context, r io.Reader, embedder llm.Embedder) (int, error) {
+	if c.closed.Load() {
+		return 0, ErrClosed
+	}
+
+	var export ChannelExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return 0, fmt.Errorf("failed to read export: %w", err)
+	}
+	if export.Version != exportVersion {
+		return 0, fmt.Errorf("unsupported export version %d", export.Version)
+	}
+
+	imported := 0
+	batch := make([]Message, 0, defaultImportBatch)
+	for _, exported := range export.Messages {
+		if err := ctx.Err(); err != nil {
+			return imported, err
+		}
+
+		msg := exported.Message()
+		if len(msg.Embedding) == 0 {
+			if embedder == nil {
+				return imported, fmt.Errorf("message %s has no embedding and no embedder was given", msg.ID)
+			}
+			embedding, err := llm.EmbedDocument(embedder, msg.Text)
+			if err != nil {
+				return imported, fmt.Errorf("failed to embed message %s: %w", msg.ID, err)
+			}
+			msg.Embedding = embedding
+		}
+
+		batch = append(batch, msg)
+		if len(batch) == cap(batch) {
+			if err := c.StoreMessages(batch); err != nil {
+				return imported, err
+			}
+			imported += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if err := c.StoreMessages(batch); err != nil {
+		return imported, err
+	}
+	imported += len(batch)
+
+	c.logger.Infof("Imported %d messages of channel %s into collection %s", imported, export.ChannelID, c.collection)
+	return imported, nil
+}
+
+// channelFilter matches the points of a channel
+func channelFilter(channelID string) *go_client.Filter {
+	return &go_client.Filter{
+		Must: []*go_client.Condition{{
+			ConditionOneOf: &go_client.Condition_Field{Field: &go_client.FieldCondition{
+				Key:   "channel_id",
+				Match: &go_client.Match{MatchValue: &go_client.Match_Keyword{Keyword: channelID}},
+			}},
+		}},
+	}
+}
@@ -0,0 +1,43 @@
+package vectordb
+
+import (
+	"time"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+)
+
+// postedAtKey is the payload field holding when a message was posted, in
+// Unix seconds, so searches can filter on it as a range
+const postedAtKey = "posted_at"
+
+// maxAgeFilter matches the points posted at most maxAge before now, or is nil
+// when maxAge is 0. Points stored before posted_at was written have no value
+// and are filtered out.
+func maxAgeFilter(maxAge time.Duration, now time.Time) *go_client.Filter {
+	if maxAge <= 0 {
+		return nil
+	}
+	return &go_client.Filter{Must: []*go_client.Condition{postedSince(now.Add(-maxAge))}}
+}
+
+// postedSince matches the points posted at or after since
+func postedSince(since time.Time) *go_client.Condition {
+	from := float64(since.Unix())
+	return &go_client.Condition{
+		ConditionOneOf: &go_client.Condition_Field{Field: &go_client.FieldCondition{
+			Key:   postedAtKey,
+			Range: &go_client.Range{Gte: &from},
+		}},
+	}
+}
+
+// tooOld reports whether msg was posted more than maxAge before now, which
+// messages without a usable timestamp always are. A maxAge of 0 keeps every
+// message.
+func tooOld(msg Message, maxAge time.Duration, now time.Time) bool {
+	if maxAge <= 0 {
+		return false
+	}
+	posted := messageTime(msg)
+	return posted.IsZero() || now.Sub(posted) > maxAge
+}
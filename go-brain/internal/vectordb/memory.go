@@ -0,0 +1,178 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"beebrain/internal/config"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryClient is an in-memory implementation of VectorDBClient that does a
+// brute-force cosine search over every stored vector. It is meant for tests
+// and small deployments that don't want to run Qdrant.
+type MemoryClient struct {
+	mu       sync.RWMutex
+	messages []Message
+	logger   *logrus.Logger
+	// maxPerChannel evicts the oldest messages of a channel beyond it, 0
+	// keeps them all
+	maxPerChannel int
+	// slowSearch is how long a search may take before it is logged, 0
+	// disables the log
+	slowSearch time.Duration
+	// maxAge skips messages posted longer ago than it, 0 searches them all
+	maxAge time.Duration
+}
+
+func NewMemoryClient(logger *logrus.Logger) *MemoryClient {
+	return &MemoryClient{
+		logger:        logger,
+		maxPerChannel: config.Int(logger, "MAX_MESSAGES_PER_CHANNEL", 0),
+		slowSearch:    config.Duration(logger, "SLOW_SEARCH_THRESHOLD", time.Second),
+		maxAge:        config.Duration(logger, "SEARCH_MAX_AGE", 0),
+	}
+}
+
+func (c *MemoryClient) StoreMessage(msg Message) error {
+	if msg.ID == "" {
+		msg.ID = uuid.New().String()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Replace an existing message with the same ID, like an upsert would
+	for i, existing := range c.messages {
+		if existing.ID == msg.ID {
+			c.messages[i] = msg
+			c.logger.Debugf("Updated message in memory store: %s", msg.ID)
+			return nil
+		}
+	}
+
+	c.messages = append(c.messages, msg)
+	c.logger.Debugf("Stored message in memory store: %s", msg.ID)
+	c.enforceChannelCap(msg.ChannelID)
+	return nil
+}
+
+// StoreMessages stores each message of a batch
+func (c *MemoryClient) StoreMessages(msgs []Message) error {
+	for _, msg := range msgs {
+		if err := c.StoreMessage(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *MemoryClient) SearchSimilar(ctx context.Context, embedding []float32, limit uint64) ([]Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		message Message
+		score   float64
+	}
+
+	started := time.Now()
+	c.mu.RLock()
+	results := make([]scored, 0, len(c.messages))
+	for _, msg := range c.messages {
+		if len(msg.Embedding) != len(embedding) || tooOld(msg, c.maxAge, started) {
+			continue
+		}
+		results = append(results, scored{message: msg, score: CosineSimilarity(embedding, msg.Embedding)})
+	}
+	c.mu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	if uint64(len(results)) > limit {
+		results = results[:limit]
+	}
+
+	messages := make([]Message, 0, len(results))
+	for _, result := range results {
+		result.message.Score = float32(result.score)
+		messages = append(messages, result.message)
+	}
+
+	logSlowSearch(c.logger, c.slowSearch, time.Since(started), "memory", limit, messages)
+	return messages, nil
+}
+
+// GetMessage returns the stored message with the given ID. The embedding is
+// cleared unless withVector is set.
+func (c *MemoryClient) GetMessage(ctx context.Context, id string, withVector bool) (Message, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, msg := range c.messages {
+		if msg.ID == id {
+			if !withVector {
+				msg.Embedding = nil
+			}
+			return msg, nil
+		}
+	}
+	return Message{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// ListIndexedChannels returns the channels with stored messages, most
+// messages first
+func (c *MemoryClient) ListIndexedChannels(ctx context.Context) ([]ChannelCount, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, msg := range c.messages {
+		if msg.ChannelID != "" {
+			counts[msg.ChannelID]++
+		}
+	}
+	return sortedChannelCounts(counts), nil
+}
+
+// RecreateCollection drops every stored message
+func (c *MemoryClient) RecreateCollection(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.messages = nil
+	c.logger.Warn("Cleared the memory store")
+	return nil
+}
+
+// Close is a no-op, the in-memory store holds no connections
+func (c *MemoryClient) Close() error {
+	return nil
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, or 0 if
+// either vector has zero length or their lengths differ
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
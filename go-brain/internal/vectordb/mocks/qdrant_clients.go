@@ -0,0 +1,117 @@
+package mocks
+
+import (
+	"context"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+)
+
+// MockPointsClient is a mock implementation of the Qdrant PointsClient. Only
+// the methods used by vectordb.Client are mocked; calling any other method
+// panics.
+type MockPointsClient struct {
+	go_client.PointsClient
+	mock.Mock
+}
+
+func (m *MockPointsClient) Upsert(ctx context.Context, in *go_client.UpsertPoints, opts ...grpc.CallOption) (*go_client.PointsOperationResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*go_client.PointsOperationResponse), args.Error(1)
+}
+
+func (m *MockPointsClient) Search(ctx context.Context, in *go_client.SearchPoints, opts ...grpc.CallOption) (*go_client.SearchResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*go_client.SearchResponse), args.Error(1)
+}
+
+func (m *MockPointsClient) Scroll(ctx context.Context, in *go_client.ScrollPoints, opts ...grpc.CallOption) (*go_client.ScrollResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*go_client.ScrollResponse), args.Error(1)
+}
+
+// Count returns the response given to Return, or calls it when it is a
+// func(*go_client.CountPoints) *go_client.CountResponse so a test can count
+// by the request's filter
+func (m *MockPointsClient) Count(ctx context.Context, in *go_client.CountPoints, opts ...grpc.CallOption) (*go_client.CountResponse, error) {
+	args := m.Called(ctx, in)
+	if count, ok := args.Get(0).(func(*go_client.CountPoints) *go_client.CountResponse); ok {
+		return count(in), args.Error(1)
+	}
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*go_client.CountResponse), args.Error(1)
+}
+
+// Get returns the response given to Return, or calls it when it is a
+// func(*go_client.GetPoints) *go_client.GetResponse so a test can answer from
+// its own state
+func (m *MockPointsClient) Get(ctx context.Context, in *go_client.GetPoints, opts ...grpc.CallOption) (*go_client.GetResponse, error) {
+	args := m.Called(ctx, in)
+	if get, ok := args.Get(0).(func(*go_client.GetPoints) *go_client.GetResponse); ok {
+		return get(in), args.Error(1)
+	}
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*go_client.GetResponse), args.Error(1)
+}
+
+func (m *MockPointsClient) SetPayload(ctx context.Context, in *go_client.SetPayloadPoints, opts ...grpc.CallOption) (*go_client.PointsOperationResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*go_client.PointsOperationResponse), args.Error(1)
+}
+
+func (m *MockPointsClient) Delete(ctx context.Context, in *go_client.DeletePoints, opts ...grpc.CallOption) (*go_client.PointsOperationResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*go_client.PointsOperationResponse), args.Error(1)
+}
+
+// MockCollectionsClient is a mock implementation of the Qdrant
+// CollectionsClient. Only the methods used by vectordb.Client are mocked;
+// calling any other method panics.
+type MockCollectionsClient struct {
+	go_client.CollectionsClient
+	mock.Mock
+}
+
+func (m *MockCollectionsClient) List(ctx context.Context, in *go_client.ListCollectionsRequest, opts ...grpc.CallOption) (*go_client.ListCollectionsResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*go_client.ListCollectionsResponse), args.Error(1)
+}
+
+func (m *MockCollectionsClient) Create(ctx context.Context, in *go_client.CreateCollection, opts ...grpc.CallOption) (*go_client.CollectionOperationResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*go_client.CollectionOperationResponse), args.Error(1)
+}
+
+func (m *MockCollectionsClient) Delete(ctx context.Context, in *go_client.DeleteCollection, opts ...grpc.CallOption) (*go_client.CollectionOperationResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*go_client.CollectionOperationResponse), args.Error(1)
+}
@@ -3,6 +3,7 @@ package mocks
 import (
 	"beebrain/internal/vectordb"
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -17,6 +18,11 @@ func (m *MockVectorDBClient) StoreMessage(msg vectordb.Message) error {
 	return args.Error(0)
 }
 
+func (m *MockVectorDBClient) StoreMessages(msgs []vectordb.Message) error {
+	args := m.Called(msgs)
+	return args.Error(0)
+}
+
 func (m *MockVectorDBClient) SearchSimilar(ctx context.Context, embedding []float32, limit uint64) ([]vectordb.Message, error) {
 	args := m.Called(ctx, embedding, limit)
 	if args.Get(0) == nil {
@@ -24,3 +30,39 @@ func (m *MockVectorDBClient) SearchSimilar(ctx context.Context, embedding []floa
 	}
 	return args.Get(0).([]vectordb.Message), args.Error(1)
 }
+
+func (m *MockVectorDBClient) GetMessage(ctx context.Context, id string, withVector bool) (vectordb.Message, error) {
+	args := m.Called(ctx, id, withVector)
+	return args.Get(0).(vectordb.Message), args.Error(1)
+}
+
+func (m *MockVectorDBClient) SetPayload(ctx context.Context, id string, payload map[string]string) error {
+	args := m.Called(ctx, id, payload)
+	return args.Error(0)
+}
+
+func (m *MockVectorDBClient) RecentMessages(ctx context.Context, channelID string, since time.Time, limit int) ([]vectordb.Message, error) {
+	args := m.Called(ctx, channelID, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]vectordb.Message), args.Error(1)
+}
+
+func (m *MockVectorDBClient) ListIndexedChannels(ctx context.Context) ([]vectordb.ChannelCount, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]vectordb.ChannelCount), args.Error(1)
+}
+
+func (m *MockVectorDBClient) RecreateCollection(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockVectorDBClient) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
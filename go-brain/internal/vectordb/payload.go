@@ -0,0 +1,67 @@
+package vectordb
+
+import (
+	"strconv"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+)
+
+// payloadString returns the payload value under key as a string, or def when
+// it is missing or null. Points written by older versions or other tools may
+// lack fields or store them with other types, so numbers and booleans are
+// converted rather than dropped.
+func payloadString(payload map[string]*go_client.Value, key, def string) string {
+	value, ok := payload[key]
+	if !ok || value == nil {
+		return def
+	}
+
+	switch kind := value.GetKind().(type) {
+	case *go_client.Value_StringValue:
+		return kind.StringValue
+	case *go_client.Value_IntegerValue:
+		return strconv.FormatInt(kind.IntegerValue, 10)
+	case *go_client.Value_DoubleValue:
+		return strconv.FormatFloat(kind.DoubleValue, 'f', -1, 64)
+	case *go_client.Value_BoolValue:
+		return strconv.FormatBool(kind.BoolValue)
+	default:
+		return def
+	}
+}
+
+// payloadMetadata returns the custom metadata stored in a payload, or nil if
+// there is none
+func payloadMetadata(payload map[string]*go_client.Value) map[string]string {
+	fields := payload["metadata"].GetStructValue().GetFields()
+	if len(fields) == 0 {
+		return nil
+	}
+
+	metadata := make(map[string]string, len(fields))
+	for key := range fields {
+		metadata[key] = payloadString(fields, key, "")
+	}
+	return metadata
+}
+
+// messageFromPoint maps a stored point back to a Message, tolerating missing
+// IDs, vectors and payload fields
+func messageFromPoint(id *go_client.PointId, payload map[string]*go_client.Value, vectors *go_client.Vectors) Message {
+	msg := Message{
+		Text:      payloadString(payload, "text", ""),
+		UserID:    payloadString(payload, "user_id", ""),
+		ChannelID: payloadString(payload, "channel_id", ""),
+		Timestamp: payloadString(payload, "timestamp", ""),
+		ThreadID:  payloadString(payload, "thread_id", ""),
+		MessageTS: payloadString(payload, "message_ts", ""),
+		RawText:   payloadString(payload, "raw_text", ""),
+		Permalink: payloadString(payload, "permalink", ""),
+		Metadata:  payloadMetadata(payload),
+		Embedding: vectors.GetVector().GetData(),
+	}
+	if id != nil {
+		msg.ID = pointKey(id)
+	}
+	return msg
+}
@@ -0,0 +1,49 @@
+package vectordb
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// RecencyScore combines a message's similarity score with how recently it
+// was posted. Its age decays a factor exponentially, halving every halfLife,
+// and weight of the score is scaled by that factor, so with a weight of 0.3
+// a message a half-life old keeps 85% of its score. Messages without a
+// usable timestamp count as infinitely old.
+func RecencyScore(msg Message, halfLife time.Duration, weight float64, now time.Time) float64 {
+	score := float64(msg.Score)
+	if halfLife <= 0 || weight <= 0 {
+		return score
+	}
+	weight = math.Min(weight, 1)
+
+	decay := 0.0
+	if posted := messageTime(msg); !posted.IsZero() {
+		age := math.Max(now.Sub(posted).Seconds(), 0)
+		decay = math.Pow(0.5, age/halfLife.Seconds())
+	}
+	return score * (1 - weight + weight*decay)
+}
+
+// RankByRecency returns results reordered by RecencyScore, highest first.
+// Scores are left as the similarity to the query.
+func RankByRecency(results []Message, halfLife time.Duration, weight float64, now time.Time) []Message {
+	type scored struct {
+		msg   Message
+		score float64
+	}
+	candidates := make([]scored, len(results))
+	for i, msg := range results {
+		candidates[i] = scored{msg: msg, score: RecencyScore(msg, halfLife, weight, now)}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	ranked := make([]Message, len(candidates))
+	for i, candidate := range candidates {
+		ranked[i] = candidate.msg
+	}
+	return ranked
+}
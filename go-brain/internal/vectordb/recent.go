@@ -0,0 +1,77 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+)
+
+const recentPageSize = 100
+
+// RecentMessages returns up to limit messages of a channel posted since the
+// given time, with their embeddings, most recent first. Messages stored
+// before posted_at was written are left out.
+func (c *Client) RecentMessages(ctx context.Context, channelID string, since time.Time, limit int) ([]Message, error) {
+	if c.closed.Load() {
+		return nil, ErrClosed
+	}
+
+	filter := channelFilter(channelID)
+	filter.Must = append(filter.Must, postedSince(since))
+
+	var messages []Message
+	pageSize := uint32(recentPageSize)
+	var offset *go_client.PointId
+	for {
+		page, err := c.pointsClient.Scroll(ctx, &go_client.ScrollPoints{
+			CollectionName: c.collection,
+			Filter:         filter,
+			Offset:         offset,
+			Limit:          &pageSize,
+			WithPayload:    &go_client.WithPayloadSelector{SelectorOptions: &go_client.WithPayloadSelector_Enable{Enable: true}},
+			WithVectors:    &go_client.WithVectorsSelector{SelectorOptions: &go_client.WithVectorsSelector_Enable{Enable: true}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scroll channel %s: %w", channelID, err)
+		}
+		for _, point := range page.Result {
+			messages = append(messages, messageFromPoint(point.Id, point.Payload, point.Vectors))
+		}
+
+		if page.NextPageOffset == nil {
+			break
+		}
+		offset = page.NextPageOffset
+	}
+	return mostRecent(messages, limit), nil
+}
+
+// RecentMessages returns up to limit messages of a channel posted since the
+// given time, most recent first
+func (c *MemoryClient) RecentMessages(ctx context.Context, channelID string, since time.Time, limit int) ([]Message, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var messages []Message
+	for _, msg := range c.messages {
+		if msg.ChannelID == channelID && !messageTime(msg).Before(since) {
+			messages = append(messages, msg)
+		}
+	}
+	return mostRecent(messages, limit), nil
+}
+
+// mostRecent sorts messages newest first and keeps the first limit of them,
+// or all of them when limit is 0
+func mostRecent(messages []Message, limit int) []Message {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messageTime(messages[i]).After(messageTime(messages[j]))
+	})
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages
+}
@@ -0,0 +1,161 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"beebrain/internal/llm"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+)
+
+const (
+	defaultReindexBatchSize = 100
+	// maxReindexPasses bounds the passes made to catch up with messages the
+	// running bot stores while a reindex is under way
+	maxReindexPasses = 5
+)
+
+// Reindex re-embeds the text of every stored message with embedder and writes
+// it to the target collection, which is created with the client's vector size.
+// Points are migrated batchSize at a time and points already in target are
+// skipped, so an interrupted run resumes when called again with the same
+// target. The bot keeps writing to the current collection meanwhile, so the
+// collection is scanned again until a pass finds nothing left to migrate, and
+// an error is returned if that doesn't happen within maxReindexPasses. Nothing
+// is switched: QDRANT_COLLECTION has to be set to target and the bot
+// restarted to use it. It returns the number of points migrated by this run.
+func (c *Client) Reindex(ctx context.Context, embedder llm.Embedder, target string, batchSize uint32) (int, error) {
+	if c.closed.Load() {
+		return 0, ErrClosed
+	}
+	if target == "" || target == c.collection {
+		return 0, fmt.Errorf("reindex target must be a collection other than %s", c.collection)
+	}
+	if batchSize == 0 {
+		batchSize = defaultReindexBatchSize
+	}
+
+	if err := c.ensureCollection(ctx, target); err != nil {
+		return 0, err
+	}
+
+	c.logger.Infof("Reindexing collection %s into %s", c.collection, target)
+
+	migrated := 0
+	for pass := 1; pass <= maxReindexPasses; pass++ {
+		count, err := c.reindexPass(ctx, embedder, target, batchSize, migrated)
+		migrated += count
+		if err != nil {
+			return migrated, err
+		}
+		// Points stored behind the scroll while a pass ran are picked up by
+		// the next one, until a pass has nothing left to copy
+		if count == 0 {
+			c.logger.Infof("Reindex of collection %s into %s complete", c.collection, target)
+			return migrated, nil
+		}
+		c.logger.Infof("Pass %d migrated %d points, checking %s for points added meanwhile", pass, count, c.collection)
+	}
+	return migrated, fmt.Errorf("points were still being added to %s after %d passes, run the reindex again", c.collection, maxReindexPasses)
+}
+
+// reindexPass scrolls the whole collection once, migrating the points not in
+// target yet, and returns how many it migrated. total is the count migrated
+// by earlier passes, for progress logs.
+func (c *Client) reindexPass(ctx context.Context, embedder llm.Embedder, target string, batchSize uint32, total int) (int, error) {
+	migrated := 0
+	var offset *go_client.PointId
+	for {
+		page, err := c.pointsClient.Scroll(ctx, &go_client.ScrollPoints{
+			CollectionName: c.collection,
+			Offset:         offset,
+			Limit:          &batchSize,
+			WithPayload:    &go_client.WithPayloadSelector{SelectorOptions: &go_client.WithPayloadSelector_Enable{Enable: true}},
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("failed to scroll collection %s: %w", c.collection, err)
+		}
+
+		count, err := c.reindexBatch(ctx, embedder, target, page.Result)
+		migrated += count
+		if err != nil {
+			return migrated, err
+		}
+		if count > 0 {
+			c.logger.Infof("Reindexed %d points into %s so far", total+migrated, target)
+		}
+
+		if page.NextPageOffset == nil {
+			return migrated, nil
+		}
+		offset = page.NextPageOffset
+	}
+}
+
+// reindexBatch re-embeds and upserts the points of one page that aren't in
+// target yet
+func (c *Client) reindexBatch(ctx context.Context, embedder llm.Embedder, target string, points []*go_client.RetrievedPoint) (int, error) {
+	if len(points) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]*go_client.PointId, 0, len(points))
+	for _, point := range points {
+		ids = append(ids, point.Id)
+	}
+	existing, err := c.pointsClient.Get(ctx, &go_client.GetPoints{
+		CollectionName: target,
+		Ids:            ids,
+		WithPayload:    &go_client.WithPayloadSelector{SelectorOptions: &go_client.WithPayloadSelector_Enable{Enable: false}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to check migrated points: %w", err)
+	}
+	migrated := make(map[string]bool, len(existing.Result))
+	for _, point := range existing.Result {
+		migrated[pointKey(point.Id)] = true
+	}
+
+	upserts := make([]*go_client.PointStruct, 0, len(points))
+	for _, point := range points {
+		if migrated[pointKey(point.Id)] {
+			continue
+		}
+
+		embedding, err := llm.EmbedDocument(embedder, payloadString(point.Payload, "text", ""))
+		if err != nil {
+			return 0, fmt.Errorf("failed to re-embed point %s: %w", pointKey(point.Id), err)
+		}
+
+		upserts = append(upserts, &go_client.PointStruct{
+			Id:      point.Id,
+			Vectors: &go_client.Vectors{VectorsOptions: &go_client.Vectors_Vector{Vector: &go_client.Vector{Data: embedding}}},
+			Payload: point.Payload,
+		})
+	}
+	if len(upserts) == 0 {
+		return 0, nil
+	}
+
+	// Wait for the batch to be applied so a resumed run sees it
+	wait := true
+	if _, err := c.pointsClient.Upsert(ctx, &go_client.UpsertPoints{
+		CollectionName: target,
+		Points:         upserts,
+		Wait:           &wait,
+	}); err != nil {
+		return 0, fmt.Errorf("failed to upsert reindexed points: %w", err)
+	}
+	return len(upserts), nil
+}
+
+// pointKey returns a string form of a point ID, which is either a UUID or a
+// number
+func pointKey(id *go_client.PointId) string {
+	if uuid := id.GetUuid(); uuid != "" {
+		return uuid
+	}
+	return strconv.FormatUint(id.GetNum(), 10)
+}
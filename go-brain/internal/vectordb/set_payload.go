@@ -0,0 +1,75 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+)
+
+// SetPayload merges payload into the metadata of the stored message with the
+// given ID, leaving its vector and other fields as they are so the message
+// doesn't need to be embedded again
+func (c *Client) SetPayload(ctx context.Context, id string, payload map[string]string) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+
+	// Qdrant replaces nested values as a whole, so the existing metadata is
+	// read first to keep the keys not being set
+	msg, err := c.GetMessage(ctx, id, false)
+	if err != nil {
+		return err
+	}
+
+	request := &go_client.SetPayloadPoints{
+		CollectionName: c.collection,
+		Payload:        map[string]*go_client.Value{"metadata": metadataValue(mergeMetadata(msg.Metadata, payload))},
+		PointsSelector: &go_client.PointsSelector{PointsSelectorOneOf: &go_client.PointsSelector_Points{
+			Points: &go_client.PointsIdsList{Ids: []*go_client.PointId{pointID(id)}},
+		}},
+	}
+	if c.waitForWrites {
+		request.Wait = &c.waitForWrites
+	}
+	if _, err := c.pointsClient.SetPayload(ctx, request); err != nil {
+		return fmt.Errorf("failed to set payload of point %s: %w", id, err)
+	}
+	return nil
+}
+
+// SetPayload merges payload into the metadata of the stored message with the
+// given ID
+func (c *MemoryClient) SetPayload(ctx context.Context, id string, payload map[string]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, msg := range c.messages {
+		if msg.ID == id {
+			c.messages[i].Metadata = mergeMetadata(msg.Metadata, payload)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// mergeMetadata returns a copy of metadata with the values of updates set
+func mergeMetadata(metadata, updates map[string]string) map[string]string {
+	merged := make(map[string]string, len(metadata)+len(updates))
+	for key, value := range metadata {
+		merged[key] = value
+	}
+	for key, value := range updates {
+		merged[key] = value
+	}
+	return merged
+}
+
+// metadataValue converts metadata to the struct value stored in a payload
+func metadataValue(metadata map[string]string) *go_client.Value {
+	fields := make(map[string]*go_client.Value, len(metadata))
+	for key, value := range metadata {
+		fields[key] = &go_client.Value{Kind: &go_client.Value_StringValue{StringValue: value}}
+	}
+	return &go_client.Value{Kind: &go_client.Value_StructValue{StructValue: &go_client.Struct{Fields: fields}}}
+}
@@ -0,0 +1,23 @@
+package vectordb
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logSlowSearch logs a search that took longer than threshold along with how
+// many results it found and the best of their scores, to tell searches that
+// need tuning apart. A threshold of 0 disables it.
+func logSlowSearch(logger *logrus.Logger, threshold, took time.Duration, collection string, limit uint64, results []Message) {
+	if threshold <= 0 || took <= threshold {
+		return
+	}
+
+	var topScore float32
+	for _, result := range results {
+		topScore = max(topScore, result.Score)
+	}
+	logger.Warnf("Slow search in collection %s took %s (threshold: %s, limit: %d, results: %d, top score: %.3f)",
+		collection, took.Round(time.Millisecond), threshold, limit, len(results), topScore)
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMemoryClientEvictsOldestBeyondChannelCap(t *testing.T) {
+	t.Setenv("MAX_MESSAGES_PER_CHANNEL", "2")
+	client := vectordb.NewMemoryClient(logrus.New())
+
+	messages := []vectordb.Message{
+		{ID: "second", ChannelID: "C1", MessageTS: "1700000200.000000", Embedding: []float32{1, 0}},
+		{ID: "first", ChannelID: "C1", MessageTS: "1700000100.000000", Embedding: []float32{1, 0}},
+		{ID: "other", ChannelID: "C2", MessageTS: "1600000000.000000", Embedding: []float32{1, 0}},
+		{ID: "third", ChannelID: "C1", MessageTS: "1700000300.000000", Embedding: []float32{1, 0}},
+	}
+	assert.NoError(t, client.StoreMessages(messages))
+
+	_, err := client.GetMessage(context.Background(), "first", false)
+	assert.ErrorIs(t, err, vectordb.ErrNotFound)
+	for _, id := range []string{"second", "third", "other"} {
+		_, err := client.GetMessage(context.Background(), id, false)
+		assert.NoError(t, err, id)
+	}
+
+	channels, err := client.ListIndexedChannels(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []vectordb.ChannelCount{{ChannelID: "C1", Count: 2}, {ChannelID: "C2", Count: 1}}, channels)
+}
+
+func TestStoreMessageEvictsOldestBeyondChannelCap(t *testing.T) {
+	tests := []struct {
+		name       string
+		cap        string
+		undated    []*go_client.RetrievedPoint
+		wantDelete string
+	}{
+		{name: "over cap", cap: "2", wantDelete: "p1"},
+		{name: "undated first", cap: "2", undated: []*go_client.RetrievedPoint{vectorPoint("p0", "", nil)}, wantDelete: "p0"},
+		{name: "within cap", cap: "3"},
+		{name: "no cap", cap: "0"},
+	}
+
+	posted := map[string]int64{"p1": 1700000100, "p2": 1700000200, "p3": 1700000300}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MAX_MESSAGES_PER_CHANNEL", tt.cap)
+			mockPoints := &mocks.MockPointsClient{}
+			client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+			mockPoints.On("Upsert", mock.Anything, mock.Anything).Return(&go_client.PointsOperationResponse{}, nil)
+			mockPoints.On("Count", mock.Anything, mock.Anything).Return(func(req *go_client.CountPoints) *go_client.CountResponse {
+				count := uint64(0)
+				for _, at := range posted {
+					if before := postedBefore(req.GetFilter()); before == nil || float64(at) < *before {
+						count++
+					}
+				}
+				return &go_client.CountResponse{Result: &go_client.CountResult{Count: count}}
+			}, nil)
+			mockPoints.On("Scroll", mock.Anything, mock.MatchedBy(func(req *go_client.ScrollPoints) bool {
+				return req.GetFilter().GetMust()[1].GetIsEmpty() != nil
+			})).Return(&go_client.ScrollResponse{Result: tt.undated}, nil)
+			// Only the points before the bisected cutoff are scrolled
+			mockPoints.On("Scroll", mock.Anything, mock.MatchedBy(func(req *go_client.ScrollPoints) bool {
+				before := postedBefore(req.GetFilter())
+				return req.GetFilter().GetMust()[0].GetField().GetMatch().GetKeyword() == "C1" &&
+					before != nil && *before == 1700000101
+			})).Return(&go_client.ScrollResponse{Result: []*go_client.RetrievedPoint{
+				vectorPoint("p1", "1700000100.000000", nil),
+			}}, nil)
+			mockPoints.On("Delete", mock.Anything, mock.MatchedBy(func(req *go_client.DeletePoints) bool {
+				ids := req.GetPoints().GetPoints().GetIds()
+				return len(ids) == 1 && ids[0].GetUuid() == tt.wantDelete
+			})).Return(&go_client.PointsOperationResponse{}, nil)
+
+			err := client.StoreMessage(vectordb.Message{ID: "p3", ChannelID: "C1", MessageTS: "1700000300.000000", Embedding: []float32{1, 0}})
+			assert.NoError(t, err)
+
+			if tt.wantDelete != "" {
+				mockPoints.AssertNumberOfCalls(t, "Delete", 1)
+			} else {
+				mockPoints.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+				mockPoints.AssertNotCalled(t, "Scroll", mock.Anything, mock.Anything)
+			}
+			if tt.cap == "0" {
+				mockPoints.AssertNotCalled(t, "Count", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+// postedBefore returns the posted_at upper bound of a filter, if it has one
+func postedBefore(filter *go_client.Filter) *float64 {
+	for _, condition := range filter.GetMust() {
+		if condition.GetField().GetKey() == "posted_at" {
+			return condition.GetField().GetRange().Lt
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func channelPoint(channelID string) *go_client.RetrievedPoint {
+	point := &go_client.RetrievedPoint{Payload: map[string]*go_client.Value{}}
+	if channelID != "" {
+		point.Payload["channel_id"] = &go_client.Value{Kind: &go_client.Value_StringValue{StringValue: channelID}}
+	}
+	return point
+}
+
+func TestListIndexedChannelsCountsAcrossPages(t *testing.T) {
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	// Only the channel_id payload is requested
+	next := &go_client.PointId{PointIdOptions: &go_client.PointId_Uuid{Uuid: "p4"}}
+	mockPoints.On("Scroll", mock.Anything, mock.MatchedBy(func(req *go_client.ScrollPoints) bool {
+		return req.Offset == nil && assert.ObjectsAreEqual([]string{"channel_id"}, req.GetWithPayload().GetInclude().GetFields())
+	})).Return(&go_client.ScrollResponse{
+		Result:         []*go_client.RetrievedPoint{channelPoint("C2"), channelPoint("C1"), channelPoint("C2")},
+		NextPageOffset: next,
+	}, nil)
+	mockPoints.On("Scroll", mock.Anything, mock.MatchedBy(func(req *go_client.ScrollPoints) bool {
+		return req.Offset.GetUuid() == "p4"
+	})).Return(&go_client.ScrollResponse{
+		// Points without a channel are left out
+		Result: []*go_client.RetrievedPoint{channelPoint("C3"), channelPoint(""), channelPoint("C2")},
+	}, nil)
+
+	channels, err := client.ListIndexedChannels(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []vectordb.ChannelCount{
+		{ChannelID: "C2", Count: 3},
+		{ChannelID: "C1", Count: 1},
+		{ChannelID: "C3", Count: 1},
+	}, channels)
+	mockPoints.AssertNumberOfCalls(t, "Scroll", 2)
+}
+
+func TestMemoryClientListIndexedChannels(t *testing.T) {
+	client := vectordb.NewMemoryClient(logrus.New())
+
+	for _, channelID := range []string{"C1", "C2", "C2"} {
+		assert.NoError(t, client.StoreMessage(vectordb.Message{ChannelID: channelID, Embedding: []float32{1, 0}}))
+	}
+
+	channels, err := client.ListIndexedChannels(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []vectordb.ChannelCount{{ChannelID: "C2", Count: 2}, {ChannelID: "C1", Count: 1}}, channels)
+}
@@ -0,0 +1,267 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestStoreMessageWaitFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		wantWait bool
+	}{
+		{name: "Wait enabled", env: "true", wantWait: true},
+		{name: "Wait disabled", env: "false", wantWait: false},
+		{name: "Wait unset", env: "", wantWait: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("QDRANT_WAIT", tt.env)
+
+			mockPoints := &mocks.MockPointsClient{}
+			client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+			mockPoints.On("Upsert", mock.Anything, mock.MatchedBy(func(req *go_client.UpsertPoints) bool {
+				return req.GetWait() == tt.wantWait
+			})).Return(&go_client.PointsOperationResponse{}, nil)
+
+			err := client.StoreMessage(vectordb.Message{Text: "hello", Embedding: []float32{0.1, 0.2}})
+			assert.NoError(t, err)
+			mockPoints.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCloseClosesConnection(t *testing.T) {
+	// The connection is established lazily, so nothing needs to listen here
+	conn, err := grpc.Dial("localhost:0", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+
+	client := vectordb.NewClientFromConn(conn, logrus.New())
+	assert.NoError(t, client.Close())
+	assert.Equal(t, connectivity.Shutdown, conn.GetState())
+
+	// Closing again is harmless
+	assert.NoError(t, client.Close())
+
+	err = client.StoreMessage(vectordb.Message{Text: "hello", Embedding: []float32{0.1, 0.2}})
+	assert.ErrorIs(t, err, vectordb.ErrClosed)
+
+	_, err = client.SearchSimilar(context.Background(), []float32{0.1, 0.2}, 5)
+	assert.ErrorIs(t, err, vectordb.ErrClosed)
+
+	assert.ErrorIs(t, client.InitializeCollection(context.Background()), vectordb.ErrClosed)
+}
+
+func TestSearchSimilarHandlesPartialPayloads(t *testing.T) {
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	mockPoints.On("Search", mock.Anything, mock.MatchedBy(func(req *go_client.SearchPoints) bool {
+		return req.GetWithPayload().GetEnable()
+	})).Return(&go_client.SearchResponse{Result: []*go_client.ScoredPoint{
+		{
+			// Written by an older version: numeric ID, no vectors, no
+			// thread or channel, timestamp stored as a number
+			Id: &go_client.PointId{PointIdOptions: &go_client.PointId_Num{Num: 42}},
+			Payload: map[string]*go_client.Value{
+				"text":      {Kind: &go_client.Value_StringValue{StringValue: "legacy message"}},
+				"timestamp": {Kind: &go_client.Value_IntegerValue{IntegerValue: 1700000000}},
+				"user_id":   nil,
+			},
+			Score: 0.8,
+		},
+		{
+			// No ID or payload at all
+			Score: 0.5,
+		},
+	}}, nil)
+
+	var messages []vectordb.Message
+	assert.NotPanics(t, func() {
+		var err error
+		messages, err = client.SearchSimilar(context.Background(), []float32{0.1, 0.2}, 2)
+		assert.NoError(t, err)
+	})
+
+	assert.Equal(t, []vectordb.Message{
+		{ID: "42", Text: "legacy message", Timestamp: "1700000000", Score: 0.8},
+		{Score: 0.5},
+	}, messages)
+}
+
+func TestMetadataRoundTrips(t *testing.T) {
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	metadata := map[string]string{"source": "backfill", "sentiment": "positive"}
+
+	var stored *go_client.PointStruct
+	mockPoints.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*go_client.UpsertPoints).Points[0]
+	}).Return(&go_client.PointsOperationResponse{}, nil)
+
+	err := client.StoreMessage(vectordb.Message{ID: "5b1c7c56-7f0c-4d6c-9a55-1f3c1f4cb2a1", Text: "hello @alice", RawText: "hello <@U123>", Metadata: metadata, Embedding: []float32{0.1, 0.2}})
+	assert.NoError(t, err)
+
+	// Search returns the point as it was upserted
+	mockPoints.On("Search", mock.Anything, mock.Anything).Return(&go_client.SearchResponse{
+		Result: []*go_client.ScoredPoint{{Id: stored.Id, Payload: stored.Payload, Score: 1}},
+	}, nil)
+
+	messages, err := client.SearchSimilar(context.Background(), []float32{0.1, 0.2}, 1)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, metadata, messages[0].Metadata)
+	assert.Equal(t, "hello <@U123>", messages[0].RawText)
+
+	// The memory store keeps metadata as well
+	memory := vectordb.NewMemoryClient(logrus.New())
+	assert.NoError(t, memory.StoreMessage(vectordb.Message{Text: "hello", Metadata: metadata, Embedding: []float32{0.1, 0.2}}))
+	messages, err = memory.SearchSimilar(context.Background(), []float32{0.1, 0.2}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, metadata, messages[0].Metadata)
+}
+
+func TestGetMessageFetchesAndMapsPoint(t *testing.T) {
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	id := "0b5e6b8e-7d1c-4c55-9f6a-3f1d5e0c8a11"
+	mockPoints.On("Get", mock.Anything, mock.MatchedBy(func(req *go_client.GetPoints) bool {
+		return req.CollectionName == "slack_messages" &&
+			len(req.Ids) == 1 && req.Ids[0].GetUuid() == id &&
+			req.GetWithPayload().GetEnable() && req.GetWithVectors().GetEnable()
+	})).Return(&go_client.GetResponse{Result: []*go_client.RetrievedPoint{{
+		Id: &go_client.PointId{PointIdOptions: &go_client.PointId_Uuid{Uuid: id}},
+		Payload: map[string]*go_client.Value{
+			"text":       {Kind: &go_client.Value_StringValue{StringValue: "Deploys happen on Fridays"}},
+			"user_id":    {Kind: &go_client.Value_StringValue{StringValue: "U1"}},
+			"channel_id": {Kind: &go_client.Value_StringValue{StringValue: "C1"}},
+			"message_ts": {Kind: &go_client.Value_StringValue{StringValue: "1700000000.000100"}},
+		},
+		Vectors: &go_client.Vectors{VectorsOptions: &go_client.Vectors_Vector{Vector: &go_client.Vector{Data: []float32{0.1, 0.2}}}},
+	}}}, nil)
+
+	msg, err := client.GetMessage(context.Background(), id, true)
+	assert.NoError(t, err)
+	assert.Equal(t, id, msg.ID)
+	assert.Equal(t, "Deploys happen on Fridays", msg.Text)
+	assert.Equal(t, "U1", msg.UserID)
+	assert.Equal(t, "C1", msg.ChannelID)
+	assert.Equal(t, "1700000000.000100", msg.MessageTS)
+	assert.Equal(t, []float32{0.1, 0.2}, msg.Embedding)
+}
+
+func TestGetMessageMissingIDIsNotFound(t *testing.T) {
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	// Numeric IDs are looked up as numeric points
+	mockPoints.On("Get", mock.Anything, mock.MatchedBy(func(req *go_client.GetPoints) bool {
+		return req.Ids[0].GetNum() == 42 && !req.GetWithVectors().GetEnable()
+	})).Return(&go_client.GetResponse{}, nil)
+
+	_, err := client.GetMessage(context.Background(), "42", false)
+	assert.ErrorIs(t, err, vectordb.ErrNotFound)
+}
+
+func TestRecreateCollectionDeletesThenCreates(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   []*go_client.CollectionDescription
+		wantDelete bool
+	}{
+		{name: "existing collection", existing: []*go_client.CollectionDescription{{Name: "other"}, {Name: "slack_messages"}}, wantDelete: true},
+		{name: "missing collection", existing: []*go_client.CollectionDescription{{Name: "other"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("QDRANT_VECTOR_SIZE", "2")
+			mockCollections := &mocks.MockCollectionsClient{}
+			client := vectordb.NewClientFromServices(mockCollections, &mocks.MockPointsClient{}, logrus.New())
+
+			var calls []string
+			mockCollections.On("List", mock.Anything, mock.Anything).Return(&go_client.ListCollectionsResponse{Collections: tt.existing}, nil)
+			mockCollections.On("Delete", mock.Anything, mock.MatchedBy(func(req *go_client.DeleteCollection) bool {
+				return req.CollectionName == "slack_messages"
+			})).Run(func(mock.Arguments) {
+				calls = append(calls, "delete")
+			}).Return(&go_client.CollectionOperationResponse{Result: true}, nil)
+			mockCollections.On("Create", mock.Anything, mock.MatchedBy(func(req *go_client.CreateCollection) bool {
+				return req.CollectionName == "slack_messages" && req.GetVectorsConfig().GetParams().GetSize() == 2
+			})).Run(func(mock.Arguments) {
+				calls = append(calls, "create")
+			}).Return(&go_client.CollectionOperationResponse{Result: true}, nil)
+
+			assert.NoError(t, client.RecreateCollection(context.Background()))
+			if tt.wantDelete {
+				assert.Equal(t, []string{"delete", "create"}, calls)
+			} else {
+				assert.Equal(t, []string{"create"}, calls)
+			}
+		})
+	}
+}
+
+func TestRecreateCollectionStopsWhenDeleteFails(t *testing.T) {
+	mockCollections := &mocks.MockCollectionsClient{}
+	client := vectordb.NewClientFromServices(mockCollections, &mocks.MockPointsClient{}, logrus.New())
+
+	mockCollections.On("List", mock.Anything, mock.Anything).Return(&go_client.ListCollectionsResponse{
+		Collections: []*go_client.CollectionDescription{{Name: "slack_messages"}},
+	}, nil)
+	mockCollections.On("Delete", mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+	err := client.RecreateCollection(context.Background())
+	assert.ErrorIs(t, err, assert.AnError)
+	mockCollections.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestInitializeCollectionToleratesConcurrentCreate(t *testing.T) {
+	tests := []struct {
+		name      string
+		createErr error
+		wantErr   bool
+	}{
+		{name: "Qdrant already exists error", createErr: status.Error(codes.InvalidArgument, "Wrong input: Collection `slack_messages` already exists!")},
+		{name: "AlreadyExists status", createErr: status.Error(codes.AlreadyExists, "collection exists")},
+		{name: "Other create error", createErr: status.Error(codes.Unavailable, "connection refused"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCollections := &mocks.MockCollectionsClient{}
+			client := vectordb.NewClientFromServices(mockCollections, &mocks.MockPointsClient{}, logrus.New())
+
+			// The collection is missing when listed, but another replica
+			// creates it before this one does
+			mockCollections.On("List", mock.Anything, mock.Anything).Return(&go_client.ListCollectionsResponse{}, nil)
+			mockCollections.On("Create", mock.Anything, mock.Anything).Return(nil, tt.createErr)
+
+			err := client.InitializeCollection(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockCollections.AssertNumberOfCalls(t, "Create", 1)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"testing"
+
+	"beebrain/internal/vectordb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterMessagesGroupsSimilarEmbeddings(t *testing.T) {
+	messages := []vectordb.Message{
+		{ID: "deploy-1", Embedding: []float32{1, 0, 0}},
+		{ID: "lunch-1", Embedding: []float32{0, 1, 0}},
+		{ID: "deploy-2", Embedding: []float32{0.95, 0.05, 0}},
+		{ID: "no-embedding"},
+		{ID: "deploy-3", Embedding: []float32{0.9, 0, 0.1}},
+		{ID: "lunch-2", Embedding: []float32{0.1, 0.9, 0}},
+		{ID: "oncall", Embedding: []float32{0, 0, 1}},
+	}
+
+	clusters := vectordb.ClusterMessages(messages, 0.9)
+
+	var ids [][]string
+	for _, cluster := range clusters {
+		var group []string
+		for _, msg := range cluster {
+			group = append(group, msg.ID)
+		}
+		ids = append(ids, group)
+	}
+	assert.Equal(t, [][]string{
+		{"deploy-1", "deploy-2", "deploy-3"},
+		{"lunch-1", "lunch-2"},
+		{"oncall"},
+	}, ids)
+}
+
+func TestClusterMessagesThresholdSplitsLooseGroups(t *testing.T) {
+	messages := []vectordb.Message{
+		{ID: "a", Embedding: []float32{1, 0}},
+		{ID: "b", Embedding: []float32{0.7, 0.7}},
+	}
+
+	assert.Len(t, vectordb.ClusterMessages(messages, 0.9), 2)
+	assert.Len(t, vectordb.ClusterMessages(messages, 0.5), 1)
+	assert.Empty(t, vectordb.ClusterMessages(nil, 0.9))
+}
+
+func TestClusterMessagesKeepsDimensionsApart(t *testing.T) {
+	messages := []vectordb.Message{
+		{ID: "old-1", Embedding: []float32{1, 0}},
+		{ID: "new-1", Embedding: []float32{1, 0, 0}},
+		{ID: "old-2", Embedding: []float32{0.9, 0.1}},
+		{ID: "new-2", Embedding: []float32{0.9, 0, 0.1}},
+	}
+
+	// Even a threshold every message meets doesn't mix dimensions
+	for _, threshold := range []float64{0.9, 0} {
+		clusters := vectordb.ClusterMessages(messages, threshold)
+
+		var ids [][]string
+		for _, cluster := range clusters {
+			var group []string
+			for _, msg := range cluster {
+				group = append(group, msg.ID)
+			}
+			ids = append(ids, group)
+		}
+		assert.Equal(t, [][]string{{"old-1", "old-2"}, {"new-1", "new-2"}}, ids)
+	}
+}
@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func clusterIDs(cluster vectordb.DuplicateCluster) (string, []string) {
+	ids := make([]string, 0, len(cluster.Duplicates))
+	for _, msg := range cluster.Duplicates {
+		ids = append(ids, msg.ID)
+	}
+	return cluster.Keep.ID, ids
+}
+
+func TestFindDuplicatesClustersSimilarMessages(t *testing.T) {
+	client := vectordb.NewMemoryClient(logrus.New())
+	messages := []vectordb.Message{
+		{ID: "repost", MessageTS: "1700000300.000000", Embedding: []float32{1, 0.01, 0}},
+		{ID: "original", MessageTS: "1700000100.000000", Embedding: []float32{1, 0, 0}},
+		{ID: "thanks-2", Timestamp: "2023-11-14T22:20:00Z", Embedding: []float32{0, 1, 0}},
+		{ID: "thanks-1", Timestamp: "2023-11-14T22:15:00Z", Embedding: []float32{0, 1, 0.01}},
+		{ID: "unrelated", MessageTS: "1700000000.000000", Embedding: []float32{0, 0, 1}},
+	}
+	for _, msg := range messages {
+		assert.NoError(t, client.StoreMessage(msg))
+	}
+
+	clusters, err := vectordb.FindDuplicates(context.Background(), client, messages, 0.99)
+	assert.NoError(t, err)
+	assert.Len(t, clusters, 2)
+
+	// The earliest message of each cluster is kept
+	keep, duplicates := clusterIDs(clusters[0])
+	assert.Equal(t, "original", keep)
+	assert.Equal(t, []string{"repost"}, duplicates)
+	keep, duplicates = clusterIDs(clusters[1])
+	assert.Equal(t, "thanks-1", keep)
+	assert.Equal(t, []string{"thanks-2"}, duplicates)
+}
+
+func TestFindDuplicatesComparesWithKeptMessage(t *testing.T) {
+	client := vectordb.NewMemoryClient(logrus.New())
+	// a and c are too far apart, but both are close to b
+	messages := []vectordb.Message{
+		{ID: "a", MessageTS: "1700000001.000000", Embedding: []float32{1, 0}},
+		{ID: "b", MessageTS: "1700000002.000000", Embedding: []float32{1, 0.15}},
+		{ID: "c", MessageTS: "1700000003.000000", Embedding: []float32{1, 0.3}},
+	}
+	for _, msg := range messages {
+		assert.NoError(t, client.StoreMessage(msg))
+	}
+
+	clusters, err := vectordb.FindDuplicates(context.Background(), client, messages[:1], 0.985)
+	assert.NoError(t, err)
+	assert.Len(t, clusters, 1)
+	_, duplicates := clusterIDs(clusters[0])
+	assert.Equal(t, []string{"b"}, duplicates)
+
+	// c is only close to b, which a keeps, so c is kept too
+	clusters, err = vectordb.FindDuplicates(context.Background(), client, messages, 0.985)
+	assert.NoError(t, err)
+	assert.Len(t, clusters, 1)
+	keep, duplicates := clusterIDs(clusters[0])
+	assert.Equal(t, "a", keep)
+	assert.Equal(t, []string{"b"}, duplicates)
+}
+
+func TestFindDuplicatesBelowThreshold(t *testing.T) {
+	client := vectordb.NewMemoryClient(logrus.New())
+	messages := []vectordb.Message{
+		{ID: "a", Embedding: []float32{1, 0}},
+		{ID: "b", Embedding: []float32{1, 1}},
+	}
+	for _, msg := range messages {
+		assert.NoError(t, client.StoreMessage(msg))
+	}
+
+	clusters, err := vectordb.FindDuplicates(context.Background(), client, messages, 0.99)
+	assert.NoError(t, err)
+	assert.Empty(t, clusters)
+}
+
+func vectorPoint(id, ts string, vector []float32) *go_client.RetrievedPoint {
+	point := uuidPoint(id, id)
+	point.Payload["message_ts"] = &go_client.Value{Kind: &go_client.Value_StringValue{StringValue: ts}}
+	point.Vectors = &go_client.Vectors{VectorsOptions: &go_client.Vectors_Vector{Vector: &go_client.Vector{Data: vector}}}
+	return point
+}
+
+func scoredPoint(point *go_client.RetrievedPoint, score float32) *go_client.ScoredPoint {
+	return &go_client.ScoredPoint{Id: point.Id, Payload: point.Payload, Score: score}
+}
+
+func TestDedupDeletesDuplicatesKeepingEarliest(t *testing.T) {
+	tests := []struct {
+		name       string
+		dryRun     bool
+		wantDelete bool
+	}{
+		{name: "collapse", wantDelete: true},
+		{name: "dry run", dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockPoints := &mocks.MockPointsClient{}
+			client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+			original := vectorPoint("p1", "1700000100.000000", []float32{1, 0})
+			repost := vectorPoint("p2", "1700000200.000000", []float32{1, 0})
+			other := vectorPoint("p3", "1700000300.000000", []float32{0, 1})
+
+			mockPoints.On("Scroll", mock.Anything, mock.Anything).Return(&go_client.ScrollResponse{
+				Result: []*go_client.RetrievedPoint{repost, original, other},
+			}, nil)
+			mockPoints.On("Search", mock.Anything, mock.MatchedBy(func(req *go_client.SearchPoints) bool {
+				return req.Vector[0] == 1
+			})).Return(&go_client.SearchResponse{Result: []*go_client.ScoredPoint{
+				scoredPoint(original, 1), scoredPoint(repost, 1), scoredPoint(other, 0),
+			}}, nil)
+			mockPoints.On("Search", mock.Anything, mock.Anything).Return(&go_client.SearchResponse{Result: []*go_client.ScoredPoint{
+				scoredPoint(other, 1), scoredPoint(original, 0), scoredPoint(repost, 0),
+			}}, nil)
+			mockPoints.On("Delete", mock.Anything, mock.MatchedBy(func(req *go_client.DeletePoints) bool {
+				ids := req.GetPoints().GetPoints().GetIds()
+				return len(ids) == 1 && ids[0].GetUuid() == "p2"
+			})).Return(&go_client.PointsOperationResponse{}, nil)
+
+			removed, err := client.Dedup(context.Background(), 0.98, tt.dryRun)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, removed)
+			if tt.wantDelete {
+				mockPoints.AssertNumberOfCalls(t, "Delete", 1)
+			} else {
+				mockPoints.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestDedupDryRunSkipsNeighboursAlreadyCollapsed(t *testing.T) {
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	original := vectorPoint("p1", "1700000100.000000", []float32{1, 0})
+	repost := vectorPoint("p2", "1700000200.000000", []float32{1, 0.1})
+	reply := vectorPoint("p3", "1700000300.000000", []float32{1, 0.2})
+
+	mockPoints.On("Scroll", mock.Anything, mock.MatchedBy(func(req *go_client.ScrollPoints) bool {
+		return req.Offset == nil
+	})).Return(&go_client.ScrollResponse{Result: []*go_client.RetrievedPoint{original, repost}, NextPageOffset: reply.Id}, nil)
+	mockPoints.On("Scroll", mock.Anything, mock.Anything).Return(&go_client.ScrollResponse{
+		Result: []*go_client.RetrievedPoint{reply},
+	}, nil)
+	// The repost is close to both, the reply isn't close to the original
+	mockPoints.On("Search", mock.Anything, mock.MatchedBy(func(req *go_client.SearchPoints) bool {
+		return req.Vector[1] == 0
+	})).Return(&go_client.SearchResponse{Result: []*go_client.ScoredPoint{
+		scoredPoint(original, 1), scoredPoint(repost, 0.99), scoredPoint(reply, 0.9),
+	}}, nil)
+	mockPoints.On("Search", mock.Anything, mock.MatchedBy(func(req *go_client.SearchPoints) bool {
+		return req.Vector[1] != 0 && req.Vector[1] < 0.15
+	})).Return(&go_client.SearchResponse{Result: []*go_client.ScoredPoint{
+		scoredPoint(repost, 1), scoredPoint(original, 0.99), scoredPoint(reply, 0.99),
+	}}, nil)
+	mockPoints.On("Search", mock.Anything, mock.Anything).Return(&go_client.SearchResponse{Result: []*go_client.ScoredPoint{
+		scoredPoint(reply, 1), scoredPoint(repost, 0.99), scoredPoint(original, 0.9),
+	}}, nil)
+
+	// Only the repost goes, as in a real run where the reply's search no
+	// longer finds it
+	removed, err := client.Dedup(context.Background(), 0.98, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	mockPoints.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
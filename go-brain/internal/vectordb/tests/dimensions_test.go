@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStoreMessagesSkipsMismatchedDimensions(t *testing.T) {
+	t.Setenv("QDRANT_VECTOR_SIZE", "2")
+	t.Setenv("SKIP_DIMENSION_MISMATCH", "true")
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	var stored []*go_client.PointStruct
+	mockPoints.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*go_client.UpsertPoints).Points
+	}).Return(&go_client.PointsOperationResponse{}, nil)
+
+	err := client.StoreMessages([]vectordb.Message{
+		{ID: "11111111-1111-1111-1111-111111111111", Text: "old model", Embedding: []float32{0.1, 0.2}},
+		{ID: "22222222-2222-2222-2222-222222222222", Text: "new model", Embedding: []float32{0.1, 0.2, 0.3}},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, "11111111-1111-1111-1111-111111111111", stored[0].Id.GetUuid())
+	}
+}
+
+func TestStoreMessageSkipsMismatchedDimension(t *testing.T) {
+	t.Setenv("QDRANT_VECTOR_SIZE", "2")
+	t.Setenv("SKIP_DIMENSION_MISMATCH", "true")
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	err := client.StoreMessage(vectordb.Message{Text: "new model", Embedding: []float32{0.1, 0.2, 0.3}})
+	assert.NoError(t, err)
+	mockPoints.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+}
+
+func TestStoreMessageSendsMismatchedDimensionWhenNotSkipping(t *testing.T) {
+	t.Setenv("QDRANT_VECTOR_SIZE", "2")
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	mockPoints.On("Upsert", mock.Anything, mock.Anything).Return(&go_client.PointsOperationResponse{}, nil)
+
+	// Qdrant is left to reject it
+	err := client.StoreMessage(vectordb.Message{Text: "new model", Embedding: []float32{0.1, 0.2, 0.3}})
+	assert.NoError(t, err)
+	mockPoints.AssertNumberOfCalls(t, "Upsert", 1)
+}
+
+func TestSearchSimilarGuardsMismatchedDimension(t *testing.T) {
+	tests := []struct {
+		name       string
+		embedding  []float32
+		wantSearch bool
+	}{
+		{name: "Matching dimension", embedding: []float32{0.1, 0.2}, wantSearch: true},
+		{name: "Mismatched dimension", embedding: []float32{0.1, 0.2, 0.3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("QDRANT_VECTOR_SIZE", "2")
+			t.Setenv("SKIP_DIMENSION_MISMATCH", "true")
+			mockPoints := &mocks.MockPointsClient{}
+			client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+			mockPoints.On("Search", mock.Anything, mock.Anything).Return(&go_client.SearchResponse{}, nil)
+
+			messages, err := client.SearchSimilar(context.Background(), tt.embedding, 5)
+			assert.NoError(t, err)
+			assert.Empty(t, messages)
+			if tt.wantSearch {
+				mockPoints.AssertNumberOfCalls(t, "Search", 1)
+			} else {
+				mockPoints.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected go_client.Distance
+		wantErr  bool
+	}{
+		{name: "cosine", expected: go_client.Distance_Cosine},
+		{name: "dot", expected: go_client.Distance_Dot},
+		{name: "Euclid", expected: go_client.Distance_Euclid},
+		{name: "manhattan", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance, err := vectordb.ParseDistance(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, distance)
+		})
+	}
+}
+
+func TestInitializeCollectionUsesConfiguredDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		distance string
+		expected go_client.Distance
+	}{
+		{name: "Default is cosine", distance: "", expected: go_client.Distance_Cosine},
+		{name: "Configured metric", distance: "dot", expected: go_client.Distance_Dot},
+		{name: "Invalid falls back to cosine", distance: "manhattan", expected: go_client.Distance_Cosine},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("QDRANT_DISTANCE", tt.distance)
+
+			mockCollections := &mocks.MockCollectionsClient{}
+			client := vectordb.NewClientFromServices(mockCollections, &mocks.MockPointsClient{}, logrus.New())
+
+			mockCollections.On("List", mock.Anything, mock.Anything).Return(&go_client.ListCollectionsResponse{}, nil)
+			mockCollections.On("Create", mock.Anything, mock.MatchedBy(func(req *go_client.CreateCollection) bool {
+				return req.GetVectorsConfig().GetParams().GetDistance() == tt.expected
+			})).Return(&go_client.CollectionOperationResponse{Result: true}, nil)
+
+			assert.NoError(t, client.InitializeCollection(context.Background()))
+			mockCollections.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchSimilarScoresAreSimilarities(t *testing.T) {
+	tests := []struct {
+		name       string
+		distance   string
+		scores     []float32
+		wantScores []float32
+	}{
+		{name: "Cosine scores are similarities", distance: "cosine", scores: []float32{0.9, 0.5}, wantScores: []float32{0.9, 0.5}},
+		{name: "Euclid distances are turned into similarities", distance: "euclid", scores: []float32{0, 1, 3}, wantScores: []float32{1, 0.5, 0.25}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("QDRANT_DISTANCE", tt.distance)
+			mockPoints := &mocks.MockPointsClient{}
+			client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+			results := make([]*go_client.ScoredPoint, len(tt.scores))
+			for i, score := range tt.scores {
+				results[i] = &go_client.ScoredPoint{Id: &go_client.PointId{PointIdOptions: &go_client.PointId_Num{Num: uint64(i)}}, Score: score}
+			}
+			mockPoints.On("Search", mock.Anything, mock.Anything).Return(&go_client.SearchResponse{Result: results}, nil)
+
+			messages, err := client.SearchSimilar(context.Background(), []float32{0.1, 0.2}, 5)
+			assert.NoError(t, err)
+			scores := make([]float32, len(messages))
+			for i, msg := range messages {
+				scores[i] = msg.Score
+			}
+			// Closer results keep scoring higher
+			assert.Equal(t, tt.wantScores, scores)
+		})
+	}
+}
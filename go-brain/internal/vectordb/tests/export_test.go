@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	llmmocks "beebrain/internal/llm/mocks"
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func exportPoint(id, text string, embedding []float32) *go_client.RetrievedPoint {
+	point := &go_client.RetrievedPoint{
+		Id: &go_client.PointId{PointIdOptions: &go_client.PointId_Uuid{Uuid: id}},
+		Payload: map[string]*go_client.Value{
+			"text":       {Kind: &go_client.Value_StringValue{StringValue: text}},
+			"user_id":    {Kind: &go_client.Value_StringValue{StringValue: "U1"}},
+			"channel_id": {Kind: &go_client.Value_StringValue{StringValue: "C1"}},
+			"timestamp":  {Kind: &go_client.Value_StringValue{StringValue: "2024-01-02T03:04:05Z"}},
+			"thread_id":  {Kind: &go_client.Value_StringValue{StringValue: "1700000000.000100"}},
+			"message_ts": {Kind: &go_client.Value_StringValue{StringValue: "1700000000.000200"}},
+			"metadata": {Kind: &go_client.Value_StructValue{StructValue: &go_client.Struct{Fields: map[string]*go_client.Value{
+				"source": {Kind: &go_client.Value_StringValue{StringValue: "backfill"}},
+			}}}},
+		},
+	}
+	if embedding != nil {
+		point.Vectors = &go_client.Vectors{VectorsOptions: &go_client.Vectors_Vector{Vector: &go_client.Vector{Data: embedding}}}
+	}
+	return point
+}
+
+func TestExportImportChannelRoundTrip(t *testing.T) {
+	source := &mocks.MockPointsClient{}
+	exporter := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, source, logrus.New())
+
+	// Only the channel's points are scrolled, across pages
+	next := &go_client.PointId{PointIdOptions: &go_client.PointId_Uuid{Uuid: "p2"}}
+	source.On("Scroll", mock.Anything, mock.MatchedBy(func(req *go_client.ScrollPoints) bool {
+		match := req.GetFilter().GetMust()[0].GetField()
+		return req.Offset == nil && match.GetKey() == "channel_id" && match.GetMatch().GetKeyword() == "C1" &&
+			req.GetWithVectors().GetEnable()
+	})).Return(&go_client.ScrollResponse{
+		Result:         []*go_client.RetrievedPoint{exportPoint("p1", "Deploys happen on Fridays", []float32{1, 0})},
+		NextPageOffset: next,
+	}, nil)
+	source.On("Scroll", mock.Anything, mock.MatchedBy(func(req *go_client.ScrollPoints) bool {
+		return req.Offset.GetUuid() == "p2"
+	})).Return(&go_client.ScrollResponse{
+		Result: []*go_client.RetrievedPoint{exportPoint("p2", "Not on holidays", []float32{0, 1})},
+	}, nil)
+
+	var export bytes.Buffer
+	exported, err := exporter.ExportChannel(context.Background(), "C1", &export, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, exported)
+
+	var document vectordb.ChannelExport
+	assert.NoError(t, json.Unmarshal(export.Bytes(), &document))
+	assert.Equal(t, "C1", document.ChannelID)
+	assert.Equal(t, vectordb.ExportedMessage{
+		ID:        "p1",
+		Text:      "Deploys happen on Fridays",
+		UserID:    "U1",
+		ChannelID: "C1",
+		Timestamp: "2024-01-02T03:04:05Z",
+		ThreadID:  "1700000000.000100",
+		MessageTS: "1700000000.000200",
+		Metadata:  map[string]string{"source": "backfill"},
+		Embedding: []float32{1, 0},
+	}, document.Messages[0])
+
+	target := &mocks.MockPointsClient{}
+	importer := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, target, logrus.New())
+
+	var upserted []*go_client.PointStruct
+	target.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		upserted = append(upserted, args.Get(1).(*go_client.UpsertPoints).Points...)
+	}).Return(&go_client.PointsOperationResponse{}, nil)
+
+	imported, err := importer.ImportChannel(context.Background(), bytes.NewReader(export.Bytes()), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, imported)
+
+	// The points come back as they were exported, IDs included
+	if assert.Len(t, upserted, 2) {
+		for i, original := range []*go_client.RetrievedPoint{exportPoint("p1", "Deploys happen on Fridays", []float32{1, 0}), exportPoint("p2", "Not on holidays", []float32{0, 1})} {
+			assert.Equal(t, original.Id.GetUuid(), upserted[i].Id.GetUuid())
+			assert.Equal(t, original.Vectors.GetVector().GetData(), upserted[i].Vectors.GetVector().GetData())
+			for _, key := range []string{"text", "user_id", "channel_id", "timestamp", "thread_id", "message_ts"} {
+				assert.Equal(t, original.Payload[key].GetStringValue(), upserted[i].Payload[key].GetStringValue(), key)
+			}
+			assert.Equal(t, "backfill", upserted[i].Payload["metadata"].GetStructValue().GetFields()["source"].GetStringValue())
+		}
+	}
+}
+
+func TestImportChannelEmbedsMessagesWithoutVectors(t *testing.T) {
+	mockPoints := &mocks.MockPointsClient{}
+	mockEmbedder := &llmmocks.MockEmbedder{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	export := `{"version":1,"channel_id":"C1","messages":[{"id":"p1","text":"Deploys happen on Fridays","user_id":"U1","channel_id":"C1","timestamp":"2024-01-02T03:04:05Z"}]}`
+
+	// Without an embedder the message can't be stored
+	_, err := client.ImportChannel(context.Background(), bytes.NewReader([]byte(export)), nil)
+	assert.Error(t, err)
+	mockPoints.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+
+	mockEmbedder.On("GetEmbedding", "Deploys happen on Fridays").Return([]float32{1, 0}, nil)
+	mockPoints.On("Upsert", mock.Anything, mock.MatchedBy(func(req *go_client.UpsertPoints) bool {
+		return len(req.Points) == 1 && assert.ObjectsAreEqual([]float32{1, 0}, req.Points[0].Vectors.GetVector().GetData())
+	})).Return(&go_client.PointsOperationResponse{}, nil)
+
+	imported, err := client.ImportChannel(context.Background(), bytes.NewReader([]byte(export)), mockEmbedder)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, imported)
+
+	_, err = client.ImportChannel(context.Background(), bytes.NewReader([]byte(`{"version":2}`)), mockEmbedder)
+	assert.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSearchSimilarAppliesMaxAge(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxAge     string
+		wantFilter bool
+	}{
+		{name: "Max age set", maxAge: "720h", wantFilter: true},
+		{name: "Max age disabled", maxAge: "0"},
+		{name: "Max age unset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SEARCH_MAX_AGE", tt.maxAge)
+			mockPoints := &mocks.MockPointsClient{}
+			client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+			var filter *go_client.Filter
+			mockPoints.On("Search", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				filter = args.Get(1).(*go_client.SearchPoints).Filter
+			}).Return(&go_client.SearchResponse{}, nil)
+
+			_, err := client.SearchSimilar(context.Background(), []float32{0.1, 0.2}, 5)
+			assert.NoError(t, err)
+
+			if !tt.wantFilter {
+				assert.Nil(t, filter)
+				return
+			}
+			if assert.NotNil(t, filter) && assert.Len(t, filter.Must, 1) {
+				field := filter.Must[0].GetField()
+				assert.Equal(t, "posted_at", field.Key)
+				since := time.Unix(int64(field.GetRange().GetGte()), 0)
+				assert.WithinDuration(t, time.Now().Add(-720*time.Hour), since, time.Minute)
+			}
+		})
+	}
+}
+
+func TestStoreMessageWritesPostedAt(t *testing.T) {
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	var stored *go_client.PointStruct
+	mockPoints.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*go_client.UpsertPoints).Points[0]
+	}).Return(&go_client.PointsOperationResponse{}, nil)
+
+	err := client.StoreMessage(vectordb.Message{Text: "hello", MessageTS: "1700000000.000100", Embedding: []float32{0.1, 0.2}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1700000000), stored.Payload["posted_at"].GetIntegerValue())
+}
+
+func TestMemorySearchAppliesMaxAge(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxAge string
+		want   []string
+	}{
+		{name: "Max age set", maxAge: "720h", want: []string{"recent"}},
+		{name: "Max age disabled", maxAge: "0", want: []string{"recent", "old", "undated"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SEARCH_MAX_AGE", tt.maxAge)
+			client := vectordb.NewMemoryClient(logrus.New())
+
+			now := time.Now()
+			slackTS := func(at time.Time) string { return strconv.FormatInt(at.Unix(), 10) + ".000100" }
+			assert.NoError(t, client.StoreMessages([]vectordb.Message{
+				{ID: "recent", Text: "recent", MessageTS: slackTS(now.Add(-time.Hour)), Embedding: []float32{1, 0}},
+				{ID: "old", Text: "old", MessageTS: slackTS(now.Add(-2000 * time.Hour)), Embedding: []float32{1, 0.1}},
+				{ID: "undated", Text: "undated", Embedding: []float32{1, 0.2}},
+			}))
+
+			messages, err := client.SearchSimilar(context.Background(), []float32{1, 0}, 5)
+			assert.NoError(t, err)
+			var ids []string
+			for _, msg := range messages {
+				ids = append(ids, msg.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"beebrain/internal/llm"
+	"beebrain/internal/vectordb"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensure the in-memory store implements the same interface as Qdrant
+var _ vectordb.VectorDBClient = (*vectordb.MemoryClient)(nil)
+
+func TestMemoryClientSearchReturnsNearest(t *testing.T) {
+	client := vectordb.NewMemoryClient(logrus.New())
+
+	messages := []vectordb.Message{
+		{ID: "east", Text: "east", Embedding: []float32{1, 0, 0}},
+		{ID: "north", Text: "north", Embedding: []float32{0, 1, 0}},
+		{ID: "up", Text: "up", Embedding: []float32{0, 0, 1}},
+	}
+	for _, msg := range messages {
+		assert.NoError(t, client.StoreMessage(msg))
+	}
+
+	results, err := client.SearchSimilar(context.Background(), []float32{0.1, 0.9, 0.2}, 2)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "north", results[0].ID)
+	assert.Equal(t, "up", results[1].ID)
+}
+
+func TestMemoryClientStoreAssignsIDAndUpserts(t *testing.T) {
+	client := vectordb.NewMemoryClient(logrus.New())
+
+	assert.NoError(t, client.StoreMessage(vectordb.Message{Text: "generated", Embedding: []float32{1, 0}}))
+	assert.NoError(t, client.StoreMessage(vectordb.Message{ID: "fixed", Text: "old", Embedding: []float32{0, 1}}))
+	assert.NoError(t, client.StoreMessage(vectordb.Message{ID: "fixed", Text: "new", Embedding: []float32{0, 1}}))
+
+	results, err := client.SearchSimilar(context.Background(), []float32{0, 1}, 10)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "new", results[0].Text)
+	assert.NotEmpty(t, results[1].ID)
+}
+
+func TestMemoryClientScoresNormalizedEmbeddings(t *testing.T) {
+	client := vectordb.NewMemoryClient(logrus.New())
+
+	// Vectors of wildly different magnitudes, as some models return them
+	messages := []vectordb.Message{
+		{ID: "same", Embedding: llm.Normalize([]float32{200, 400, 0})},
+		{ID: "close", Embedding: llm.Normalize([]float32{0.1, 0.3, 0.05})},
+		{ID: "opposite", Embedding: llm.Normalize([]float32{-7, -14, 0})},
+	}
+	for _, msg := range messages {
+		assert.NoError(t, client.StoreMessage(msg))
+	}
+
+	results, err := client.SearchSimilar(context.Background(), llm.Normalize([]float32{1, 2, 0}), 3)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	assert.Equal(t, "same", results[0].ID)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-6)
+	assert.Equal(t, "opposite", results[2].ID)
+	assert.InDelta(t, -1.0, results[2].Score, 1e-6)
+	for _, result := range results {
+		assert.GreaterOrEqual(t, result.Score, float32(-1.0001))
+		assert.LessOrEqual(t, result.Score, float32(1.0001))
+	}
+}
+
+func TestMemoryClientGetMessage(t *testing.T) {
+	client := vectordb.NewMemoryClient(logrus.New())
+	assert.NoError(t, client.StoreMessage(vectordb.Message{ID: "m1", Text: "hello", Embedding: []float32{1, 0}}))
+
+	msg, err := client.GetMessage(context.Background(), "m1", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", msg.Text)
+	assert.Nil(t, msg.Embedding)
+
+	_, err = client.GetMessage(context.Background(), "missing", true)
+	assert.ErrorIs(t, err, vectordb.ErrNotFound)
+}
+
+func TestMemoryClientRecreateCollectionClearsMessages(t *testing.T) {
+	client := vectordb.NewMemoryClient(logrus.New())
+	assert.NoError(t, client.StoreMessage(vectordb.Message{ID: "old", ChannelID: "C1", Embedding: []float32{1, 0}}))
+
+	assert.NoError(t, client.RecreateCollection(context.Background()))
+
+	_, err := client.GetMessage(context.Background(), "old", false)
+	assert.ErrorIs(t, err, vectordb.ErrNotFound)
+	channels, err := client.ListIndexedChannels(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, channels)
+}
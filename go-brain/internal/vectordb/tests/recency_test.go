@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"beebrain/internal/vectordb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankByRecencyPrefersRecentOnEqualSimilarity(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	results := []vectordb.Message{
+		{ID: "old", Score: 0.8, Timestamp: now.Add(-90 * 24 * time.Hour).Format(time.RFC3339)},
+		{ID: "undated", Score: 0.8},
+		{ID: "new", Score: 0.8, MessageTS: "1717156800.000100"}, // a day before now
+		{ID: "month", Score: 0.8, Timestamp: now.Add(-30 * 24 * time.Hour).Format(time.RFC3339)},
+	}
+
+	ranked := vectordb.RankByRecency(results, 30*24*time.Hour, 0.3, now)
+
+	var ids []string
+	for _, msg := range ranked {
+		ids = append(ids, msg.ID)
+	}
+	assert.Equal(t, []string{"new", "month", "old", "undated"}, ids)
+	// Scores stay the similarity to the query
+	assert.Equal(t, float32(0.8), ranked[0].Score)
+}
+
+func TestRankByRecencyKeepsMuchMoreSimilarFirst(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	results := []vectordb.Message{
+		{ID: "stale", Score: 0.95, Timestamp: now.Add(-60 * 24 * time.Hour).Format(time.RFC3339)},
+		{ID: "fresh", Score: 0.5, Timestamp: now.Add(-time.Hour).Format(time.RFC3339)},
+	}
+
+	ranked := vectordb.RankByRecency(results, 30*24*time.Hour, 0.3, now)
+	assert.Equal(t, "stale", ranked[0].ID)
+}
+
+func TestRecencyScore(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	halfLife := 24 * time.Hour
+	msg := vectordb.Message{Score: 1, Timestamp: now.Add(-halfLife).Format(time.RFC3339)}
+
+	assert.InDelta(t, 0.85, vectordb.RecencyScore(msg, halfLife, 0.3, now), 1e-9)
+	assert.InDelta(t, 0.5, vectordb.RecencyScore(msg, halfLife, 1, now), 1e-9)
+	// Disabled without a half-life or weight
+	assert.InDelta(t, 1, vectordb.RecencyScore(msg, 0, 0.3, now), 1e-9)
+	assert.InDelta(t, 1, vectordb.RecencyScore(msg, halfLife, 0, now), 1e-9)
+	// Messages from the future aren't boosted past their similarity
+	future := vectordb.Message{Score: 1, Timestamp: now.Add(time.Hour).Format(time.RFC3339)}
+	assert.InDelta(t, 1, vectordb.RecencyScore(future, halfLife, 0.3, now), 1e-9)
+}
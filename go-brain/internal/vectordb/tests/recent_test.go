@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRecentMessagesFiltersChannelAndTime(t *testing.T) {
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	since := time.Unix(1700000000, 0)
+	mockPoints.On("Scroll", mock.Anything, mock.MatchedBy(func(req *go_client.ScrollPoints) bool {
+		must := req.GetFilter().GetMust()
+		return len(must) == 2 &&
+			must[0].GetField().GetMatch().GetKeyword() == "C1" &&
+			must[1].GetField().GetKey() == "posted_at" &&
+			must[1].GetField().GetRange().GetGte() == 1700000000 &&
+			req.GetWithVectors().GetEnable()
+	})).Return(&go_client.ScrollResponse{Result: []*go_client.RetrievedPoint{
+		{Id: &go_client.PointId{PointIdOptions: &go_client.PointId_Num{Num: 1}}, Payload: map[string]*go_client.Value{
+			"message_ts": {Kind: &go_client.Value_StringValue{StringValue: "1700000100.000100"}},
+		}},
+		{Id: &go_client.PointId{PointIdOptions: &go_client.PointId_Num{Num: 2}}, Payload: map[string]*go_client.Value{
+			"message_ts": {Kind: &go_client.Value_StringValue{StringValue: "1700000200.000100"}},
+		}},
+	}}, nil)
+
+	messages, err := client.RecentMessages(context.Background(), "C1", since, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, "2", messages[0].ID)
+	}
+
+	memory := vectordb.NewMemoryClient(logrus.New())
+	assert.NoError(t, memory.StoreMessages([]vectordb.Message{
+		{ID: "old", ChannelID: "C1", MessageTS: "1699999000.000100", Embedding: []float32{1, 0}},
+		{ID: "recent", ChannelID: "C1", MessageTS: "1700000100.000100", Embedding: []float32{1, 0}},
+		{ID: "other", ChannelID: "C2", MessageTS: "1700000100.000100", Embedding: []float32{1, 0}},
+	}))
+	messages, err = memory.RecentMessages(context.Background(), "C1", since, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, "recent", messages[0].ID)
+	}
+}
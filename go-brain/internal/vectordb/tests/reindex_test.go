@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	llmmocks "beebrain/internal/llm/mocks"
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func uuidPoint(id, text string) *go_client.RetrievedPoint {
+	return &go_client.RetrievedPoint{
+		Id: &go_client.PointId{PointIdOptions: &go_client.PointId_Uuid{Uuid: id}},
+		Payload: map[string]*go_client.Value{
+			"text": {Kind: &go_client.Value_StringValue{StringValue: text}},
+		},
+	}
+}
+
+func TestReindexScrollsReembedsAndUpserts(t *testing.T) {
+	t.Setenv("QDRANT_VECTOR_SIZE", "2")
+
+	mockCollections := &mocks.MockCollectionsClient{}
+	mockPoints := &mocks.MockPointsClient{}
+	mockEmbedder := &llmmocks.MockEmbedder{}
+	client := vectordb.NewClientFromServices(mockCollections, mockPoints, logrus.New())
+
+	mockCollections.On("List", mock.Anything, mock.Anything).Return(&go_client.ListCollectionsResponse{}, nil)
+	mockCollections.On("Create", mock.Anything, mock.MatchedBy(func(req *go_client.CreateCollection) bool {
+		return req.CollectionName == "slack_messages_v2" && req.GetVectorsConfig().GetParams().GetSize() == 2
+	})).Return(&go_client.CollectionOperationResponse{Result: true}, nil)
+
+	// Two pages, the second reached through the first page's offset
+	next := &go_client.PointId{PointIdOptions: &go_client.PointId_Uuid{Uuid: "p3"}}
+	mockPoints.On("Scroll", mock.Anything, mock.MatchedBy(func(req *go_client.ScrollPoints) bool {
+		return req.CollectionName == "slack_messages" && req.Offset == nil && req.GetLimit() == 2
+	})).Return(&go_client.ScrollResponse{
+		Result:         []*go_client.RetrievedPoint{uuidPoint("p1", "one"), uuidPoint("p2", "two")},
+		NextPageOffset: next,
+	}, nil)
+	mockPoints.On("Scroll", mock.Anything, mock.MatchedBy(func(req *go_client.ScrollPoints) bool {
+		return req.Offset.GetUuid() == "p3"
+	})).Return(&go_client.ScrollResponse{
+		Result: []*go_client.RetrievedPoint{uuidPoint("p3", "three")},
+	}, nil)
+
+	// p2 was migrated by an earlier, interrupted run
+	inTarget := map[string]bool{"p2": true}
+	mockPoints.On("Get", mock.Anything, mock.MatchedBy(func(req *go_client.GetPoints) bool {
+		return req.CollectionName == "slack_messages_v2"
+	})).Return(func(req *go_client.GetPoints) *go_client.GetResponse {
+		resp := &go_client.GetResponse{}
+		for _, id := range req.Ids {
+			if inTarget[id.GetUuid()] {
+				resp.Result = append(resp.Result, uuidPoint(id.GetUuid(), ""))
+			}
+		}
+		return resp
+	}, nil)
+
+	mockEmbedder.On("GetEmbedding", "one").Return([]float32{1, 0}, nil)
+	mockEmbedder.On("GetEmbedding", "three").Return([]float32{0, 1}, nil)
+
+	var upserted []string
+	mockPoints.On("Upsert", mock.Anything, mock.MatchedBy(func(req *go_client.UpsertPoints) bool {
+		return req.CollectionName == "slack_messages_v2" && req.GetWait()
+	})).Run(func(args mock.Arguments) {
+		for _, point := range args.Get(1).(*go_client.UpsertPoints).Points {
+			assert.Len(t, point.Vectors.GetVector().Data, 2)
+			upserted = append(upserted, point.Id.GetUuid())
+			inTarget[point.Id.GetUuid()] = true
+		}
+	}).Return(&go_client.PointsOperationResponse{}, nil)
+
+	migrated, err := client.Reindex(context.Background(), mockEmbedder, "slack_messages_v2", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, migrated)
+	assert.Equal(t, []string{"p1", "p3"}, upserted)
+	// Switching to the target is left to QDRANT_COLLECTION
+	assert.Equal(t, "slack_messages", client.Collection())
+
+	mockEmbedder.AssertNotCalled(t, "GetEmbedding", "two")
+	mockPoints.AssertExpectations(t)
+	mockCollections.AssertExpectations(t)
+}
+
+func TestReindexCatchesUpWithPointsStoredMeanwhile(t *testing.T) {
+	tests := []struct {
+		name    string
+		stored  int
+		wantErr bool
+	}{
+		{name: "Stored during the first pass", stored: 1},
+		{name: "Still being stored", stored: 10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("QDRANT_VECTOR_SIZE", "2")
+			mockCollections := &mocks.MockCollectionsClient{}
+			mockPoints := &mocks.MockPointsClient{}
+			mockEmbedder := &llmmocks.MockEmbedder{}
+			client := vectordb.NewClientFromServices(mockCollections, mockPoints, logrus.New())
+
+			mockCollections.On("List", mock.Anything, mock.Anything).Return(&go_client.ListCollectionsResponse{
+				Collections: []*go_client.CollectionDescription{{Name: "slack_messages_v2"}},
+			}, nil)
+			mockEmbedder.On("GetEmbedding", mock.Anything).Return([]float32{1, 0}, nil)
+
+			// The bot stores a point while each pass runs, up to tt.stored
+			source := &go_client.ScrollResponse{Result: []*go_client.RetrievedPoint{uuidPoint("p1", "one")}}
+			mockPoints.On("Scroll", mock.Anything, mock.Anything).Return(source, nil)
+
+			inTarget := map[string]bool{}
+			mockPoints.On("Get", mock.Anything, mock.Anything).Return(func(req *go_client.GetPoints) *go_client.GetResponse {
+				resp := &go_client.GetResponse{}
+				for _, id := range req.Ids {
+					if inTarget[id.GetUuid()] {
+						resp.Result = append(resp.Result, uuidPoint(id.GetUuid(), ""))
+					}
+				}
+				return resp
+			}, nil)
+			mockPoints.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				for _, point := range args.Get(1).(*go_client.UpsertPoints).Points {
+					inTarget[point.Id.GetUuid()] = true
+				}
+				if stored := len(source.Result) - 1; stored < tt.stored {
+					id := fmt.Sprintf("p0-%d", stored)
+					source.Result = append([]*go_client.RetrievedPoint{uuidPoint(id, id)}, source.Result...)
+				}
+			}).Return(&go_client.PointsOperationResponse{}, nil)
+
+			migrated, err := client.Reindex(context.Background(), mockEmbedder, "slack_messages_v2", 10)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 2, migrated)
+			assert.True(t, inTarget["p0-0"])
+		})
+	}
+}
+
+func TestReindexRejectsCurrentCollection(t *testing.T) {
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, &mocks.MockPointsClient{}, logrus.New())
+
+	_, err := client.Reindex(context.Background(), &llmmocks.MockEmbedder{}, client.Collection(), 10)
+	assert.Error(t, err)
+}
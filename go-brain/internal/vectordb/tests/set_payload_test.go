@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSetPayloadUpdatesMetadataWithoutVector(t *testing.T) {
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+
+	id := "0b5e6b8e-7d1c-4c55-9f6a-3f1d5e0c8a11"
+	mockPoints.On("Get", mock.Anything, mock.Anything).Return(&go_client.GetResponse{Result: []*go_client.RetrievedPoint{{
+		Id: &go_client.PointId{PointIdOptions: &go_client.PointId_Uuid{Uuid: id}},
+		Payload: map[string]*go_client.Value{
+			"text": {Kind: &go_client.Value_StringValue{StringValue: "Deploys happen on Fridays"}},
+			"metadata": {Kind: &go_client.Value_StructValue{StructValue: &go_client.Struct{Fields: map[string]*go_client.Value{
+				"source": {Kind: &go_client.Value_StringValue{StringValue: "backfill"}},
+			}}}},
+		},
+	}}}, nil)
+
+	var request *go_client.SetPayloadPoints
+	mockPoints.On("SetPayload", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		request = args.Get(1).(*go_client.SetPayloadPoints)
+	}).Return(&go_client.PointsOperationResponse{}, nil)
+
+	err := client.SetPayload(context.Background(), id, map[string]string{"tag": "deploys"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "slack_messages", request.CollectionName)
+	assert.Equal(t, id, request.GetPointsSelector().GetPoints().GetIds()[0].GetUuid())
+	// Only the metadata is sent, keeping the existing keys
+	assert.Len(t, request.Payload, 1)
+	fields := request.Payload["metadata"].GetStructValue().GetFields()
+	assert.Equal(t, "backfill", fields["source"].GetStringValue())
+	assert.Equal(t, "deploys", fields["tag"].GetStringValue())
+	// The vector is neither fetched nor written
+	mockPoints.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+	mockPoints.AssertCalled(t, "Get", mock.Anything, mock.MatchedBy(func(req *go_client.GetPoints) bool {
+		return !req.GetWithVectors().GetEnable()
+	}))
+}
+
+func TestSetPayloadMissingMessageIsNotFound(t *testing.T) {
+	mockPoints := &mocks.MockPointsClient{}
+	client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logrus.New())
+	mockPoints.On("Get", mock.Anything, mock.Anything).Return(&go_client.GetResponse{}, nil)
+
+	err := client.SetPayload(context.Background(), "42", map[string]string{"tag": "deploys"})
+	assert.ErrorIs(t, err, vectordb.ErrNotFound)
+	mockPoints.AssertNotCalled(t, "SetPayload", mock.Anything, mock.Anything)
+
+	memory := vectordb.NewMemoryClient(logrus.New())
+	assert.ErrorIs(t, memory.SetPayload(context.Background(), "42", map[string]string{"tag": "deploys"}), vectordb.ErrNotFound)
+}
+
+func TestMemorySetPayloadKeepsVector(t *testing.T) {
+	client := vectordb.NewMemoryClient(logrus.New())
+	assert.NoError(t, client.StoreMessage(vectordb.Message{ID: "a", Text: "hello", Metadata: map[string]string{"source": "backfill"}, Embedding: []float32{0.1, 0.2}}))
+
+	assert.NoError(t, client.SetPayload(context.Background(), "a", map[string]string{"tag": "greeting"}))
+
+	msg, err := client.GetMessage(context.Background(), "a", true)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"source": "backfill", "tag": "greeting"}, msg.Metadata)
+	assert.Equal(t, []float32{0.1, 0.2}, msg.Embedding)
+	assert.Equal(t, "hello", msg.Text)
+}
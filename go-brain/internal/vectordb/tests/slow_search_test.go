@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"beebrain/internal/vectordb"
+	"beebrain/internal/vectordb/mocks"
+
+	go_client "github.com/qdrant/go-client/qdrant"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSlowSearchIsLogged(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold string
+		delay     time.Duration
+		wantLog   bool
+	}{
+		{name: "Slow search", threshold: "20ms", delay: 50 * time.Millisecond, wantLog: true},
+		{name: "Fast search", threshold: "1s", delay: 0, wantLog: false},
+		{name: "Disabled", threshold: "0", delay: 50 * time.Millisecond, wantLog: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SLOW_SEARCH_THRESHOLD", tt.threshold)
+			logger, hook := test.NewNullLogger()
+			mockPoints := &mocks.MockPointsClient{}
+			client := vectordb.NewClientFromServices(&mocks.MockCollectionsClient{}, mockPoints, logger)
+
+			mockPoints.On("Search", mock.Anything, mock.Anything).After(tt.delay).Return(&go_client.SearchResponse{Result: []*go_client.ScoredPoint{
+				{Score: 0.42},
+				{Score: 0.61},
+			}}, nil)
+
+			_, err := client.SearchSimilar(context.Background(), []float32{0.1, 0.2}, 5)
+			assert.NoError(t, err)
+
+			var logged []string
+			for _, entry := range hook.AllEntries() {
+				if entry.Level == logrus.WarnLevel {
+					logged = append(logged, entry.Message)
+				}
+			}
+			if !tt.wantLog {
+				assert.Empty(t, logged)
+				return
+			}
+			if assert.Len(t, logged, 1) {
+				assert.Contains(t, logged[0], "Slow search in collection slack_messages")
+				assert.Contains(t, logged[0], "results: 2")
+				assert.Contains(t, logged[0], "top score: 0.610")
+			}
+		})
+	}
+}